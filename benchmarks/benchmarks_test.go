@@ -0,0 +1,157 @@
+package benchmarks_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// BenchmarkUnmarshal decodes each corpus into an interface{}.
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, name := range corpora {
+		data := corpus(b, name)
+
+		b.Run(benchName(name)+"/jingo", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				var v interface{}
+				if err := encoding.Unmarshal(data, &v, encoding.WithDisableSizeLimit()); err != nil {
+					b.Fatalf("Unmarshal failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(benchName(name)+"/encoding_json", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				var v interface{}
+				if err := json.Unmarshal(data, &v); err != nil {
+					b.Fatalf("Unmarshal failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMarshal encodes each corpus from the interface{} it decodes to.
+func BenchmarkMarshal(b *testing.B) {
+	for _, name := range corpora {
+		data := corpus(b, name)
+
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			b.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		b.Run(benchName(name)+"/jingo", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := encoding.Marshal(v, encoding.WithDisableSizeLimit()); err != nil {
+					b.Fatalf("Marshal failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(benchName(name)+"/encoding_json", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := json.Marshal(v); err != nil {
+					b.Fatalf("Marshal failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecoder decodes each corpus from a reader with the streaming decoders.
+func BenchmarkDecoder(b *testing.B) {
+	for _, name := range corpora {
+		data := corpus(b, name)
+
+		b.Run(benchName(name)+"/jingo", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				dec, err := encoding.NewDecoder(bytes.NewReader(data), encoding.WithDisableSizeLimit())
+				if err != nil {
+					b.Fatalf("NewDecoder failed: %v", err)
+				}
+
+				var v interface{}
+				if err := dec.Decode(&v); err != nil {
+					b.Fatalf("Decode failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(benchName(name)+"/encoding_json", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				var v interface{}
+				if err := json.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+					b.Fatalf("Decode failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEncoder encodes each corpus to a writer with the streaming encoders.
+func BenchmarkEncoder(b *testing.B) {
+	for _, name := range corpora {
+		data := corpus(b, name)
+
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			b.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		var buf bytes.Buffer
+
+		b.Run(benchName(name)+"/jingo", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			enc, err := encoding.NewEncoder(&buf, encoding.WithDisableSizeLimit())
+			if err != nil {
+				b.Fatalf("NewEncoder failed: %v", err)
+			}
+
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+
+				if err := enc.Encode(v); err != nil {
+					b.Fatalf("Encode failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(benchName(name)+"/encoding_json", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			enc := json.NewEncoder(&buf)
+
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+
+				if err := enc.Encode(v); err != nil {
+					b.Fatalf("Encode failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package benchmarks_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// corpora lists the documents benchmarked, by file name in testdata.
+var corpora = []string{"twitter.json", "canada.json", "citm_catalog.json"}
+
+// generators build a stand-in for each corpus when its file is missing.
+var generators = map[string]func(r *rand.Rand) string{
+	"twitter.json":      generateTwitter,
+	"canada.json":       generateCanada,
+	"citm_catalog.json": generateCatalog,
+}
+
+var (
+	corpusMu    sync.Mutex
+	corpusCache = map[string][]byte{}
+)
+
+// corpus returns the contents of testdata/name, or a generated document of the same
+// shape when the file is missing.
+func corpus(b *testing.B, name string) []byte {
+	b.Helper()
+
+	corpusMu.Lock()
+	defer corpusMu.Unlock()
+
+	if data, ok := corpusCache[name]; ok {
+		return data
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if os.IsNotExist(err) {
+		b.Logf("testdata/%s not found, using a generated document", name)
+		data, err = []byte(generators[name](rand.New(rand.NewSource(1)))), nil
+	}
+
+	if err != nil {
+		b.Fatalf("Failed to read corpus: %v", err)
+	}
+
+	corpusCache[name] = data
+
+	return data
+}
+
+// benchName returns the corpus name without its extension.
+func benchName(name string) string {
+	return strings.TrimSuffix(name, ".json")
+}
+
+// generateTwitter builds search results like twitter.json.
+func generateTwitter(r *rand.Rand) string {
+	words := []string{"jingo", "json", "stream", "parser", "日本語", "テスト", "café", "naïve", "😀", "go"}
+
+	var b strings.Builder
+
+	b.WriteString(`{"statuses": [`)
+
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		text := make([]string, 8+r.Intn(12))
+		for j := range text {
+			text[j] = words[r.Intn(len(words))]
+		}
+
+		fmt.Fprintf(&b, `{"id": %d, "id_str": "%d", "text": "%s", "truncated": false, "retweet_count": %d,`,
+			505874924095815681+i, 505874924095815681+i, strings.Join(text, " "), r.Intn(1000))
+		fmt.Fprintf(&b, ` "entities": {"hashtags": [], "urls": [], "user_mentions": [{"screen_name": "user%d", "indices": [%d, %d]}]},`,
+			r.Intn(1000), r.Intn(10), 10+r.Intn(10))
+		fmt.Fprintf(&b, ` "user": {"id": %d, "name": "%s", "screen_name": "user%d", "followers_count": %d,`,
+			r.Int63n(1<<40), words[r.Intn(len(words))], i, r.Intn(100000))
+		fmt.Fprintf(&b, ` "verified": %t, "profile_image_url": "http://pbs.example.com/profile_images/%d/normal.jpeg"},`,
+			r.Intn(2) == 0, r.Int63())
+		b.WriteString(` "in_reply_to_status_id": null, "favorited": false, "lang": "ja"}`)
+	}
+
+	b.WriteString(`], "search_metadata": {"completed_in": 0.087, "max_id": 505874924095815700, "count": 100}}`)
+
+	return b.String()
+}
+
+// generateCanada builds a GeoJSON polygon like canada.json.
+func generateCanada(r *rand.Rand) string {
+	var b strings.Builder
+
+	b.WriteString(`{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"name": "Canada"},`)
+	b.WriteString(` "geometry": {"type": "Polygon", "coordinates": [`)
+
+	for i := 0; i < 50; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteByte('[')
+
+		for j := 0; j < 1000; j++ {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+
+			fmt.Fprintf(&b, "[%.15f,%.15f]", -141+r.Float64()*88, 41+r.Float64()*42)
+		}
+
+		b.WriteByte(']')
+	}
+
+	b.WriteString(`]}}]}`)
+
+	return b.String()
+}
+
+// generateCatalog builds an event catalog like citm_catalog.json.
+func generateCatalog(r *rand.Rand) string {
+	var b strings.Builder
+
+	b.WriteString(`{"events": {`)
+
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		fmt.Fprintf(&b, `"%d": {"id": %d, "name": "Event %d", "subTopicIds": [%d, %d, %d], "topicIds": [%d],`,
+			138586341+i, 138586341+i, i, r.Intn(1000), r.Intn(1000), r.Intn(1000), r.Intn(100))
+		b.WriteString(` "description": null, "logo": null, "subjectCode": null, "subtitle": null}`)
+	}
+
+	b.WriteString(`}, "performances": [`)
+
+	for i := 0; i < 500; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		fmt.Fprintf(&b, `{"eventId": %d, "id": %d, "start": %d, "venueCode": "PLEYEL_PLEYEL", "prices": [`,
+			138586341+r.Intn(200), 339887544+i, 1372701600000+int64(i)*86400000)
+
+		for j := 0; j < 3; j++ {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+
+			fmt.Fprintf(&b, `{"amount": %d, "audienceSubCategoryId": 337100890, "seatCategoryId": %d}`,
+				r.Intn(200)*1000, 338937295+j)
+		}
+
+		b.WriteString(`], "seatCategories": [{"areas": [{"areaId": 205705999, "blockIds": []}], "seatCategoryId": 338937295}]}`)
+	}
+
+	b.WriteString(`]}`)
+
+	return b.String()
+}
@@ -0,0 +1,21 @@
+// Package benchmarks compares jingo with encoding/json on the standard JSON benchmark
+// corpora:
+//
+//   - twitter.json: search results with many strings and non-ASCII text
+//   - canada.json: a GeoJSON polygon made almost entirely of floating point numbers
+//   - citm_catalog.json: an event catalog with many integers and repeated keys
+//
+// The files are not part of the repository. Copy them from a JSON benchmark suite such
+// as https://github.com/miloyip/nativejson-benchmark into benchmarks/testdata to
+// benchmark against them; without them a generated document with the same shape is
+// used, which is enough to compare changes but not to publish numbers.
+//
+// Run the suite with
+//
+//	go test ./benchmarks -run '^$' -bench . -benchmem
+//
+// and profile a single case with the phase labels of encoding.WithProfileLabels:
+//
+//	go test ./benchmarks -run '^$' -bench 'Unmarshal/canada/jingo' -cpuprofile cpu.out
+//	go tool pprof -tagfocus jingo=parse cpu.out
+package benchmarks
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// diffPatch returns the JSON Patch (RFC 6902) that turns a into b. Members and elements
+// are compared in place, so a value that moved is reported as removed and added.
+func diffPatch(a, b parser.Value) *parser.Array {
+	patch := parser.NewArray()
+	appendDiff(patch, nil, a, b)
+
+	return patch
+}
+
+// appendDiff appends to patch the operations that turn a into b, both found at path.
+func appendDiff(patch *parser.Array, path []string, a, b parser.Value) {
+	switch left := a.(type) {
+	case *parser.Object:
+		if right, ok := b.(*parser.Object); ok {
+			appendObjectDiff(patch, path, left, right)
+			return
+		}
+	case *parser.Array:
+		if right, ok := b.(*parser.Array); ok {
+			appendArrayDiff(patch, path, left, right)
+			return
+		}
+	}
+
+	if !parser.Equal(a, b) {
+		appendOperation(patch, "replace", path, b)
+	}
+}
+
+// appendObjectDiff removes the members missing from b, compares the common ones and
+// adds the new ones.
+func appendObjectDiff(patch *parser.Array, path []string, a, b *parser.Object) {
+	for _, key := range a.OrderedKeys() {
+		memberPath := append(slices.Clip(path), key)
+
+		if right, ok := b.Get(key); ok {
+			left, _ := a.Get(key)
+			appendDiff(patch, memberPath, left, right)
+		} else {
+			appendOperation(patch, "remove", memberPath, nil)
+		}
+	}
+
+	for _, key := range b.OrderedKeys() {
+		if _, ok := a.Get(key); !ok {
+			right, _ := b.Get(key)
+			appendOperation(patch, "add", append(slices.Clip(path), key), right)
+		}
+	}
+}
+
+// appendArrayDiff compares the elements at the same index, then appends the extra
+// elements of b or removes those of a from the end, so that every index stays valid
+// when the operations are applied in order.
+func appendArrayDiff(patch *parser.Array, path []string, a, b *parser.Array) {
+	common := min(len(a.Elements), len(b.Elements))
+
+	for i := 0; i < common; i++ {
+		appendDiff(patch, append(slices.Clip(path), strconv.Itoa(i)), a.Elements[i], b.Elements[i])
+	}
+
+	for i := common; i < len(b.Elements); i++ {
+		appendOperation(patch, "add", append(slices.Clip(path), strconv.Itoa(i)), b.Elements[i])
+	}
+
+	for i := len(a.Elements) - 1; i >= common; i-- {
+		appendOperation(patch, "remove", append(slices.Clip(path), strconv.Itoa(i)), nil)
+	}
+}
+
+// appendOperation appends the operation op on path, with value unless it is nil.
+func appendOperation(patch *parser.Array, op string, path []string, value parser.Value) {
+	operation := parser.NewObject()
+	operation.Set("op", parser.NewString(op))
+	operation.Set("path", parser.NewString(formatPointer(path)))
+
+	if value != nil {
+		operation.Set("value", value)
+	}
+
+	patch.Elements = append(patch.Elements, operation)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// json5ToJSON rewrites a JSON5 document as JSON text: comments are dropped, identifier
+// keys and single-quoted strings are double-quoted, the JSON5 escapes and line
+// continuations are rewritten, and hexadecimal numbers, signs and bare decimal points
+// are normalized. Trailing commas are left to the parser. Infinity and NaN have no
+// JSON equivalent and are rejected.
+func json5ToJSON(data []byte) ([]byte, error) {
+	t := &json5Translator{src: data}
+	if err := t.translate(); err != nil {
+		line := bytes.Count(data[:t.pos], []byte("\n")) + 1
+		column := t.pos - bytes.LastIndexByte(data[:t.pos], '\n')
+
+		return nil, fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+
+	return t.out.Bytes(), nil
+}
+
+// errInfinity rejects the JSON5 numbers that JSON cannot represent.
+var errInfinity = errors.New("Infinity and NaN cannot be represented in JSON")
+
+// json5Translator holds the state of json5ToJSON.
+type json5Translator struct {
+	src []byte
+	pos int
+	out bytes.Buffer
+}
+
+// translate rewrites the whole input.
+func (t *json5Translator) translate() error {
+	for t.pos < len(t.src) {
+		r, size := utf8.DecodeRune(t.src[t.pos:])
+
+		switch {
+		case json5Space(r):
+			t.out.WriteByte(' ')
+			t.pos += size
+		case r == '/':
+			if err := t.skipComment(); err != nil {
+				return err
+			}
+		case r == '"' || r == '\'':
+			if err := t.translateString(byte(r)); err != nil {
+				return err
+			}
+		case strings.ContainsRune("{}[]:,", r):
+			t.out.WriteRune(r)
+			t.pos += size
+		case r == '+' || r == '-' || r == '.' || r >= '0' && r <= '9':
+			if err := t.translateNumber(); err != nil {
+				return err
+			}
+		case json5IdentifierStart(r):
+			if err := t.translateIdentifier(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return nil
+}
+
+// skipComment skips a line or block comment.
+func (t *json5Translator) skipComment() error {
+	rest := t.src[t.pos:]
+
+	switch {
+	case bytes.HasPrefix(rest, []byte("//")):
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+
+		t.pos += end
+	case bytes.HasPrefix(rest, []byte("/*")):
+		end := bytes.Index(rest[2:], []byte("*/"))
+		if end < 0 {
+			return errors.New("unterminated comment")
+		}
+
+		t.out.WriteByte(' ')
+		t.pos += end + 4
+	default:
+		return errors.New("unexpected character '/'")
+	}
+
+	return nil
+}
+
+// translateString rewrites a string delimited by quote as a double-quoted JSON string.
+func (t *json5Translator) translateString(quote byte) error {
+	t.out.WriteByte('"')
+	t.pos++
+
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+
+		switch {
+		case c == quote:
+			t.out.WriteByte('"')
+			t.pos++
+
+			return nil
+		case c == '"':
+			t.out.WriteString(`\"`)
+			t.pos++
+		case c == '\n' || c == '\r':
+			return errors.New("unterminated string")
+		case c == '\\':
+			if err := t.translateEscape(); err != nil {
+				return err
+			}
+		default:
+			t.out.WriteByte(c)
+			t.pos++
+		}
+	}
+
+	return errors.New("unterminated string")
+}
+
+// translateEscape rewrites the escape sequence at the current position.
+func (t *json5Translator) translateEscape() error {
+	t.pos++
+	if t.pos >= len(t.src) {
+		return errors.New("unterminated string")
+	}
+
+	r, size := utf8.DecodeRune(t.src[t.pos:])
+	t.pos += size
+
+	switch r {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', 'u':
+		// The JSON escapes are kept and the parser checks the digits of \u
+		t.out.WriteByte('\\')
+		t.out.WriteRune(r)
+	case '\'':
+		t.out.WriteByte('\'')
+	case 'v':
+		t.out.WriteString(`\u000b`)
+	case '0':
+		if t.pos < len(t.src) && t.src[t.pos] >= '0' && t.src[t.pos] <= '9' {
+			return errors.New("invalid escape \\0 followed by a digit")
+		}
+
+		t.out.WriteString(`\u0000`)
+	case 'x':
+		if t.pos+2 > len(t.src) || !isHex(t.src[t.pos]) || !isHex(t.src[t.pos+1]) {
+			return errors.New("invalid escape \\x")
+		}
+
+		t.out.WriteString(`\u00` + string(t.src[t.pos:t.pos+2]))
+		t.pos += 2
+	case '\r':
+		// A line continuation adds nothing to the string
+		if t.pos < len(t.src) && t.src[t.pos] == '\n' {
+			t.pos++
+		}
+	case '\n', '\u2028', '\u2029':
+	default:
+		if r >= '1' && r <= '9' {
+			return fmt.Errorf("invalid escape \\%c", r)
+		}
+
+		// Any other escaped character stands for itself
+		t.out.WriteRune(r)
+	}
+
+	return nil
+}
+
+// translateNumber rewrites a number: a leading "+" is dropped, a bare decimal point
+// gets a zero digit and a hexadecimal number is written in decimal.
+func (t *json5Translator) translateNumber() error {
+	switch t.src[t.pos] {
+	case '-':
+		t.out.WriteByte('-')
+		t.pos++
+	case '+':
+		t.pos++
+	}
+
+	rest := t.src[t.pos:]
+
+	switch {
+	case bytes.HasPrefix(rest, []byte("Infinity")), bytes.HasPrefix(rest, []byte("NaN")):
+		return errInfinity
+	case bytes.HasPrefix(rest, []byte("0x")), bytes.HasPrefix(rest, []byte("0X")):
+		end := 2
+		for end < len(rest) && isHex(rest[end]) {
+			end++
+		}
+
+		n, ok := new(big.Int).SetString(string(rest[2:end]), 16)
+		if !ok {
+			return errors.New("invalid hexadecimal number")
+		}
+
+		t.out.WriteString(n.String())
+		t.pos += end
+
+		return nil
+	}
+
+	if t.pos < len(t.src) && t.src[t.pos] == '.' {
+		t.out.WriteByte('0')
+	}
+
+	digits := false
+
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.':
+			if !digits {
+				break
+			}
+
+			// A trailing decimal point needs a digit after it
+			if next := t.pos + 1; next >= len(t.src) || t.src[next] < '0' || t.src[next] > '9' {
+				t.out.WriteString(".0")
+				t.pos++
+
+				continue
+			}
+		case c == 'e' || c == 'E' || c == '+' || c == '-':
+		default:
+			return nil
+		}
+
+		t.out.WriteByte(c)
+		t.pos++
+	}
+
+	return nil
+}
+
+// translateIdentifier rewrites an unquoted member name as a string. The literals true,
+// false and null are kept, and Infinity and NaN are rejected.
+func (t *json5Translator) translateIdentifier() error {
+	start := t.pos
+
+	for t.pos < len(t.src) {
+		r, size := utf8.DecodeRune(t.src[t.pos:])
+		if !json5IdentifierStart(r) && !unicode.IsDigit(r) && !unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc) &&
+			r != '\u200c' && r != '\u200d' {
+			break
+		}
+
+		t.pos += size
+	}
+
+	name := string(t.src[start:t.pos])
+
+	switch name {
+	case "true", "false", "null":
+		t.out.WriteString(name)
+	case "Infinity", "NaN":
+		t.pos = start
+		return errInfinity
+	default:
+		t.out.WriteString(`"` + name + `"`)
+	}
+
+	return nil
+}
+
+// json5Space reports whether r is white space in JSON5.
+func json5Space(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00a0', '\u2028', '\u2029', '\ufeff':
+		return true
+	}
+
+	return unicode.Is(unicode.Zs, r)
+}
+
+// json5IdentifierStart reports whether r may start an identifier.
+func json5IdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+// isHex reports whether c is a hexadecimal digit.
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,455 @@
+// Command jingo validates, formats, queries, compares, patches and converts JSON
+// documents.
+//
+// Usage:
+//
+//	jingo validate [-jsonc] [file...]
+//	jingo fmt [-indent string] [-sort] [-width n] [file]
+//	jingo get <pointer> [file]
+//	jingo diff <file> <file>
+//	jingo patch <patch file> [file]
+//	jingo convert -from json|jsonc|json5|ndjson -to json|ndjson [file]
+//
+// Input is read from the named file, or from standard input when no file or "-" is
+// given. Pointers follow RFC 6901 ("/servers/0/port") and patches RFC 6902.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/format"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+const usage = `Usage: jingo <command> [arguments]
+
+Commands:
+  validate [-jsonc] [file...]       check that the input is well-formed JSON
+  fmt [-indent s] [-sort] [-width n] [file]
+                                    pretty-print the input
+  get <pointer> [file]              print the value at a JSON Pointer
+  diff <file> <file>                print a JSON Patch from the first document to the second
+  patch <patch file> [file]         apply a JSON Patch to the input
+  convert -from f -to f [file]      convert between json, jsonc, json5 and ndjson
+`
+
+// errDifferent makes diff exit with status 1 when the documents differ.
+var errDifferent = errors.New("documents differ")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command in args and returns the exit status: 0 on success, 1 when
+// the input is invalid or the documents differ, and 2 on a usage error.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	commands := map[string]struct {
+		run      func(*command) error
+		synopsis string
+	}{
+		"validate": {validate, "validate [-jsonc] [file...]"},
+		"fmt":      {formatCommand, "fmt [-indent string] [-sort] [-width n] [file]"},
+		"get":      {get, "get <pointer> [file]"},
+		"diff":     {diff, "diff <file> <file>"},
+		"patch":    {patch, "patch <patch file> [file]"},
+		"convert":  {convert, "convert -from json|jsonc|json5|ndjson -to json|ndjson [file]"},
+	}
+
+	cmdDef, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jingo: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	cmd := &command{
+		flags:  flag.NewFlagSet(args[0], flag.ContinueOnError),
+		args:   args[1:],
+		stdin:  stdin,
+		stdout: stdout,
+	}
+	cmd.flags.SetOutput(stderr)
+	cmd.flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: jingo %s\n", cmdDef.synopsis)
+		cmd.flags.PrintDefaults()
+	}
+
+	err := cmdDef.run(cmd)
+
+	var usageErr usageError
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "jingo %s: %v\n", args[0], err)
+		cmd.flags.Usage()
+
+		return 2
+	case errors.Is(err, errDifferent):
+		return 1
+	default:
+		fmt.Fprintf(stderr, "jingo %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+// usageError reports invalid command-line arguments.
+type usageError string
+
+// Error implements the error interface.
+func (e usageError) Error() string { return string(e) }
+
+// command holds the arguments and streams of a command being run.
+type command struct {
+	flags  *flag.FlagSet
+	args   []string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// parse parses the flags of the command and checks that between min and max positional
+// arguments remain.
+func (c *command) parse(min, max int) ([]string, error) {
+	if err := c.flags.Parse(c.args); err != nil {
+		return nil, err
+	}
+
+	args := c.flags.Args()
+	if len(args) < min || max >= 0 && len(args) > max {
+		return nil, usageError("wrong number of arguments")
+	}
+
+	return args, nil
+}
+
+// read returns the content of the named file, or of standard input for "" or "-".
+func (c *command) read(name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(c.stdin)
+	}
+
+	return os.ReadFile(name)
+}
+
+// optional returns args[i], or "" when there are fewer arguments.
+func optional(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return ""
+}
+
+// validate checks every input without decoding it.
+func validate(c *command) error {
+	jsonc := c.flags.Bool("jsonc", false, "accept comments and trailing commas")
+
+	files, err := c.parse(0, -1)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	opts := []encoding.Option{encoding.WithDisableSizeLimit()}
+	if *jsonc {
+		opts = append(opts, encoding.WithAllowComments(), encoding.WithAllowTrailingCommas())
+	}
+
+	failed := false
+
+	for _, name := range files {
+		data, err := c.read(name)
+		if err == nil {
+			err = encoding.ValidateStream(bytes.NewReader(data), opts...)
+		}
+
+		if err != nil {
+			fmt.Fprintf(c.stdout, "%s: %v\n", name, err)
+			failed = true
+
+			continue
+		}
+
+		fmt.Fprintf(c.stdout, "%s: ok\n", name)
+	}
+
+	if failed {
+		return errors.New("invalid input")
+	}
+
+	return nil
+}
+
+// formatCommand pretty-prints the input.
+func formatCommand(c *command) error {
+	indent := c.flags.String("indent", "  ", "indentation per level; empty for compact output")
+	sortKeys := c.flags.Bool("sort", false, "sort object keys")
+	width := c.flags.Int("width", 0, "keep containers that fit in this many columns on one line")
+
+	args, err := c.parse(0, 1)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.read(optional(args, 0))
+	if err != nil {
+		return err
+	}
+
+	out, err := format.Format(data, format.Style{
+		Indent:          *indent,
+		SpaceAfterColon: *indent != "",
+		SortKeys:        *sortKeys,
+		TrailingNewline: true,
+		MaxInlineWidth:  *width,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.stdout.Write(out)
+
+	return err
+}
+
+// get prints the value at a JSON Pointer.
+func get(c *command) error {
+	args, err := c.parse(1, 2)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := parsePointer(args[0])
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	root, err := c.parseInput(optional(args, 1))
+	if err != nil {
+		return err
+	}
+
+	v, err := resolve(root, tokens)
+	if err != nil {
+		return err
+	}
+
+	return c.print(v)
+}
+
+// diff prints the JSON Patch that turns the first document into the second.
+func diff(c *command) error {
+	args, err := c.parse(2, 2)
+	if err != nil {
+		return err
+	}
+
+	a, err := c.parseInput(args[0])
+	if err != nil {
+		return err
+	}
+
+	b, err := c.parseInput(args[1])
+	if err != nil {
+		return err
+	}
+
+	patch := diffPatch(a, b)
+	if len(patch.Elements) == 0 {
+		return nil
+	}
+
+	if err := c.print(patch); err != nil {
+		return err
+	}
+
+	return errDifferent
+}
+
+// patch applies a JSON Patch to the input.
+func patch(c *command) error {
+	args, err := c.parse(1, 2)
+	if err != nil {
+		return err
+	}
+
+	ops, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	root, err := c.parseInput(optional(args, 1))
+	if err != nil {
+		return err
+	}
+
+	if root, err = applyPatch(root, ops); err != nil {
+		return err
+	}
+
+	return c.print(root)
+}
+
+// convert converts the input between formats.
+func convert(c *command) error {
+	from := c.flags.String("from", "json", "input format: json, jsonc, json5 or ndjson")
+	to := c.flags.String("to", "json", "output format: json or ndjson")
+
+	args, err := c.parse(0, 1)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.read(optional(args, 0))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *to != "json" && *to != "ndjson":
+		return usageError(fmt.Sprintf("unknown output format %q", *to))
+	case *from == "ndjson":
+		return c.joinLines(data, *to)
+	case *from == "json" || *from == "jsonc" || *from == "json5":
+		opts := []encoding.Option{encoding.WithDisableSizeLimit()}
+
+		switch *from {
+		case "jsonc":
+			opts = append(opts, encoding.WithAllowComments(), encoding.WithAllowTrailingCommas())
+		case "json5":
+			if data, err = json5ToJSON(data); err != nil {
+				return err
+			}
+
+			opts = append(opts, encoding.WithAllowTrailingCommas())
+		}
+
+		v, err := encoding.Parse(data, opts...)
+		if err != nil {
+			return err
+		}
+
+		if *to == "ndjson" {
+			return c.splitLines(v)
+		}
+
+		return c.print(v)
+	default:
+		return usageError(fmt.Sprintf("unknown input format %q", *from))
+	}
+}
+
+// joinLines writes the values of an NDJSON stream as a JSON array, or again as NDJSON
+// with one compact value per line.
+func (c *command) joinLines(data []byte, to string) error {
+	s := parser.NewSplitter(bytes.NewReader(data))
+	w := bufio.NewWriter(c.stdout)
+
+	if to == "json" {
+		w.WriteString("[")
+	}
+
+	n := 0
+
+	for ; s.Next(); n++ {
+		value, err := compact(s.Bytes())
+		if err != nil {
+			return fmt.Errorf("value %d: %w", n+1, err)
+		}
+
+		switch {
+		case to == "ndjson":
+			w.WriteString(value + "\n")
+		case n == 0:
+			w.WriteString("\n  " + value)
+		default:
+			w.WriteString(",\n  " + value)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	if to == "json" && n > 0 {
+		w.WriteString("\n]\n")
+	} else if to == "json" {
+		w.WriteString("]\n")
+	}
+
+	return w.Flush()
+}
+
+// splitLines writes the elements of an array, or the single document v, as NDJSON.
+func (c *command) splitLines(v parser.Value) error {
+	w := bufio.NewWriter(c.stdout)
+
+	if arr, ok := v.(*parser.Array); ok {
+		for _, elem := range arr.Elements {
+			w.WriteString(elem.String() + "\n")
+		}
+	} else {
+		w.WriteString(v.String() + "\n")
+	}
+
+	return w.Flush()
+}
+
+// compact validates a single JSON value of any kind and returns it in compact form.
+func compact(raw []byte) (string, error) {
+	// Top-level values must be containers, so the value is formatted inside an array
+	wrapped := append(append([]byte("["), raw...), ']')
+
+	out, err := format.Format(wrapped, format.Style{})
+	if err != nil {
+		return "", err
+	}
+
+	return string(out[1 : len(out)-1]), nil
+}
+
+// parseInput parses the named input into a tree.
+func (c *command) parseInput(name string) (parser.Value, error) {
+	data, err := c.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return encoding.Parse(data, encoding.WithDisableSizeLimit())
+}
+
+// print writes v as indented JSON followed by a newline.
+func (c *command) print(v parser.Value) error {
+	out := []byte(v.String() + "\n")
+
+	switch v.(type) {
+	case *parser.Object, *parser.Array:
+		var err error
+
+		out, err = format.Format(out, format.Style{Indent: "  ", SpaceAfterColon: true, TrailingNewline: true})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := c.stdout.Write(out)
+
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	doc := write("doc.json", `{"name": "api", "servers": [{"host": "a", "port": 80}], "a/b": {"~x": 1}}`)
+	other := write("other.json", `{"name": "web", "servers": [{"host": "a", "port": 80}], "a/b": {"~x": 1}}`)
+	ops := write("patch.json", `[
+		{"op": "replace", "path": "/servers/0/port", "value": 8080},
+		{"op": "add", "path": "/servers/-", "value": {"host": "b"}},
+		{"op": "copy", "from": "/servers/0/host", "path": "/primary"},
+		{"op": "move", "from": "/a~1b/~0x", "path": "/x"},
+		{"op": "remove", "path": "/a~1b"},
+		{"op": "test", "path": "/x", "value": 1}
+	]`)
+	failing := write("failing.json", `[{"op": "test", "path": "/name", "value": "web"}]`)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		status int
+		stdout string
+	}{
+		{name: "No command", status: 2},
+		{name: "Unknown command", args: []string{"bogus"}, status: 2},
+		{name: "Validate", args: []string{"validate", doc}, status: 0, stdout: doc + ": ok\n"},
+		{name: "Validate invalid", args: []string{"validate"}, stdin: `{"a": }`, status: 1},
+		{name: "Validate JSONC", args: []string{"validate", "-jsonc"}, stdin: "{\"a\": 1, // c\n}", status: 0, stdout: "-: ok\n"},
+		{
+			name:   "Format",
+			args:   []string{"fmt", "-width", "40", doc},
+			stdout: "{\n  \"name\": \"api\",\n  \"servers\": [{\"host\": \"a\", \"port\": 80}],\n  \"a/b\": {\"~x\": 1}\n}\n",
+		},
+		{name: "Format compact", args: []string{"fmt", "-indent", "", "-sort"}, stdin: `{"b": 1, "a": [true]}`, stdout: "{\"a\":[true],\"b\":1}\n"},
+		{name: "Get", args: []string{"get", "/a~1b/~0x", doc}, stdout: "1\n"},
+		{name: "Get container", args: []string{"get", "/servers/0"}, stdin: `{"servers": [{"port": 1}]}`, stdout: "{\n  \"port\": 1\n}\n"},
+		{name: "Get missing", args: []string{"get", "/servers/01", doc}, status: 1},
+		{name: "Get invalid pointer", args: []string{"get", "name", doc}, status: 2},
+		{
+			name:   "Diff",
+			args:   []string{"diff", doc, other},
+			status: 1,
+			stdout: "[\n  {\n    \"op\": \"replace\",\n    \"path\": \"/name\",\n    \"value\": \"web\"\n  }\n]\n",
+		},
+		{name: "Diff equal", args: []string{"diff", doc, doc}, status: 0},
+		{
+			name:   "Patch",
+			args:   []string{"patch", ops, doc},
+			stdout: "{\n  \"name\": \"api\",\n  \"servers\": [\n    {\n      \"host\": \"a\",\n      \"port\": 8080\n    },\n    {\n      \"host\": \"b\"\n    }\n  ],\n  \"primary\": \"a\",\n  \"x\": 1\n}\n",
+		},
+		{name: "Patch test failure", args: []string{"patch", failing, doc}, status: 1},
+		{name: "Convert JSONC", args: []string{"convert", "-from", "jsonc"}, stdin: "[1, /* c */ 2,]", stdout: "[\n  1,\n  2\n]\n"},
+		{name: "Convert JSON rejects comments", args: []string{"convert"}, stdin: "[1 /* c */]", status: 1},
+		{name: "Split NDJSON", args: []string{"convert", "-to", "ndjson"}, stdin: `[{"a": 1}, 2, "x"]`, stdout: "{\"a\":1}\n2\n\"x\"\n"},
+		{name: "Join NDJSON", args: []string{"convert", "-from", "ndjson"}, stdin: "{\"a\": 1}\n2\n", stdout: "[\n  {\"a\":1},\n  2\n]\n"},
+		{name: "Join empty NDJSON", args: []string{"convert", "-from", "ndjson"}, stdin: "", stdout: "[]\n"},
+		{
+			name:   "Convert JSON5",
+			args:   []string{"convert", "-from", "json5", "-to", "ndjson"},
+			stdin:  "// c\n[{key: 'it\\'s \"x\"', $b: +.5, c: 5., d: 0x1F, e: '\\x41\\\nB'}, -0Xff,]",
+			stdout: "{\"key\":\"it's \\\"x\\\"\",\"$b\":0.5,\"c\":5.0,\"d\":31,\"e\":\"AB\"}\n-255\n",
+		},
+		{name: "Convert JSON5 rejects Infinity", args: []string{"convert", "-from", "json5"}, stdin: "[-Infinity]", status: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			status := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d (stderr: %s)", tt.status, status, stderr.String())
+			}
+
+			if tt.stdout != "" && stdout.String() != tt.stdout {
+				t.Errorf("Expected output:\n%s\ngot:\n%s", tt.stdout, stdout.String())
+			}
+		})
+	}
+}
+
+func TestDiffPatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "Equal", a: `{"a": [1, 2]}`, b: `{"a": [1, 2]}`},
+		{name: "Members", a: `{"a": 1, "b": {"c": true}, "d/e": "~"}`, b: `{"b": {"c": false, "f": null}, "g": [1]}`},
+		{name: "Longer array", a: `[1, [2], {"x": 3}]`, b: `[1, [2, 4], {"x": 3}, 5, 6]`},
+		{name: "Shorter array", a: `[1, 2, 3, 4]`, b: `[0, 2]`},
+		{name: "Changed type", a: `{"a": [1]}`, b: `{"a": {"0": 1}}`},
+		{name: "Root", a: `[1]`, b: `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := encoding.Parse([]byte(tt.a))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := encoding.Parse([]byte(tt.b))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ops := diffPatch(a, b)
+
+			patched, err := applyPatch(a, []byte(ops.String()))
+			if err != nil {
+				t.Fatalf("Failed to apply %s: %v", ops, err)
+			}
+
+			if !parser.Equal(patched, b) {
+				t.Errorf("Expected %s after applying %s, got %s", tt.b, ops, patched)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// applyPatch applies the JSON Patch (RFC 6902) in data to root and returns the new root.
+// The operations are applied in order and the first failing one stops the patch.
+func applyPatch(root parser.Value, data []byte) (parser.Value, error) {
+	v, err := encoding.Parse(data, encoding.WithDisableSizeLimit())
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	ops, ok := v.(*parser.Array)
+	if !ok {
+		return nil, errors.New("invalid patch: expected an array of operations")
+	}
+
+	for i := range ops.Elements {
+		op, _ := ops.GetObject(i)
+		if op == nil {
+			return nil, fmt.Errorf("operation %d: expected an object", i)
+		}
+
+		name, _ := op.GetString("op")
+
+		if root, err = applyOperation(root, op, name); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, name, err)
+		}
+	}
+
+	return root, nil
+}
+
+// applyOperation applies a single patch operation named name.
+func applyOperation(root parser.Value, op *parser.Object, name string) (parser.Value, error) {
+	path, err := pointerMember(op, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	value, hasValue := op.Get("value")
+
+	switch name {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, errors.New(`missing "value"`)
+		}
+	}
+
+	switch name {
+	case "add":
+		return add(root, path, value)
+	case "remove":
+		_, root, err := remove(root, path)
+		return root, err
+	case "replace":
+		if _, root, err = remove(root, path); err != nil {
+			return nil, err
+		}
+
+		return add(root, path, value)
+	case "move", "copy":
+		from, err := pointerMember(op, "from")
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "move" {
+			if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+				return nil, errors.New("cannot move a value into itself")
+			}
+
+			if value, root, err = remove(root, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = resolve(root, from); err != nil {
+				return nil, err
+			}
+
+			// The copy must not share nodes with the original
+			if value, err = encoding.Parse([]byte("[" + value.String() + "]")); err != nil {
+				return nil, err
+			}
+
+			value = value.(*parser.Array).Elements[0]
+		}
+
+		return add(root, path, value)
+	case "test":
+		current, err := resolve(root, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !parser.Equal(current, value) {
+			return nil, fmt.Errorf("test failed: %s is %s", formatPointer(path), current)
+		}
+
+		return root, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+// pointerMember parses the pointer stored in op under key.
+func pointerMember(op *parser.Object, key string) ([]string, error) {
+	pointer, ok := op.GetString(key)
+	if !ok {
+		return nil, fmt.Errorf("missing %q", key)
+	}
+
+	return parsePointer(pointer)
+}
+
+// add adds value at path: a member is added or replaced, an element inserted, or
+// appended for the index "-".
+func add(root parser.Value, path []string, value parser.Value) (parser.Value, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := resolve(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case *parser.Object:
+		container.Set(last, value)
+	case *parser.Array:
+		index := len(container.Elements)
+
+		if last != "-" {
+			var ok bool
+			if index, ok = arrayIndex(last, len(container.Elements)+1); !ok {
+				return nil, fmt.Errorf("%s: index out of range", formatPointer(path))
+			}
+		}
+
+		container.Elements = slices.Insert(container.Elements, index, value)
+	default:
+		return nil, fmt.Errorf("%s is not a container", formatPointer(path[:len(path)-1]))
+	}
+
+	return root, nil
+}
+
+// remove removes the value at path and returns it along with the new root.
+func remove(root parser.Value, path []string) (parser.Value, parser.Value, error) {
+	value, err := resolve(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(path) == 0 {
+		return value, nil, nil
+	}
+
+	parent, _ := resolve(root, path[:len(path)-1])
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case *parser.Object:
+		container.Delete(last)
+	case *parser.Array:
+		index, _ := arrayIndex(last, len(container.Elements))
+		container.Elements = slices.Delete(container.Elements, index, index+1)
+	}
+
+	return value, root, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens. The
+// empty pointer refers to the whole document and has no tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q: must be empty or start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// resolve returns the value that tokens refer to in root.
+func resolve(root parser.Value, tokens []string) (parser.Value, error) {
+	v := root
+
+	for i, token := range tokens {
+		var ok bool
+
+		switch container := v.(type) {
+		case *parser.Object:
+			v, ok = container.Get(token)
+		case *parser.Array:
+			var index int
+			if index, ok = arrayIndex(token, len(container.Elements)); ok {
+				v, ok = container.Get(index)
+			}
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("%s not found", formatPointer(tokens[:i+1]))
+		}
+	}
+
+	return v, nil
+}
+
+// arrayIndex parses an array index token, which has no sign or leading zeros, and
+// reports whether it is below n.
+func arrayIndex(token string, n int) (int, bool) {
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return 0, false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+
+	index, err := strconv.Atoi(token)
+
+	return index, err == nil && index < n
+}
+
+// formatPointer joins reference tokens into a JSON Pointer.
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+
+	for _, token := range tokens {
+		b.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+
+	return b.String()
+}
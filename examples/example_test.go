@@ -2,7 +2,6 @@ package examples
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/rafaelmgr12/jingo/pkg/parser"
 )
@@ -17,7 +16,7 @@ func Example() {
         }
     }`
 
-	lexer := parser.NewLexer(input)
+	lexer := parser.NewLexerString(input)
 	p := parser.NewParser(lexer)
 
 	value, err := p.ParseJSON()
@@ -27,14 +26,20 @@ func Example() {
 	}
 
 	obj := value.(*parser.Object)
-	fmt.Printf("Name: %s\n", obj.Pairs["name"].(*parser.StringLiteral).Value)
-	age, _ := strconv.Atoi(obj.Pairs["age"].(*parser.NumberLiteral).Value)
-	fmt.Printf("Age: %d\n", age)
+	name, _ := obj.GetString("name")
+	age, _ := obj.GetInt("age")
+	address, _ := obj.GetObject("address")
+	city, _ := address.GetString("city")
+	fmt.Printf("Name: %s\nAge: %d\nCity: %s\n", name, age, city)
+	// Output:
+	// Name: John Doe
+	// Age: 30
+	// City: New York
 }
 
 func ExampleParser_ParseJSON() {
 	input := `{"key": "value"}`
-	lexer := parser.NewLexer(input)
+	lexer := parser.NewLexerString(input)
 	p := parser.NewParser(lexer)
 
 	value, err := p.ParseJSON()
@@ -44,6 +49,7 @@ func ExampleParser_ParseJSON() {
 	}
 
 	obj := value.(*parser.Object)
-	fmt.Printf("Value: %s\n", obj.Pairs["key"].(*parser.StringLiteral).Value)
+	key, _ := obj.GetString("key")
+	fmt.Printf("Value: %s\n", key)
 	// Output: Value: value
 }
@@ -0,0 +1,911 @@
+// Package convert translates configuration documents between JSON and other formats,
+// using the jingo syntax tree as the pivot representation: a document is read into a
+// parser.Value, which can be inspected, edited, encoded as JSON or written back in
+// another format.
+//
+//	v, err := convert.YAMLToValue(r)
+//	...
+//	err = convert.ValueToYAML(v, w)
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// YAMLToValue reads a single YAML document from r and returns it as a syntax tree.
+//
+// The block and flow styles, quoted and plain scalars, literal and folded block scalars,
+// comments, anchors and aliases are supported; tags, complex keys and multiple documents
+// are not. Plain scalars are resolved with the YAML 1.2 core schema: null, true, false
+// and numbers become the matching JSON values, and everything else a string. Values
+// that JSON cannot represent, such as .inf or .nan, are reported as errors.
+func YAMLToValue(r io.Reader) (parser.Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	src := strings.TrimPrefix(string(data), "\ufeff")
+	src = strings.TrimSuffix(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	y := &yamlReader{
+		lines:   strings.Split(src, "\n"),
+		anchors: make(map[string]parser.Value),
+	}
+
+	return y.document()
+}
+
+var (
+	yamlDecimal = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	jsonNumber  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// yamlReader reads a YAML document line by line. Block collections are delimited by
+// indentation, so each of them is read by a loop over the lines at its own indentation.
+type yamlReader struct {
+	lines   []string
+	pos     int
+	anchors map[string]parser.Value
+}
+
+// errorf returns an error located at the current line.
+func (y *yamlReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", y.pos+1, fmt.Sprintf(format, args...))
+}
+
+// document reads the whole input, which holds at most one document.
+func (y *yamlReader) document() (parser.Value, error) {
+	for ; y.pos < len(y.lines); y.pos++ {
+		line := y.lines[y.pos]
+		if strings.HasPrefix(line, "%") || isBlank(line) {
+			continue
+		}
+
+		if line == "---" {
+			y.pos++
+		} else if strings.HasPrefix(line, "--- ") {
+			y.lines[y.pos] = strings.TrimLeft(line[3:], " ")
+		}
+
+		break
+	}
+
+	value, err := y.node(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	y.skipBlank()
+
+	if y.pos < len(y.lines) && y.lines[y.pos] == "..." {
+		y.pos++
+		y.skipBlank()
+	}
+
+	if y.pos < len(y.lines) {
+		if strings.HasPrefix(y.lines[y.pos], "---") {
+			return nil, y.errorf("multiple documents are not supported")
+		}
+
+		return nil, y.errorf("unexpected content after the document")
+	}
+
+	return value, nil
+}
+
+// skipBlank moves past empty lines and lines holding only a comment.
+func (y *yamlReader) skipBlank() {
+	for y.pos < len(y.lines) && isBlank(y.lines[y.pos]) {
+		y.pos++
+	}
+}
+
+// isBlank reports whether line is empty or holds only a comment.
+func isBlank(line string) bool {
+	line = strings.TrimLeft(line, " \t")
+	return line == "" || line[0] == '#'
+}
+
+// line returns the indentation of the current line and its content without the comment.
+func (y *yamlReader) line() (int, string, error) {
+	raw := y.lines[y.pos]
+	text := strings.TrimLeft(raw, " ")
+	indent := len(raw) - len(text)
+
+	if strings.HasPrefix(text, "\t") {
+		return 0, "", y.errorf("tabs are not allowed in indentation")
+	}
+
+	return indent, stripComment(text), nil
+}
+
+// isDocumentMarker reports whether a line with the given indentation and content ends
+// the document.
+func isDocumentMarker(indent int, text string) bool {
+	return indent == 0 && (text == "..." || text == "---" || strings.HasPrefix(text, "--- "))
+}
+
+// node reads the block node starting on the next non-blank line, which must be indented
+// more than parent; the node is null otherwise.
+func (y *yamlReader) node(parent int) (parser.Value, error) {
+	y.skipBlank()
+
+	if y.pos == len(y.lines) {
+		return parser.NewNull(), nil
+	}
+
+	indent, text, err := y.line()
+	if err != nil {
+		return nil, err
+	}
+
+	if indent <= parent || isDocumentMarker(indent, text) {
+		return parser.NewNull(), nil
+	}
+
+	return y.block(indent, text, parent)
+}
+
+// block reads the node whose first line, the current one, has the given indentation and
+// content.
+func (y *yamlReader) block(indent int, text string, parent int) (parser.Value, error) {
+	if isSequenceEntry(text) {
+		return y.sequence(indent)
+	}
+
+	if _, _, ok := mappingKey(text); ok {
+		return y.mapping(indent)
+	}
+
+	return y.value(text, parent, false)
+}
+
+// isSequenceEntry reports whether text starts a block sequence entry.
+func isSequenceEntry(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// mappingKey splits a block mapping entry into its key and the rest of the line, and
+// reports whether text is one.
+func mappingKey(text string) (key, rest string, ok bool) {
+	if text == "" || strings.ContainsRune("[{#&*!|>%@`", rune(text[0])) || isSequenceEntry(text) {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		key, n, err := quoted(text)
+		if err != nil {
+			return "", "", false
+		}
+
+		after := strings.TrimLeft(text[n:], " ")
+		if after == ":" || strings.HasPrefix(after, ": ") {
+			return key, strings.TrimSpace(after[1:]), true
+		}
+
+		return "", "", false
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// mapping reads a block mapping whose keys have the given indentation.
+func (y *yamlReader) mapping(indent int) (parser.Value, error) {
+	obj := parser.NewObject()
+
+	for y.skipBlank(); y.pos < len(y.lines); y.skipBlank() {
+		ind, text, err := y.line()
+		if err != nil {
+			return nil, err
+		}
+
+		if ind < indent || isDocumentMarker(ind, text) {
+			break
+		}
+
+		if ind > indent {
+			return nil, y.errorf("unexpected indentation")
+		}
+
+		key, rest, ok := mappingKey(text)
+		if !ok {
+			if strings.HasPrefix(text, "? ") {
+				return nil, y.errorf("complex keys are not supported")
+			}
+
+			return nil, y.errorf("expected a mapping key, found %q", text)
+		}
+
+		if _, dup := obj.Get(key); dup {
+			return nil, y.errorf("duplicate key %q", key)
+		}
+
+		value, err := y.value(rest, indent, true)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(key, value)
+	}
+
+	return obj, nil
+}
+
+// sequence reads a block sequence whose dashes have the given indentation.
+func (y *yamlReader) sequence(indent int) (parser.Value, error) {
+	arr := parser.NewArray()
+
+	for y.skipBlank(); y.pos < len(y.lines); y.skipBlank() {
+		ind, text, err := y.line()
+		if err != nil {
+			return nil, err
+		}
+
+		if ind < indent || isDocumentMarker(ind, text) || ind == indent && !isSequenceEntry(text) {
+			break
+		}
+
+		if ind > indent {
+			return nil, y.errorf("unexpected indentation")
+		}
+
+		rest := strings.TrimLeft(text[1:], " ")
+
+		var item parser.Value
+
+		if _, _, ok := mappingKey(rest); ok || isSequenceEntry(rest) {
+			// A compact collection such as "- key: value" continues at the column of
+			// its first entry, so the line is rewritten as if it started there
+			offset := ind + len(text) - len(rest)
+			y.lines[y.pos] = strings.Repeat(" ", offset) + rest
+			item, err = y.block(offset, rest, indent)
+		} else {
+			item, err = y.value(rest, indent, false)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		arr.Elements = append(arr.Elements, item)
+	}
+
+	return arr, nil
+}
+
+// value reads the value of a mapping entry or sequence entry whose indicator is on the
+// current line at the parent indentation, followed by rest.
+func (y *yamlReader) value(rest string, parent int, inMapping bool) (parser.Value, error) {
+	anchor := ""
+
+	if strings.HasPrefix(rest, "&") {
+		name, after, _ := strings.Cut(rest[1:], " ")
+		if name == "" {
+			return nil, y.errorf("missing anchor name")
+		}
+
+		anchor, rest = name, strings.TrimSpace(after)
+
+		if _, _, ok := mappingKey(rest); ok {
+			return nil, y.errorf("anchors on compact mappings are not supported")
+		}
+	}
+
+	var (
+		v   parser.Value
+		err error
+	)
+
+	switch {
+	case rest == "":
+		v, err = y.nested(parent, inMapping)
+	case rest[0] == '*':
+		var ok bool
+		if v, ok = y.anchors[rest[1:]]; !ok {
+			return nil, y.errorf("unknown alias %q", rest[1:])
+		}
+
+		y.pos++
+	case rest[0] == '!':
+		return nil, y.errorf("tags are not supported")
+	case rest[0] == '|' || rest[0] == '>':
+		v, err = y.blockScalar(rest, parent)
+	case rest[0] == '[' || rest[0] == '{':
+		v, err = y.flow(rest)
+	case rest[0] == '"' || rest[0] == '\'':
+		v, err = y.quotedScalar(rest)
+	default:
+		v, err = y.plainScalar(rest, parent)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if anchor != "" {
+		y.anchors[anchor] = v
+	}
+
+	return v, nil
+}
+
+// nested reads the value of an entry with nothing after its indicator: the block node on
+// the following lines, or null. The entries of a sequence that is the value of a mapping
+// entry may have the indentation of the key.
+func (y *yamlReader) nested(parent int, inMapping bool) (parser.Value, error) {
+	y.pos++
+	y.skipBlank()
+
+	if y.pos == len(y.lines) {
+		return parser.NewNull(), nil
+	}
+
+	indent, text, err := y.line()
+	if err != nil {
+		return nil, err
+	}
+
+	if inMapping && indent == parent && isSequenceEntry(text) {
+		return y.sequence(indent)
+	}
+
+	return y.node(parent)
+}
+
+// quotedScalar reads a single- or double-quoted scalar that makes up the rest of the line.
+func (y *yamlReader) quotedScalar(text string) (parser.Value, error) {
+	s, n, err := quoted(text)
+	if err != nil {
+		return nil, y.errorf("%v", err)
+	}
+
+	if strings.TrimSpace(text[n:]) != "" {
+		return nil, y.errorf("unexpected %q after quoted scalar", strings.TrimSpace(text[n:]))
+	}
+
+	y.pos++
+
+	return parser.NewString(s), nil
+}
+
+// plainScalar reads a plain scalar, which continues on the following lines indented
+// more than parent. The lines are folded into one, an empty line becoming a line break.
+func (y *yamlReader) plainScalar(text string, parent int) (parser.Value, error) {
+	start := y.pos
+	y.pos++
+
+	breaks := ""
+
+	for ; y.pos < len(y.lines); y.pos++ {
+		raw := y.lines[y.pos]
+		if strings.TrimSpace(raw) == "" {
+			breaks += "\n"
+			continue
+		}
+
+		indent, next, err := y.line()
+		if err != nil || indent <= parent || isBlank(raw) || isDocumentMarker(indent, next) {
+			break
+		}
+
+		if _, _, ok := mappingKey(next); ok {
+			return nil, y.errorf("unexpected mapping entry in plain scalar")
+		}
+
+		if breaks == "" {
+			breaks = " "
+		}
+
+		text += breaks + next
+		breaks = ""
+	}
+
+	// Trailing empty lines belong to whatever follows
+	for y.pos > start+1 && strings.TrimSpace(y.lines[y.pos-1]) == "" {
+		y.pos--
+	}
+
+	v, err := resolveScalar(text)
+	if err != nil {
+		y.pos = start
+		return nil, y.errorf("%v", err)
+	}
+
+	return v, nil
+}
+
+// blockScalar reads a literal (|) or folded (>) block scalar with the given header.
+func (y *yamlReader) blockScalar(header string, parent int) (parser.Value, error) {
+	chomp, explicit := byte(0), 0
+
+	for i := 1; i < len(header); i++ {
+		switch c := header[i]; {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return nil, y.errorf("invalid block scalar header %q", header)
+		}
+	}
+
+	y.pos++
+
+	indent := -1
+	if explicit > 0 {
+		indent = max(parent, 0) + explicit
+	}
+
+	var lines []string
+
+	for ; y.pos < len(y.lines); y.pos++ {
+		raw := y.lines[y.pos]
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		ind := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		if indent < 0 {
+			if ind <= parent {
+				break
+			}
+
+			indent = ind
+		}
+
+		if ind < indent || isDocumentMarker(ind, raw) {
+			break
+		}
+
+		lines = append(lines, raw[indent:])
+	}
+
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+
+	y.pos -= trailing
+	lines = lines[:len(lines)-trailing]
+
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = fold(lines)
+	}
+
+	switch {
+	case len(lines) == 0 && chomp != '+':
+	case chomp == '-':
+	case chomp == '+':
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		text += "\n"
+	}
+
+	return parser.NewString(text), nil
+}
+
+// fold joins the lines of a folded block scalar: a line break between two lines becomes
+// a space, unless empty or more indented lines surround it.
+func fold(lines []string) string {
+	var b strings.Builder
+
+	empty := 0
+
+	for i, line := range lines {
+		if line == "" {
+			empty++
+			continue
+		}
+
+		switch {
+		case b.Len() == 0 && i == empty:
+			b.WriteString(strings.Repeat("\n", empty))
+		case line[0] == ' ' || lines[i-empty-1][0] == ' ':
+			b.WriteString(strings.Repeat("\n", empty+1))
+		case empty > 0:
+			b.WriteString(strings.Repeat("\n", empty))
+		default:
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(line)
+
+		empty = 0
+	}
+
+	return b.String()
+}
+
+// flow reads a flow collection, which may continue on the following lines.
+func (y *yamlReader) flow(text string) (parser.Value, error) {
+	start := y.pos
+
+	for flowDepth(text) > 0 {
+		y.pos++
+		if y.pos == len(y.lines) {
+			y.pos = start
+			return nil, y.errorf("unterminated flow collection")
+		}
+
+		_, next, err := y.line()
+		if err != nil {
+			return nil, err
+		}
+
+		text += " " + next
+	}
+
+	f := &flowReader{s: text, anchors: y.anchors}
+
+	v, err := f.value()
+	if err == nil {
+		f.space()
+
+		if f.i < len(f.s) {
+			err = fmt.Errorf("unexpected %q after flow collection", f.s[f.i:])
+		}
+	}
+
+	if err != nil {
+		y.pos = start
+		return nil, y.errorf("%v", err)
+	}
+
+	y.pos++
+
+	return v, nil
+}
+
+// flowDepth returns the number of flow collections left open at the end of text.
+func flowDepth(text string) int {
+	depth := 0
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '"', '\'':
+			if _, n, err := quoted(text[i:]); err == nil {
+				i += n - 1
+			}
+		}
+	}
+
+	return depth
+}
+
+// flowReader reads a flow collection from a string.
+type flowReader struct {
+	s       string
+	i       int
+	anchors map[string]parser.Value
+}
+
+// space skips spaces.
+func (f *flowReader) space() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t') {
+		f.i++
+	}
+}
+
+// value reads a flow node.
+func (f *flowReader) value() (parser.Value, error) {
+	f.space()
+
+	if f.i == len(f.s) {
+		return nil, fmt.Errorf("unexpected end of flow collection")
+	}
+
+	switch c := f.s[f.i]; c {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		s, n, err := quoted(f.s[f.i:])
+		if err != nil {
+			return nil, err
+		}
+
+		f.i += n
+
+		return parser.NewString(s), nil
+	case '*':
+		name := f.plain()
+		if v, ok := f.anchors[name[1:]]; ok {
+			return v, nil
+		}
+
+		return nil, fmt.Errorf("unknown alias %q", name[1:])
+	case '&', '!':
+		return nil, fmt.Errorf("anchors and tags are not supported in flow collections")
+	default:
+		return resolveScalar(f.plain())
+	}
+}
+
+// plain reads a plain scalar, which ends before a flow indicator or a colon followed by
+// a space or a flow indicator.
+func (f *flowReader) plain() string {
+	start := f.i
+
+	for ; f.i < len(f.s); f.i++ {
+		c := f.s[f.i]
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+
+		if c == ':' && (f.i+1 == len(f.s) || strings.IndexByte(" ,]}", f.s[f.i+1]) >= 0) {
+			break
+		}
+	}
+
+	return strings.TrimSpace(f.s[start:f.i])
+}
+
+// separator skips the comma after an entry and reports whether close follows instead.
+func (f *flowReader) separator(close byte) (bool, error) {
+	f.space()
+
+	switch {
+	case f.i == len(f.s):
+		return false, fmt.Errorf("unexpected end of flow collection")
+	case f.s[f.i] == close:
+		f.i++
+		return true, nil
+	case f.s[f.i] == ',':
+		f.i++
+		f.space()
+
+		// A trailing comma is allowed
+		if f.i < len(f.s) && f.s[f.i] == close {
+			f.i++
+			return true, nil
+		}
+
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected ',' or '%c', found %q", close, f.s[f.i])
+	}
+}
+
+// sequence reads a flow sequence.
+func (f *flowReader) sequence() (parser.Value, error) {
+	arr := parser.NewArray()
+	f.i++
+	f.space()
+
+	if f.i < len(f.s) && f.s[f.i] == ']' {
+		f.i++
+		return arr, nil
+	}
+
+	for {
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+
+		arr.Elements = append(arr.Elements, v)
+
+		if done, err := f.separator(']'); err != nil || done {
+			return arr, err
+		}
+	}
+}
+
+// mapping reads a flow mapping.
+func (f *flowReader) mapping() (parser.Value, error) {
+	obj := parser.NewObject()
+	f.i++
+	f.space()
+
+	if f.i < len(f.s) && f.s[f.i] == '}' {
+		f.i++
+		return obj, nil
+	}
+
+	for {
+		f.space()
+
+		var key string
+
+		if f.i < len(f.s) && (f.s[f.i] == '"' || f.s[f.i] == '\'') {
+			s, n, err := quoted(f.s[f.i:])
+			if err != nil {
+				return nil, err
+			}
+
+			key = s
+			f.i += n
+		} else {
+			key = f.plain()
+		}
+
+		f.space()
+
+		if f.i == len(f.s) || f.s[f.i] != ':' {
+			return nil, fmt.Errorf("expected ':' after key %q", key)
+		}
+
+		f.i++
+
+		if _, dup := obj.Get(key); dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(key, v)
+
+		if done, err := f.separator('}'); err != nil || done {
+			return obj, err
+		}
+	}
+}
+
+// stripComment removes the comment at the end of a line, if any. A comment starts with a
+// '#' outside quotes, at the start of the line or after whitespace.
+func stripComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", text[i-1]) >= 0):
+			if _, n, err := quoted(text[i:]); err == nil {
+				i += n - 1
+			}
+		}
+	}
+
+	return strings.TrimRight(text, " \t")
+}
+
+// quoted unquotes the single- or double-quoted scalar at the start of text and returns
+// it with the length of its quoted form.
+func quoted(text string) (string, int, error) {
+	var b strings.Builder
+
+	if text[0] == '\'' {
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				b.WriteByte(text[i])
+			} else if i+1 < len(text) && text[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+			} else {
+				return b.String(), i + 1, nil
+			}
+		}
+
+		return "", 0, fmt.Errorf("unterminated quoted scalar")
+	}
+
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			n, err := unescape(&b, text[i+1:])
+			if err != nil {
+				return "", 0, err
+			}
+
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated quoted scalar")
+}
+
+// yamlEscapes maps the single-character escapes of double-quoted scalars to their values.
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085",
+	'_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// unescape writes the value of the escape sequence at the start of text, just after its
+// backslash, and returns the length of the sequence.
+func unescape(b *strings.Builder, text string) (int, error) {
+	if text == "" {
+		return 0, fmt.Errorf("unterminated quoted scalar")
+	}
+
+	if s, ok := yamlEscapes[text[0]]; ok {
+		b.WriteString(s)
+		return 1, nil
+	}
+
+	size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[text[0]]
+	if size == 0 || len(text) <= size {
+		return 0, fmt.Errorf("invalid escape sequence \\%c", text[0])
+	}
+
+	code, err := strconv.ParseUint(text[1:1+size], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, fmt.Errorf("invalid escape sequence \\%s", text[:1+size])
+	}
+
+	b.WriteRune(rune(code))
+
+	return 1 + size, nil
+}
+
+// resolveScalar returns the value of a plain scalar under the YAML 1.2 core schema.
+func resolveScalar(s string) (parser.Value, error) {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return parser.NewNull(), nil
+	case "true", "True", "TRUE":
+		return parser.NewBoolean(true), nil
+	case "false", "False", "FALSE":
+		return parser.NewBoolean(false), nil
+	}
+
+	switch strings.ToLower(strings.TrimLeft(s, "+-")) {
+	case ".inf", ".nan":
+		return nil, fmt.Errorf("%s cannot be represented in JSON", s)
+	}
+
+	base, digits := 10, s
+
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		base, digits = 16, s[2:]
+	case strings.HasPrefix(s, "0o"):
+		base, digits = 8, s[2:]
+	case !yamlDecimal.MatchString(s):
+		if !yamlFloat.MatchString(s) {
+			return parser.NewString(s), nil
+		}
+
+		if jsonNumber.MatchString(s) {
+			return parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: s}), nil
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("number %s is out of range", s)
+		}
+
+		return parser.NewFloat(f)
+	}
+
+	n, err := strconv.ParseInt(digits, base, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return nil, fmt.Errorf("integer %s is out of range", s)
+	} else if err != nil {
+		return parser.NewString(s), nil
+	}
+
+	return parser.NewNumber(n), nil
+}
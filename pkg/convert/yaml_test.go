@@ -0,0 +1,195 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/convert"
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+func TestYAMLToValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Block mapping",
+			input: `# service config
+name: api   # inline comment
+port: 8080
+ratio: 0.5
+debug: false
+owner: ~
+url: http://example.com/#top
+`,
+			expected: `{"name":"api","port":8080,"ratio":0.5,"debug":false,"owner":null,"url":"http://example.com/#top"}`,
+		},
+		{
+			name: "Nested collections",
+			input: `---
+servers:
+  - host: a
+    ports: [80, 443]
+  - host: b
+    ports:
+    - 8080
+tags:
+- - x
+  - y
+- {k: v, "q": 'it''s'}
+empty:
+`,
+			expected: `{"servers":[{"host":"a","ports":[80,443]},{"host":"b","ports":[8080]}],"tags":[["x","y"],{"k":"v","q":"it's"}],"empty":null}`,
+		},
+		{
+			name:     "Scalars",
+			input:    "- 0x1F\n- 0o17\n- +12\n- 007\n- 1e3\n- .5\n- 1.\n- True\n- 'true'\n- \"a\\tb\\u00e9\"\n- a - b",
+			expected: `[31,15,12,7,1e3,0.5,1,true,"true","a\tbé","a - b"]`,
+		},
+		{
+			name: "Block scalars",
+			input: `literal: |
+  line one
+    indented
+
+  line three
+folded: >-
+  folded
+  text
+
+  new paragraph
+keep: |+
+  kept
+
+strip: |-
+  stripped
+`,
+			expected: `{"literal":"line one\n  indented\n\nline three\n","folded":"folded text\nnew paragraph","keep":"kept\n\n","strip":"stripped"}`,
+		},
+		{
+			name:     "Plain multi-line scalar",
+			input:    "text: first\n  second\n\n  third\nnext: 1",
+			expected: `{"text":"first second\nthird","next":1}`,
+		},
+		{
+			name:     "Multi-line flow collection",
+			input:    "list: [\n  a, # first\n  b,\n]\n",
+			expected: `{"list":["a","b"]}`,
+		},
+		{
+			name:     "Anchors and aliases",
+			input:    "base: &base\n  retries: 3\nservice:\n  defaults: *base\n  name: &n svc\n  alias: *n",
+			expected: `{"base":{"retries":3},"service":{"defaults":{"retries":3},"name":"svc","alias":"svc"}}`,
+		},
+		{
+			name:     "Top-level scalar",
+			input:    "--- hello world\n...\n",
+			expected: `"hello world"`,
+		},
+		{
+			name:     "Empty document",
+			input:    "# nothing here\n",
+			expected: `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := convert.YAMLToValue(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if v.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, v.String())
+			}
+		})
+	}
+}
+
+func TestYAMLToValueErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "Duplicate key", input: "a: 1\na: 2", err: `line 2: duplicate key "a"`},
+		{name: "Bad indentation", input: "a:\n  b: 1\n c: 2", err: "line 3: unexpected indentation"},
+		{name: "Tab indentation", input: "a:\n\tb: 1", err: "line 2: tabs are not allowed"},
+		{name: "Infinity", input: "a: .inf", err: "line 1: .inf cannot be represented in JSON"},
+		{name: "Unknown alias", input: "a: *missing", err: `line 1: unknown alias "missing"`},
+		{name: "Tag", input: "a: !!str 1", err: "line 1: tags are not supported"},
+		{name: "Multiple documents", input: "a: 1\n---\nb: 2", err: "line 2: multiple documents"},
+		{name: "Unterminated flow", input: "a: [1, 2\nb: 3", err: "line 1: unterminated flow collection"},
+		{name: "Unterminated string", input: `a: "open`, err: "line 1: unterminated quoted scalar"},
+		{name: "Integer overflow", input: "a: 99999999999999999999", err: "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := convert.YAMLToValue(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValueToYAML(t *testing.T) {
+	input := `{
+		"name": "api",
+		"version": "1.0",
+		"enabled": "yes",
+		"port": 8080,
+		"tags": ["a", "- b", ""],
+		"db": {"hosts": [{"host": "x", "port": 1}, [1, 2]], "opts": {}, "list": []},
+		"note": "line\nbreak",
+		"key: colon": null
+	}`
+
+	expected := `name: api
+version: "1.0"
+enabled: "yes"
+port: 8080
+tags:
+  - a
+  - "- b"
+  - ""
+db:
+  hosts:
+    - host: x
+      port: 1
+    - - 1
+      - 2
+  opts: {}
+  list: []
+note: "line\nbreak"
+"key: colon": null
+`
+
+	v, err := encoding.Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := convert.ValueToYAML(v, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	back, err := convert.YAMLToValue(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading the output back: %v", err)
+	}
+
+	if !parser.Equal(v, back) {
+		t.Errorf("Round trip changed the value: %s", back)
+	}
+}
@@ -0,0 +1,175 @@
+package convert
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// ValueToYAML writes v to w as a YAML document in block style, indented by two spaces.
+// Object members keep their order, and strings are quoted when they would otherwise be
+// read back as another type or are not valid plain scalars, so that YAMLToValue returns
+// a value equal to v.
+func ValueToYAML(v parser.Value, w io.Writer) error {
+	y := &yamlWriter{}
+
+	switch val := v.(type) {
+	case *parser.Object:
+		if len(val.Pairs) > 0 {
+			y.mapping(val, 0, false)
+			break
+		}
+
+		y.b.WriteString("{}\n")
+	case *parser.Array:
+		if len(val.Elements) > 0 {
+			y.sequence(val, 0, false)
+			break
+		}
+
+		y.b.WriteString("[]\n")
+	default:
+		y.b.WriteString(yamlScalar(v) + "\n")
+	}
+
+	_, err := io.WriteString(w, y.b.String())
+
+	return err
+}
+
+// yamlWriter writes a syntax tree as YAML.
+type yamlWriter struct {
+	b strings.Builder
+}
+
+// mapping writes the members of a non-empty object indented by indent spaces. When
+// inline is set, the indentation of the first line has already been written.
+func (y *yamlWriter) mapping(obj *parser.Object, indent int, inline bool) {
+	for i, key := range obj.OrderedKeys() {
+		if i > 0 || !inline {
+			y.b.WriteString(strings.Repeat(" ", indent))
+		}
+
+		y.b.WriteString(yamlString(key) + ":")
+		y.entry(obj.Pairs[key], indent+2)
+	}
+}
+
+// sequence writes the elements of a non-empty array indented by indent spaces. When
+// inline is set, the indentation of the first line has already been written.
+func (y *yamlWriter) sequence(arr *parser.Array, indent int, inline bool) {
+	for i, elem := range arr.Elements {
+		if i > 0 || !inline {
+			y.b.WriteString(strings.Repeat(" ", indent))
+		}
+
+		y.b.WriteString("-")
+
+		switch val := elem.(type) {
+		case *parser.Object:
+			if len(val.Pairs) > 0 {
+				y.b.WriteByte(' ')
+				y.mapping(val, indent+2, true)
+
+				continue
+			}
+		case *parser.Array:
+			if len(val.Elements) > 0 {
+				y.b.WriteByte(' ')
+				y.sequence(val, indent+2, true)
+
+				continue
+			}
+		}
+
+		y.b.WriteString(" " + yamlScalar(elem) + "\n")
+	}
+}
+
+// entry writes the value of an object member after its key: a non-empty container on
+// the following lines, indented by indent spaces, or a scalar on the same line.
+func (y *yamlWriter) entry(v parser.Value, indent int) {
+	switch val := v.(type) {
+	case *parser.Object:
+		if len(val.Pairs) > 0 {
+			y.b.WriteByte('\n')
+			y.mapping(val, indent, false)
+
+			return
+		}
+	case *parser.Array:
+		if len(val.Elements) > 0 {
+			y.b.WriteByte('\n')
+			y.sequence(val, indent, false)
+
+			return
+		}
+	}
+
+	y.b.WriteString(" " + yamlScalar(v) + "\n")
+}
+
+// yamlScalar returns a scalar or an empty container in flow style.
+func yamlScalar(v parser.Value) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case *parser.Object:
+		return "{}"
+	case *parser.Array:
+		return "[]"
+	case *parser.StringLiteral:
+		return yamlString(val.Value)
+	default:
+		return v.String()
+	}
+}
+
+// yaml11Booleans are the plain scalars that YAML 1.1 readers take for booleans. They are
+// quoted for the sake of those readers.
+var yaml11Booleans = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"n": true, "N": true, "no": true, "No": true, "NO": true,
+	"on": true, "On": true, "ON": true, "off": true, "Off": true, "OFF": true,
+}
+
+// yamlString returns s as a plain scalar when it reads back as the same string, and as
+// a double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if needsQuotes(s) {
+		return parser.NewString(s).String()
+	}
+
+	return s
+}
+
+// needsQuotes reports whether s cannot be written as a plain scalar.
+func needsQuotes(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || yaml11Booleans[s] {
+		return true
+	}
+
+	if v, err := resolveScalar(s); err != nil {
+		return true
+	} else if _, ok := v.(*parser.StringLiteral); !ok {
+		return true
+	}
+
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return true
+	}
+
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+
+	for _, r := range s {
+		if !unicode.IsPrint(r) && r != ' ' {
+			return true
+		}
+	}
+
+	return false
+}
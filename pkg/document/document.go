@@ -0,0 +1,242 @@
+// Package document provides a mutable JSON document addressed by dotted paths such as
+// "servers[0].port", for tools that read, edit and write back configuration files.
+//
+//	doc, err := document.Parse(data)
+//	...
+//	port, _ := doc.Get("servers[0].port")
+//	_ = doc.Set("servers[0].tls.enabled", true)
+//	_ = doc.Delete("legacy")
+//	out, err := doc.Bytes()
+//
+// Object members keep their order, so untouched parts of a document are written back
+// as they were read.
+package document
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// ErrNotFound is returned when a path does not address an existing value.
+var ErrNotFound = errors.New("path not found")
+
+// Document is a JSON document that can be read and modified by path. It is not safe for
+// concurrent use.
+type Document struct {
+	root parser.Value
+	opts []encoding.Option
+}
+
+// Parse parses data into a Document. opts apply to parsing (size limit, JSONC, duplicate
+// keys, ...) and to Bytes.
+func Parse(data []byte, opts ...encoding.Option) (*Document, error) {
+	root, err := encoding.Parse(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{root: root, opts: opts}, nil
+}
+
+// New creates a Document from a Go value, as parser.FromInterface converts it.
+func New(v interface{}, opts ...encoding.Option) (*Document, error) {
+	root, err := parser.FromInterface(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{root: root, opts: opts}, nil
+}
+
+// Root returns the root value of the document.
+func (d *Document) Root() parser.Value {
+	return d.root
+}
+
+// Get returns the value at path. The empty path addresses the root.
+func (d *Document) Get(path string) (parser.Value, error) {
+	segments, err := encoding.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.lookup(segments)
+}
+
+// Exists reports whether path addresses an existing value.
+func (d *Document) Exists(path string) bool {
+	_, err := d.Get(path)
+	return err == nil
+}
+
+// Set stores v, converted with parser.FromInterface, at path. Missing objects and arrays
+// along the path are created, and an index equal to the length of an array appends to
+// it. Setting the empty path replaces the root.
+func (d *Document) Set(path string, v interface{}) error {
+	segments, err := encoding.ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	value, err := parser.FromInterface(v)
+	if err != nil {
+		return fmt.Errorf("set %q: %w", path, err)
+	}
+
+	if len(segments) == 0 {
+		d.root = value
+		return nil
+	}
+
+	parent, err := d.ensure(segments)
+	if err != nil {
+		return err
+	}
+
+	last := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case *parser.Object:
+		if last.IsIndex {
+			return fmt.Errorf("set %q: cannot index object at %q", path, encoding.FormatPath(segments[:len(segments)-1]))
+		}
+
+		container.Set(last.Key, value)
+	case *parser.Array:
+		if !last.IsIndex {
+			return fmt.Errorf("set %q: cannot set key %q on array", path, last.Key)
+		}
+
+		switch {
+		case last.Index < len(container.Elements):
+			container.Elements[last.Index] = value
+		case last.Index == len(container.Elements):
+			container.Elements = append(container.Elements, value)
+		default:
+			return fmt.Errorf("set %q: index %d out of range (length %d)", path, last.Index, len(container.Elements))
+		}
+	default:
+		return fmt.Errorf("set %q: %q is not an object or array", path, encoding.FormatPath(segments[:len(segments)-1]))
+	}
+
+	return nil
+}
+
+// Delete removes the value at path. Deleting an array element shifts the elements after
+// it. The root cannot be deleted.
+func (d *Document) Delete(path string) error {
+	segments, err := encoding.ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	if len(segments) == 0 {
+		return fmt.Errorf("cannot delete the document root")
+	}
+
+	parent, err := d.lookup(segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+
+	last := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case *parser.Object:
+		if _, ok := container.Pairs[last.Key]; last.IsIndex || !ok {
+			return fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+
+		container.Delete(last.Key)
+	case *parser.Array:
+		if !last.IsIndex || last.Index >= len(container.Elements) {
+			return fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+
+		container.Elements = append(container.Elements[:last.Index], container.Elements[last.Index+1:]...)
+	default:
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	return nil
+}
+
+// Bytes serializes the document with the options given to Parse or New.
+func (d *Document) Bytes() ([]byte, error) {
+	return encoding.Marshal(d.root, d.opts...)
+}
+
+// MarshalJSON implements encoding.Marshaler
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return d.Bytes()
+}
+
+// lookup follows segments from the root.
+func (d *Document) lookup(segments []parser.PathElement) (parser.Value, error) {
+	current := d.root
+
+	for i, s := range segments {
+		next, ok := child(current, s)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, encoding.FormatPath(segments[:i+1]))
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
+
+// ensure follows all but the last of segments from the root and returns the parent of
+// the value they address. A missing key is created as an array when the segment after it
+// is an index, and as an object otherwise.
+func (d *Document) ensure(segments []parser.PathElement) (parser.Value, error) {
+	current := d.root
+
+	for i, s := range segments[:len(segments)-1] {
+		if next, ok := child(current, s); ok {
+			current = next
+			continue
+		}
+
+		obj, isObject := current.(*parser.Object)
+		if !isObject || s.IsIndex {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, encoding.FormatPath(segments[:i+1]))
+		}
+
+		var next parser.Value = parser.NewObject()
+		if segments[i+1].IsIndex {
+			next = parser.NewArray()
+		}
+
+		obj.Set(s.Key, next)
+		current = next
+	}
+
+	return current, nil
+}
+
+// child returns the member or element of v addressed by s.
+func child(v parser.Value, s parser.PathElement) (parser.Value, bool) {
+	switch container := v.(type) {
+	case *parser.Object:
+		if s.IsIndex {
+			return nil, false
+		}
+
+		value, ok := container.Pairs[s.Key]
+
+		return value, ok
+	case *parser.Array:
+		if !s.IsIndex || s.Index >= len(container.Elements) {
+			return nil, false
+		}
+
+		return container.Elements[s.Index], true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,170 @@
+package document_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/document"
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+const config = `{"name": "api", "servers": [{"host": "a", "port": 80}, {"host": "b", "port": 81}], "legacy": true}`
+
+func parse(t *testing.T) *document.Document {
+	t.Helper()
+
+	doc, err := document.Parse([]byte(config))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return doc
+}
+
+func TestGet(t *testing.T) {
+	doc := parse(t)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "name", want: `"api"`},
+		{path: "servers[1].host", want: `"b"`},
+		{path: "servers[0].port", want: "80"},
+		{path: "legacy", want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, err := doc.Get(tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := v.String(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if root, err := doc.Get(""); err != nil || root != doc.Root() {
+		t.Errorf("Expected the empty path to address the root, got %v (%v)", root, err)
+	}
+
+	for _, path := range []string{"missing", "servers[2]", "servers[0].port.x", "name[0]"} {
+		if _, err := doc.Get(path); !errors.Is(err, document.ErrNotFound) {
+			t.Errorf("Get(%q): expected ErrNotFound, got %v", path, err)
+		}
+
+		if doc.Exists(path) {
+			t.Errorf("Exists(%q) = true", path)
+		}
+	}
+
+	for _, path := range []string{"servers[x]", "servers[*].port", "a..b"} {
+		if _, err := doc.Get(path); err == nil || errors.Is(err, document.ErrNotFound) {
+			t.Errorf("Get(%q): expected a path syntax error, got %v", path, err)
+		}
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	doc := parse(t)
+
+	steps := []func() error{
+		func() error { return doc.Set("servers[0].port", 8080) },
+		func() error { return doc.Set("servers[2]", map[string]interface{}{"host": "c"}) },
+		func() error { return doc.Set("servers[1].tls.enabled", true) },
+		func() error { return doc.Set("tags[0]", "x") },
+		func() error { return doc.Delete("legacy") },
+		func() error { return doc.Delete("servers[1].port") },
+	}
+
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d: unexpected error: %v", i, err)
+		}
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{"name":"api","servers":[{"host":"a","port":8080},{"host":"b","tls":{"enabled":true}},{"host":"c"}],"tags":["x"]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	if err := doc.Delete("servers[0]"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if v, _ := doc.Get("servers[0].host"); v == nil || v.String() != `"b"` {
+		t.Errorf("Expected elements to shift after delete, got %v", v)
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	doc := parse(t)
+
+	tests := []struct {
+		name string
+		path string
+		msg  string
+	}{
+		{name: "Index out of range", path: "servers[5]", msg: "index 5 out of range"},
+		{name: "Key on array", path: "servers.x", msg: `cannot set key "x" on array`},
+		{name: "Index on object", path: "name[0]", msg: "not an object or array"},
+		{name: "Missing array", path: "ports[1]", msg: "index 1 out of range (length 0)"},
+		{name: "Missing element", path: "servers[4].host", msg: "path not found"},
+		{name: "Scalar parent", path: "name.first", msg: "not an object or array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.Set(tt.path, 1)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected error containing %q, got %v", tt.msg, err)
+			}
+		})
+	}
+
+	if err := doc.Delete("missing"); !errors.Is(err, document.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := doc.Delete(""); err == nil {
+		t.Error("Expected an error deleting the root")
+	}
+}
+
+func TestNewAndMarshal(t *testing.T) {
+	doc, err := document.New(map[string]interface{}{"b": 1}, encoding.WithSortKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := doc.Set("a", "x"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := encoding.Marshal(map[string]interface{}{"doc": doc})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(data) != `{"doc":{"a":"x","b":1}}` {
+		t.Errorf("Unexpected output: %s", data)
+	}
+
+	if err := doc.Set("", parser.NewNull()); err != nil || doc.Root().String() != "null" {
+		t.Errorf("Expected the root to be replaced, got %v (%v)", doc.Root(), err)
+	}
+
+	if _, err := document.Parse([]byte(`{"a": }`)); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
@@ -0,0 +1,213 @@
+package encoding
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Decimal is an arbitrary-precision decimal number whose value is Coefficient × 10^Exponent.
+// It keeps the digits of a JSON number exactly, including trailing zeros, so that 1.50 is
+// written back as 1.50. The zero value is 0.
+type Decimal struct {
+	Coefficient *big.Int
+	Exponent    int32
+}
+
+// ParseDecimal parses a JSON number into a Decimal.
+func ParseDecimal(s string) (Decimal, error) {
+	if n := parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: s}); !n.IsValid && !n.IsBig {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	mantissa, exp, hasExp := strings.Cut(strings.ToLower(s), "e")
+
+	exponent := int64(0)
+
+	if hasExp {
+		var err error
+		if exponent, err = strconv.ParseInt(exp, 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("decimal %q has an exponent out of range", s)
+		}
+	}
+
+	whole, frac, _ := strings.Cut(mantissa, ".")
+
+	exponent -= int64(len(frac))
+	if exponent < -1<<31 {
+		return Decimal{}, fmt.Errorf("decimal %q has an exponent out of range", s)
+	}
+
+	coefficient, _ := new(big.Int).SetString(whole+frac, 10)
+
+	return Decimal{Coefficient: coefficient, Exponent: int32(exponent)}, nil
+}
+
+// String returns d as a JSON number: in positional notation when it has no positive
+// exponent and few leading zeros, and as the coefficient and an exponent otherwise.
+func (d Decimal) String() string {
+	if d.Coefficient == nil {
+		return "0"
+	}
+
+	digits := d.Coefficient.String()
+
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+
+	switch exp := int(d.Exponent); {
+	case exp == 0:
+		return sign + digits
+	case exp > 0 || -exp > len(digits)+6:
+		return sign + digits + "e" + strconv.Itoa(exp)
+	case -exp >= len(digits):
+		return sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits
+	default:
+		point := len(digits) + exp
+
+		return sign + digits[:point] + "." + digits[point:]
+	}
+}
+
+// Rat returns the exact value of d.
+func (d Decimal) Rat() *big.Rat {
+	if d.Coefficient == nil {
+		return new(big.Rat)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(int64(d.Exponent)))), nil)
+
+	if d.Exponent < 0 {
+		return new(big.Rat).SetFrac(d.Coefficient, scale)
+	}
+
+	return new(big.Rat).SetInt(new(big.Int).Mul(d.Coefficient, scale))
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	decimalType  = reflect.TypeOf(Decimal{})
+)
+
+// isBigNumberType reports whether t, or the type t points to, is big.Int, big.Float or
+// Decimal.
+func isBigNumberType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == bigIntType || t == bigFloatType || t == decimalType
+}
+
+// marshalBigNumber writes a big.Int, big.Float or Decimal, or a pointer to one, as a
+// number with all of its digits.
+func marshalBigNumber(v reflect.Value) (parser.Value, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return parser.NewNull(), nil
+		}
+
+		v = v.Elem()
+	}
+
+	var literal string
+
+	switch x := v.Interface().(type) {
+	case big.Int:
+		literal = x.String()
+	case big.Float:
+		if x.IsInf() {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("unsupported number %v", x.String()))
+		}
+
+		literal = x.Text('g', -1)
+	case Decimal:
+		literal = x.String()
+	}
+
+	return parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: literal}), nil
+}
+
+// unmarshalBigNumber stores num into rv, which holds a big.Int, big.Float or Decimal or a
+// pointer to one. Integers keep every digit; a big.Float gets enough precision for all the
+// digits of the literal.
+func unmarshalBigNumber(num *parser.NumberLiteral, rv reflect.Value) error {
+	t := rv.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var ptr reflect.Value
+
+	switch t {
+	case bigIntType:
+		x, ok := new(big.Int).SetString(num.String(), 10)
+		if !ok {
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot unmarshal %s into %v", num.String(), rv.Type()))
+		}
+
+		ptr = reflect.ValueOf(x)
+	case bigFloatType:
+		x, _, err := big.ParseFloat(num.String(), 10, bigFloatPrecision(num.String()), big.ToNearestEven)
+		if err != nil {
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot unmarshal %s into %v", num.String(), rv.Type())).WithCause(err)
+		}
+
+		ptr = reflect.ValueOf(x)
+	default:
+		d, err := ParseDecimal(num.String())
+		if err != nil {
+			return NewJSONError(ErrInvalidValue, "invalid decimal").WithCause(err)
+		}
+
+		ptr = reflect.ValueOf(&d)
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		rv.Set(ptr)
+	} else {
+		rv.Set(ptr.Elem())
+	}
+
+	return nil
+}
+
+// bigFloatPrecision returns the precision in bits that holds the digits of literal.
+func bigFloatPrecision(literal string) uint {
+	digits := 0
+
+	for _, c := range literal {
+		if c == 'e' || c == 'E' {
+			break
+		}
+
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+
+	// log2(10) < 3.33 bits per decimal digit
+	return max(64, uint(digits)*10/3+1)
+}
+
+// bigInterface returns the value stored in an interface{} for a number out of the range
+// of int64 and float64: a *big.Int for an integer literal and a *big.Float otherwise.
+func bigInterface(num *parser.NumberLiteral) (interface{}, error) {
+	if x, ok := new(big.Int).SetString(num.String(), 10); ok {
+		return x, nil
+	}
+
+	x, _, err := big.ParseFloat(num.String(), 10, bigFloatPrecision(num.String()), big.ToNearestEven)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidValue, "cannot unmarshal "+num.String()).WithCause(err)
+	}
+
+	return x, nil
+}
@@ -0,0 +1,144 @@
+package encoding_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestBigNumbers(t *testing.T) {
+	type account struct {
+		ID      *big.Int         `json:"id"`
+		Balance encoding.Decimal `json:"balance"`
+		Rate    *big.Float       `json:"rate"`
+		Total   big.Int          `json:"total"`
+	}
+
+	input := `{"id":340282366920938463463374607431768211455,"balance":12345678901234567890.50,` +
+		`"rate":1.00000000000000000000000001,"total":42}`
+
+	if err := encoding.Unmarshal([]byte(input), &account{}); err == nil {
+		t.Error("Expected numbers out of range to be rejected without WithBigNumbers")
+	}
+
+	var a account
+	if err := encoding.Unmarshal([]byte(input), &a, encoding.WithBigNumbers()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if a.ID.String() != "340282366920938463463374607431768211455" || a.Balance.String() != "12345678901234567890.50" ||
+		a.Total.Int64() != 42 {
+		t.Errorf("Unexpected result: %v %v %v", a.ID, a.Balance, &a.Total)
+	}
+
+	if a.Rate.Cmp(big.NewFloat(1)) <= 0 {
+		t.Errorf("Expected the rate to keep its precision, got %v", a.Rate)
+	}
+
+	data, err := encoding.Marshal(a)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(data) != input {
+		t.Errorf("Expected %s, got %s", input, data)
+	}
+}
+
+func TestBigNumbersInterface(t *testing.T) {
+	var v []interface{}
+	if err := encoding.Unmarshal([]byte(`[18446744073709551616, 1e400, 7]`), &v, encoding.WithBigNumbers()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if x, ok := v[0].(*big.Int); !ok || x.String() != "18446744073709551616" {
+		t.Errorf("Expected a *big.Int, got %T %v", v[0], v[0])
+	}
+
+	if x, ok := v[1].(*big.Float); !ok || x.IsInf() {
+		t.Errorf("Expected a finite *big.Float, got %T %v", v[1], v[1])
+	}
+
+	if v[2] != int64(7) {
+		t.Errorf("Expected int64 7, got %T %v", v[2], v[2])
+	}
+
+	var n []int64
+	if err := encoding.Unmarshal([]byte(`[18446744073709551616]`), &n, encoding.WithBigNumbers()); err == nil {
+		t.Error("Expected an overflow error for int64")
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		rat      string
+	}{
+		{input: "0", expected: "0", rat: "0/1"},
+		{input: "1.50", expected: "1.50", rat: "3/2"},
+		{input: "-0.001", expected: "-0.001", rat: "-1/1000"},
+		{input: "12e3", expected: "12e3", rat: "12000/1"},
+		{input: "1.5E-20", expected: "15e-21", rat: "3/200000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := encoding.ParseDecimal(tt.input)
+			if err != nil {
+				t.Fatalf("ParseDecimal failed: %v", err)
+			}
+
+			if d.String() != tt.expected || d.Rat().String() != tt.rat {
+				t.Errorf("Expected %s (%s), got %s (%s)", tt.expected, tt.rat, d, d.Rat())
+			}
+		})
+	}
+
+	for _, input := range []string{"", "1.2.3", "abc", "1e99999999999"} {
+		if _, err := encoding.ParseDecimal(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestInt64AsString(t *testing.T) {
+	type ids struct {
+		Small int64    `json:"small"`
+		Large int64    `json:"large"`
+		Neg   int      `json:"neg"`
+		Big   *big.Int `json:"big"`
+		Count int32    `json:"count"`
+	}
+
+	in := ids{Small: 9007199254740991, Large: 1<<53 + 1, Neg: -(1 << 60), Big: new(big.Int).Lsh(big.NewInt(1), 70), Count: 5}
+
+	data, err := encoding.Marshal(in, encoding.WithInt64AsString())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := `{"small":9007199254740991,"large":"9007199254740993","neg":"-1152921504606846976",` +
+		`"big":"1180591620717411303424","count":5}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var out ids
+	if err := encoding.Unmarshal(data, &out, encoding.WithInt64AsString()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if out.Small != in.Small || out.Large != in.Large || out.Neg != in.Neg || out.Big.Cmp(in.Big) != 0 {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+
+	if err := encoding.Unmarshal([]byte(`{"large":"1.5"}`), &out, encoding.WithInt64AsString()); err == nil {
+		t.Error("Expected an error for a string that is not an integer")
+	}
+
+	if err := encoding.Unmarshal([]byte(`{"large":"7"}`), &out); err == nil {
+		t.Error("Expected an error without WithInt64AsString")
+	}
+}
@@ -0,0 +1,497 @@
+// Package bson reads BSON documents, such as the .bson files written by mongodump, into
+// jingo syntax trees and Go values. BSON types that JSON lacks, such as ObjectIDs, dates
+// and binary data, are converted by a configurable set of Extensions: MongoDB Extended
+// JSON by default, or plain JSON values with SimpleJSON.
+//
+//	r := bson.NewReader(f, bson.WithExtensions(bson.SimpleJSON))
+//	for {
+//		doc, err := r.Read()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+package bson
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// MaxDocumentSize is the size of the largest document accepted, that of MongoDB.
+const MaxDocumentSize = 16 * 1024 * 1024
+
+// elementTypes lists the element types that can be decoded: every type of the BSON
+// specification, deprecated ones included.
+const elementTypes = "\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x7f\xff"
+
+// maxDepth bounds the nesting of embedded documents and arrays.
+const maxDepth = 1000
+
+// Option configures a Reader or Parse.
+type Option func(*decoder)
+
+// WithExtensions converts the BSON types that JSON lacks with ext. Nil functions of ext
+// fall back to those of ExtendedJSON.
+func WithExtensions(ext Extensions) Option {
+	return func(d *decoder) {
+		d.ext = ext.withDefaults()
+	}
+}
+
+// Parse decodes the single BSON document that makes up data. Errors are
+// *encoding.JSONError values carrying the offset of the problem.
+func Parse(data []byte, opts ...Option) (parser.Value, error) {
+	d := newDecoder(data, opts)
+
+	doc, err := d.document()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos < len(d.data) {
+		return nil, d.errorf("unexpected data after the document")
+	}
+
+	return doc, nil
+}
+
+// Unmarshal decodes the BSON document in data and stores it in the value pointed to by
+// v, as encoding.Unmarshal does for JSON.
+func Unmarshal(data []byte, v interface{}, opts ...Option) error {
+	doc, err := Parse(data, opts...)
+	if err != nil {
+		return err
+	}
+
+	return encoding.UnmarshalValue(doc, v)
+}
+
+// Reader reads a sequence of BSON documents, as found in database dumps.
+type Reader struct {
+	r      io.Reader
+	opts   []Option
+	offset int64
+}
+
+// NewReader creates a Reader that reads documents from r.
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	return &Reader{r: r, opts: opts}
+}
+
+// Read returns the next document, or io.EOF when the input ends between documents.
+// Offsets in errors are relative to the start of the input.
+func (r *Reader) Read() (parser.Value, error) {
+	var size [4]byte
+
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, r.errorf("truncated document length", 0)
+		}
+
+		return nil, err
+	}
+
+	n := int32(binary.LittleEndian.Uint32(size[:]))
+	if n < 5 || n > MaxDocumentSize {
+		return nil, r.errorf("invalid document length "+strconv.Itoa(int(n)), 0)
+	}
+
+	data := make([]byte, n)
+	copy(data, size[:])
+
+	if _, err := io.ReadFull(r.r, data[4:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, r.errorf("truncated document", 4)
+		}
+
+		return nil, err
+	}
+
+	doc, err := Parse(data, r.opts...)
+
+	var jsonErr *encoding.JSONError
+	if errors.As(err, &jsonErr) {
+		jsonErr.Offset += r.offset
+	}
+
+	r.offset += int64(n)
+
+	return doc, err
+}
+
+// errorf returns an error at offset from the start of the current document.
+func (r *Reader) errorf(msg string, offset int64) error {
+	return encoding.NewJSONError(encoding.ErrInvalidValue, "bson: "+msg).WithOffset(r.offset + offset)
+}
+
+// decoder reads a BSON document from a buffer.
+type decoder struct {
+	data  []byte
+	pos   int
+	depth int
+	ext   Extensions
+}
+
+// newDecoder creates a decoder for data with the given options.
+func newDecoder(data []byte, opts []Option) *decoder {
+	d := &decoder{data: data, ext: ExtendedJSON}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// errorf returns an error located at the current offset.
+func (d *decoder) errorf(msg string) *encoding.JSONError {
+	return encoding.NewJSONError(encoding.ErrInvalidValue, "bson: "+msg).WithOffset(int64(d.pos))
+}
+
+// next returns the next n bytes.
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, d.errorf("unexpected end of data")
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+// int32 reads a little-endian 32-bit integer.
+func (d *decoder) int32() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// uint64 reads a little-endian 64-bit integer.
+func (d *decoder) uint64() (uint64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// cstring reads a NUL-terminated string.
+func (d *decoder) cstring() (string, error) {
+	for i := d.pos; i < len(d.data); i++ {
+		if d.data[i] == 0 {
+			s := string(d.data[d.pos:i])
+			if !utf8.ValidString(s) {
+				return "", d.errorf("invalid UTF-8 in name")
+			}
+
+			d.pos = i + 1
+
+			return s, nil
+		}
+	}
+
+	return "", d.errorf("unterminated name")
+}
+
+// string reads a length-prefixed, NUL-terminated string.
+func (d *decoder) string() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+
+	if n < 1 || int(n) > len(d.data)-d.pos {
+		d.pos -= 4
+		return "", d.errorf("invalid string length")
+	}
+
+	b, _ := d.next(int(n))
+
+	if b[n-1] != 0 || !utf8.Valid(b[:n-1]) {
+		d.pos -= int(n)
+		return "", d.errorf("invalid string")
+	}
+
+	return string(b[:n-1]), nil
+}
+
+// elements reads the length-prefixed list of elements of a document or an array, calling
+// fn with the name and value of each.
+func (d *decoder) elements(fn func(name string, value parser.Value)) error {
+	start := d.pos
+
+	if d.depth++; d.depth > maxDepth {
+		return encoding.NewJSONError(encoding.ErrLimitExceeded, "bson: nesting too deep").
+			WithOffset(int64(start))
+	}
+
+	defer func() { d.depth-- }()
+
+	n, err := d.int32()
+	if err != nil {
+		return err
+	}
+
+	if n < 5 || int(n) > len(d.data)-start {
+		d.pos = start
+		return d.errorf("invalid document length")
+	}
+
+	end := start + int(n) - 1
+
+	for d.pos < end {
+		typ := d.data[d.pos]
+		if strings.IndexByte(elementTypes, typ) < 0 {
+			return d.errorf("unsupported element type 0x" + strconv.FormatUint(uint64(typ), 16))
+		}
+
+		d.pos++
+
+		name, err := d.cstring()
+		if err != nil {
+			return err
+		}
+
+		value, err := d.value(typ)
+		if err != nil {
+			return err
+		}
+
+		fn(name, value)
+	}
+
+	if d.pos != end || d.data[end] != 0 {
+		return d.errorf("document length does not match its content")
+	}
+
+	d.pos++
+
+	return nil
+}
+
+// document reads an embedded document.
+func (d *decoder) document() (*parser.Object, error) {
+	obj := parser.NewObject()
+
+	err := d.elements(obj.Set)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// array reads an array, which is a document whose names are the indexes.
+func (d *decoder) array() (*parser.Array, error) {
+	arr := parser.NewArray()
+
+	err := d.elements(func(_ string, value parser.Value) {
+		arr.Elements = append(arr.Elements, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return arr, nil
+}
+
+// value reads the value of an element of one of elementTypes.
+func (d *decoder) value(typ byte) (parser.Value, error) {
+	switch typ {
+	case 0x01:
+		bits, err := d.uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		f := math.Float64frombits(bits)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return d.ext.NonFinite(f), nil
+		}
+
+		return parser.NewFloat(f)
+	case 0x02, 0x0e:
+		s, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.NewString(s), nil
+	case 0x03:
+		return d.document()
+	case 0x04:
+		return d.array()
+	case 0x05:
+		return d.binary()
+	case 0x06, 0x0a:
+		return parser.NewNull(), nil
+	case 0x07:
+		b, err := d.next(12)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext.ObjectID([12]byte(b)), nil
+	case 0x08:
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] > 1 {
+			d.pos--
+			return nil, d.errorf("invalid boolean")
+		}
+
+		return parser.NewBoolean(b[0] == 1), nil
+	case 0x09:
+		ms, err := d.uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext.DateTime(int64(ms)), nil
+	case 0x0b:
+		pattern, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+
+		options, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext.Regex(pattern, options), nil
+	case 0x0c:
+		return d.dbPointer()
+	case 0x0d:
+		code, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext.Code(code, nil), nil
+	case 0x0f:
+		return d.codeWithScope()
+	case 0x10:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.NewNumber(int64(n)), nil
+	case 0x11:
+		ts, err := d.uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext.Timestamp(uint32(ts>>32), uint32(ts)), nil
+	case 0x12:
+		n, err := d.uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.NewNumber(int64(n)), nil
+	case 0x13:
+		b, err := d.next(16)
+		if err != nil {
+			return nil, err
+		}
+
+		low, high := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+
+		return d.ext.Decimal128(formatDecimal128(high, low)), nil
+	default:
+		return d.ext.Key(typ == 0x7f), nil
+	}
+}
+
+// binary reads binary data with its subtype.
+func (d *decoder) binary() (parser.Value, error) {
+	n, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 0 {
+		d.pos -= 4
+		return nil, d.errorf("invalid binary length")
+	}
+
+	b, err := d.next(int(n) + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	subtype, data := b[0], b[1:]
+
+	// The old binary subtype repeats the length of the data
+	if subtype == 0x02 && len(data) >= 4 && int(binary.LittleEndian.Uint32(data)) == len(data)-4 {
+		data = data[4:]
+	}
+
+	return d.ext.Binary(subtype, data), nil
+}
+
+// dbPointer reads a deprecated DBPointer as {"$ref": collection, "$id": id}.
+func (d *decoder) dbPointer() (parser.Value, error) {
+	ref, err := d.string()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := d.next(12)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := parser.NewObject()
+	obj.Set("$ref", parser.NewString(ref))
+	obj.Set("$id", d.ext.ObjectID([12]byte(id)))
+
+	return obj, nil
+}
+
+// codeWithScope reads JavaScript code with its scope document.
+func (d *decoder) codeWithScope() (parser.Value, error) {
+	start := d.pos
+
+	n, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := d.string()
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := d.document()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos-start != int(n) {
+		d.pos = start
+		return nil, d.errorf("code with scope length does not match its content")
+	}
+
+	return d.ext.Code(code, scope), nil
+}
@@ -0,0 +1,234 @@
+package bson_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/encoding/bson"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// element is a BSON element for building test documents.
+type element struct {
+	typ  byte
+	name string
+	data []byte
+}
+
+// document encodes elements as a BSON document.
+func document(elements ...element) []byte {
+	var body []byte
+
+	for _, e := range elements {
+		body = append(body, e.typ)
+		body = append(body, e.name...)
+		body = append(body, 0)
+		body = append(body, e.data...)
+	}
+
+	doc := binary.LittleEndian.AppendUint32(nil, uint32(len(body)+5))
+	doc = append(doc, body...)
+
+	return append(doc, 0)
+}
+
+func str(s string) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(s)+1)), append([]byte(s), 0)...)
+}
+
+func i32(n int32) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(n)) }
+
+func i64(n int64) []byte { return binary.LittleEndian.AppendUint64(nil, uint64(n)) }
+
+var objectID = []byte{0x5f, 0x1d, 0x7a, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+
+// sample holds one element of every supported type.
+var sample = document(
+	element{0x07, "_id", objectID},
+	element{0x02, "name", str("Ana")},
+	element{0x10, "age", i32(30)},
+	element{0x12, "views", i64(1 << 40)},
+	element{0x01, "score", i64(int64(math.Float64bits(9.5)))},
+	element{0x08, "active", []byte{1}},
+	element{0x0a, "none", nil},
+	element{0x09, "created", i64(1595757600123)},
+	element{0x05, "avatar", append(i32(3), 0x00, 'a', 'b', 'c')},
+	element{0x04, "tags", document(element{0x02, "0", str("x")}, element{0x10, "1", i32(2)})},
+	element{0x03, "address", document(element{0x02, "city", str("Recife")})},
+	element{0x0b, "pattern", []byte("^a\x00i\x00")},
+	element{0x11, "ts", i64(5<<32 | 7)},
+	element{0x13, "price", append(i64(150), i64(0x303c<<48)...)},
+	element{0x01, "inf", i64(int64(math.Float64bits(math.Inf(1))))},
+	element{0x0d, "code", str("f()")},
+	element{0xff, "min", nil},
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []bson.Option
+		expected string
+	}{
+		{
+			name: "Extended JSON",
+			expected: `{"_id":{"$oid":"5f1d7a001122334455667788"},"name":"Ana","age":30,"views":1099511627776,` +
+				`"score":9.5,"active":true,"none":null,"created":{"$date":"2020-07-26T10:00:00.123Z"},` +
+				`"avatar":{"$binary":{"base64":"YWJj","subType":"00"}},"tags":["x",2],"address":{"city":"Recife"},` +
+				`"pattern":{"$regularExpression":{"pattern":"^a","options":"i"}},"ts":{"$timestamp":{"t":5,"i":7}},` +
+				`"price":{"$numberDecimal":"1.50"},"inf":{"$numberDouble":"Infinity"},"code":{"$code":"f()"},"min":{"$minKey":1}}`,
+		},
+		{
+			name: "Simple JSON",
+			opts: []bson.Option{bson.WithExtensions(bson.SimpleJSON)},
+			expected: `{"_id":"5f1d7a001122334455667788","name":"Ana","age":30,"views":1099511627776,` +
+				`"score":9.5,"active":true,"none":null,"created":"2020-07-26T10:00:00.123Z",` +
+				`"avatar":"YWJj","tags":["x",2],"address":{"city":"Recife"},` +
+				`"pattern":"/^a/i","ts":{"t":5,"i":7},"price":"1.50","inf":null,"code":"f()","min":null}`,
+		},
+		{
+			name: "Custom ObjectID",
+			opts: []bson.Option{bson.WithExtensions(bson.Extensions{
+				ObjectID: func(id [12]byte) parser.Value { return parser.NewString("id") },
+			})},
+			expected: `{"_id":"id","name":"Ana","age":30,"views":1099511627776,` +
+				`"score":9.5,"active":true,"none":null,"created":{"$date":"2020-07-26T10:00:00.123Z"},` +
+				`"avatar":{"$binary":{"base64":"YWJj","subType":"00"}},"tags":["x",2],"address":{"city":"Recife"},` +
+				`"pattern":{"$regularExpression":{"pattern":"^a","options":"i"}},"ts":{"$timestamp":{"t":5,"i":7}},` +
+				`"price":{"$numberDecimal":"1.50"},"inf":{"$numberDouble":"Infinity"},"code":{"$code":"f()"},"min":{"$minKey":1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := bson.Parse(sample, tt.opts...)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if v.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestDecimal128(t *testing.T) {
+	tests := []struct {
+		high, low uint64
+		expected  string
+	}{
+		{high: 0x3040 << 48, low: 0, expected: "0"},
+		{high: 0xb040 << 48, low: 1, expected: "-1"},
+		{high: 0x3040 << 48, low: 123456789, expected: "123456789"},
+		{high: 0x3034 << 48, low: 1, expected: "0.000001"},
+		{high: 0x3030 << 48, low: 1, expected: "1E-8"},
+		{high: 0x3042 << 48, low: 15, expected: "1.5E+2"},
+		{high: 0x7800 << 48, low: 0, expected: "Infinity"},
+		{high: 0x7c00 << 48, low: 0, expected: "NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			doc := document(element{0x13, "d", append(i64(int64(tt.low)), i64(int64(tt.high))...)})
+
+			v, err := bson.Parse(doc, bson.WithExtensions(bson.SimpleJSON))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if s, _ := v.(*parser.Object).GetString("d"); s != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var user struct {
+		ID      string        `json:"_id"`
+		Name    string        `json:"name"`
+		Age     int           `json:"age"`
+		Created string        `json:"created"`
+		Tags    []interface{} `json:"tags"`
+		Score   float64       `json:"score"`
+	}
+
+	if err := bson.Unmarshal(sample, &user, bson.WithExtensions(bson.SimpleJSON)); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if user.ID != "5f1d7a001122334455667788" || user.Name != "Ana" || user.Age != 30 ||
+		user.Created != "2020-07-26T10:00:00.123Z" || len(user.Tags) != 2 || user.Score != 9.5 {
+		t.Errorf("Unexpected result: %+v", user)
+	}
+}
+
+func TestReader(t *testing.T) {
+	first := document(element{0x10, "n", i32(1)})
+	second := document(element{0x10, "n", i32(2)})
+
+	r := bson.NewReader(bytes.NewReader(append(append(first, second...), 0x05, 0x00)))
+
+	for _, expected := range []string{`{"n":1}`, `{"n":2}`} {
+		v, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+
+		if v.String() != expected {
+			t.Errorf("Expected %s, got %s", expected, v)
+		}
+	}
+
+	_, err := r.Read()
+
+	var jsonErr *encoding.JSONError
+	if !errors.As(err, &jsonErr) || jsonErr.Offset != int64(2*len(first)) {
+		t.Errorf("Expected a truncated length error at offset %d, got %v", 2*len(first), err)
+	}
+
+	r = bson.NewReader(bytes.NewReader(first))
+	r.Read()
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := document(element{0x02, "a", str("b")})
+
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int64
+	}{
+		{name: "Empty", data: nil, offset: 0},
+		{name: "Length too large", data: append([]byte{0xff, 0, 0, 0}, valid[4:]...), offset: 0},
+		{name: "Missing terminator", data: append(append([]byte{}, valid[:len(valid)-1]...), 1), offset: int64(len(valid) - 1)},
+		{name: "Bad string length", data: document(element{0x02, "a", i32(100)}), offset: 7},
+		{name: "Unknown type", data: document(element{0x20, "a", nil}), offset: 4},
+		{name: "Invalid boolean", data: document(element{0x08, "a", []byte{2}}), offset: 7},
+		{name: "Trailing data", data: append(append([]byte{}, valid...), 0), offset: int64(len(valid))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bson.Parse(tt.data)
+
+			var jsonErr *encoding.JSONError
+			if !errors.As(err, &jsonErr) {
+				t.Fatalf("Expected a JSONError, got %v", err)
+			}
+
+			if jsonErr.Code != encoding.ErrInvalidValue || jsonErr.Offset != tt.offset {
+				t.Errorf("Expected invalid value at offset %d, got %v at offset %d", tt.offset, err, jsonErr.Offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package bson
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Extensions converts the BSON types that JSON lacks into syntax trees. Deprecated types
+// are mapped to their replacements: undefined to null, symbols to strings and DBPointers
+// to {"$ref": collection, "$id": ObjectID}.
+type Extensions struct {
+	// ObjectID converts a 12-byte ObjectID.
+	ObjectID func(id [12]byte) parser.Value
+	// DateTime converts a UTC date, in milliseconds since the Unix epoch.
+	DateTime func(ms int64) parser.Value
+	// Binary converts binary data with its subtype.
+	Binary func(subtype byte, data []byte) parser.Value
+	// Regex converts a regular expression with its options.
+	Regex func(pattern, options string) parser.Value
+	// Timestamp converts an internal MongoDB timestamp.
+	Timestamp func(seconds, increment uint32) parser.Value
+	// Decimal128 converts a 128-bit decimal, given in its string form such as "1.50",
+	// "-2E+10", "Infinity" or "NaN".
+	Decimal128 func(s string) parser.Value
+	// NonFinite converts a double that is infinite or NaN.
+	NonFinite func(f float64) parser.Value
+	// Code converts JavaScript code, with its scope document if it has one.
+	Code func(code string, scope *parser.Object) parser.Value
+	// Key converts the MinKey and MaxKey sentinels.
+	Key func(max bool) parser.Value
+}
+
+// ExtendedJSON converts values as the relaxed format of MongoDB Extended JSON v2 does,
+// for example {"$oid": "5f1d..."} or {"$date": "2020-07-26T10:00:00Z"}, which keeps the
+// type of each value.
+var ExtendedJSON = Extensions{
+	ObjectID: func(id [12]byte) parser.Value {
+		return wrap("$oid", parser.NewString(hex.EncodeToString(id[:])))
+	},
+	DateTime: func(ms int64) parser.Value {
+		if t := time.UnixMilli(ms).UTC(); t.Year() >= 1970 && t.Year() <= 9999 {
+			return wrap("$date", parser.NewString(formatDate(t)))
+		}
+
+		return wrap("$date", wrap("$numberLong", parser.NewString(strconv.FormatInt(ms, 10))))
+	},
+	Binary: func(subtype byte, data []byte) parser.Value {
+		bin := parser.NewObject()
+		bin.Set("base64", parser.NewString(base64.StdEncoding.EncodeToString(data)))
+		bin.Set("subType", parser.NewString(fmt.Sprintf("%02x", subtype)))
+
+		return wrap("$binary", bin)
+	},
+	Regex: func(pattern, options string) parser.Value {
+		regex := parser.NewObject()
+		regex.Set("pattern", parser.NewString(pattern))
+		regex.Set("options", parser.NewString(options))
+
+		return wrap("$regularExpression", regex)
+	},
+	Timestamp: func(seconds, increment uint32) parser.Value {
+		return wrap("$timestamp", timestamp(seconds, increment))
+	},
+	Decimal128: func(s string) parser.Value {
+		return wrap("$numberDecimal", parser.NewString(s))
+	},
+	NonFinite: func(f float64) parser.Value {
+		return wrap("$numberDouble", parser.NewString(formatNonFinite(f)))
+	},
+	Code: func(code string, scope *parser.Object) parser.Value {
+		obj := wrap("$code", parser.NewString(code))
+		if scope != nil {
+			obj.Set("$scope", scope)
+		}
+
+		return obj
+	},
+	Key: func(max bool) parser.Value {
+		if max {
+			return wrap("$maxKey", parser.NewNumber(1))
+		}
+
+		return wrap("$minKey", parser.NewNumber(1))
+	},
+}
+
+// SimpleJSON converts values to plain JSON values that decode into ordinary Go types:
+// ObjectIDs to hex strings, dates to RFC 3339 strings, binary data and decimals to
+// base64 and decimal strings, regular expressions to "/pattern/options", timestamps to
+// {"t": seconds, "i": increment}, JavaScript code to its source, and non-finite doubles,
+// MinKey and MaxKey to null.
+var SimpleJSON = Extensions{
+	ObjectID: func(id [12]byte) parser.Value {
+		return parser.NewString(hex.EncodeToString(id[:]))
+	},
+	DateTime: func(ms int64) parser.Value {
+		return parser.NewString(formatDate(time.UnixMilli(ms).UTC()))
+	},
+	Binary: func(_ byte, data []byte) parser.Value {
+		return parser.NewString(base64.StdEncoding.EncodeToString(data))
+	},
+	Regex: func(pattern, options string) parser.Value {
+		return parser.NewString("/" + pattern + "/" + options)
+	},
+	Timestamp: func(seconds, increment uint32) parser.Value {
+		return timestamp(seconds, increment)
+	},
+	Decimal128: func(s string) parser.Value { return parser.NewString(s) },
+	NonFinite:  func(float64) parser.Value { return parser.NewNull() },
+	Code:       func(code string, _ *parser.Object) parser.Value { return parser.NewString(code) },
+	Key:        func(bool) parser.Value { return parser.NewNull() },
+}
+
+// withDefaults returns ext with its nil functions replaced by those of ExtendedJSON.
+func (ext Extensions) withDefaults() Extensions {
+	defaults := ExtendedJSON
+
+	if ext.ObjectID == nil {
+		ext.ObjectID = defaults.ObjectID
+	}
+
+	if ext.DateTime == nil {
+		ext.DateTime = defaults.DateTime
+	}
+
+	if ext.Binary == nil {
+		ext.Binary = defaults.Binary
+	}
+
+	if ext.Regex == nil {
+		ext.Regex = defaults.Regex
+	}
+
+	if ext.Timestamp == nil {
+		ext.Timestamp = defaults.Timestamp
+	}
+
+	if ext.Decimal128 == nil {
+		ext.Decimal128 = defaults.Decimal128
+	}
+
+	if ext.NonFinite == nil {
+		ext.NonFinite = defaults.NonFinite
+	}
+
+	if ext.Code == nil {
+		ext.Code = defaults.Code
+	}
+
+	if ext.Key == nil {
+		ext.Key = defaults.Key
+	}
+
+	return ext
+}
+
+// wrap returns the object {key: v}.
+func wrap(key string, v parser.Value) *parser.Object {
+	obj := parser.NewObject()
+	obj.Set(key, v)
+
+	return obj
+}
+
+// timestamp returns the object {"t": seconds, "i": increment}.
+func timestamp(seconds, increment uint32) *parser.Object {
+	ts := parser.NewObject()
+	ts.Set("t", parser.NewNumber(int64(seconds)))
+	ts.Set("i", parser.NewNumber(int64(increment)))
+
+	return ts
+}
+
+// formatDate formats t in RFC 3339 with milliseconds when it has any.
+func formatDate(t time.Time) string {
+	if t.Nanosecond() == 0 {
+		return t.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	return t.Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// formatNonFinite returns the Extended JSON name of an infinite or NaN double.
+func formatNonFinite(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return "NaN"
+	}
+}
+
+// formatDecimal128 returns the string form of the IEEE 754-2008 decimal128 value with the
+// given high and low 64 bits, in binary integer decimal encoding, as specified for BSON.
+func formatDecimal128(high, low uint64) string {
+	sign := ""
+	if high>>63 == 1 {
+		sign = "-"
+	}
+
+	var (
+		exponent    int
+		coefficient = new(big.Int)
+	)
+
+	switch combination := high >> 58 & 0x1f; {
+	case combination == 0x1f:
+		return "NaN"
+	case combination == 0x1e:
+		return sign + "Infinity"
+	case combination>>3 == 3:
+		// The implied coefficient exceeds the maximum, so the value is zero
+		exponent = int(high >> 47 & 0x3fff)
+	default:
+		exponent = int(high >> 49 & 0x3fff)
+		coefficient.SetUint64(high & (1<<49 - 1))
+		coefficient.Lsh(coefficient, 64)
+		coefficient.Or(coefficient, new(big.Int).SetUint64(low))
+
+		if coefficient.Cmp(new(big.Int).Exp(big.NewInt(10), big.NewInt(34), nil)) >= 0 {
+			coefficient.SetInt64(0)
+		}
+	}
+
+	exponent -= 6176
+	digits := coefficient.String()
+	adjusted := exponent + len(digits) - 1
+
+	if exponent > 0 || adjusted < -6 {
+		s := digits[:1]
+		if len(digits) > 1 {
+			s += "." + digits[1:]
+		}
+
+		return fmt.Sprintf("%s%sE%+d", sign, s, adjusted)
+	}
+
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	point := len(digits) + exponent
+	if point <= 0 {
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	}
+
+	return sign + digits[:point] + "." + digits[point:]
+}
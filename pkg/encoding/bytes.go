@@ -0,0 +1,63 @@
+package encoding
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// isBytesType reports whether t is a byte slice, which is written as a base64 string
+// like in encoding/json.
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// hasTagOption reports whether the json tag of a struct field lists opt after its name.
+func hasTagOption(tag, opt string) bool {
+	_, opts, _ := strings.Cut(tag, ",")
+
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// marshalBytes writes a byte slice as a base64 string, or null when it is nil. Fields
+// tagged with the base64url option use the URL-safe alphabet without padding.
+func marshalBytes(v reflect.Value, url bool) parser.Value {
+	if v.IsNil() {
+		return parser.NewNull()
+	}
+
+	enc := base64.StdEncoding
+	if url {
+		enc = base64.RawURLEncoding
+	}
+
+	return parser.NewString(enc.EncodeToString(v.Bytes()))
+}
+
+// unmarshalBytes decodes the base64 string str into the byte slice rv. Fields tagged with
+// the base64url option accept the URL-safe alphabet with or without padding.
+func unmarshalBytes(str *parser.StringLiteral, rv reflect.Value, url bool) error {
+	enc, s := base64.StdEncoding, str.Value
+	if url {
+		enc, s = base64.RawURLEncoding, strings.TrimRight(s, "=")
+	}
+
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot decode base64 string into %v", rv.Type())).
+			WithCause(err)
+	}
+
+	rv.SetBytes(b)
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package encoding_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestBytesBase64(t *testing.T) {
+	type blob struct {
+		Data  []byte `json:"data"`
+		Token []byte `json:"token,base64url"`
+		Empty []byte `json:"empty"`
+		Nil   []byte `json:"nil"`
+	}
+
+	in := blob{Data: []byte{0xfb, 0xff, 0x01}, Token: []byte{0xfb, 0xff}, Empty: []byte{}}
+
+	data, err := encoding.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := `{"data":"+/8B","token":"-_8","empty":"","nil":null}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var out blob
+	if err := encoding.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !bytes.Equal(out.Data, in.Data) || !bytes.Equal(out.Token, in.Token) || out.Empty == nil || out.Nil != nil {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+
+	// Padding is optional for base64url
+	if err := encoding.Unmarshal([]byte(`{"token":"-_8="}`), &out); err != nil || !bytes.Equal(out.Token, in.Token) {
+		t.Errorf("Expected padded base64url to decode, got %v (%v)", out.Token, err)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"data":"-_8"}`), &out)
+	checkJSONError(t, err, encoding.ErrInvalidValue, "cannot decode base64")
+
+	var m map[string][]byte
+	if err := encoding.Unmarshal([]byte(`{"a":"aGk="}`), &m); err != nil || string(m["a"]) != "hi" {
+		t.Errorf("Expected map values to be decoded, got %v (%v)", m, err)
+	}
+}
@@ -0,0 +1,212 @@
+package encoding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Coercion identifies the JSON type a value is converted to before it is decoded
+type Coercion int
+
+const (
+	// CoerceString turns numbers and booleans into strings holding their literal text
+	CoerceString Coercion = iota + 1
+	// CoerceFloat64 turns numbers and numeric strings into floating-point numbers
+	CoerceFloat64
+	// CoerceInt64 turns integral numbers and numeric strings into integers
+	CoerceInt64
+	// CoerceBool turns "true"/"false" strings and 0/1 numbers into booleans
+	CoerceBool
+)
+
+// String returns the name of the coercion
+func (c Coercion) String() string {
+	switch c {
+	case CoerceString:
+		return "string"
+	case CoerceFloat64:
+		return "float64"
+	case CoerceInt64:
+		return "int64"
+	case CoerceBool:
+		return "bool"
+	default:
+		return fmt.Sprintf("Coercion(%d)", int(c))
+	}
+}
+
+// coercionRule is a compiled path pattern together with its target coercion.
+type coercionRule struct {
+	pattern []pathSegment
+	target  Coercion
+}
+
+// WithCoercions registers per-path coercion rules applied while decoding. Keys are path
+// patterns such as "metrics.*" or "ids[*]", where "*" matches any object key and "[*]" any
+// array index. Values matching a pattern are converted to the target type before they are
+// stored, which tames feeds that mix e.g. numbers and numeric strings for the same field.
+// Nulls are never coerced.
+func WithCoercions(rules map[string]Coercion) Option {
+	return func(o *Options) error {
+		patterns := make([]string, 0, len(rules))
+		for pattern := range rules {
+			patterns = append(patterns, pattern)
+		}
+
+		// Sort for deterministic precedence when several patterns match the same path
+		sort.Strings(patterns)
+
+		for _, pattern := range patterns {
+			target := rules[pattern]
+			if target < CoerceString || target > CoerceBool {
+				return fmt.Errorf("invalid coercion %v for path %q", target, pattern)
+			}
+
+			segments, err := parsePath(pattern)
+			if err != nil {
+				return err
+			}
+
+			o.coercions = append(o.coercions, coercionRule{pattern: segments, target: target})
+		}
+
+		return nil
+	}
+}
+
+// coerce applies the first coercion rule matching path to v.
+func (d *decodeState) coerce(v parser.Value) (parser.Value, error) {
+	if _, isNull := v.(*parser.Null); isNull || v == nil {
+		return v, nil
+	}
+
+	for _, rule := range d.options.coercions {
+		if matchPath(rule.pattern, d.path) {
+			coerced, err := coerceValue(v, rule.target)
+			if err != nil {
+				return nil, fmt.Errorf("coerce %s to %v: %v", formatPath(d.path), rule.target, err)
+			}
+
+			return coerced, nil
+		}
+	}
+
+	return v, nil
+}
+
+// coerceValue converts a scalar value into the target JSON type.
+func coerceValue(v parser.Value, target Coercion) (parser.Value, error) {
+	switch target {
+	case CoerceString:
+		switch val := v.(type) {
+		case *parser.StringLiteral:
+			return val, nil
+		case *parser.NumberLiteral:
+			return newStringValue(val.Value), nil
+		case *parser.Boolean:
+			return newStringValue(strconv.FormatBool(val.Value)), nil
+		}
+	case CoerceFloat64:
+		switch val := v.(type) {
+		case *parser.NumberLiteral:
+			return newFloatValue(val.Float), nil
+		case *parser.StringLiteral:
+			f, err := strconv.ParseFloat(val.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", val.Value)
+			}
+
+			return newFloatValue(f), nil
+		}
+	case CoerceInt64:
+		switch val := v.(type) {
+		case *parser.NumberLiteral:
+			if val.IsInt {
+				return val, nil
+			}
+
+			if val.Float != math.Trunc(val.Float) {
+				return nil, fmt.Errorf("number %s is not an integer", val.Value)
+			}
+
+			// float64(math.MaxInt64) rounds up to 2^63, so the bounds are written exactly
+			if val.Float >= 0x1p63 || val.Float < -0x1p63 {
+				return nil, fmt.Errorf("number %s is out of the range of int64", val.Value)
+			}
+
+			return newIntValue(int64(val.Float)), nil
+		case *parser.StringLiteral:
+			i, err := strconv.ParseInt(val.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer %q", val.Value)
+			}
+
+			return newIntValue(i), nil
+		}
+	case CoerceBool:
+		switch val := v.(type) {
+		case *parser.Boolean:
+			return val, nil
+		case *parser.StringLiteral:
+			b, err := strconv.ParseBool(val.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean %q", val.Value)
+			}
+
+			return newBoolValue(b), nil
+		case *parser.NumberLiteral:
+			if val.Float != 0 && val.Float != 1 {
+				return nil, fmt.Errorf("number %s is not 0 or 1", val.Value)
+			}
+
+			return newBoolValue(val.Float == 1), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported source type %T", v)
+}
+
+// newStringValue builds a string node.
+func newStringValue(s string) *parser.StringLiteral {
+	return &parser.StringLiteral{
+		Token: parser.Token{Type: parser.TokenString, Literal: s},
+		Value: s,
+	}
+}
+
+// newIntValue builds an integer number node.
+func newIntValue(i int64) *parser.NumberLiteral {
+	return parser.NewNumberLiteral(parser.Token{
+		Type:    parser.TokenNumber,
+		Literal: strconv.FormatInt(i, 10),
+	})
+}
+
+// newFloatValue builds a number node that decodes as a float even when it is integral.
+func newFloatValue(f float64) *parser.NumberLiteral {
+	literal := strconv.FormatFloat(f, 'g', -1, 64)
+
+	return &parser.NumberLiteral{
+		Token:   parser.Token{Type: parser.TokenNumber, Literal: literal},
+		Value:   literal,
+		Float:   f,
+		IsValid: true,
+	}
+}
+
+// newBoolValue builds a boolean node.
+func newBoolValue(b bool) *parser.Boolean {
+	typ := parser.TokenFalse
+	if b {
+		typ = parser.TokenTrue
+	}
+
+	return &parser.Boolean{
+		Token: parser.Token{Type: typ, Literal: strconv.FormatBool(b)},
+		Value: b,
+	}
+}
@@ -0,0 +1,117 @@
+package encoding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestUnmarshalWithCoercions(t *testing.T) {
+	input := []byte(`{
+		"metrics": {"cpu": 1, "mem": "2.5"},
+		"ids": [10, "11", 12],
+		"flags": {"enabled": "true", "beta": 0},
+		"count": 3.0
+	}`)
+
+	var result map[string]interface{}
+
+	err := encoding.Unmarshal(input, &result, encoding.WithCoercions(map[string]encoding.Coercion{
+		"metrics.*":  encoding.CoerceFloat64,
+		"ids[*]":     encoding.CoerceString,
+		"flags.*":    encoding.CoerceBool,
+		"count":      encoding.CoerceInt64,
+		"missing[0]": encoding.CoerceString,
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"metrics": map[string]interface{}{"cpu": float64(1), "mem": 2.5},
+		"ids":     []interface{}{"10", "11", "12"},
+		"flags":   map[string]interface{}{"enabled": true, "beta": false},
+		"count":   int64(3),
+	}
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestUnmarshalWithCoercionsIntoStruct(t *testing.T) {
+	var result struct {
+		IDs []string `json:"ids"`
+	}
+
+	err := encoding.Unmarshal([]byte(`{"ids": [1, 2]}`), &result, encoding.WithCoercions(map[string]encoding.Coercion{
+		"ids[*]": encoding.CoerceString,
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.IDs, []string{"1", "2"}) {
+		t.Fatalf("Expected [1 2], got %v", result.IDs)
+	}
+}
+
+func TestCoercionErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		rules     map[string]encoding.Coercion
+		errorCode encoding.ErrorCode
+	}{
+		{
+			name:      "Invalid pattern",
+			input:     `{"a": 1}`,
+			rules:     map[string]encoding.Coercion{"a[": encoding.CoerceString},
+			errorCode: encoding.ErrInvalidOptions,
+		},
+		{
+			name:      "Invalid target",
+			input:     `{"a": 1}`,
+			rules:     map[string]encoding.Coercion{"a": encoding.Coercion(42)},
+			errorCode: encoding.ErrInvalidOptions,
+		},
+		{
+			name:      "Unparseable string",
+			input:     `{"a": "abc"}`,
+			rules:     map[string]encoding.Coercion{"a": encoding.CoerceFloat64},
+			errorCode: encoding.ErrInvalidValue,
+		},
+		{
+			name:      "Fractional integer",
+			input:     `{"a": 1.5}`,
+			rules:     map[string]encoding.Coercion{"a": encoding.CoerceInt64},
+			errorCode: encoding.ErrInvalidValue,
+		},
+		{
+			name:      "Integer above int64",
+			input:     `{"a": 9223372036854775808.0}`,
+			rules:     map[string]encoding.Coercion{"a": encoding.CoerceInt64},
+			errorCode: encoding.ErrInvalidValue,
+		},
+		{
+			name:      "Float at 2^63",
+			input:     `{"a": 9.223372036854775808e18}`,
+			rules:     map[string]encoding.Coercion{"a": encoding.CoerceInt64},
+			errorCode: encoding.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result interface{}
+
+			err := encoding.Unmarshal([]byte(tt.input), &result, encoding.WithCoercions(tt.rules))
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+
+			checkJSONError(t, err, tt.errorCode, "")
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// Decompressor wraps a compressed reader with one that yields the decompressed bytes
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// compressionFormat is a compressed stream format recognized by its magic bytes.
+type compressionFormat struct {
+	name         string
+	magic        []byte
+	decompressor Decompressor
+}
+
+var (
+	compressionMu sync.RWMutex
+
+	// compressionFormats lists the formats sniffed by WithTransparentDecompression. Only
+	// gzip is built in; zstd and other formats the standard library does not implement
+	// are added with RegisterDecompressor.
+	compressionFormats = []compressionFormat{
+		{name: "gzip", magic: []byte{0x1f, 0x8b}, decompressor: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}},
+	}
+)
+
+// RegisterDecompressor makes WithTransparentDecompression decompress streams that start
+// with magic using d, replacing any decompressor registered for the same name. A nil d
+// removes the format. It is typically used to plug in a zstd implementation:
+//
+//	encoding.RegisterDecompressor("zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+func RegisterDecompressor(name string, magic []byte, d Decompressor) {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+
+	for i, f := range compressionFormats {
+		if f.name != name {
+			continue
+		}
+
+		if d == nil {
+			compressionFormats = append(compressionFormats[:i], compressionFormats[i+1:]...)
+		} else {
+			compressionFormats[i] = compressionFormat{name: name, magic: magic, decompressor: d}
+		}
+
+		return
+	}
+
+	if d == nil {
+		return
+	}
+
+	compressionFormats = append(compressionFormats, compressionFormat{name: name, magic: magic, decompressor: d})
+}
+
+// decompressReader sniffs the first bytes of r and wraps it with the matching
+// decompressor. Input that does not start with a known magic number is returned as is.
+func decompressReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+
+	for _, f := range compressionFormats {
+		head, _ := br.Peek(len(f.magic))
+		if !bytes.Equal(head, f.magic) {
+			continue
+		}
+
+		dr, err := f.decompressor(br)
+		if err != nil {
+			return nil, NewJSONError(ErrInvalidJSON, "failed to open "+f.name+" stream").WithCause(err)
+		}
+
+		return dr, nil
+	}
+
+	return br, nil
+}
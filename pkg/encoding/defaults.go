@@ -0,0 +1,77 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Defaulter is the interface implemented by types that set their own default values.
+// With WithDefaults, SetDefaults is called on a struct before its members are decoded, so
+// the members present in the input override the defaults.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// setDefaults calls SetDefaults on the struct rv if it implements Defaulter.
+func setDefaults(rv reflect.Value) {
+	if !rv.CanAddr() {
+		return
+	}
+
+	if defaulter, ok := rv.Addr().Interface().(Defaulter); ok {
+		defaulter.SetDefaults()
+	}
+}
+
+// defaultField sets the field fv, whose key name is missing from the input, to the value
+// of its default tag. A struct field without the tag gets the defaults of its own fields.
+func (d *decodeState) defaultField(field reflect.StructField, name string, fv reflect.Value) error {
+	if field.PkgPath != "" {
+		return nil
+	}
+
+	tag, ok := field.Tag.Lookup("default")
+	if !ok {
+		if fv.Kind() != reflect.Struct {
+			return nil
+		}
+
+		// A type that decodes itself is not given an input it did not receive
+		if _, ok := fv.Addr().Interface().(Unmarshaler); ok {
+			return nil
+		}
+
+		return d.unmarshalChild(keySegment(name), &parser.Object{Pairs: map[string]parser.Value{}}, fv)
+	}
+
+	value, err := parseDefault(tag, fv.Type())
+	if err != nil {
+		return fmt.Errorf("invalid default for field %s: %w", field.Name, err)
+	}
+
+	return d.unmarshalChild(keySegment(name), value, fv)
+}
+
+// parseDefault parses the default tag of a field of type t. The tag of a string field is
+// the string itself; any other tag is a JSON value, e.g. `default:"8080"` or
+// `default:"[\"a\", \"b\"]"`.
+func parseDefault(tag string, t reflect.Type) (parser.Value, error) {
+	if t.Kind() == reflect.String {
+		return &parser.StringLiteral{Token: parser.Token{Type: parser.TokenString, Literal: tag}, Value: tag}, nil
+	}
+
+	// The parser only accepts an object or an array at the top level, so the tag is
+	// parsed as the only element of an array
+	value, err := parser.NewParser(parser.NewLexerString("[" + tag + "]")).ParseJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if elems := value.(*parser.Array).Elements; len(elems) == 1 {
+		return elems[0], nil
+	}
+
+	return nil, fmt.Errorf("expected a single value, got %q", tag)
+}
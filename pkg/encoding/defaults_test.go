@@ -0,0 +1,77 @@
+package encoding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type serverConfig struct {
+	Host    string   `json:"host" default:"localhost"`
+	Port    int      `json:"port" default:"8080"`
+	Debug   bool     `json:"debug" default:"true"`
+	Origins []string `json:"origins" default:"[\"*\"]"`
+	Limits  limits   `json:"limits"`
+	Name    string   `json:"name"`
+}
+
+type limits struct {
+	Rate    float64 `json:"rate" default:"1.5"`
+	Workers int     `json:"workers"`
+}
+
+// SetDefaults implements encoding.Defaulter
+func (l *limits) SetDefaults() {
+	l.Workers = 4
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected serverConfig
+	}{
+		{
+			name:  "Empty",
+			input: `{}`,
+			expected: serverConfig{
+				Host: "localhost", Port: 8080, Debug: true, Origins: []string{"*"},
+				Limits: limits{Rate: 1.5, Workers: 4},
+			},
+		},
+		{
+			name:  "Overridden",
+			input: `{"port": 9000, "debug": false, "limits": {"workers": 8}, "name": "api"}`,
+			expected: serverConfig{
+				Host: "localhost", Port: 9000, Debug: false, Origins: []string{"*"},
+				Limits: limits{Rate: 1.5, Workers: 8}, Name: "api",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg serverConfig
+			if err := encoding.Unmarshal([]byte(tt.input), &cfg, encoding.WithDefaults()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, cfg)
+			}
+		})
+	}
+
+	var cfg serverConfig
+	if err := encoding.Unmarshal([]byte(`{}`), &cfg); err != nil || !reflect.DeepEqual(cfg, serverConfig{}) {
+		t.Errorf("Expected no defaults without WithDefaults, got %+v, %v", cfg, err)
+	}
+
+	var bad struct {
+		Port int `json:"port" default:"http"`
+	}
+
+	err := encoding.Unmarshal([]byte(`{}`), &bad, encoding.WithDefaults())
+	checkJSONError(t, err, encoding.ErrUnmarshalFailure, "invalid default for field Port")
+}
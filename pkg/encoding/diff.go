@@ -0,0 +1,286 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// DiffKind identifies how a value differs between two documents
+type DiffKind string
+
+const (
+	DiffAdded   DiffKind = "added"
+	DiffRemoved DiffKind = "removed"
+	DiffChanged DiffKind = "changed"
+)
+
+// Difference is a single difference between two documents
+type Difference struct {
+	// Kind tells whether the value was added, removed or changed
+	Kind DiffKind
+
+	// Path locates the value in dotted notation, e.g. "items[2].name"; it is empty for the root
+	Path string
+
+	// Before is the value in the first document, nil when the value was added
+	Before interface{}
+
+	// After is the value in the second document, nil when the value was removed
+	After interface{}
+}
+
+// String returns a human-readable, single-line description of the difference
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", path, diffValueString(d.After))
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", path, diffValueString(d.Before))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, diffValueString(d.Before), diffValueString(d.After))
+	}
+}
+
+// DiffOption configures Diff
+type DiffOption func(*diffConfig) error
+
+// diffConfig holds the settings used by Diff.
+type diffConfig struct {
+	ignoreArrayOrder bool
+	ignorePaths      [][]pathSegment
+}
+
+// IgnoreArrayOrder compares arrays as multisets, so reordered elements are not reported
+func IgnoreArrayOrder() DiffOption {
+	return func(c *diffConfig) error {
+		c.ignoreArrayOrder = true
+
+		return nil
+	}
+}
+
+// IgnorePaths skips the given paths and everything below them. Paths use the dotted
+// notation of Difference.Path and may contain "*" and "[*]" wildcards.
+func IgnorePaths(paths ...string) DiffOption {
+	return func(c *diffConfig) error {
+		for _, path := range paths {
+			segments, err := parsePath(path)
+			if err != nil {
+				return err
+			}
+
+			c.ignorePaths = append(c.ignorePaths, segments)
+		}
+
+		return nil
+	}
+}
+
+// Diff compares two JSON documents and reports the added, removed and changed values.
+// Object key order and whitespace are ignored and numbers are compared by value.
+func Diff(a, b []byte, opts ...DiffOption) ([]Difference, error) {
+	config := &diffConfig{}
+
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, NewJSONError(ErrInvalidOptions, "invalid diff options").WithCause(err)
+		}
+	}
+
+	left, err := parseBytes(a, defaultOptions())
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, "failed to parse first document").WithCause(err)
+	}
+
+	right, err := parseBytes(b, defaultOptions())
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, "failed to parse second document").WithCause(err)
+	}
+
+	d := &differ{config: config}
+	d.diff(left, right)
+
+	return d.differences, nil
+}
+
+// differ walks two parsed documents side by side and collects their differences.
+type differ struct {
+	config      *diffConfig
+	path        []pathSegment
+	differences []Difference
+}
+
+// diff compares two values at the current path.
+func (d *differ) diff(a, b parser.Value) {
+	if d.ignored() {
+		return
+	}
+
+	switch left := a.(type) {
+	case *parser.Object:
+		if right, ok := b.(*parser.Object); ok {
+			d.diffObjects(left, right)
+			return
+		}
+	case *parser.Array:
+		if right, ok := b.(*parser.Array); ok {
+			if d.config.ignoreArrayOrder {
+				d.diffUnorderedArrays(left, right)
+			} else {
+				d.diffArrays(left, right)
+			}
+
+			return
+		}
+	}
+
+	if !parser.Equal(a, b) {
+		d.add(DiffChanged, a, b)
+	}
+}
+
+// diffObjects compares the members of two objects in key order.
+func (d *differ) diffObjects(a, b *parser.Object) {
+	keys := make([]string, 0, len(a.Pairs)+len(b.Pairs))
+
+	for k := range a.Pairs {
+		keys = append(keys, k)
+	}
+
+	for k := range b.Pairs {
+		if _, ok := a.Pairs[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d.path = append(d.path, keySegment(k))
+
+		left, inLeft := a.Pairs[k]
+		right, inRight := b.Pairs[k]
+
+		switch {
+		case !inRight:
+			d.addUnlessIgnored(DiffRemoved, left, nil)
+		case !inLeft:
+			d.addUnlessIgnored(DiffAdded, nil, right)
+		default:
+			d.diff(left, right)
+		}
+
+		d.path = d.path[:len(d.path)-1]
+	}
+}
+
+// diffArrays compares two arrays element by element.
+func (d *differ) diffArrays(a, b *parser.Array) {
+	for i := 0; i < len(a.Elements) || i < len(b.Elements); i++ {
+		d.path = append(d.path, indexSegment(i))
+
+		switch {
+		case i >= len(b.Elements):
+			d.addUnlessIgnored(DiffRemoved, a.Elements[i], nil)
+		case i >= len(a.Elements):
+			d.addUnlessIgnored(DiffAdded, nil, b.Elements[i])
+		default:
+			d.diff(a.Elements[i], b.Elements[i])
+		}
+
+		d.path = d.path[:len(d.path)-1]
+	}
+}
+
+// diffUnorderedArrays compares two arrays as multisets. Elements without an equal
+// counterpart are reported as removed or added at their own index.
+func (d *differ) diffUnorderedArrays(a, b *parser.Array) {
+	matched := make([]bool, len(b.Elements))
+
+	for i, left := range a.Elements {
+		found := false
+
+		for j, right := range b.Elements {
+			if !matched[j] && parser.Equal(left, right) {
+				matched[j] = true
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			d.path = append(d.path, indexSegment(i))
+			d.addUnlessIgnored(DiffRemoved, left, nil)
+			d.path = d.path[:len(d.path)-1]
+		}
+	}
+
+	for j, right := range b.Elements {
+		if !matched[j] {
+			d.path = append(d.path, indexSegment(j))
+			d.addUnlessIgnored(DiffAdded, nil, right)
+			d.path = d.path[:len(d.path)-1]
+		}
+	}
+}
+
+// ignored reports whether the current path is excluded from the comparison.
+func (d *differ) ignored() bool {
+	for _, pattern := range d.config.ignorePaths {
+		if matchPath(pattern, d.path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addUnlessIgnored records a difference at the current path unless the path is ignored.
+func (d *differ) addUnlessIgnored(kind DiffKind, before, after parser.Value) {
+	if !d.ignored() {
+		d.add(kind, before, after)
+	}
+}
+
+// add records a difference at the current path.
+func (d *differ) add(kind DiffKind, before, after parser.Value) {
+	d.differences = append(d.differences, Difference{
+		Kind:   kind,
+		Path:   formatPath(d.path),
+		Before: toInterface(before),
+		After:  toInterface(after),
+	})
+}
+
+// toInterface converts a parsed value into plain Go maps, slices and scalars.
+func toInterface(v parser.Value) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	var result interface{}
+	if err := newDecodeState(defaultOptions()).unmarshalValue(v, reflect.ValueOf(&result).Elem()); err != nil {
+		return nil
+	}
+
+	return result
+}
+
+// diffValueString renders a difference value as compact JSON with sorted keys.
+func diffValueString(v interface{}) string {
+	data, err := Marshal(v, WithFrozenOutput(), WithDisableSizeLimit())
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
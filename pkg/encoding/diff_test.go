@@ -0,0 +1,136 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		opts     []encoding.DiffOption
+		expected []string
+	}{
+		{
+			name:     "Equal documents",
+			a:        `{"a": 1, "b": [1, 2], "c": {"d": 1e2}}`,
+			b:        `{"c": {"d": 100}, "b": [1, 2], "a": 1.0}`,
+			expected: nil,
+		},
+		{
+			name: "Added removed and changed",
+			a:    `{"a": 1, "b": {"c": "x"}, "gone": true}`,
+			b:    `{"a": 2, "b": {"c": "x", "d": [1]}, "new": null}`,
+			expected: []string{
+				`~ a: 1 -> 2`,
+				`+ b.d: [1]`,
+				`- gone: true`,
+				`+ new: null`,
+			},
+		},
+		{
+			name: "Array elements",
+			a:    `{"items": [1, 2, 3]}`,
+			b:    `{"items": [1, 5]}`,
+			expected: []string{
+				`~ items[1]: 2 -> 5`,
+				`- items[2]: 3`,
+			},
+		},
+		{
+			name:     "Ignore array order",
+			a:        `[{"id": 1}, {"id": 2}, 3]`,
+			b:        `[3, {"id": 2}, {"id": 1}]`,
+			opts:     []encoding.DiffOption{encoding.IgnoreArrayOrder()},
+			expected: nil,
+		},
+		{
+			name:     "Ignore array order with changes",
+			a:        `[1, 2, 2]`,
+			b:        `[2, 1, 4]`,
+			opts:     []encoding.DiffOption{encoding.IgnoreArrayOrder()},
+			expected: []string{`- [2]: 2`, `+ [2]: 4`},
+		},
+		{
+			name:     "Ignore paths",
+			a:        `{"meta": {"ts": 1, "v": 1}, "items": [{"id": 1, "at": 5}]}`,
+			b:        `{"meta": {"ts": 2, "v": 1}, "items": [{"id": 1, "at": 6}]}`,
+			opts:     []encoding.DiffOption{encoding.IgnorePaths("meta.ts", "items[*].at")},
+			expected: nil,
+		},
+		{
+			name:     "Type change",
+			a:        `{"a": {"b": 1}}`,
+			b:        `{"a": [1]}`,
+			expected: []string{`~ a: {"b":1} -> [1]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := encoding.Diff([]byte(tt.a), []byte(tt.b), tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(diffs) != len(tt.expected) {
+				t.Fatalf("Expected %d differences, got %d: %v", len(tt.expected), len(diffs), diffs)
+			}
+
+			for i, d := range diffs {
+				if d.String() != tt.expected[i] {
+					t.Errorf("Difference %d: expected %q, got %q", i, tt.expected[i], d.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	if _, err := encoding.Diff([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("Expected error for invalid first document")
+	} else {
+		checkJSONError(t, err, encoding.ErrInvalidJSON, "first document")
+	}
+
+	if _, err := encoding.Diff([]byte(`{}`), []byte(`{}`), encoding.IgnorePaths("a[")); err == nil {
+		t.Error("Expected error for invalid ignore path")
+	} else {
+		checkJSONError(t, err, encoding.ErrInvalidOptions, "")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: `{"a": 1, "b": [true, null]}`, b: "{\n  \"b\": [true, null],\n  \"a\": 1\n}", expected: true},
+		{a: `{"n": 1e2}`, b: `{"n": 100}`, expected: true},
+		{a: `{"n": 0.5}`, b: `{"n": 5e-1}`, expected: true},
+		{a: `{"n": -0}`, b: `{"n": 0.0}`, expected: true},
+		{a: `{"s": "A"}`, b: `{"s": "A"}`, expected: true},
+		{a: `[1, 2]`, b: `[2, 1]`, expected: false},
+		{a: `{"a": 1}`, b: `{"a": 1, "b": 2}`, expected: false},
+		{a: `{"a": "1"}`, b: `{"a": 1}`, expected: false},
+		{a: `{"n": 9007199254740993}`, b: `{"n": 9007199254740992}`, expected: false},
+	}
+
+	for i, tt := range tests {
+		equal, err := encoding.Equal([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Fatalf("Test %d: unexpected error: %v", i, err)
+		}
+
+		if equal != tt.expected {
+			t.Errorf("Test %d: Equal(%s, %s) = %v, want %v", i, tt.a, tt.b, equal, tt.expected)
+		}
+	}
+
+	if _, err := encoding.Equal([]byte(`{}`), []byte(`[`)); err == nil {
+		t.Error("Expected error for invalid document")
+	}
+}
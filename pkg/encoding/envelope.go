@@ -0,0 +1,231 @@
+package encoding
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// EnvelopeError is a single error entry reported alongside an API payload
+type EnvelopeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path"`
+}
+
+// Envelope wraps a single API payload together with any errors. It encodes as
+// {"data": ..., "errors": [...]}, omitting "errors" when there are none.
+type Envelope[T any] struct {
+	Data   T
+	Errors []EnvelopeError
+}
+
+// MarshalJSON implements Marshaler
+func (e Envelope[T]) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString(`{"data":`)
+
+	data, err := Marshal(e.Data, WithDisableSizeLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	b.Write(data)
+
+	if err := writeEnvelopeErrors(&b, e.Errors); err != nil {
+		return nil, err
+	}
+
+	b.WriteByte('}')
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements Unmarshaler
+func (e *Envelope[T]) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Data   T               `json:"data"`
+		Errors []EnvelopeError `json:"errors"`
+	}
+
+	if err := Unmarshal(data, &aux, WithDisableSizeLimit()); err != nil {
+		return err
+	}
+
+	e.Data = aux.Data
+	e.Errors = aux.Errors
+
+	return nil
+}
+
+// Page is one page of a paginated API result. It encodes as
+// {"data": [...], "next_cursor": "...", "errors": [...]}, omitting "next_cursor" on the
+// last page and "errors" when there are none.
+type Page[T any] struct {
+	Data       []T
+	NextCursor string
+	Errors     []EnvelopeError
+}
+
+// HasMore reports whether another page can be requested with NextCursor
+func (p Page[T]) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// MarshalJSON implements Marshaler
+func (p Page[T]) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	if err := p.EncodeTo(&b, WithDisableSizeLimit()); err != nil {
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements Unmarshaler
+func (p *Page[T]) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Data       []T             `json:"data"`
+		NextCursor string          `json:"next_cursor"`
+		Errors     []EnvelopeError `json:"errors"`
+	}
+
+	if err := Unmarshal(data, &aux, WithDisableSizeLimit()); err != nil {
+		return err
+	}
+
+	p.Data = aux.Data
+	p.NextCursor = aux.NextCursor
+	p.Errors = aux.Errors
+
+	return nil
+}
+
+// EncodeTo streams the page to w one element at a time, so the encoded data array is
+// never held in memory as a whole. Options apply to each element.
+func (p Page[T]) EncodeTo(w io.Writer, opts ...Option) error {
+	pw, err := NewPageWriter[T](w, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range p.Data {
+		if err := pw.Write(item); err != nil {
+			return err
+		}
+	}
+
+	return pw.Close(p.NextCursor, p.Errors)
+}
+
+// PageWriter streams a Page envelope to a writer element by element, for servers that
+// produce result sets incrementally (e.g. from a database cursor).
+type PageWriter[T any] struct {
+	writer  *bufio.Writer
+	options []Option
+	count   int
+	closed  bool
+}
+
+// NewPageWriter creates a PageWriter and validates the options used for every element.
+func NewPageWriter[T any](w io.Writer, opts ...Option) (*PageWriter[T], error) {
+	if _, err := applyOptions(opts...); err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid page writer options").WithCause(err)
+	}
+
+	return &PageWriter[T]{
+		writer:  bufio.NewWriter(w),
+		options: opts,
+	}, nil
+}
+
+// Write appends one element to the data array.
+func (pw *PageWriter[T]) Write(item T) error {
+	if pw.closed {
+		return NewJSONError(ErrMarshalFailure, "page writer is closed")
+	}
+
+	data, err := Marshal(item, pw.options...)
+	if err != nil {
+		return err
+	}
+
+	if pw.count == 0 {
+		_, err = pw.writer.WriteString(`{"data":[`)
+	} else {
+		err = pw.writer.WriteByte(',')
+	}
+
+	if err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to write page element").WithCause(err)
+	}
+
+	if _, err := pw.writer.Write(data); err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to write page element").WithCause(err)
+	}
+
+	pw.count++
+
+	return nil
+}
+
+// Close terminates the data array, writes the cursor and errors and flushes the output.
+// An empty nextCursor marks the last page.
+func (pw *PageWriter[T]) Close(nextCursor string, errs []EnvelopeError) error {
+	if pw.closed {
+		return nil
+	}
+
+	pw.closed = true
+
+	var b strings.Builder
+
+	if pw.count == 0 {
+		b.WriteString(`{"data":[`)
+	}
+
+	b.WriteByte(']')
+
+	if nextCursor != "" {
+		b.WriteString(`,"next_cursor":`)
+
+		if err := writeValue(&b, newStringValue(nextCursor)); err != nil {
+			return NewJSONError(ErrMarshalFailure, "failed to write page cursor").WithCause(err)
+		}
+	}
+
+	if err := writeEnvelopeErrors(&b, errs); err != nil {
+		return err
+	}
+
+	b.WriteByte('}')
+
+	if _, err := pw.writer.WriteString(b.String()); err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to write page").WithCause(err)
+	}
+
+	if err := pw.writer.Flush(); err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to flush page").WithCause(err)
+	}
+
+	return nil
+}
+
+// writeEnvelopeErrors appends the "errors" member when errs is not empty.
+func writeEnvelopeErrors(b *strings.Builder, errs []EnvelopeError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	data, err := Marshal(errs, WithDisableSizeLimit())
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(`,"errors":`)
+	b.Write(data)
+
+	return nil
+}
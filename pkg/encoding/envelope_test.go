@@ -0,0 +1,122 @@
+package encoding_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type pageItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestPageRoundTrip(t *testing.T) {
+	page := encoding.Page[pageItem]{
+		Data:       []pageItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}},
+		NextCursor: "abc",
+		Errors:     []encoding.EnvelopeError{{Code: "partial", Message: "one shard timed out"}},
+	}
+
+	data, err := encoding.Marshal(page)
+	if err != nil {
+		t.Fatalf("Failed to marshal page: %v", err)
+	}
+
+	var decoded encoding.Page[pageItem]
+	if err := encoding.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal page: %v", err)
+	}
+
+	if !reflect.DeepEqual(page, decoded) {
+		t.Fatalf("Expected %+v, got %+v", page, decoded)
+	}
+
+	if !decoded.HasMore() {
+		t.Error("Expected HasMore to be true")
+	}
+}
+
+func TestPageEncodeTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     encoding.Page[int]
+		expected string
+	}{
+		{
+			name:     "Last page",
+			page:     encoding.Page[int]{Data: []int{1, 2, 3}},
+			expected: `{"data":[1,2,3]}`,
+		},
+		{
+			name:     "Empty page with cursor",
+			page:     encoding.Page[int]{NextCursor: "next"},
+			expected: `{"data":[],"next_cursor":"next"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.page.EncodeTo(&buf); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Fatalf("Expected %s, got %s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestPageWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	pw, err := encoding.NewPageWriter[string](&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, s := range []string{"x", "y"} {
+		if err := pw.Write(s); err != nil {
+			t.Fatalf("Failed to write element: %v", err)
+		}
+	}
+
+	if err := pw.Close("", nil); err != nil {
+		t.Fatalf("Failed to close page writer: %v", err)
+	}
+
+	if buf.String() != `{"data":["x","y"]}` {
+		t.Fatalf("Unexpected output: %s", buf.String())
+	}
+
+	if err := pw.Write("z"); err == nil {
+		t.Fatal("Expected error writing to a closed page writer")
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	env := encoding.Envelope[map[string]string]{Data: map[string]string{"k": "v"}}
+
+	data, err := encoding.Marshal(env)
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope: %v", err)
+	}
+
+	if strings.Contains(string(data), "errors") {
+		t.Errorf("Expected errors to be omitted, got %s", data)
+	}
+
+	var decoded encoding.Envelope[map[string]string]
+	if err := encoding.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+
+	if decoded.Data["k"] != "v" {
+		t.Fatalf("Expected data to round-trip, got %+v", decoded)
+	}
+}
@@ -0,0 +1,21 @@
+package encoding
+
+import (
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Equal reports whether two JSON documents are semantically equal. Object key order,
+// whitespace and numeric formatting are ignored, so {"a": 1e2} equals {"a":100}.
+func Equal(a, b []byte) (bool, error) {
+	left, err := parseBytes(a, defaultOptions())
+	if err != nil {
+		return false, NewJSONError(ErrInvalidJSON, "failed to parse first document").WithCause(err)
+	}
+
+	right, err := parseBytes(b, defaultOptions())
+	if err != nil {
+		return false, NewJSONError(ErrInvalidJSON, "failed to parse second document").WithCause(err)
+	}
+
+	return parser.Equal(left, right), nil
+}
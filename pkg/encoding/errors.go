@@ -1,6 +1,14 @@
 package encoding
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
 
 // ErrorCode represents specific error types that can occur during encoding
 type ErrorCode string
@@ -9,11 +17,18 @@ const (
 	// Size-related errors
 	ErrSizeExceeded ErrorCode = "size_exceeded"
 
+	// ErrLimitExceeded reports input that exceeds a depth, string, array or object limit
+	ErrLimitExceeded ErrorCode = "limit_exceeded"
+
 	// Parse-related errors
 	ErrInvalidJSON    ErrorCode = "invalid_json"
 	ErrUnexpectedType ErrorCode = "unexpected_type"
 	ErrInvalidValue   ErrorCode = "invalid_value"
 
+	// ErrTrailingData reports data after the top-level value, as the cause of an
+	// ErrInvalidJSON error unless WithAllowTrailingData is set
+	ErrTrailingData ErrorCode = "trailing_data"
+
 	// Marshal-related errors
 	ErrMarshalFailure  ErrorCode = "marshal_failure"
 	ErrUnsupportedType ErrorCode = "unsupported_type"
@@ -22,10 +37,38 @@ const (
 	ErrUnmarshalFailure ErrorCode = "unmarshal_failure"
 	ErrInvalidTarget    ErrorCode = "invalid_target"
 
+	// ErrValidationFailure reports decoded values whose ValidateJSON method failed
+	ErrValidationFailure ErrorCode = "validation_failure"
+
 	// Configuration errors
 	ErrInvalidOptions ErrorCode = "invalid_options"
+
+	// Cancellation errors
+	ErrCanceled ErrorCode = "canceled"
+
+	// Lookup errors
+	ErrPathNotFound ErrorCode = "path_not_found"
+)
+
+// Sentinel errors for errors.Is, which also matches every ErrorCode above against a
+// JSONError with that code
+const (
+	// ErrSyntax matches syntax errors in the input, like ErrInvalidJSON
+	ErrSyntax = ErrInvalidJSON
+
+	// ErrSizeLimit matches input or output larger than the size limit, like ErrSizeExceeded
+	ErrSizeLimit = ErrSizeExceeded
+
+	// ErrMaxDepth matches input nested deeper than WithMaxDepth allows, which is
+	// reported with the ErrLimitExceeded code
+	ErrMaxDepth ErrorCode = "max_depth"
 )
 
+// Error implements the error interface, so that a code can be used as a sentinel error
+func (c ErrorCode) Error() string {
+	return string(c)
+}
+
 // JSONError represents a structured error that occurs during JSON processing
 type JSONError struct {
 	// Code identifies the specific type of error
@@ -34,9 +77,19 @@ type JSONError struct {
 	// Message provides a human-readable description of the error
 	Message string
 
-	// Path represents the JSON path where the error occurred (if applicable)
+	// Path represents the JSON path where the error occurred (if applicable). Marshal
+	// and unmarshal errors use a JSON Pointer such as "/items/2/name"
 	Path string
 
+	// Offset is the byte offset in the input where a syntax error was detected, or of the
+	// value that could not be unmarshaled (if applicable)
+	Offset int64
+
+	// Line and Column locate Offset in the input, with lines counted from 1; Line is 0
+	// when the location is not known
+	Line   int
+	Column int
+
 	// Value contains the problematic value (if applicable)
 	Value interface{}
 
@@ -52,7 +105,9 @@ func (e *JSONError) Error() string {
 		msg += ": " + e.Message
 	}
 
-	if e.Path != "" {
+	if e.Path != "" && e.Line > 0 {
+		msg += fmt.Sprintf(" (at %s, line %d, column %d)", e.Path, e.Line, e.Column)
+	} else if e.Path != "" {
 		msg += fmt.Sprintf(" (at %s)", e.Path)
 	}
 
@@ -68,6 +123,23 @@ func (e *JSONError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether target is the ErrorCode of e, so that errors.Is(err, ErrSyntax)
+// works without a type assertion. ErrMaxDepth matches an exceeded depth limit.
+func (e *JSONError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	if !ok {
+		return false
+	}
+
+	if code == ErrMaxDepth {
+		var limitErr *parser.LimitError
+
+		return e.Code == ErrLimitExceeded && errors.As(e.Cause, &limitErr) && limitErr.Limit == "MaxDepth"
+	}
+
+	return e.Code == code
+}
+
 // NewJSONError creates a new JSONError with the given code and message
 func NewJSONError(code ErrorCode, msg string) *JSONError {
 	return &JSONError{
@@ -83,6 +155,21 @@ func (e *JSONError) WithPath(path string) *JSONError {
 	return e
 }
 
+// WithOffset adds the byte offset of a syntax error to the error
+func (e *JSONError) WithOffset(offset int64) *JSONError {
+	e.Offset = offset
+
+	return e
+}
+
+// WithPosition adds the line and column of Offset to the error
+func (e *JSONError) WithPosition(line, column int) *JSONError {
+	e.Line = line
+	e.Column = column
+
+	return e
+}
+
 // WithValue adds a problematic value to the error
 func (e *JSONError) WithValue(value interface{}) *JSONError {
 	e.Value = value
@@ -117,3 +204,145 @@ func NewUnmarshalTypeError(expected, got string) *JSONError {
 	return NewJSONError(ErrUnmarshalFailure,
 		fmt.Sprintf("cannot unmarshal %s into %s", got, expected))
 }
+
+// newTypeError reports a JSON value of the given kind that cannot be stored in a value of type t
+func newTypeError(kind string, t reflect.Type) *JSONError {
+	return NewJSONError(ErrUnexpectedType, fmt.Sprintf("cannot unmarshal %s into %v", kind, t))
+}
+
+// codeOf returns the code of the first JSONError in the chain of err, or fallback when there
+// is none, so that the error wrapping a failure keeps its code
+func codeOf(err error, fallback ErrorCode) ErrorCode {
+	var jsonErr *JSONError
+	if errors.As(err, &jsonErr) {
+		return jsonErr.Code
+	}
+
+	return fallback
+}
+
+// newParseError reports a syntax error found by p, at the position of the failing token
+func newParseError(p *parser.Parser, msg string, err error) *JSONError {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return NewCanceledError(err).WithOffset(p.Offset())
+	}
+
+	if limitErr := newLimitError(err); limitErr != nil {
+		return limitErr
+	}
+
+	tok := p.CurrentToken()
+
+	var parseErr *parser.ParseError
+	if errors.As(err, &parseErr) {
+		tok.Offset, tok.Line, tok.Column = parseErr.Offset, parseErr.Line, parseErr.Column
+	}
+
+	return NewJSONError(ErrInvalidJSON, msg).WithCause(err).WithOffset(tok.Offset).WithPosition(tok.Line, tok.Column)
+}
+
+// newLimitError creates an ErrLimitExceeded error located where the limit was exceeded,
+// or returns nil when err is not about a limit.
+func newLimitError(err error) *JSONError {
+	var limitErr *parser.LimitError
+	if !errors.As(err, &limitErr) {
+		return nil
+	}
+
+	jsonErr := NewJSONError(ErrLimitExceeded, limitErr.Error()).WithCause(err)
+
+	var parseErr *parser.ParseError
+	if errors.As(err, &parseErr) {
+		jsonErr = jsonErr.WithOffset(parseErr.Offset).WithPosition(parseErr.Line, parseErr.Column)
+	}
+
+	return jsonErr
+}
+
+// newMarshalError reports that v could not be converted, with the JSON Pointer of the
+// failing value as the path
+func newMarshalError(err error, v interface{}) *JSONError {
+	e := NewJSONError(codeOf(err, ErrMarshalFailure), "failed to marshal value").WithValue(v)
+
+	if pe, ok := err.(*pathError); ok {
+		e.Path = pointerPath(pe.path)
+		err = pe.err
+	}
+
+	return e.WithCause(err)
+}
+
+// pathError carries the location of a marshal failure while the recursion unwinds.
+type pathError struct {
+	path []pathSegment
+	err  error
+}
+
+// Error implements the error interface
+func (e *pathError) Error() string {
+	return fmt.Sprintf("%s: %v", pointerPath(e.path), e.err)
+}
+
+// Unwrap implements the unwrap interface for error chains
+func (e *pathError) Unwrap() error {
+	return e.err
+}
+
+// withSegment records that err occurred below the segment s.
+func withSegment(s pathSegment, err error) error {
+	pe, ok := err.(*pathError)
+	if !ok {
+		pe = &pathError{err: err}
+	}
+
+	pe.path = append([]pathSegment{s}, pe.path...)
+
+	return pe
+}
+
+// NewCanceledError reports that an operation stopped because its context is done
+func NewCanceledError(err error) *JSONError {
+	return NewJSONError(ErrCanceled, "operation canceled").WithCause(err)
+}
+
+// FieldError reports a value that could not be decoded in partial decode mode.
+type FieldError struct {
+	// Path is the JSON Pointer of the value in the input, e.g. "/items/2/name"
+	Path string
+
+	// Err is the reason the value could not be decoded
+	Err error
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap implements the unwrap interface for error chains
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors lists every value that could not be decoded in partial decode mode.
+type FieldErrors []*FieldError
+
+// Error implements the error interface
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+
+	return fmt.Sprintf("%d values could not be decoded: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual field errors, so errors.Is and errors.As inspect each one
+func (e FieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+
+	return errs
+}
@@ -0,0 +1,170 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// GetField returns the raw bytes of the value at path, a dotted path such as
+// "items[2].name", without parsing the whole document. Tokens are scanned from the start
+// of data and the scan stops as soon as the value is found; members and elements that
+// are not on the path are skipped without being decoded. Only the structure of the
+// skipped parts is checked, so malformed input may go unnoticed. The result aliases data.
+func GetField(data []byte, path string) (RawMessage, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidValue, "invalid path").WithCause(err)
+	}
+
+	s := parser.NewScanner(bytes.NewReader(data))
+
+	for i, seg := range segments {
+		if seg.wildcard {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid path %q: wildcards are not supported", path))
+		}
+
+		found, err := enterSegment(s, seg)
+		if err != nil {
+			return nil, NewJSONError(ErrInvalidJSON, fmt.Sprintf("failed to scan for %q", path)).WithCause(err)
+		}
+
+		if !found {
+			return nil, NewJSONError(ErrPathNotFound, fmt.Sprintf("path %q not found", path)).
+				WithPath(formatPath(segments[:i+1]))
+		}
+	}
+
+	if _, _, err := s.SkipSeparators(""); err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, fmt.Sprintf("failed to scan for %q", path)).WithCause(unexpectedEOF(err))
+	}
+
+	start := s.Offset()
+	if _, err := s.ScanValue(); err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, fmt.Sprintf("failed to scan for %q", path)).WithCause(unexpectedEOF(err))
+	}
+
+	return RawMessage(data[start:s.Offset()]), nil
+}
+
+// enterSegment moves into the value at the current position, which must be an object for a key
+// segment and an array for an index segment, and stops in front of the member or element
+// addressed by seg. It reports false when the container has no such child.
+func enterSegment(s *parser.Scanner, seg pathSegment) (bool, error) {
+	opening, closing := byte('{'), byte('}')
+	if seg.isIndex {
+		opening, closing = '[', ']'
+	}
+
+	if err := expectByte(s, opening); err != nil {
+		return false, err
+	}
+
+	for i := 0; ; i++ {
+		if _, _, err := s.SkipSeparators(""); err != nil {
+			return false, unexpectedEOF(err)
+		}
+
+		if c, _ := s.Peek(); c == closing {
+			return false, nil
+		}
+
+		if i > 0 {
+			if err := expectByte(s, ','); err != nil {
+				return false, err
+			}
+		}
+
+		match := seg.isIndex && i == seg.index
+
+		if !seg.isIndex {
+			key, err := readKey(s)
+			if err != nil {
+				return false, err
+			}
+
+			if err := expectByte(s, ':'); err != nil {
+				return false, err
+			}
+
+			match = key == seg.key
+		}
+
+		if match {
+			return true, nil
+		}
+
+		if _, _, err := s.SkipSeparators(""); err != nil {
+			return false, unexpectedEOF(err)
+		}
+
+		if _, err := s.ScanValue(); err != nil {
+			return false, unexpectedEOF(err)
+		}
+	}
+}
+
+// expectByte skips whitespace and consumes c.
+func expectByte(s *parser.Scanner, c byte) error {
+	if _, _, err := s.SkipSeparators(""); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	got, err := s.ReadByte()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+
+	if got != c {
+		return fmt.Errorf("expected %q, got %q at offset %d", c, got, s.Offset()-1)
+	}
+
+	return nil
+}
+
+// readKey skips whitespace and consumes an object key, returning it unescaped.
+func readKey(s *parser.Scanner) (string, error) {
+	if _, _, err := s.SkipSeparators(""); err != nil {
+		return "", unexpectedEOF(err)
+	}
+
+	var raw bytes.Buffer
+
+	s.SetSink(&raw)
+	defer s.SetSink(nil)
+
+	if err := expectByte(s, '"'); err != nil {
+		return "", err
+	}
+
+	if err := s.ScanString(); err != nil {
+		return "", err
+	}
+
+	return unquoteKey(raw.Bytes())
+}
+
+// unquoteKey returns the object key whose quoted form is raw, unescaped.
+func unquoteKey(raw []byte) (string, error) {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1]), nil
+	}
+
+	tok := parser.NewLexerBytes(raw).NextToken()
+	if tok.Type != parser.TokenString {
+		return "", fmt.Errorf("invalid object key %s: %s", raw, tok.Literal)
+	}
+
+	return tok.Literal, nil
+}
+
+// unexpectedEOF converts io.EOF in the middle of a value into io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,176 @@
+package encoding_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+func TestGetField(t *testing.T) {
+	data := []byte(`{
+		"skip": {"a": [1, {"b": "}]"}], "c": "\"quoted\""},
+		"items": [
+			{"name": "first", "tags": ["x"]},
+			{"name": "second", "price": 9.5, "ok": true}
+		],
+		"escaped": null,
+		"empty": {}
+	}`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "items[1].name", want: `"second"`},
+		{path: "items[1].price", want: `9.5`},
+		{path: "items[1].ok", want: `true`},
+		{path: "items[0].tags", want: `["x"]`},
+		{path: "skip.c", want: `"\"quoted\""`},
+		{path: "skip.a[1]", want: `{"b": "}]"}`},
+		{path: "escaped", want: `null`},
+		{path: "empty", want: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := encoding.GetField(data, tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetFieldErrors(t *testing.T) {
+	data := []byte(`{"a": {"b": [1, 2]}, "s": "x"}`)
+
+	tests := []struct {
+		name string
+		data []byte
+		path string
+		code encoding.ErrorCode
+		msg  string
+	}{
+		{name: "Missing key", data: data, path: "a.c", code: encoding.ErrPathNotFound, msg: "(at a.c)"},
+		{name: "Index out of range", data: data, path: "a.b[2]", code: encoding.ErrPathNotFound},
+		{name: "Key on array", data: data, path: "a.b.c", code: encoding.ErrInvalidJSON, msg: `expected '{'`},
+		{name: "Index on string", data: data, path: "s[0]", code: encoding.ErrInvalidJSON},
+		{name: "Truncated", data: []byte(`{"a": [1, `), path: "b", code: encoding.ErrInvalidJSON, msg: "unexpected EOF"},
+		{name: "Wildcard", data: data, path: "a.*", code: encoding.ErrInvalidValue},
+		{name: "Empty path", data: data, path: "", code: encoding.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encoding.GetField(tt.data, tt.path)
+			checkJSONError(t, err, tt.code, tt.msg)
+		})
+	}
+}
+
+func TestRawMessage(t *testing.T) {
+	var v struct {
+		Kind    string              `json:"kind"`
+		Payload encoding.RawMessage `json:"payload"`
+	}
+
+	if err := encoding.Unmarshal([]byte(`{"kind": "point", "payload": {"x": 1}}`), &v); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(v.Payload) != `{"x":1}` {
+		t.Errorf("Unexpected payload: %s", v.Payload)
+	}
+
+	data, err := encoding.Marshal(map[string]interface{}{"raw": encoding.RawMessage(`[1, 2]`)})
+	if err != nil || string(data) != `{"raw":[1,2]}` {
+		t.Errorf("Unexpected output %s: %v", data, err)
+	}
+}
+
+func BenchmarkGetField(b *testing.B) {
+	var sb strings.Builder
+
+	sb.WriteString(`{"items": [`)
+
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		fmt.Fprintf(&sb, `{"id": %d, "name": "item %d", "tags": ["a", "b", "c"]}`, i, i)
+	}
+
+	sb.WriteString(`], "total": 1000}`)
+
+	data := []byte(sb.String())
+
+	b.Run("GetField", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := encoding.GetField(data, "total"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Index", func(b *testing.B) {
+		x, err := encoding.NewIndex(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := x.Get("total"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Unmarshal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v struct {
+				Total int `json:"total"`
+			}
+
+			if err := encoding.Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestParsePath(t *testing.T) {
+	elements, err := encoding.ParsePath("items[2].name.x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []parser.PathElement{{Key: "items"}, {Index: 2, IsIndex: true}, {Key: "name"}, {Key: "x"}}
+	if !reflect.DeepEqual(elements, expected) {
+		t.Errorf("Expected %v, got %v", expected, elements)
+	}
+
+	if path := encoding.FormatPath(elements); path != "items[2].name.x" {
+		t.Errorf("Expected the path to format back, got %q", path)
+	}
+
+	if elements, err := encoding.ParsePath(""); err != nil || len(elements) != 0 {
+		t.Errorf("Expected the empty path to address the root, got %v, %v", elements, err)
+	}
+
+	for _, path := range []string{"items[*]", "*.name", "a.", "a[1", "a[-1]"} {
+		if _, err := encoding.ParsePath(path); err == nil {
+			t.Errorf("ParsePath(%q): expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// WithIncludeFields makes Marshal and the encoders write only the object members on the
+// given paths, along with their ancestors and everything below them, so that an API can
+// honor a ?fields= query parameter without building intermediate maps. An ancestor that
+// turns out to be a scalar, or an array of scalars, is left out. Paths are object
+// keys joined by dots, e.g. "id" or "author.name"; arrays are transparent, so
+// "items.id" selects the id of every element of items. A "*" segment matches any key.
+// WithExcludeFields is applied on top of the included members. The output of a
+// Marshaler is written as it is.
+func WithIncludeFields(paths ...string) Option {
+	return func(o *Options) error {
+		patterns, err := parseFieldPaths(paths)
+		if err != nil {
+			return err
+		}
+
+		o.includeFields = append(o.includeFields, patterns...)
+
+		return nil
+	}
+}
+
+// WithExcludeFields makes Marshal and the encoders leave out the object members on the
+// given paths, which have the form described in WithIncludeFields.
+func WithExcludeFields(paths ...string) Option {
+	return func(o *Options) error {
+		patterns, err := parseFieldPaths(paths)
+		if err != nil {
+			return err
+		}
+
+		o.excludeFields = append(o.excludeFields, patterns...)
+
+		return nil
+	}
+}
+
+// parseFieldPaths splits field paths into their keys.
+func parseFieldPaths(paths []string) ([][]string, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("at least one field path must be given")
+	}
+
+	patterns := make([][]string, len(paths))
+
+	for i, path := range paths {
+		patterns[i] = strings.Split(path, ".")
+
+		for _, key := range patterns[i] {
+			if key == "" {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+		}
+	}
+
+	return patterns, nil
+}
+
+// fieldMatch is how a member relates to the included and excluded field paths.
+type fieldMatch int
+
+const (
+	// fieldIncluded members are written in full
+	fieldIncluded fieldMatch = iota
+	// fieldOmitted members are left out
+	fieldOmitted
+	// fieldAncestor members lead to included members and are written only if they are
+	// objects or arrays
+	fieldAncestor
+)
+
+// match relates the member key of the current object to the included and excluded field
+// paths.
+func (m *marshalState) match(key string) fieldMatch {
+	if m.include == nil && m.exclude == nil {
+		return fieldIncluded
+	}
+
+	keys := make([]string, 0, len(m.path)+1)
+
+	for _, s := range m.path {
+		if !s.isIndex {
+			keys = append(keys, s.key)
+		}
+	}
+
+	keys = append(keys, key)
+
+	for _, pattern := range m.exclude {
+		if len(pattern) == len(keys) && matchFieldPath(pattern, keys) {
+			return fieldOmitted
+		}
+	}
+
+	if m.include == nil {
+		return fieldIncluded
+	}
+
+	result := fieldOmitted
+
+	for _, pattern := range m.include {
+		n := min(len(pattern), len(keys))
+		if !matchFieldPath(pattern[:n], keys[:n]) {
+			continue
+		}
+
+		if len(keys) >= len(pattern) {
+			return fieldIncluded
+		}
+
+		result = fieldAncestor
+	}
+
+	return result
+}
+
+// member converts v, the member key of the current object, and reports false if it is
+// filtered out or skipped by WithSkipUnsupported.
+func (m *marshalState) member(key string, v reflect.Value) (parser.Value, bool, error) {
+	match := m.match(key)
+	if match == fieldOmitted || m.skipUnsupported && unsupportedKind(v) {
+		return nil, false, nil
+	}
+
+	value, err := m.child(keySegment(key), v)
+	if err != nil || match == fieldIncluded {
+		return value, err == nil, err
+	}
+
+	value, ok := pruneAncestor(value)
+
+	return value, ok, nil
+}
+
+// pruneAncestor keeps the objects and arrays of an ancestor of included members, whose
+// own members were filtered already, and drops the scalars and the arrays that only hold
+// scalars.
+func pruneAncestor(v parser.Value) (parser.Value, bool) {
+	switch v := v.(type) {
+	case *parser.Object:
+		return v, true
+	case *parser.Array:
+		elems := v.Elements[:0]
+
+		for _, elem := range v.Elements {
+			if elem, ok := pruneAncestor(elem); ok {
+				elems = append(elems, elem)
+			}
+		}
+
+		// An array of scalars has no included members
+		if len(elems) == 0 && len(v.Elements) > 0 {
+			return nil, false
+		}
+
+		v.Elements = elems
+
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// matchFieldPath reports whether keys match pattern, which has the same length.
+func matchFieldPath(pattern, keys []string) bool {
+	for i, p := range pattern {
+		if p != "*" && p != keys[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type author struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type article struct {
+	ID     int               `json:"id"`
+	Title  string            `json:"title"`
+	Author author            `json:"author"`
+	Tags   []string          `json:"tags"`
+	Meta   map[string]string `json:"meta"`
+}
+
+func TestFieldFilters(t *testing.T) {
+	articles := []article{{
+		ID:     1,
+		Title:  "t",
+		Author: author{Name: "Ana", Email: "a@b.c"},
+		Tags:   []string{"go"},
+		Meta:   map[string]string{"views": "3", "lang": "en"},
+	}}
+
+	tests := []struct {
+		name string
+		opts []encoding.Option
+		want string
+	}{
+		{
+			name: "Include",
+			opts: []encoding.Option{encoding.WithIncludeFields("id", "author.name")},
+			want: `[{"id":1,"author":{"name":"Ana"}}]`,
+		},
+		{
+			name: "Include subtree",
+			opts: []encoding.Option{encoding.WithIncludeFields("author", "tags")},
+			want: `[{"author":{"name":"Ana","email":"a@b.c"},"tags":["go"]}]`,
+		},
+		{
+			name: "Exclude",
+			opts: []encoding.Option{encoding.WithExcludeFields("author.email", "meta", "tags")},
+			want: `[{"id":1,"title":"t","author":{"name":"Ana"}}]`,
+		},
+		{
+			name: "Wildcard",
+			opts: []encoding.Option{encoding.WithIncludeFields("*.name", "meta.lang")},
+			want: `[{"author":{"name":"Ana"},"meta":{"lang":"en"}}]`,
+		},
+		{
+			name: "Include and exclude",
+			opts: []encoding.Option{encoding.WithIncludeFields("author"), encoding.WithExcludeFields("author.email")},
+			want: `[{"author":{"name":"Ana"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(articles, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+		})
+	}
+
+	for _, opt := range []encoding.Option{encoding.WithIncludeFields(), encoding.WithExcludeFields("a..b")} {
+		if _, err := encoding.Marshal(articles, opt); err == nil {
+			t.Error("Expected an error for invalid field paths")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Flatten converts the JSON document in data into a map from paths to leaf values, with
+// the object keys and array indexes of each path joined by sep: {"db": {"hosts": ["a"]}}
+// becomes {"db.hosts.0": "a"}. Empty objects and arrays are kept as values, so that
+// Unflatten restores the document. Values are decoded like Unmarshal into an interface{}.
+// A key that contains sep makes two paths ambiguous; such a collision is reported as an
+// ErrInvalidValue error.
+func Flatten(data []byte, sep string) (map[string]interface{}, error) {
+	if sep == "" {
+		return nil, NewJSONError(ErrInvalidOptions, "separator must not be empty")
+	}
+
+	options := defaultOptions()
+	if len(data) > options.MaxSize {
+		return nil, NewSizeExceededError(len(data), options.MaxSize)
+	}
+
+	flat := make(map[string]interface{})
+
+	var b strings.Builder
+
+	err := decodeLeaves(bytes.NewReader(data), options, func(path []parser.PathElement, value interface{}) error {
+		b.Reset()
+
+		for i, el := range path {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+
+			b.WriteString(el.String())
+		}
+
+		key := b.String()
+		if _, ok := flat[key]; ok {
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("flattened path %q is ambiguous", key))
+		}
+
+		flat[key] = value
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flat, nil
+}
+
+// Unflatten converts a map produced by Flatten back into a nested document, splitting
+// each path at sep. A level whose keys are exactly the indexes 0 to n-1 becomes an array,
+// and any other level an object. The root is returned as a map[string]interface{} or an
+// []interface{}, or as the value of the empty path. A path that is both a value and the
+// parent of other paths is reported as an ErrInvalidValue error.
+func Unflatten(flat map[string]interface{}, sep string) (interface{}, error) {
+	if sep == "" {
+		return nil, NewJSONError(ErrInvalidOptions, "separator must not be empty")
+	}
+
+	if value, ok := flat[""]; ok {
+		if len(flat) > 1 {
+			return nil, NewJSONError(ErrInvalidValue, "the empty path cannot be combined with other paths")
+		}
+
+		return value, nil
+	}
+
+	// Paths are added in order so that errors do not depend on map iteration
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	root := flatNode{}
+
+	for _, path := range paths {
+		keys := strings.Split(path, sep)
+		node := root
+
+		for i, key := range keys[:len(keys)-1] {
+			child, ok := node[key]
+			if !ok {
+				child = flatNode{}
+				node[key] = child
+			}
+
+			if node, ok = child.(flatNode); !ok {
+				return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("path %q is both a value and a parent", strings.Join(keys[:i+1], sep)))
+			}
+		}
+
+		last := keys[len(keys)-1]
+		if _, ok := node[last]; ok {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("path %q is both a value and a parent", path))
+		}
+
+		node[last] = flat[path]
+	}
+
+	return root.build(), nil
+}
+
+// flatNode is a level of the document being rebuilt by Unflatten.
+type flatNode map[string]interface{}
+
+// build converts the node and its children into maps and slices.
+func (n flatNode) build() interface{} {
+	values := make([]interface{}, len(n))
+	isArray := len(n) > 0
+
+	for key, child := range n {
+		if c, ok := child.(flatNode); ok {
+			child = c.build()
+			n[key] = child
+		}
+
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(n) || strconv.Itoa(i) != key {
+			isArray = false
+			continue
+		}
+
+		values[i] = child
+	}
+
+	if isArray {
+		return values
+	}
+
+	return map[string]interface{}(n)
+}
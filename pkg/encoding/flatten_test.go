@@ -0,0 +1,94 @@
+package encoding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sep      string
+		expected map[string]interface{}
+	}{
+		{
+			name:  "Nested",
+			input: `{"db": {"hosts": ["a", "b"], "port": 5432}, "debug": false, "tags": {}}`,
+			sep:   ".",
+			expected: map[string]interface{}{
+				"db.hosts.0": "a",
+				"db.hosts.1": "b",
+				"db.port":    int64(5432),
+				"debug":      false,
+				"tags":       map[string]interface{}{},
+			},
+		},
+		{
+			name:     "Separator",
+			input:    `[{"a": null}, 1.5]`,
+			sep:      "__",
+			expected: map[string]interface{}{"0__a": nil, "1": 1.5},
+		},
+		{
+			name:     "Empty",
+			input:    `[]`,
+			sep:      ".",
+			expected: map[string]interface{}{"": []interface{}{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flat, err := encoding.Flatten([]byte(tt.input), tt.sep)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(flat, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, flat)
+			}
+
+			var want interface{}
+			if err := encoding.Unmarshal([]byte(tt.input), &want); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got, err := encoding.Unflatten(flat, tt.sep)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected the round trip to give %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestFlattenErrors(t *testing.T) {
+	_, err := encoding.Flatten([]byte(`{"a.b": 1, "a": {"b": 2}}`), ".")
+	checkJSONError(t, err, encoding.ErrInvalidValue, `"a.b" is ambiguous`)
+
+	_, err = encoding.Flatten([]byte(`{"a": }`), ".")
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+
+	_, err = encoding.Flatten([]byte(`{}`), "")
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "")
+
+	_, err = encoding.Unflatten(map[string]interface{}{"a": 1, "a.b": 2}, ".")
+	checkJSONError(t, err, encoding.ErrInvalidValue, `"a" is both a value and a parent`)
+
+	// Indexes that do not start at 0 or have gaps make an object
+	got, err := encoding.Unflatten(map[string]interface{}{"x.1": "a", "x.2": "b"}, ".")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{"x": map[string]interface{}{"1": "a", "2": "b"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,68 @@
+package encoding
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// marshalFloat writes a float32 or float64 like encoding/json, with the number of decimal
+// places set by WithFloatDecimals, or with the formatter set by WithNumberFormatter. NaN
+// and infinities have no JSON form.
+func (m *marshalState) marshalFloat(v reflect.Value) (parser.Value, error) {
+	f := v.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("unsupported number %v", f))
+	}
+
+	bits := 64
+	if v.Kind() == reflect.Float32 {
+		bits = 32
+	}
+
+	var literal string
+
+	switch {
+	case m.numberFormatter != nil:
+		literal = string(m.numberFormatter(f, bits))
+		if !validNumber(literal) {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("number formatter wrote %q for %v, which is not a JSON number", literal, f))
+		}
+	case m.fixedFloats:
+		literal = strconv.FormatFloat(f, 'f', m.floatDecimals, bits)
+	default:
+		literal = formatFloat(f, bits)
+	}
+
+	return parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: literal}), nil
+}
+
+// formatFloat formats f like encoding/json: with the fewest digits that read back as the
+// same float of the given bit size, and in positional notation unless its magnitude is
+// below 1e-6 or at least 1e21. Unlike encoding/json, magnitudes from 2^63 up are written
+// with an exponent too, since the parser reads longer integers only with WithBigNumbers.
+func formatFloat(f float64, bits int) string {
+	format := byte('f')
+
+	if abs := math.Abs(f); abs != 0 {
+		// Like encoding/json, a float32 is compared in its own precision
+		if bits == 64 && (abs < 1e-6 || abs >= 1<<63) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1<<63) {
+			format = 'e'
+		}
+	}
+
+	b := strconv.AppendFloat(make([]byte, 0, 32), f, format, -1, bits)
+
+	// Like encoding/json, write a two-digit negative exponent without its leading zero:
+	// 1e-07 becomes 1e-7
+	if n := len(b); format == 'e' && n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+		b[n-2] = b[n-1]
+		b = b[:n-1]
+	}
+
+	return string(b)
+}
@@ -0,0 +1,108 @@
+package encoding_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestMarshalFloatCompatible(t *testing.T) {
+	floats := []float64{
+		0, math.Copysign(0, -1), 1, -1.5, 0.1, 1e6, 123456789, 9e18, 1e21, 1e30, 1e-6, 1e-7,
+		1.4e-45, math.MaxFloat32, 0.000001234, 100, 1.0 / 3, math.Pi * 1e15,
+	}
+
+	for _, f := range floats {
+		t.Run(strconv.FormatFloat(f, 'g', -1, 64), func(t *testing.T) {
+			want, _ := json.Marshal([]interface{}{f, float32(f)})
+
+			got, err := encoding.Marshal([]interface{}{f, float32(f)})
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+
+			// The float reads back unchanged
+			var back []float64
+			if err := encoding.Unmarshal(got, &back); err != nil || back[0] != f {
+				t.Errorf("Expected %v to round trip, got %v (%v)", f, back, err)
+			}
+		})
+	}
+
+	// Integers too long for an int64 are written with an exponent to be read back
+	for f, want := range map[float64]string{1e20: "1e+20", -math.MaxFloat64: "-1.7976931348623157e+308"} {
+		if got, err := encoding.Marshal([]float64{f}); err != nil || string(got) != "["+want+"]" {
+			t.Errorf("Expected [%s], got %s (%v)", want, got, err)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := encoding.Marshal(map[string]float64{"f": f})
+		checkJSONError(t, err, encoding.ErrInvalidValue, "unsupported number")
+	}
+}
+
+func TestWithFloatDecimals(t *testing.T) {
+	v := struct {
+		Price float64 `json:"price"`
+		Ratio float32 `json:"ratio"`
+		Count int     `json:"count"`
+	}{Price: 2.5, Ratio: 0.125, Count: 3}
+
+	data, err := encoding.Marshal(v, encoding.WithFloatDecimals(2))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if expected := `{"price":2.50,"ratio":0.12,"count":3}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	if data, _ := encoding.Marshal(1.7, encoding.WithFloatDecimals(0)); string(data) != "2" {
+		t.Errorf("Expected 2, got %s", data)
+	}
+
+	_, err = encoding.Marshal(v, encoding.WithFloatDecimals(-1))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "must not be negative")
+
+	_, err = encoding.Marshal(v, encoding.WithFloatDecimals(2), encoding.WithCanonicalNumbers())
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "cannot be combined")
+}
+
+func TestWithNumberFormatter(t *testing.T) {
+	// Trailing zeros are trimmed from two fixed decimals
+	format := func(f float64, bits int) []byte {
+		b := strconv.AppendFloat(nil, f, 'f', 2, bits)
+		b = bytes.TrimRight(b, "0")
+
+		return bytes.TrimSuffix(b, []byte("."))
+	}
+
+	data, err := encoding.Marshal(map[string]interface{}{"a": 2.5, "b": 3.0, "c": float32(0.125), "n": 7}, encoding.WithNumberFormatter(format))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if expected := `{"a":2.5,"b":3,"c":0.12,"n":7}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	bad := func(f float64, bits int) []byte { return []byte("1,5") }
+
+	_, err = encoding.Marshal([]float64{1.5}, encoding.WithNumberFormatter(bad))
+	checkJSONError(t, err, encoding.ErrInvalidValue, `number formatter wrote "1,5" for 1.5`)
+
+	_, err = encoding.Marshal(1.5, encoding.WithNumberFormatter(nil))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "must not be nil")
+
+	_, err = encoding.Marshal(1.5, encoding.WithNumberFormatter(format), encoding.WithFloatDecimals(2))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "cannot be combined")
+}
@@ -0,0 +1,325 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Index is a structural index of a JSON document. It is built in one pass over the bytes
+// that records where the braces, brackets, colons, commas and string quotes are. Get and
+// Query then walk the index instead of the text. They jump over containers that are not
+// on their path in constant time and parse only the values they return. A large document
+// queried many times is therefore scanned once. Like GetField, only the structure of the
+// document is checked.
+type Index struct {
+	data []byte
+	// pos holds the offsets of { } [ ] : , outside strings and of both quotes of every string
+	pos []int
+	// closing holds, for every { and [ in pos, the position in pos of its } or ]
+	closing []int
+}
+
+// indexSpan is the extent of a value in an indexed document.
+type indexSpan struct {
+	start, end int
+	// at is the position in pos of the first character of an object, array or string, or
+	// -1 for a number or literal
+	at int
+	// next is the position in pos of the first structural character after the value
+	next int
+}
+
+// NewIndex builds the structural index of data. The index aliases data, which must not be
+// modified while the index is in use.
+func NewIndex(data []byte) (*Index, error) {
+	x := &Index{data: data}
+
+	var open []int
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '"':
+			end := stringEnd(data, i)
+			if end < 0 {
+				return nil, indexError(i, "unterminated string")
+			}
+
+			x.add(i)
+			x.add(end)
+			i = end
+		case '{', '[':
+			open = append(open, len(x.pos))
+			x.add(i)
+		case '}', ']':
+			if len(open) == 0 || data[x.pos[open[len(open)-1]]] != matchingOpener(c) {
+				return nil, indexError(i, fmt.Sprintf("unexpected %q", c))
+			}
+
+			x.closing[open[len(open)-1]] = len(x.pos)
+			open = open[:len(open)-1]
+			x.add(i)
+		case ',', ':':
+			x.add(i)
+		}
+	}
+
+	if len(open) > 0 {
+		return nil, indexError(x.pos[open[len(open)-1]], "unclosed container")
+	}
+
+	if skipSpace(data, 0) == len(data) {
+		return nil, indexError(len(data), "empty input")
+	}
+
+	return x, nil
+}
+
+// add records the structural character at offset i.
+func (x *Index) add(i int) {
+	x.pos = append(x.pos, i)
+	x.closing = append(x.closing, 0)
+}
+
+// Get returns the raw bytes of the value at path, a dotted path such as "items[2].name",
+// with the same results and errors as GetField. The result aliases the indexed data.
+func (x *Index) Get(path string) (RawMessage, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidValue, "invalid path").WithCause(err)
+	}
+
+	v, err := x.valueAt(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, seg := range segments {
+		if seg.wildcard {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid path %q: wildcards are not supported; use Query", path))
+		}
+
+		if opener := containerOpener(seg); x.data[v.start] != opener {
+			return nil, indexError(v.start, fmt.Sprintf("expected %q, got %q", opener, x.data[v.start]))
+		}
+
+		found := false
+
+		err := x.each(v, seg, func(child indexSpan) bool {
+			v, found = child, true
+
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, NewJSONError(ErrPathNotFound, fmt.Sprintf("path %q not found", path)).
+				WithPath(formatPath(segments[:i+1]))
+		}
+	}
+
+	return RawMessage(x.data[v.start:v.end]), nil
+}
+
+// Query returns the raw bytes of every value matching pattern, in document order. The
+// pattern is a dotted path in which "*" matches any object key and "[*]" any array
+// index, such as "items[*].name". The results alias the indexed data.
+func (x *Index) Query(pattern string) ([]RawMessage, error) {
+	segments, err := parsePath(pattern)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidValue, "invalid path").WithCause(err)
+	}
+
+	root, err := x.valueAt(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []RawMessage
+
+	err = x.query(root, segments, &matches)
+
+	return matches, err
+}
+
+// query adds the values below v that match segments to matches.
+func (x *Index) query(v indexSpan, segments []pathSegment, matches *[]RawMessage) error {
+	if len(segments) == 0 {
+		*matches = append(*matches, RawMessage(x.data[v.start:v.end]))
+
+		return nil
+	}
+
+	var err error
+
+	eachErr := x.each(v, segments[0], func(child indexSpan) bool {
+		err = x.query(child, segments[1:], matches)
+
+		return err == nil
+	})
+	if eachErr != nil {
+		return eachErr
+	}
+
+	return err
+}
+
+// each calls fn with the members or elements of v that seg matches, until fn returns
+// false. A value that is not the object or array seg addresses has no such children.
+func (x *Index) each(v indexSpan, seg pathSegment, fn func(child indexSpan) bool) error {
+	if v.at < 0 || x.data[v.start] != containerOpener(seg) {
+		return nil
+	}
+
+	closer := x.closing[v.at]
+	from, k := v.start+1, v.at+1
+
+	if skipSpace(x.data, from) == x.pos[closer] {
+		return nil
+	}
+
+	for i := 0; ; i++ {
+		match := seg.wildcard || seg.isIndex && i == seg.index
+
+		if !seg.isIndex {
+			key, err := x.valueAt(from, k)
+			if err != nil {
+				return err
+			}
+
+			if key.at < 0 || x.data[key.start] != '"' {
+				return indexError(key.start, "expected an object key")
+			}
+
+			if key.next >= closer || x.data[x.pos[key.next]] != ':' {
+				return indexError(key.end, "expected ':' after object key")
+			}
+
+			if !match {
+				name, err := unquoteKey(x.data[key.start:key.end])
+				if err != nil {
+					return NewJSONError(ErrInvalidJSON, "failed to query index").WithCause(err).
+						WithOffset(int64(key.start))
+				}
+
+				match = name == seg.key
+			}
+
+			from, k = x.pos[key.next]+1, key.next+1
+		}
+
+		child, err := x.valueAt(from, k)
+		if err != nil {
+			return err
+		}
+
+		if match && !fn(child) {
+			return nil
+		}
+
+		if child.next == closer {
+			return nil
+		}
+
+		if child.next > closer || x.data[x.pos[child.next]] != ',' {
+			return indexError(child.end, "expected ',' or the end of the container")
+		}
+
+		from, k = x.pos[child.next]+1, child.next+1
+	}
+}
+
+// valueAt locates the value starting at the first non-whitespace byte at or after from,
+// where k is the position in pos of the first structural character at or after from.
+func (x *Index) valueAt(from, k int) (indexSpan, error) {
+	start := skipSpace(x.data, from)
+	if start == len(x.data) {
+		return indexSpan{}, indexError(start, "unexpected end of input")
+	}
+
+	if k < len(x.pos) && x.pos[k] == start {
+		switch x.data[start] {
+		case '{', '[':
+			c := x.closing[k]
+
+			return indexSpan{start: start, end: x.pos[c] + 1, at: k, next: c + 1}, nil
+		case '"':
+			return indexSpan{start: start, end: x.pos[k+1] + 1, at: k, next: k + 2}, nil
+		default:
+			return indexSpan{}, indexError(start, fmt.Sprintf("unexpected %q", x.data[start]))
+		}
+	}
+
+	end := len(x.data)
+	if k < len(x.pos) {
+		end = x.pos[k]
+	}
+
+	for end > start && isSpace(x.data[end-1]) {
+		end--
+	}
+
+	return indexSpan{start: start, end: end, at: -1, next: k}, nil
+}
+
+// stringEnd returns the offset of the quote that closes the string opened at offset i of
+// data, or -1 if the string is not terminated.
+func stringEnd(data []byte, i int) int {
+	for p := i + 1; ; {
+		q := bytes.IndexByte(data[p:], '"')
+		if q < 0 {
+			return -1
+		}
+
+		q += p
+
+		backslashes := 0
+		for j := q - 1; j > i && data[j] == '\\'; j-- {
+			backslashes++
+		}
+
+		if backslashes%2 == 0 {
+			return q
+		}
+
+		p = q + 1
+	}
+}
+
+// containerOpener returns the character that opens the container seg addresses.
+func containerOpener(seg pathSegment) byte {
+	if seg.isIndex {
+		return '['
+	}
+
+	return '{'
+}
+
+// matchingOpener returns the brace or bracket that the closing c ends.
+func matchingOpener(c byte) byte {
+	if c == '}' {
+		return '{'
+	}
+
+	return '['
+}
+
+// skipSpace returns the offset of the first byte at or after i that is not JSON whitespace.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+
+	return i
+}
+
+// isSpace reports whether c is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// indexError reports malformed structure at offset i of an indexed document.
+func indexError(i int, msg string) *JSONError {
+	return NewJSONError(ErrInvalidJSON, fmt.Sprintf("%s at offset %d", msg, i)).WithOffset(int64(i))
+}
@@ -0,0 +1,136 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestIndexGet(t *testing.T) {
+	data := []byte(`{
+		"skip": {"a": [1, {"b": "}]"}], "c": "\"quoted\\\\"},
+		"items": [
+			{"name": "first", "tags": ["x"]},
+			{"name": "second", "price": 9.5, "ok": true}
+		],
+		"escaped": null,
+		"empty": {},
+		"list": [ ],
+		"n": -1.5e3
+	}`)
+
+	x, err := encoding.NewIndex(data)
+	if err != nil {
+		t.Fatalf("NewIndex failed: %v", err)
+	}
+
+	paths := []string{
+		"items[1].name", "items[1].price", "items[1].ok", "items[0].tags", "skip.c", "skip.a[1]",
+		"skip.a[0]", "escaped", "empty", "list", "n",
+	}
+
+	// Get finds the same values as GetField
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			want, err := encoding.GetField(data, path)
+			if err != nil {
+				t.Fatalf("GetField failed: %v", err)
+			}
+
+			got, err := x.Get(path)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+
+	tests := []struct {
+		name string
+		path string
+		code encoding.ErrorCode
+		msg  string
+	}{
+		{name: "Missing key", path: "items[0].price", code: encoding.ErrPathNotFound, msg: "(at items[0].price)"},
+		{name: "Index out of range", path: "items[2]", code: encoding.ErrPathNotFound},
+		{name: "Empty array", path: "list[0]", code: encoding.ErrPathNotFound},
+		{name: "Key on array", path: "items.name", code: encoding.ErrInvalidJSON, msg: `expected '{'`},
+		{name: "Wildcard", path: "items[*]", code: encoding.ErrInvalidValue, msg: "use Query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := x.Get(tt.path)
+			checkJSONError(t, err, tt.code, tt.msg)
+		})
+	}
+}
+
+func TestIndexQuery(t *testing.T) {
+	x, err := encoding.NewIndex([]byte(`{"items": [{"name": "a", "tags": [1, 2]}, {"name": "b"}, 3], "name": "top"}`))
+	if err != nil {
+		t.Fatalf("NewIndex failed: %v", err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "items[*].name", want: []string{`"a"`, `"b"`}},
+		{pattern: "items[*].tags[*]", want: []string{`1`, `2`}},
+		{pattern: "*", want: []string{`[{"name": "a", "tags": [1, 2]}, {"name": "b"}, 3]`, `"top"`}},
+		{pattern: "items[1].name", want: []string{`"b"`}},
+		{pattern: "items[*].missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			matches, err := x.Query(tt.pattern)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+
+			if len(matches) != len(tt.want) {
+				t.Fatalf("Expected %d matches, got %d: %s", len(tt.want), len(matches), matches)
+			}
+
+			for i, m := range matches {
+				if string(m) != tt.want[i] {
+					t.Errorf("Match %d: expected %s, got %s", i, tt.want[i], m)
+				}
+			}
+		})
+	}
+}
+
+func TestNewIndexErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{input: `{"a": [1, 2}`, msg: "unexpected '}' at offset 11"},
+		{input: `{"a": "x`, msg: "unterminated string at offset 6"},
+		{input: `[1, [2]`, msg: "unclosed container at offset 0"},
+		{input: `]`, msg: "unexpected ']'"},
+		{input: "  \n", msg: "empty input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := encoding.NewIndex([]byte(tt.input))
+			checkJSONError(t, err, encoding.ErrInvalidJSON, tt.msg)
+		})
+	}
+
+	// Separators out of place are found by the queries that reach them
+	x, err := encoding.NewIndex([]byte(`{"a": 1, "b" 2, "c": [1,,2]}`))
+	if err != nil {
+		t.Fatalf("NewIndex failed: %v", err)
+	}
+
+	_, err = x.Get("c")
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "expected ':' after object key")
+}
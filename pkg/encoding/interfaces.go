@@ -1,15 +1,47 @@
 package encoding
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // JSONDecoder defines the interface for decoding JSON values from a stream
 type JSONDecoder interface {
-	// Decode reads the next JSON-encoded value from its input and stores it in v
+	// Decode reads the next JSON-encoded value from its input and stores it in v. It returns
+	// io.EOF once the input holds no more values
 	Decode(v interface{}) error
+	// Skip reads past the next JSON value without decoding it
+	Skip() error
+	// DecodeArrayStream reads the next value, which must be an array, one element at a
+	// time and calls fn with the encoding of each element
+	DecodeArrayStream(fn func(elem RawMessage) error) error
 	// More reports whether there is another value in the input stream
 	More() bool
 	// BufferSize returns the size of the underlying buffer
 	BufferSize() int
+	// InputOffset returns the number of input bytes consumed by the values decoded so far
+	InputOffset() int64
+	// Buffered returns the input read from the underlying reader but not consumed yet
+	Buffered() io.Reader
+}
+
+// ContextDecoder is implemented by the decoders returned by NewDecoder, which can be
+// canceled while decoding:
+//
+//	if cd, ok := dec.(encoding.ContextDecoder); ok {
+//		err = cd.DecodeContext(ctx, &v)
+//	}
+type ContextDecoder interface {
+	// DecodeContext is like Decode but gives up between input chunks once ctx is done
+	DecodeContext(ctx context.Context, v interface{}) error
+}
+
+// ResettableDecoder is a JSONDecoder that can be reused for another input. The decoders
+// returned by NewDecoder implement it.
+type ResettableDecoder interface {
+	JSONDecoder
+	// Reset discards the state of the decoder and makes it read from r
+	Reset(r io.Reader)
 }
 
 // JSONEncoder defines the interface for encoding JSON values to a stream
@@ -20,6 +52,33 @@ type JSONEncoder interface {
 	SetIndent(prefix, indent string)
 	// Flush ensures all buffered data is written to the underlying writer
 	Flush() error
+	// BeginArray starts writing an array element by element
+	BeginArray() error
+	// EndArray closes the array started by the matching BeginArray
+	EndArray() error
+	// BeginObject starts writing an object member by member
+	BeginObject() error
+	// EndObject closes the object started by the matching BeginObject
+	EndObject() error
+	// WriteKey writes the key of the next member of the current object
+	WriteKey(key string) error
+	// WriteValue writes the JSON encoding of v as the next array element or member value
+	WriteValue(v interface{}) error
+}
+
+// ContextEncoder is implemented by the encoders returned by NewEncoder, which can be
+// canceled while encoding.
+type ContextEncoder interface {
+	// EncodeContext is like Encode but gives up between output chunks once ctx is done
+	EncodeContext(ctx context.Context, v interface{}) error
+}
+
+// ResettableEncoder is a JSONEncoder that can be reused for another output. The encoders
+// returned by NewEncoder implement it.
+type ResettableEncoder interface {
+	JSONEncoder
+	// Reset discards the state and unflushed output of the encoder and makes it write to w
+	Reset(w io.Writer)
 }
 
 // JSONStreamProcessor combines encoding and decoding capabilities
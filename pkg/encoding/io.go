@@ -0,0 +1,294 @@
+package encoding
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// UnmarshalReader parses the JSON document read from r and stores the result in the value
+// pointed to by v. The input is lexed as it is read instead of being loaded in full
+// first, and MaxSize is enforced on the bytes consumed so far.
+func UnmarshalReader(r io.Reader, v interface{}, opts ...Option) error {
+	lr := &sizeLimitReader{r: r}
+	err := unmarshalReader(lr, v, opts...)
+
+	return stats.recordDecode(int(lr.n), err)
+}
+
+// unmarshalReader implements UnmarshalReader.
+func unmarshalReader(lr *sizeLimitReader, v interface{}, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return NewInvalidTargetError("unmarshal target must be a non-nil pointer")
+	}
+
+	if !options.DisableSizeLimit {
+		lr.limit = int64(options.MaxSize)
+	}
+
+	var r io.Reader = lr
+	if options.Transcode {
+		r = parser.NewTranscodingReader(lr)
+	}
+
+	if options.ProfileLabels {
+		defer profilePhase("parse")()
+	}
+
+	p := parser.NewParser(parser.NewLexerSize(r, options.BufferSize), options.parserOptions()...)
+
+	value, err := p.ParseJSON()
+	if lr.exceeded {
+		return NewJSONError(ErrSizeExceeded, fmt.Sprintf("input exceeds limit %d", options.MaxSize))
+	}
+
+	if err == nil && !options.AllowTrailingData && p.More() {
+		err = errTrailingData
+	}
+
+	if err != nil {
+		return newParseError(p, "failed to parse JSON", err)
+	}
+
+	if options.ProfileLabels {
+		profilePhase("decode")
+	}
+
+	if err := newDecodeState(options).decode(value, rv.Elem()); err != nil {
+		return err.WithValue(v)
+	}
+
+	return nil
+}
+
+// ValidateStream checks that r holds a well-formed JSON document without decoding it or
+// building a tree, as a pre-flight check for untrusted input. Tokens are checked as they
+// are read, keeping only the stack of open containers, so memory use stays constant
+// however large the document is. Data after the document is an error matching
+// ErrTrailingData unless WithAllowTrailingData is set. MaxSize is enforced like in
+// UnmarshalReader, and the syntax options (comments, trailing commas, duplicate keys)
+// apply. The first syntax error is returned with its offset, and wraps a
+// *parser.ParseError that also has its line and column.
+func ValidateStream(r io.Reader, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	lr := &sizeLimitReader{r: r}
+	if !options.DisableSizeLimit {
+		lr.limit = int64(options.MaxSize)
+	}
+
+	var input io.Reader = lr
+	if options.Transcode {
+		input = parser.NewTranscodingReader(lr)
+	}
+
+	p := parser.NewParser(parser.NewLexerSize(input, options.BufferSize), options.parserOptions()...)
+
+	err = p.Validate()
+	if lr.exceeded {
+		return NewJSONError(ErrSizeExceeded, fmt.Sprintf("input exceeds limit %d", options.MaxSize))
+	}
+
+	if err == nil && !options.AllowTrailingData && p.More() {
+		err = errTrailingData
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return newParseError(p, "invalid JSON", err)
+	}
+
+	if limitErr := newLimitError(err); limitErr != nil {
+		return limitErr
+	}
+
+	var parseErr *parser.ParseError
+	if errors.As(err, &parseErr) {
+		return NewJSONError(ErrInvalidJSON, "invalid JSON").WithCause(err).WithOffset(parseErr.Offset).
+			WithPosition(parseErr.Line, parseErr.Column)
+	}
+
+	if err != nil {
+		return newParseError(p, "invalid JSON", err)
+	}
+
+	return nil
+}
+
+// sizeLimitReader counts the bytes read and stops reading once more than limit bytes were
+// requested, if limit is positive. The lexer treats a failed read as the end of input, so
+// the limit is reported through exceeded.
+type sizeLimitReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+// Read implements io.Reader
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.limit > 0 {
+		if l.n >= l.limit {
+			// Probe for a single byte to tell input of exactly limit bytes from longer input
+			var b [1]byte
+			if n, _ := io.ReadFull(l.r, b[:]); n > 0 {
+				l.exceeded = true
+			}
+
+			return 0, io.EOF
+		}
+
+		if remaining := l.limit - l.n; int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+
+	return n, err
+}
+
+// MarshalWrite writes the compact JSON encoding of v to w. The output is streamed through
+// a buffer of BufferSize bytes instead of being built in memory first, and MaxSize is
+// enforced as it is written. When the limit is exceeded, part of the document may
+// already have been written to w.
+func MarshalWrite(w io.Writer, v interface{}, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return stats.recordEncode(0, NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err))
+	}
+
+	n, err := marshalWrite(w, v, options)
+
+	return stats.recordEncode(n, err)
+}
+
+// marshalWrite implements MarshalWrite and returns the number of bytes written.
+func marshalWrite(w io.Writer, v interface{}, options *Options) (int, error) {
+	value, err := newMarshalState(options).marshal(reflect.ValueOf(v))
+	if err != nil {
+		return 0, newMarshalError(err, v)
+	}
+
+	bw := bufio.NewWriterSize(w, options.BufferSize)
+	lw := &sizeLimitWriter{w: bw}
+
+	if !options.DisableSizeLimit {
+		lw.limit = options.MaxSize
+	}
+
+	if err := newEncodeState(options).writeValue(lw, value); err != nil {
+		return lw.n, NewJSONError(ErrMarshalFailure, "failed to write value").
+			WithCause(err)
+	}
+
+	if lw.err == nil {
+		lw.err = bw.Flush()
+	}
+
+	if lw.exceeded {
+		return lw.n, NewSizeExceededError(lw.n, options.MaxSize)
+	}
+
+	if lw.err != nil {
+		return lw.n, NewJSONError(ErrMarshalFailure, "failed to write to stream").
+			WithCause(lw.err)
+	}
+
+	return lw.n, nil
+}
+
+// sizeLimitWriter counts the bytes written and refuses writes that would go over limit,
+// if limit is positive. The encoder ignores write errors, so the first one is kept in err
+// and turns every later write into a no-op.
+type sizeLimitWriter struct {
+	w        *bufio.Writer
+	limit    int
+	n        int
+	exceeded bool
+	err      error
+}
+
+// reserve accounts for n more bytes and reports whether they may be written.
+func (l *sizeLimitWriter) reserve(n int) bool {
+	if l.err != nil {
+		return false
+	}
+
+	if l.limit > 0 && l.n+n > l.limit {
+		l.n += n
+		l.exceeded = true
+		l.err = io.ErrShortWrite
+
+		return false
+	}
+
+	l.n += n
+
+	return true
+}
+
+// Write implements io.Writer
+func (l *sizeLimitWriter) Write(p []byte) (int, error) {
+	if !l.reserve(len(p)) {
+		return 0, l.err
+	}
+
+	n, err := l.w.Write(p)
+	l.err = err
+
+	return n, err
+}
+
+// WriteString implements io.StringWriter
+func (l *sizeLimitWriter) WriteString(s string) (int, error) {
+	if !l.reserve(len(s)) {
+		return 0, l.err
+	}
+
+	n, err := l.w.WriteString(s)
+	l.err = err
+
+	return n, err
+}
+
+// WriteByte implements io.ByteWriter
+func (l *sizeLimitWriter) WriteByte(c byte) error {
+	if !l.reserve(1) {
+		return l.err
+	}
+
+	l.err = l.w.WriteByte(c)
+
+	return l.err
+}
+
+// WriteRune writes the UTF-8 encoding of r
+func (l *sizeLimitWriter) WriteRune(r rune) (int, error) {
+	if !l.reserve(utf8.RuneLen(r)) {
+		return 0, l.err
+	}
+
+	n, err := l.w.WriteRune(r)
+	l.err = err
+
+	return n, err
+}
@@ -0,0 +1,356 @@
+package encoding_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+func TestUnmarshalReader(t *testing.T) {
+	var v struct {
+		Name  string `json:"name"`
+		Items []int  `json:"items"`
+	}
+
+	err := encoding.UnmarshalReader(strings.NewReader(`{"name": "x", "items": [1, 2]}`), &v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if v.Name != "x" || len(v.Items) != 2 {
+		t.Errorf("Unexpected result: %+v", v)
+	}
+}
+
+func TestUnmarshalReaderErrors(t *testing.T) {
+	large := `{"data": "` + strings.Repeat("x", 2000) + `"}`
+	exact := `{"d": "` + strings.Repeat("x", 1024-9) + `"}`
+
+	tests := []struct {
+		name   string
+		input  string
+		target interface{}
+		opts   []encoding.Option
+		code   encoding.ErrorCode
+		msg    string
+	}{
+		{
+			name:   "Size limit",
+			input:  large,
+			target: &map[string]string{},
+			opts:   []encoding.Option{encoding.WithMaxSize(1024)},
+			code:   encoding.ErrSizeExceeded,
+			msg:    "input exceeds limit 1024",
+		},
+		{
+			name:   "Invalid target",
+			input:  `{}`,
+			target: map[string]string{},
+			code:   encoding.ErrInvalidTarget,
+		},
+		{
+			name:   "Invalid JSON",
+			input:  `{"a": }`,
+			target: &map[string]string{},
+			code:   encoding.ErrInvalidJSON,
+		},
+		{
+			name:   "Invalid options",
+			input:  `{}`,
+			target: &map[string]string{},
+			opts:   []encoding.Option{encoding.WithMaxSize(-1)},
+			code:   encoding.ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.UnmarshalReader(strings.NewReader(tt.input), tt.target, tt.opts...)
+			checkJSONError(t, err, tt.code, tt.msg)
+		})
+	}
+
+	var v map[string]string
+	if err := encoding.UnmarshalReader(strings.NewReader(exact), &v, encoding.WithMaxSize(1024)); err != nil {
+		t.Errorf("Expected input of exactly MaxSize bytes to be accepted, got %v", err)
+	}
+
+	if err := encoding.UnmarshalReader(strings.NewReader(large), &v, encoding.WithDisableSizeLimit()); err != nil {
+		t.Errorf("Unexpected error with disabled size limit: %v", err)
+	}
+}
+
+func TestMarshalWrite(t *testing.T) {
+	v := map[string]interface{}{
+		"name":  "caf\u00e9",
+		"items": []interface{}{1, 2, 3},
+	}
+
+	var buf bytes.Buffer
+	if err := encoding.MarshalWrite(&buf, v, encoding.WithSortKeys(), encoding.WithBufferSize(1)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want, err := encoding.Marshal(v, encoding.WithSortKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != string(want) {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+}
+
+func TestMarshalWriteErrors(t *testing.T) {
+	large := map[string]string{"data": strings.Repeat("x", 2000)}
+
+	t.Run("Size limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := encoding.MarshalWrite(&buf, large, encoding.WithMaxSize(1024))
+		checkJSONError(t, err, encoding.ErrSizeExceeded, "")
+
+		if buf.Len() > 1024 {
+			t.Errorf("Expected at most 1024 bytes written, got %d", buf.Len())
+		}
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		err := encoding.MarshalWrite(&bytes.Buffer{}, make(chan int))
+		checkJSONError(t, err, encoding.ErrUnsupportedType, "")
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		err := encoding.MarshalWrite(&bytes.Buffer{}, large, encoding.WithMaxSize(-1))
+		checkJSONError(t, err, encoding.ErrInvalidOptions, "")
+	})
+
+	t.Run("Writer error", func(t *testing.T) {
+		err := encoding.MarshalWrite(failingWriter{}, large, encoding.WithDisableSizeLimit())
+		checkJSONError(t, err, encoding.ErrMarshalFailure, "failed to write to stream")
+
+		if !errors.Is(err, errWriteFailed) {
+			t.Errorf("Expected cause %v, got %v", errWriteFailed, err)
+		}
+	})
+
+	var buf bytes.Buffer
+	if err := encoding.MarshalWrite(&buf, large, encoding.WithDisableSizeLimit()); err != nil {
+		t.Errorf("Unexpected error with disabled size limit: %v", err)
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingWriter rejects every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestValidateStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   []encoding.Option
+		code   encoding.ErrorCode
+		offset int64
+		line   int
+		column int
+	}{
+		{name: "Object", input: `{"a": [1, {"b": null}, "x", true], "c": {}}`},
+		{name: "Nested arrays", input: `[[], [[1.5e3]], [{}]]`},
+		{name: "Trailing data allowed", input: `{} {`, opts: []encoding.Option{encoding.WithAllowTrailingData()}},
+		{name: "Trailing garbage", input: `[1,2] x`, code: encoding.ErrInvalidJSON, offset: 6},
+		{name: "Second document", input: `{"a":1}{"b":2}`, code: encoding.ErrInvalidJSON, offset: 7},
+		{name: "Missing value", input: `{"a": }`, code: encoding.ErrInvalidJSON, offset: 6},
+		{name: "Missing colon", input: "{\n  \"a\" 1}", code: encoding.ErrInvalidJSON, offset: 8, line: 2, column: 7},
+		{name: "Mismatched bracket", input: `[1, 2}`, code: encoding.ErrInvalidJSON, offset: 5, line: 1, column: 6},
+		{name: "Trailing comma", input: `[1,]`, code: encoding.ErrInvalidJSON, offset: 3, line: 1, column: 4},
+		{name: "Unterminated", input: `{"a": [1`, code: encoding.ErrInvalidJSON, offset: 8, line: 1, column: 8},
+		{name: "Non-string key", input: `{1: 2}`, code: encoding.ErrInvalidJSON, offset: 1, line: 1, column: 2},
+		{name: "Scalar document", input: `1`, code: encoding.ErrInvalidJSON, offset: 0, line: 1, column: 1},
+		{
+			name:  "Trailing comma allowed",
+			input: `{"a": [1,], }`,
+			opts:  []encoding.Option{encoding.WithAllowTrailingCommas()},
+		},
+		{
+			name:   "Duplicate key",
+			input:  `{"a": 1, "b": {"a": 1}, "a": 2}`,
+			opts:   []encoding.Option{encoding.WithDuplicateKeyCheck()},
+			code:   encoding.ErrInvalidJSON,
+			offset: 24,
+			line:   1,
+			column: 25,
+		},
+		{
+			name:   "Trailing data",
+			input:  `{} {}`,
+			code:   encoding.ErrInvalidJSON,
+			offset: 3,
+		},
+		{
+			name:  "Size limit",
+			input: `[` + strings.Repeat(`1, `, 1000) + `1]`,
+			opts:  []encoding.Option{encoding.WithMaxSize(1024)},
+			code:  encoding.ErrSizeExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.ValidateStream(strings.NewReader(tt.input), tt.opts...)
+
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			checkJSONError(t, err, tt.code, "")
+
+			if tt.code != encoding.ErrInvalidJSON {
+				return
+			}
+
+			if jsonErr := err.(*encoding.JSONError); jsonErr.Offset != tt.offset {
+				t.Errorf("Expected offset %d, got %d", tt.offset, jsonErr.Offset)
+			}
+
+			var parseErr *parser.ParseError
+			if tt.line > 0 && (!errors.As(err, &parseErr) || parseErr.Line != tt.line || parseErr.Column != tt.column) {
+				t.Errorf("Expected error at %d:%d, got %v", tt.line, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestValidateStreamDeepNesting(t *testing.T) {
+	depth := 100000
+	input := strings.Repeat(`{"a": [`, depth) + strings.Repeat(`]}`, depth)
+
+	if err := encoding.ValidateStream(strings.NewReader(input), encoding.WithDisableSizeLimit()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestAllowTrailingData(t *testing.T) {
+	type entry struct {
+		A int `json:"a"`
+	}
+
+	input := `{"a": 1} garbage {`
+
+	unmarshal := map[string]func(v *entry, opts ...encoding.Option) error{
+		"Unmarshal": func(v *entry, opts ...encoding.Option) error {
+			return encoding.Unmarshal([]byte(input), v, opts...)
+		},
+		"UnmarshalReader": func(v *entry, opts ...encoding.Option) error {
+			return encoding.UnmarshalReader(strings.NewReader(input), v, opts...)
+		},
+	}
+
+	for name, fn := range unmarshal {
+		t.Run(name, func(t *testing.T) {
+			// Without the option, trailing data is rejected where it starts
+			err := fn(&entry{})
+			checkJSONError(t, err, encoding.ErrInvalidJSON, "unexpected data after top-level value")
+
+			var jsonErr *encoding.JSONError
+			if !errors.Is(err, encoding.ErrTrailingData) || !errors.As(err, &jsonErr) || jsonErr.Offset != 9 {
+				t.Errorf("Expected ErrTrailingData at offset 9, got %v", err)
+			}
+
+			var v entry
+			if err := fn(&v, encoding.WithAllowTrailingData()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if v.A != 1 {
+				t.Errorf("Expected the value to be decoded, got %+v", v)
+			}
+		})
+	}
+
+	t.Run("UnmarshalPrefix", func(t *testing.T) {
+		var v entry
+
+		n, err := encoding.UnmarshalPrefix([]byte(input), &v)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if v.A != 1 || input[n:] != " garbage {" {
+			t.Errorf("Expected the value and the rest of the input, got %+v and %q", v, input[n:])
+		}
+
+		if _, err := encoding.UnmarshalPrefix([]byte(`{"a": }`), &v); !errors.Is(err, encoding.ErrSyntax) {
+			t.Errorf("Expected a syntax error, got %v", err)
+		}
+	})
+
+	t.Run("Decoder", func(t *testing.T) {
+		input := `{"a": 1} {"a": 2}|rest`
+
+		dec, err := encoding.NewDecoder(strings.NewReader(input), encoding.WithAllowTrailingData())
+		if err != nil {
+			t.Fatalf("NewDecoder failed: %v", err)
+		}
+
+		var v entry
+		if err := dec.Decode(&v); err != nil || v.A != 1 {
+			t.Fatalf("Expected the first value, got %+v (%v)", v, err)
+		}
+
+		if err := dec.Decode(&v); err != nil || v.A != 2 {
+			t.Fatalf("Expected the second value, got %+v (%v)", v, err)
+		}
+
+		if dec.More() {
+			t.Error("Expected More to be false at the trailing data")
+		}
+
+		err = dec.Decode(&v)
+		checkJSONError(t, err, encoding.ErrTrailingData, "unexpected data after top-level value")
+
+		var jsonErr *encoding.JSONError
+		if !errors.As(err, &jsonErr) || input[jsonErr.Offset:] != "|rest" || jsonErr.Offset != dec.InputOffset() {
+			t.Errorf("Expected the offset of the trailing data, got %v at %d", err, dec.InputOffset())
+		}
+
+		var rest bytes.Buffer
+		if _, err := rest.ReadFrom(dec.Buffered()); err != nil || rest.String() != "|rest" {
+			t.Errorf("Expected the trailing data to be buffered, got %q (%v)", rest.String(), err)
+		}
+
+		// Without the option, the decoder rejects it as a syntax error
+		dec, err = encoding.NewDecoder(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("NewDecoder failed: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := dec.Decode(&v); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		if !dec.More() {
+			t.Error("Expected More to report the trailing data")
+		}
+
+		err = dec.Decode(&v)
+		checkJSONError(t, err, encoding.ErrInvalidJSON, "unexpected data after top-level value")
+
+		if !errors.Is(err, encoding.ErrTrailingData) || !errors.As(err, &jsonErr) || jsonErr.Offset != 17 {
+			t.Errorf("Expected ErrTrailingData at offset 17, got %v", err)
+		}
+	})
+}
@@ -1,9 +1,17 @@
 package encoding
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/rafaelmgr12/jingo/pkg/parser"
 )
@@ -13,19 +21,28 @@ import (
 func Marshal(v interface{}, opts ...Option) ([]byte, error) {
 	options, err := applyOptions(opts...)
 	if err != nil {
-		return nil, NewJSONError(ErrInvalidOptions, "invalid options configuration").
-			WithCause(err)
+		return nil, stats.recordEncode(0, NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err))
+	}
+
+	data, err := marshal(v, options)
+
+	return data, stats.recordEncode(len(data), err)
+}
+
+// marshal converts a Go value into compact JSON using already validated options.
+func marshal(v interface{}, options *Options) ([]byte, error) {
+	if options.ProfileLabels {
+		defer profilePhase("encode")()
 	}
 
-	value, err := marshalValue(reflect.ValueOf(v))
+	value, err := newMarshalState(options).marshal(reflect.ValueOf(v))
 	if err != nil {
-		return nil, NewJSONError(ErrMarshalFailure, "failed to marshal value").
-			WithCause(err).
-			WithValue(v)
+		return nil, newMarshalError(err, v)
 	}
 
 	var b strings.Builder
-	if err := writeValue(&b, value); err != nil {
+	if err := newEncodeState(options).writeValue(&b, value); err != nil {
 		return nil, NewJSONError(ErrMarshalFailure, "failed to write value").
 			WithCause(err)
 	}
@@ -43,16 +60,32 @@ func Marshal(v interface{}, opts ...Option) ([]byte, error) {
 func MarshalIndent(v interface{}, prefix, indent string, opts ...Option) ([]byte, error) {
 	options, err := applyOptions(opts...)
 	if err != nil {
-		return nil, NewJSONError(ErrInvalidOptions, "invalid options configuration").WithCause(err)
+		return nil, stats.recordEncode(0, NewJSONError(ErrInvalidOptions, "invalid options configuration").WithCause(err))
 	}
 
-	value, err := marshalValue(reflect.ValueOf(v))
+	data, err := marshalIndent(v, prefix, indent, options)
+
+	return data, stats.recordEncode(len(data), err)
+}
+
+// marshalIndent converts a Go value into indented JSON using already validated options.
+// Frozen output always uses the compact layout.
+func marshalIndent(v interface{}, prefix, indent string, options *Options) ([]byte, error) {
+	if options.FrozenOutput {
+		return marshal(v, options)
+	}
+
+	if options.ProfileLabels {
+		defer profilePhase("encode")()
+	}
+
+	value, err := newMarshalState(options).marshal(reflect.ValueOf(v))
 	if err != nil {
-		return nil, NewJSONError(ErrMarshalFailure, "failed to marshal value").WithCause(err).WithValue(v)
+		return nil, newMarshalError(err, v)
 	}
 
 	var b strings.Builder
-	if err := writeIndentedValue(&b, value, prefix, indent, 0); err != nil {
+	if err := newEncodeState(options).writeIndentedRoot(&b, value, prefix, indent); err != nil {
 		return nil, NewJSONError(ErrMarshalFailure, "failed to write value").WithCause(err)
 	}
 
@@ -64,60 +97,25 @@ func MarshalIndent(v interface{}, prefix, indent string, opts ...Option) ([]byte
 	return result, nil
 }
 
-func writeIndentedValue(b *strings.Builder, v parser.Value, prefix, indent string, level int) error {
-	currentIndent := strings.Repeat(indent, level)
-
-	switch val := v.(type) {
-	case *parser.Object:
-		b.WriteString("{\n")
-		i := 0
-		for k, v := range val.Pairs {
-			if i > 0 {
-				b.WriteString(",\n")
-			}
-			b.WriteString(currentIndent + indent)
-			fmt.Fprintf(b, "%q: ", k)
-			if err := writeIndentedValue(b, v, prefix, indent, level+1); err != nil {
-				return err
-			}
-			i++
-		}
-		b.WriteString("\n" + currentIndent + "}")
-	case *parser.Array:
-		b.WriteString("[\n")
-		for i, v := range val.Elements {
-			if i > 0 {
-				b.WriteString(",\n")
-			}
-			b.WriteString(currentIndent + indent)
-			if err := writeIndentedValue(b, v, prefix, indent, level+1); err != nil {
-				return err
-			}
-		}
-		b.WriteString("\n" + currentIndent + "]")
-	case *parser.StringLiteral:
-		fmt.Fprintf(b, "%q", val.Value)
-	case *parser.NumberLiteral:
-		b.WriteString(val.String())
-	case *parser.Boolean:
-		b.WriteString(fmt.Sprintf("%t", val.Value))
-	case *parser.Null:
-		b.WriteString("null")
-	default:
-		return fmt.Errorf("unknown value type: %T", v)
-	}
-	return nil
-}
-
 // Unmarshal parses JSON data and stores the result in the value pointed to by v.
 // The target value must be a non-nil pointer.
 func Unmarshal(data []byte, v interface{}, opts ...Option) error {
+	return stats.recordDecode(len(data), unmarshal(data, v, opts...))
+}
+
+// unmarshal implements Unmarshal.
+func unmarshal(data []byte, v interface{}, opts ...Option) error {
 	options, err := applyOptions(opts...)
 	if err != nil {
 		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
 			WithCause(err)
 	}
 
+	return unmarshalOptions(data, v, options)
+}
+
+// unmarshalOptions implements Unmarshal with already validated options.
+func unmarshalOptions(data []byte, v interface{}, options *Options) error {
 	if !options.DisableSizeLimit && len(data) > options.MaxSize {
 		return NewSizeExceededError(len(data), options.MaxSize)
 	}
@@ -127,30 +125,341 @@ func Unmarshal(data []byte, v interface{}, opts ...Option) error {
 		return NewInvalidTargetError("unmarshal target must be a non-nil pointer")
 	}
 
-	l := parser.NewLexer(string(data))
-	p := parser.NewParser(l)
+	value, err := parseBytes(data, options)
+	if err != nil {
+		return err
+	}
 
-	value, err := p.ParseJSON()
+	if options.ProfileLabels {
+		defer profilePhase("decode")()
+	}
+
+	if err := newDecodeState(options).decode(value, rv.Elem()); err != nil {
+		return err.WithValue(v)
+	}
+
+	return nil
+}
+
+// UnmarshalPrefix parses the JSON value at the start of data, which may be followed by
+// other data such as the rest of a log line, and stores it in the value pointed to by v.
+// It returns the number of bytes the value spans, so data[n:] is the input after it,
+// starting with any whitespace that follows the value. Data after the value is accepted
+// without WithAllowTrailingData.
+func UnmarshalPrefix(data []byte, v interface{}, opts ...Option) (int, error) {
+	n, err := unmarshalPrefix(data, v, opts...)
+
+	return n, stats.recordDecode(n, err)
+}
+
+// unmarshalPrefix implements UnmarshalPrefix.
+func unmarshalPrefix(data []byte, v interface{}, opts ...Option) (int, error) {
+	options, err := applyOptions(append(opts, WithAllowTrailingData())...)
 	if err != nil {
-		return NewJSONError(ErrInvalidJSON, "failed to parse JSON").
+		return 0, NewJSONError(ErrInvalidOptions, "invalid options configuration").
 			WithCause(err)
 	}
 
-	if err := unmarshalValue(value, rv.Elem()); err != nil {
-		return NewJSONError(ErrUnmarshalFailure, "failed to unmarshal value").
-			WithCause(err).
-			WithValue(v)
+	if !options.DisableSizeLimit && len(data) > options.MaxSize {
+		return 0, NewSizeExceededError(len(data), options.MaxSize)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return 0, NewInvalidTargetError("unmarshal target must be a non-nil pointer")
+	}
+
+	value, n, err := parsePrefix(data, options)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := newDecodeState(options).decode(value, rv.Elem()); err != nil {
+		return n, err.WithValue(v)
+	}
+
+	return n, nil
+}
+
+// UnmarshalAll parses every top-level value in data, such as a series of documents
+// separated by newlines, and returns them in order as the interface{} values Unmarshal
+// produces. Each value must be an object or an array.
+func UnmarshalAll(data []byte, opts ...Option) ([]interface{}, error) {
+	values, err := unmarshalAll(data, opts...)
+
+	return values, stats.recordDecode(len(data), err)
+}
+
+// unmarshalAll implements UnmarshalAll.
+func unmarshalAll(data []byte, opts ...Option) ([]interface{}, error) {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	if !options.DisableSizeLimit && len(data) > options.MaxSize {
+		return nil, NewSizeExceededError(len(data), options.MaxSize)
+	}
+
+	if options.Transcode {
+		// Reading from memory cannot fail
+		data, _ = io.ReadAll(parser.NewTranscodingReader(bytes.NewReader(data)))
+	}
+
+	p := parser.NewParser(parser.NewLexerBytes(data), options.parserOptions()...)
+
+	var values []interface{}
+
+	for {
+		value, err := p.ParseNext()
+		if err == io.EOF {
+			return values, nil
+		}
+
+		if err != nil {
+			return nil, newParseError(p, "failed to parse JSON", err)
+		}
+
+		var v interface{}
+		if err := newDecodeState(options).decode(value, reflect.ValueOf(&v).Elem()); err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+}
+
+// Parse parses data into a parser.Value, applying the size limit and the syntax options
+// (comments, trailing commas, duplicate keys, trailing data) in opts. The result can be
+// inspected or modified and written back with Marshal.
+func Parse(data []byte, opts ...Option) (parser.Value, error) {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	if !options.DisableSizeLimit && len(data) > options.MaxSize {
+		return nil, NewSizeExceededError(len(data), options.MaxSize)
+	}
+
+	value, err := parseBytes(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// MarshalValue converts a Go value into a parser.Value with the same rules, hooks and
+// field options as Marshal, for writing it in another format.
+func MarshalValue(v interface{}, opts ...Option) (parser.Value, error) {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	value, err := newMarshalState(options).marshal(reflect.ValueOf(v))
+	if err != nil {
+		return nil, newMarshalError(err, v)
+	}
+
+	return value, nil
+}
+
+// UnmarshalValue stores value, read from JSON or another format, in the value pointed to
+// by v with the same rules as Unmarshal.
+func UnmarshalValue(value parser.Value, v interface{}, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return NewInvalidTargetError("unmarshal target must be a non-nil pointer")
+	}
+
+	if err := newDecodeState(options).decode(value, rv.Elem()); err != nil {
+		return err.WithValue(v)
 	}
 
 	return nil
 }
 
-// marshalValue converts a reflect.Value to a parser.Value
+// parseBytes parses a complete JSON document held in memory. Syntax errors are returned
+// as a JSONError carrying their offset.
+func parseBytes(data []byte, options *Options) (parser.Value, error) {
+	value, _, err := parsePrefix(data, options)
+
+	return value, err
+}
+
+// parsePrefix implements parseBytes and also returns the number of bytes the document
+// spans.
+func parsePrefix(data []byte, options *Options) (parser.Value, int, error) {
+	if options.ProfileLabels {
+		defer profilePhase("parse")()
+	}
+
+	if options.Transcode {
+		// Reading from memory cannot fail
+		data, _ = io.ReadAll(parser.NewTranscodingReader(bytes.NewReader(data)))
+	}
+
+	l := parser.NewLexerBytes(data)
+	p := parser.NewParser(l, options.parserOptions()...)
+
+	value, err := p.ParseJSON()
+	if err != nil {
+		return nil, 0, newParseError(p, "failed to parse JSON", err)
+	}
+
+	if !options.AllowTrailingData && p.More() {
+		return nil, 0, newParseError(p, "failed to parse JSON", errTrailingData)
+	}
+
+	return value, int(p.InputOffset()), nil
+}
+
+// errTrailingData reports input left over after the document without AllowTrailingData. It is
+// wrapped in an ErrInvalidJSON error, so that errors.Is matches both codes.
+var errTrailingData = NewJSONError(ErrTrailingData, "unexpected data after top-level value")
+
+// marshalState carries the encode hooks and, when there are any, the path of the value
+// being converted by marshalValue.
+type marshalState struct {
+	hooks []EncodeHook
+	path  []pathSegment
+	// redact holds the lowercased names of the object members written as RedactedValue
+	redact map[string]bool
+	// naming derives the key of struct fields without a name in their json tag
+	naming KeyNamingStrategy
+	// include and exclude select the object members that are written
+	include, exclude [][]string
+	// proto follows the protobuf JSON mapping
+	proto bool
+	// int64AsString writes the integers JavaScript cannot represent exactly as strings
+	int64AsString bool
+	// base64URL is set while the byte slice of a field tagged base64url is written
+	base64URL bool
+	// skipUnsupported leaves out the members holding values of unsupportedKind
+	skipUnsupported bool
+	// fixedFloats writes floats with floatDecimals digits after the decimal point
+	fixedFloats   bool
+	floatDecimals int
+	// numberFormatter writes floats instead, if set
+	numberFormatter func(f float64, bits int) []byte
+}
+
+// newMarshalState creates a marshalState for the given options.
+func newMarshalState(options *Options) *marshalState {
+	return &marshalState{
+		hooks:           options.encodeHooks,
+		redact:          options.redactFields,
+		naming:          options.KeyNaming,
+		include:         options.includeFields,
+		exclude:         options.excludeFields,
+		proto:           options.ProtoJSON,
+		int64AsString:   options.Int64AsString,
+		skipUnsupported: options.SkipUnsupported,
+		fixedFloats:     options.FloatDecimals >= 0,
+		floatDecimals:   options.FloatDecimals,
+		numberFormatter: options.numberFormatter,
+	}
+}
+
+// redactedValue returns the string that replaces a redacted value.
+func redactedValue() parser.Value {
+	return &parser.StringLiteral{Value: RedactedValue, Token: parser.Token{Type: parser.TokenString}}
+}
+
+// marshalValue converts a reflect.Value to a parser.Value without calling encode hooks
 func marshalValue(v reflect.Value) (parser.Value, error) {
+	return (&marshalState{}).value(v)
+}
+
+// marshal converts v, the value at the current path, giving the hooks the first chance to
+// replace it. A replacement is converted without calling the hooks on it.
+func (m *marshalState) marshal(v reflect.Value) (parser.Value, error) {
+	if len(m.hooks) > 0 {
+		path := formatPath(m.path)
+
+		for _, hook := range m.hooks {
+			if r, ok := hook(path, v); ok {
+				return marshalValue(reflect.ValueOf(r))
+			}
+		}
+	}
+
+	return m.value(v)
+}
+
+// child converts v, the member or element s of the current value.
+func (m *marshalState) child(s pathSegment, v reflect.Value) (parser.Value, error) {
+	if m.redact != nil && !s.isIndex && m.redact[strings.ToLower(s.key)] {
+		return redactedValue(), nil
+	}
+
+	if len(m.hooks) == 0 && m.include == nil && m.exclude == nil {
+		return m.value(v)
+	}
+
+	m.path = append(m.path, s)
+	defer func() { m.path = m.path[:len(m.path)-1] }()
+
+	return m.marshal(v)
+}
+
+// value converts v to a parser.Value.
+func (m *marshalState) value(v reflect.Value) (parser.Value, error) {
+	if !v.IsValid() {
+		return &parser.Null{Token: parser.Token{Type: parser.TokenNull}}, nil
+	}
+
 	if v.Kind() == reflect.Interface && !v.IsNil() {
 		v = v.Elem()
 	}
 
+	// Values built with the parser package are written as they are
+	if v.Type().Implements(reflect.TypeOf((*parser.Value)(nil)).Elem()) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return &parser.Null{Token: parser.Token{Type: parser.TokenNull}}, nil
+		}
+
+		return v.Interface().(parser.Value), nil
+	}
+
+	if v.Type().Implements(optionalType) && v.CanInterface() {
+		value, defined, null := v.Interface().(optional).optionalState()
+		if !defined || null {
+			return parser.NewNull(), nil
+		}
+
+		return m.value(value)
+	}
+
+	if v.Type() == reflect.PointerTo(orderedMapType) && !v.IsNil() {
+		return m.marshalOrderedMap(v.Interface().(*OrderedMap))
+	}
+
+	// big.Int and big.Float implement Marshaler, but are written by their digits here
+	if isBigNumberType(v.Type()) {
+		value, err := marshalBigNumber(v)
+		if num, ok := value.(*parser.NumberLiteral); ok && m.int64AsString {
+			return quoteUnsafeInteger(num), err
+		}
+
+		return value, err
+	}
+
+	if v.Type() == numberType {
+		return marshalNumber(Number(v.String()))
+	}
+
 	if v.Type().Implements(reflect.TypeOf((*Marshaler)(nil)).Elem()) {
 		marshaler := v.Interface().(Marshaler)
 
@@ -159,7 +468,7 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 			return nil, NewJSONError(ErrMarshalFailure, "failed to marshal value").WithCause(err)
 		}
 
-		l := parser.NewLexer(data)
+		l := parser.NewLexerBytes(data)
 		p := parser.NewParser(l)
 
 		value, err := p.ParseJSON()
@@ -170,6 +479,12 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 		return value, nil
 	}
 
+	if m.proto {
+		if value, ok, err := m.protoValue(v); ok {
+			return value, err
+		}
+	}
+
 	switch v.Kind() {
 	case reflect.String:
 		return &parser.StringLiteral{
@@ -189,19 +504,19 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 			Literal: fmt.Sprintf("%d", v.Int()),
 		})
 
+		if m.int64AsString {
+			return quoteUnsafeInteger(num), nil
+		}
+
 		return num, nil
 
 	case reflect.Float32, reflect.Float64:
-		num := parser.NewNumberLiteral(parser.Token{
-			Type:    parser.TokenNumber,
-			Literal: fmt.Sprintf("%g", v.Float()),
-		})
-
-		return num, nil
+		return m.marshalFloat(v)
 
 	case reflect.Map:
-		if v.Type().Key().Kind() != reflect.String {
-			return nil, fmt.Errorf("map key must be string")
+		entries, err := mapEntries(v)
+		if err != nil {
+			return nil, err
 		}
 
 		obj := &parser.Object{
@@ -209,28 +524,33 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 			Pairs: make(map[string]parser.Value),
 		}
 
-		iter := v.MapRange()
-		for iter.Next() {
-			value, err := marshalValue(iter.Value())
+		for _, e := range entries {
+			value, ok, err := m.member(e.name, v.MapIndex(e.key))
 			if err != nil {
-				return nil, fmt.Errorf("map value: %v", err)
+				return nil, withSegment(keySegment(e.name), err)
 			}
 
-			obj.Pairs[iter.Key().String()] = value
+			if ok {
+				obj.Set(e.name, value)
+			}
 		}
 
 		return obj, nil
 
 	case reflect.Slice, reflect.Array:
+		if isBytesType(v.Type()) {
+			return marshalBytes(v, m.base64URL), nil
+		}
+
 		arr := &parser.Array{
 			Token:    parser.Token{Type: parser.TokenBracketOpen},
 			Elements: make([]parser.Value, 0, v.Len()),
 		}
 
 		for i := 0; i < v.Len(); i++ {
-			value, err := marshalValue(v.Index(i))
+			value, err := m.child(indexSegment(i), v.Index(i))
 			if err != nil {
-				return nil, fmt.Errorf("index %d: %v", i, err)
+				return nil, withSegment(indexSegment(i), err)
 			}
 
 			arr.Elements = append(arr.Elements, value)
@@ -243,7 +563,7 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 			return &parser.Null{Token: parser.Token{Type: parser.TokenNull}}, nil
 		}
 
-		return marshalValue(v.Elem())
+		return m.value(v.Elem())
 
 	case reflect.Struct:
 		obj := &parser.Object{
@@ -251,200 +571,768 @@ func marshalValue(v reflect.Value) (parser.Value, error) {
 			Pairs: make(map[string]parser.Value),
 		}
 
-		t := v.Type()
-		for i := 0; i < v.NumField(); i++ {
-			field := t.Field(i)
+		var extra reflect.Value
+
+		fields, err := encodedFields(v.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			// The fields of a nil embedded pointer are left out
+			fv, ok := fieldByIndex(v, field.Index, false)
+			if !ok {
+				continue
+			}
+
+			if isExtraField(field) {
+				extra = fv
+				continue
+			}
+
+			tag := field.Tag.Get("json")
+			if tag == "-" || field.Type == presenceType || isUndefinedOptional(fv) {
+				continue
+			}
+
+			tagParts := strings.Split(tag, ",")
+			name := fieldKey(field.Name, tagParts[0], m.naming)
+			redact := false
+
+			for _, opt := range tagParts[1:] {
+				redact = redact || opt == "redact"
+			}
+
+			if redact {
+				if m.match(name) != fieldOmitted {
+					obj.Set(name, redactedValue())
+				}
+
+				continue
+			}
+
+			m.base64URL = isBytesType(field.Type) && hasTagOption(tag, "base64url")
+			value, ok, err := m.member(name, fv)
+			m.base64URL = false
+
+			if err != nil {
+				return nil, withSegment(keySegment(name), err)
+			}
+
+			if ok {
+				obj.Set(name, value)
+			}
+		}
+
+		if extra.IsValid() {
+			if err := m.extra(obj, extra); err != nil {
+				return nil, err
+			}
+		}
+
+		return obj, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return &parser.Null{Token: parser.Token{Type: parser.TokenNull}}, nil
+		}
+
+		return m.value(v.Elem())
+
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return nil, NewUnsupportedTypeError(fmt.Sprintf("%v has no JSON representation", v.Type()))
+
+	default:
+		return nil, NewUnsupportedTypeError(v.Type().String())
+	}
+}
+
+// unsupportedKind reports whether v holds a channel, function, complex number or unsafe
+// pointer, directly or through interfaces.
+func unsupportedKind(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeState carries the configuration and the current location while a parsed value
+// is stored into a Go value.
+type decodeState struct {
+	options *Options
+	path    []pathSegment
+	errPath string
+	// errToken is the first token of the value at errPath, which locates it in the input
+	errToken parser.Token
+	failures FieldErrors
+	// invalid lists the decoded values that failed their ValidateJSON method
+	invalid ValidationErrors
+	// base64URL is set while the byte slice of a field tagged base64url is decoded
+	base64URL bool
+}
+
+// newDecodeState creates a decodeState for the given options.
+func newDecodeState(options *Options) *decodeState {
+	return &decodeState{options: options}
+}
+
+// decode stores v into rv. A failure is reported with the JSON Pointer of the value that
+// could not be decoded as its Path, and with its offset, line and column in the input
+// when v was parsed; in partial decode mode the cause lists every such value as
+// FieldErrors.
+func (d *decodeState) decode(v parser.Value, rv reflect.Value) *JSONError {
+	if err := d.unmarshalValue(v, rv); err != nil {
+		if d.errPath == "" {
+			d.errToken = parser.StartToken(v)
+		}
+
+		return NewJSONError(codeOf(err, ErrUnmarshalFailure), "failed to unmarshal value").
+			WithCause(err).
+			WithPath(d.errPath).
+			WithOffset(d.errToken.Offset).
+			WithPosition(d.errToken.Line, d.errToken.Column)
+	}
+
+	if len(d.failures) > 0 {
+		return NewJSONError(ErrUnmarshalFailure, "failed to unmarshal value").
+			WithCause(d.failures)
+	}
+
+	if err := d.afterDecode(rv); err != nil {
+		return NewJSONError(codeOf(err, ErrUnmarshalFailure), "failed to unmarshal value").
+			WithCause(err).
+			WithPath(d.errPath)
+	}
+
+	if len(d.invalid) > 0 {
+		return NewJSONError(ErrValidationFailure, "validation failed").
+			WithCause(d.invalid)
+	}
+
+	return nil
+}
+
+// push descends into a child of the current value.
+func (d *decodeState) push(s pathSegment) {
+	d.path = append(d.path, s)
+}
+
+// pop returns to the parent of the current value.
+func (d *decodeState) pop() {
+	d.path = d.path[:len(d.path)-1]
+}
+
+// unmarshalChild decodes v into rv one level below the current path.
+func (d *decodeState) unmarshalChild(s pathSegment, v parser.Value, rv reflect.Value) error {
+	d.push(s)
+	defer d.pop()
+
+	err := d.unmarshalValue(v, rv)
+	if err == nil {
+		err = d.afterDecode(rv)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if d.options.PartialDecode {
+		d.failures = append(d.failures, &FieldError{Path: pointerPath(d.path), Err: err})
+
+		return nil
+	}
+
+	// The innermost failing value is reached first while the recursion unwinds
+	if d.errPath == "" {
+		d.errPath = pointerPath(d.path)
+		d.errToken = parser.StartToken(v)
+	}
+
+	return err
+}
+
+// afterDecode runs the decode hooks and the Validator of the value just decoded into rv.
+func (d *decodeState) afterDecode(rv reflect.Value) error {
+	if len(d.options.decodeHooks) > 0 {
+		if err := d.hook(rv); err != nil {
+			return err
+		}
+	}
+
+	d.validate(rv)
+
+	return nil
+}
+
+// hook gives the decode hooks the chance to replace the value decoded into rv. The
+// replacement is converted between numeric types and between types of the same kind.
+func (d *decodeState) hook(rv reflect.Value) error {
+	path := formatPath(d.path)
+
+	for _, hook := range d.options.decodeHooks {
+		r, ok := hook(path, rv)
+		if !ok {
+			continue
+		}
+
+		rr := reflect.ValueOf(r)
+
+		switch {
+		case !rr.IsValid():
+			rv.Set(reflect.Zero(rv.Type()))
+		case rr.Type().AssignableTo(rv.Type()):
+			rv.Set(rr)
+		case rr.Type().ConvertibleTo(rv.Type()) && (rr.Kind() == rv.Kind() || isNumberKind(rr.Kind()) && isNumberKind(rv.Kind())):
+			rv.Set(rr.Convert(rv.Type()))
+		default:
+			return NewJSONError(ErrUnexpectedType,
+				fmt.Sprintf("decode hook for %q returned %T, which cannot be stored in %v", path, r, rv.Type()))
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// isNumberKind reports whether k is an integer or floating-point kind. Other conversions,
+// such as an integer into a string, are not what a hook means.
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// unmarshalValue converts a parser.Value to a reflect.Value
+func (d *decodeState) unmarshalValue(v parser.Value, rv reflect.Value) error {
+	if len(d.options.coercions) > 0 {
+		coerced, err := d.coerce(v)
+		if err != nil {
+			return NewJSONError(ErrInvalidValue, "failed to coerce value").WithCause(err)
+		}
+
+		v = coerced
+	}
+
+	if str, ok := v.(*parser.StringLiteral); ok && d.options.Int64AsString && isIntegerTarget(rv) {
+		num, err := unquoteInteger(str, rv)
+		if err != nil {
+			return err
+		}
+
+		v = num
+	}
+
+	if target, ok := rv.Addr().Interface().(optionalTarget); ok {
+		_, null := v.(*parser.Null)
+		if value := target.setOptional(null); !null {
+			return d.unmarshalValue(v, value)
+		}
+
+		return nil
+	}
+
+	if num, ok := v.(*parser.NumberLiteral); ok && isBigNumberType(rv.Type()) {
+		return unmarshalBigNumber(num, rv)
+	}
+
+	if rv.Type() == numberType {
+		return unmarshalNumberText(v, rv)
+	}
+
+	if unmarshaler, ok := rv.Addr().Interface().(Unmarshaler); ok {
+		var b strings.Builder
+
+		if err := writeValue(&b, v); err != nil {
+			return NewJSONError(ErrUnmarshalFailure, "failed to write value").WithCause(err)
+		}
+
+		if err := unmarshaler.UnmarshalJSON([]byte(b.String())); err != nil {
+			return NewJSONError(ErrUnmarshalFailure, "failed to unmarshal value").WithCause(err)
+		}
+
+		return nil
+	}
+
+	if v == nil {
+		return NewJSONError(ErrUnmarshalFailure, "value is nil")
+	}
+
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		switch val := v.(type) {
+		case *parser.Object:
+			if d.options.OrderedMaps {
+				return d.unmarshalOrderedMap(val, rv)
+			}
+
+			if t := d.options.MapType; t != nil {
+				return d.unmarshalContainer(val, rv, t)
+			}
+
+			obj := map[string]interface{}{}
+
+			if existing, ok := rv.Interface().(map[string]interface{}); ok && existing != nil && d.options.MergeInto {
+				obj = existing
+			}
+
+			for k, v := range val.Pairs {
+				mapValue := obj[k]
+				if err := d.unmarshalChild(keySegment(k), v, reflect.ValueOf(&mapValue).Elem()); err != nil {
+					return err
+				}
+
+				obj[k] = mapValue
+			}
+
+			rv.Set(reflect.ValueOf(obj))
+
+		case *parser.Array:
+			if t := d.options.SliceType; t != nil {
+				return d.unmarshalContainer(val, rv, t)
+			}
+
+			arr := make([]interface{}, len(val.Elements))
+
+			for i, elem := range val.Elements {
+				var arrayValue interface{}
+				if err := d.unmarshalChild(indexSegment(i), elem, reflect.ValueOf(&arrayValue).Elem()); err != nil {
+					return err
+				}
+
+				arr[i] = arrayValue
+			}
+
+			rv.Set(reflect.ValueOf(arr))
+
+		case *parser.StringLiteral:
+			rv.Set(reflect.ValueOf(val.Value))
+
+		case *parser.NumberLiteral:
+			x, err := numberInterface(val, d.options.NumberPolicy)
+			if err != nil {
+				return err
+			}
+
+			rv.Set(reflect.ValueOf(x))
+
+		case *parser.Boolean:
+			rv.Set(reflect.ValueOf(val.Value))
+
+		case *parser.Null:
+			rv.Set(reflect.Zero(rv.Type()))
+
+		default:
+			return NewJSONError(ErrUnexpectedType, fmt.Sprintf("unknown value type: %T", v))
+		}
+
+		return nil
+	}
+
+	switch val := v.(type) {
+	case *parser.Object:
+		return d.unmarshalObject(val, rv)
+
+	case *parser.Array:
+		return d.unmarshalArray(val, rv)
+
+	case *parser.StringLiteral:
+		if isBytesType(rv.Type()) {
+			return unmarshalBytes(val, rv, d.base64URL)
+		}
+
+		return unmarshalString(val, rv)
+
+	case *parser.NumberLiteral:
+		return unmarshalNumber(val, rv)
+
+	case *parser.Boolean:
+		return unmarshalBool(val, rv)
+
+	case *parser.Null:
+		return unmarshalNull(rv)
+
+	default:
+		return NewJSONError(ErrUnexpectedType, fmt.Sprintf("unknown value type: %T", v))
+	}
+}
+
+// unmarshalContainer decodes an object or array into a new value of type t, chosen by
+// WithMapType or WithSliceType, and stores it in the interface rv.
+func (d *decodeState) unmarshalContainer(v parser.Value, rv reflect.Value, t reflect.Type) error {
+	container := reflect.New(t).Elem()
+
+	var err error
+	if obj, ok := v.(*parser.Object); ok {
+		err = d.unmarshalObject(obj, container)
+	} else {
+		err = d.unmarshalArray(v.(*parser.Array), container)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rv.Set(container)
+
+	return nil
+}
+
+// unmarshalObject handles unmarshaling of JSON objects into Go structs or maps
+func (d *decodeState) unmarshalObject(obj *parser.Object, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() || d.options.ZeroMissingFields {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+
+		for k, v := range obj.Pairs {
+			elemType := rv.Type().Elem()
+			mapValue := reflect.New(elemType).Elem()
+
+			key, err := mapKey(k, rv.Type().Key())
+			if err != nil {
+				return err
+			}
+
+			if d.options.MergeInto {
+				if existing := rv.MapIndex(key); existing.IsValid() {
+					mapValue.Set(existing)
+				}
+			}
+
+			if err := d.unmarshalChild(keySegment(k), v, mapValue); err != nil {
+				return err
+			}
+
+			rv.SetMapIndex(key, mapValue)
+		}
+
+	case reflect.Struct:
+		t := rv.Type()
+
+		extra, presence := -1, -1
+
+		for i := 0; i < t.NumField(); i++ {
+			switch {
+			case isExtraField(t.Field(i)) && t.Field(i).PkgPath == "":
+				extra = i
+			case t.Field(i).Type == presenceType && t.Field(i).PkgPath == "":
+				presence = i
+			}
+		}
+
+		if d.options.ZeroMissingFields {
+			zeroFields(rv)
+		}
+
+		if d.options.Defaults {
+			setDefaults(rv)
+		}
+
+		var known map[string]bool
+		if d.options.rejectUnknownFields() || extra >= 0 {
+			known = make(map[string]bool, t.NumField())
+		}
+
+		fields, err := encodedFields(t)
+		if err != nil {
+			return err
+		}
+
+		for _, field := range fields {
+			if len(field.Index) == 1 && (field.Index[0] == extra || field.Index[0] == presence) {
+				continue
+			}
 
 			tag := field.Tag.Get("json")
 			if tag == "-" {
 				continue
 			}
 
-			name := field.Name
+			name, _, _ := strings.Cut(tag, ",")
+			name = fieldKey(field.Name, name, d.options.KeyNaming)
 
-			if tag != "" {
-				tagParts := strings.Split(tag, ",")
-				if len(tagParts) > 0 && tagParts[0] != "" {
-					name = tagParts[0]
-				}
+			if known != nil {
+				known[name] = true
 			}
 
-			value, err := marshalValue(v.Field(i))
-			if err != nil {
-				return nil, fmt.Errorf("field %s: %v", name, err)
+			v, present := obj.Pairs[name]
+
+			// Nil embedded pointers are only allocated for the members present in the input
+			fv, ok := fieldByIndex(rv, field.Index, present)
+			if !ok {
+				if present {
+					return NewJSONError(ErrInvalidTarget,
+						fmt.Sprintf("cannot set embedded pointer to unexported struct in %v", t))
+				}
+
+				continue
 			}
 
-			obj.Pairs[name] = value
-		}
+			if present {
+				d.base64URL = isBytesType(field.Type) && hasTagOption(tag, "base64url")
+				err := d.unmarshalChild(keySegment(name), v, fv)
+				d.base64URL = false
 
-		return obj, nil
+				if err != nil {
+					return err
+				}
+			} else if d.options.Defaults {
+				if err := d.defaultField(field, name, fv); err != nil {
+					return err
+				}
+			}
+		}
 
-	case reflect.Interface:
-		if v.IsNil() {
-			return &parser.Null{Token: parser.Token{Type: parser.TokenNull}}, nil
+		if presence >= 0 {
+			rv.Field(presence).Set(reflect.ValueOf(newPresence(obj)))
 		}
 
-		return marshalValue(v.Elem())
+		if extra >= 0 {
+			return d.unmarshalExtra(obj, known, rv.Field(extra))
+		}
 
-	default:
-		return nil, fmt.Errorf("unsupported type: %v", v.Type())
-	}
-}
+		if known != nil {
+			var unknown []string
 
-// unmarshalValue converts a parser.Value to a reflect.Value
-func unmarshalValue(v parser.Value, rv reflect.Value) error {
-	if unmarshaler, ok := rv.Addr().Interface().(Unmarshaler); ok {
-		var b strings.Builder
+			for k := range obj.Pairs {
+				if !known[k] {
+					unknown = append(unknown, k)
+				}
+			}
 
-		if err := writeValue(&b, v); err != nil {
-			return NewJSONError(ErrUnmarshalFailure, "failed to write value").WithCause(err)
-		}
+			if len(unknown) > 0 {
+				sort.Strings(unknown)
 
-		if err := unmarshaler.UnmarshalJSON([]byte(b.String())); err != nil {
-			return NewJSONError(ErrUnmarshalFailure, "failed to unmarshal value").WithCause(err)
+				return NewJSONError(ErrInvalidValue, fmt.Sprintf("unknown field %q in %v", unknown[0], t))
+			}
 		}
 
-		return nil
+	default:
+		return newTypeError("object", rv.Type())
 	}
 
-	if v == nil {
-		return NewJSONError(ErrUnmarshalFailure, "value is nil")
+	return nil
+}
+
+// zeroFields sets the fields of the struct rv that are decoded from JSON to their zero
+// values. Unexported fields and fields tagged "-" are kept, and so are nil embedded
+// pointers.
+func zeroFields(rv reflect.Value) {
+	t := rv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		switch field := t.Field(i); {
+		case promotesFields(field):
+			if fv := reflect.Indirect(rv.Field(i)); fv.IsValid() {
+				zeroFields(fv)
+			}
+		case field.PkgPath == "" && (field.Tag.Get("json") != "-" || isExtraField(field)):
+			rv.Field(i).Set(reflect.Zero(field.Type))
+		}
 	}
+}
 
-	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
-		switch val := v.(type) {
-		case *parser.Object:
-			obj := map[string]interface{}{}
+// encodedFields returns the fields of the struct type t that are encoded, in order. The
+// exported fields of an untagged embedded struct, or pointer to a struct, are promoted
+// like in encoding/json, whether the embedded type is exported or not, unless a field of
+// the same Go name at a shallower depth hides them or several fields of that name are at
+// the same depth. Their Index is the path to pass to fieldByIndex.
+func encodedFields(t reflect.Type) ([]reflect.StructField, error) {
+	return structFields(t, []reflect.Type{t})
+}
 
-			for k, v := range val.Pairs {
-				var mapValue interface{}
-				if err := unmarshalValue(v, reflect.ValueOf(&mapValue).Elem()); err != nil {
-					return fmt.Errorf("map key %q: %v", k, err)
-				}
+// structFields implements encodedFields. embedding holds the struct types being
+// expanded, so that a type embedding a pointer to itself is expanded only once.
+func structFields(t reflect.Type, embedding []reflect.Type) ([]reflect.StructField, error) {
+	fields := make([]reflect.StructField, 0, t.NumField())
 
-				obj[k] = mapValue
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if promotesFields(field) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
 			}
 
-			rv.Set(reflect.ValueOf(obj))
+			if slices.Contains(embedding, embedded) {
+				continue
+			}
 
-		case *parser.Array:
-			arr := make([]interface{}, len(val.Elements))
+			promoted, err := structFields(embedded, append(embedding, embedded))
+			if err != nil {
+				return nil, err
+			}
 
-			for i, elem := range val.Elements {
-				var arrayValue interface{}
-				if err := unmarshalValue(elem, reflect.ValueOf(&arrayValue).Elem()); err != nil {
-					return fmt.Errorf("index %d: %v", i, err)
-				}
+			for _, f := range promoted {
+				f.Index = append([]int{i}, f.Index...)
 
-				arr[i] = arrayValue
+				if shallowest, ok := t.FieldByName(f.Name); ok && slices.Equal(shallowest.Index, f.Index) {
+					fields = append(fields, f)
+				}
 			}
 
-			rv.Set(reflect.ValueOf(arr))
-
-		case *parser.StringLiteral:
-			rv.Set(reflect.ValueOf(val.Value))
+			continue
+		}
 
-		case *parser.NumberLiteral:
-			if val.IsInt {
-				rv.Set(reflect.ValueOf(val.Int))
-			} else {
-				rv.Set(reflect.ValueOf(val.Float))
+		if ok, err := exportedField(t, field); !ok {
+			if err != nil {
+				return nil, err
 			}
 
-		case *parser.Boolean:
-			rv.Set(reflect.ValueOf(val.Value))
+			continue
+		}
 
-		case *parser.Null:
-			rv.Set(reflect.Zero(rv.Type()))
+		fields = append(fields, field)
+	}
 
-		default:
-			return fmt.Errorf("unknown value type: %T", v)
-		}
+	return fields, nil
+}
 
-		return nil
+// promotesFields reports whether field is an untagged embedded struct, or pointer to a
+// struct, whose exported fields are encoded as fields of the outer struct.
+func promotesFields(field reflect.StructField) bool {
+	if !field.Anonymous || field.Tag.Get("json") != "" {
+		return false
 	}
 
-	switch val := v.(type) {
-	case *parser.Object:
-		return unmarshalObject(val, rv)
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
 
-	case *parser.Array:
-		return unmarshalArray(val, rv)
+	return t.Kind() == reflect.Struct
+}
 
-	case *parser.StringLiteral:
-		return unmarshalString(val, rv)
+// fieldByIndex returns the field of the struct rv at index, a path returned by
+// encodedFields. The nil embedded pointers on the way are allocated when alloc is set;
+// otherwise, or for a pointer to an unexported struct that cannot be set, ok is false.
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (fv reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc || !rv.CanSet() {
+					return reflect.Value{}, false
+				}
 
-	case *parser.NumberLiteral:
-		return unmarshalNumber(val, rv)
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
 
-	case *parser.Boolean:
-		return unmarshalBool(val, rv)
+			rv = rv.Elem()
+		}
 
-	case *parser.Null:
-		return unmarshalNull(rv)
+		rv = rv.Field(x)
+	}
 
-	default:
-		return fmt.Errorf("unknown value type: %T", v)
+	return rv, true
+}
+
+// exportedField reports whether field of the struct type t is encoded. Unexported fields
+// are skipped like in encoding/json, and a json tag on one is an error since it would be
+// ignored.
+func exportedField(t reflect.Type, field reflect.StructField) (bool, error) {
+	if field.PkgPath == "" {
+		return true, nil
+	}
+
+	if tag := field.Tag.Get("json"); tag != "" && tag != "-" {
+		return false, NewJSONError(ErrUnsupportedType,
+			fmt.Sprintf("json tag %q on unexported field %s of %v", tag, field.Name, t))
 	}
+
+	return false, nil
 }
 
-// unmarshalObject handles unmarshaling of JSON objects into Go structs or maps
-func unmarshalObject(obj *parser.Object, rv reflect.Value) error {
-	switch rv.Kind() {
-	case reflect.Map:
-		if rv.IsNil() {
-			rv.Set(reflect.MakeMap(rv.Type()))
+// isExtraField reports whether field collects the object members that match no other
+// field, as marked by a `json:",inline"` or `json:"-,extra"` tag.
+func isExtraField(field reflect.StructField) bool {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+
+	for _, opt := range parts[1:] {
+		if parts[0] == "" && opt == "inline" || parts[0] == "-" && opt == "extra" {
+			return true
 		}
+	}
 
-		for k, v := range obj.Pairs {
-			elemType := rv.Type().Elem()
-			mapValue := reflect.New(elemType).Elem()
+	return false
+}
 
-			if err := unmarshalValue(v, mapValue); err != nil {
-				return fmt.Errorf("map value %q: %v", k, err)
-			}
+// extra adds the entries of the catch-all map em to obj, in key order. Entries whose key
+// is already taken by a struct field are dropped.
+func (m *marshalState) extra(obj *parser.Object, em reflect.Value) error {
+	if em.Kind() != reflect.Map || em.Type().Key().Kind() != reflect.String {
+		return NewInvalidTargetError(fmt.Sprintf("catch-all field must be a map with string keys, got %v", em.Type()))
+	}
+
+	keys := make([]string, 0, em.Len())
+	for _, k := range em.MapKeys() {
+		keys = append(keys, k.String())
+	}
 
-			rv.SetMapIndex(reflect.ValueOf(k), mapValue)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, ok := obj.Pairs[k]; ok {
+			continue
 		}
 
-	case reflect.Struct:
-		t := rv.Type()
+		value, ok, err := m.member(k, em.MapIndex(reflect.ValueOf(k).Convert(em.Type().Key())))
+		if err != nil {
+			return withSegment(keySegment(k), err)
+		}
 
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
+		if ok {
+			obj.Set(k, value)
+		}
+	}
 
-			tag := field.Tag.Get("json")
-			if tag == "-" {
-				continue
-			}
+	return nil
+}
 
-			name := field.Name
-			if tag != "" {
-				name = strings.Split(tag, ",")[0]
-			}
+// unmarshalExtra stores the members of obj that are not known struct fields into the
+// catch-all map m.
+func (d *decodeState) unmarshalExtra(obj *parser.Object, known map[string]bool, m reflect.Value) error {
+	if m.Kind() != reflect.Map || m.Type().Key().Kind() != reflect.String {
+		return NewInvalidTargetError(fmt.Sprintf("catch-all field must be a map with string keys, got %v", m.Type()))
+	}
 
-			if v, ok := obj.Pairs[name]; ok {
-				if err := unmarshalValue(v, rv.Field(i)); err != nil {
-					return fmt.Errorf("field %s: %v", name, err)
-				}
-			}
+	for k, v := range obj.Pairs {
+		if known[k] {
+			continue
 		}
 
-	default:
-		return fmt.Errorf("cannot unmarshal object into %v", rv.Type())
+		if m.IsNil() {
+			m.Set(reflect.MakeMap(m.Type()))
+		}
+
+		value := reflect.New(m.Type().Elem()).Elem()
+		if err := d.unmarshalChild(keySegment(k), v, value); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(reflect.ValueOf(k).Convert(m.Type().Key()), value)
 	}
 
 	return nil
 }
 
 // unmarshalArray handles unmarshaling of JSON arrays into Go slices or arrays
-func unmarshalArray(arr *parser.Array, rv reflect.Value) error {
+func (d *decodeState) unmarshalArray(arr *parser.Array, rv reflect.Value) error {
 	switch rv.Kind() {
 	case reflect.Slice:
 		slice := reflect.MakeSlice(rv.Type(), len(arr.Elements), len(arr.Elements))
+		if d.options.MergeInto {
+			reflect.Copy(slice, rv)
+		}
+
 		for i, elem := range arr.Elements {
-			if err := unmarshalValue(elem, slice.Index(i)); err != nil {
-				return fmt.Errorf("index %d: %v", i, err)
+			if err := d.unmarshalChild(indexSegment(i), elem, slice.Index(i)); err != nil {
+				return err
 			}
 		}
 
@@ -452,18 +1340,18 @@ func unmarshalArray(arr *parser.Array, rv reflect.Value) error {
 
 	case reflect.Array:
 		if rv.Len() != len(arr.Elements) {
-			return fmt.Errorf("cannot unmarshal array of length %d into array of length %d",
-				len(arr.Elements), rv.Len())
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot unmarshal array of length %d into array of length %d",
+				len(arr.Elements), rv.Len()))
 		}
 
 		for i, elem := range arr.Elements {
-			if err := unmarshalValue(elem, rv.Index(i)); err != nil {
-				return fmt.Errorf("index %d: %v", i, err)
+			if err := d.unmarshalChild(indexSegment(i), elem, rv.Index(i)); err != nil {
+				return err
 			}
 		}
 
 	default:
-		return fmt.Errorf("cannot unmarshal array into %v", rv.Type())
+		return newTypeError("array", rv.Type())
 	}
 
 	return nil
@@ -472,7 +1360,7 @@ func unmarshalArray(arr *parser.Array, rv reflect.Value) error {
 // unmarshalString handles unmarshaling of JSON strings into Go strings
 func unmarshalString(str *parser.StringLiteral, rv reflect.Value) error {
 	if rv.Kind() != reflect.String {
-		return fmt.Errorf("cannot unmarshal string into %v", rv.Type())
+		return newTypeError("string", rv.Type())
 	}
 
 	rv.SetString(str.Value)
@@ -482,17 +1370,29 @@ func unmarshalString(str *parser.StringLiteral, rv reflect.Value) error {
 
 // unmarshalNumber handles unmarshaling of JSON numbers into Go numeric types
 func unmarshalNumber(num *parser.NumberLiteral, rv reflect.Value) error {
+	if num.IsBig && isNumberKind(rv.Kind()) {
+		return NewJSONError(ErrInvalidValue, fmt.Sprintf("number %s overflows %v", num.Value, rv.Type()))
+	}
+
 	switch rv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if !num.IsInt {
-			return fmt.Errorf("cannot unmarshal float into %v", rv.Type())
+			return newTypeError("float", rv.Type())
+		}
+
+		if rv.OverflowInt(num.Int) {
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("number %s overflows %v", num.Value, rv.Type()))
 		}
 
 		rv.SetInt(num.Int)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		if !num.IsInt || num.Int < 0 {
-			return fmt.Errorf("cannot unmarshal negative number into %v", rv.Type())
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot unmarshal negative number into %v", rv.Type()))
+		}
+
+		if rv.OverflowUint(uint64(num.Int)) {
+			return NewJSONError(ErrInvalidValue, fmt.Sprintf("number %s overflows %v", num.Value, rv.Type()))
 		}
 
 		rv.SetUint(uint64(num.Int))
@@ -501,7 +1401,7 @@ func unmarshalNumber(num *parser.NumberLiteral, rv reflect.Value) error {
 		rv.SetFloat(num.Float)
 
 	default:
-		return fmt.Errorf("cannot unmarshal number into %v", rv.Type())
+		return newTypeError("number", rv.Type())
 	}
 
 	return nil
@@ -510,7 +1410,7 @@ func unmarshalNumber(num *parser.NumberLiteral, rv reflect.Value) error {
 // unmarshalBool handles unmarshaling of JSON booleans into Go bools
 func unmarshalBool(b *parser.Boolean, rv reflect.Value) error {
 	if rv.Kind() != reflect.Bool {
-		return fmt.Errorf("cannot unmarshal boolean into %v", rv.Type())
+		return newTypeError("boolean", rv.Type())
 	}
 
 	rv.SetBool(b.Value)
@@ -525,29 +1425,51 @@ func unmarshalNull(rv reflect.Value) error {
 		rv.Set(reflect.Zero(rv.Type()))
 		return nil
 	default:
-		return fmt.Errorf("cannot unmarshal null into %v", rv.Type())
+		return newTypeError("null", rv.Type())
 	}
 }
 
-// writeValue writes a parser.Value to a strings.Builder
-func writeValue(b *strings.Builder, v parser.Value) error {
+// encodeState carries the configuration used while rendering parsed values as text.
+type encodeState struct {
+	options *Options
+}
+
+// newEncodeState creates an encodeState for the given options.
+func newEncodeState(options *Options) *encodeState {
+	return &encodeState{options: options}
+}
+
+// jsonWriter receives the output of an encodeState. It is implemented by
+// strings.Builder and by the writer MarshalWrite streams through.
+type jsonWriter interface {
+	io.Writer
+	io.StringWriter
+	io.ByteWriter
+	WriteRune(r rune) (int, error)
+}
+
+// writeValue writes a parser.Value to a jsonWriter using the default options
+func writeValue(b jsonWriter, v parser.Value) error {
+	return newEncodeState(defaultOptions()).writeValue(b, v)
+}
+
+// writeValue writes a parser.Value to a jsonWriter
+func (e *encodeState) writeValue(b jsonWriter, v parser.Value) error {
 	switch val := v.(type) {
 	case *parser.Object:
 		b.WriteString("{")
 
-		i := 0
-		for k, v := range val.Pairs {
+		for i, k := range e.objectKeys(val) {
 			if i > 0 {
 				b.WriteString(",")
 			}
 
-			fmt.Fprintf(b, "%q:", k)
+			e.writeString(b, k)
+			b.WriteString(":")
 
-			if err := writeValue(b, v); err != nil {
+			if err := e.writeValue(b, val.Pairs[k]); err != nil {
 				return err
 			}
-
-			i++
 		}
 
 		b.WriteString("}")
@@ -560,18 +1482,150 @@ func writeValue(b *strings.Builder, v parser.Value) error {
 				b.WriteString(",")
 			}
 
-			if err := writeValue(b, v); err != nil {
+			if err := e.writeValue(b, v); err != nil {
 				return err
 			}
 		}
 
 		b.WriteString("]")
 
+	default:
+		return e.writeScalar(b, v)
+	}
+
+	return nil
+}
+
+// writeIndentedRoot writes a top-level parser.Value like writeIndentedValue, with its
+// leading and trailing comments on lines of their own before and after it.
+func (e *encodeState) writeIndentedRoot(b jsonWriter, v parser.Value, prefix, indent string) error {
+	comments := parser.NodeComments(v)
+
+	if comments != nil {
+		for _, c := range comments.Leading {
+			b.WriteString(c + "\n")
+		}
+	}
+
+	if err := e.writeIndentedValue(b, v, prefix, indent, 0); err != nil {
+		return err
+	}
+
+	// The trailing comments may span several lines after the end of the document
+	if comments != nil {
+		writeCommentLines(b, comments.Trailing, "")
+	}
+
+	return nil
+}
+
+// writeIndentedValue writes a parser.Value to a jsonWriter with one element per line.
+// Comments attached by parser.WithPreserveComments are written around the members and
+// elements.
+func (e *encodeState) writeIndentedValue(b jsonWriter, v parser.Value, prefix, indent string, level int) error {
+	currentIndent := strings.Repeat(indent, level)
+
+	var footer []string
+	if comments := parser.NodeComments(v); comments != nil {
+		footer = comments.Footer
+	}
+
+	switch val := v.(type) {
+	case *parser.Object:
+		keys := e.objectKeys(val)
+
+		b.WriteString("{")
+
+		for i, k := range keys {
+			member := val.Pairs[k]
+			comments := parser.NodeComments(member)
+
+			if comments != nil {
+				writeCommentLines(b, comments.Leading, currentIndent+indent)
+			}
+
+			b.WriteString("\n" + currentIndent + indent)
+			e.writeString(b, k)
+			b.WriteString(": ")
+
+			if err := e.writeIndentedValue(b, member, prefix, indent, level+1); err != nil {
+				return err
+			}
+
+			if i < len(keys)-1 {
+				b.WriteString(",")
+			}
+
+			if comments != nil {
+				writeTrailingComments(b, comments.Trailing)
+			}
+		}
+
+		writeCommentLines(b, footer, currentIndent+indent)
+		b.WriteString("\n" + currentIndent + "}")
+
+	case *parser.Array:
+		b.WriteString("[")
+
+		for i, v := range val.Elements {
+			comments := parser.NodeComments(v)
+
+			if comments != nil {
+				writeCommentLines(b, comments.Leading, currentIndent+indent)
+			}
+
+			b.WriteString("\n" + currentIndent + indent)
+
+			if err := e.writeIndentedValue(b, v, prefix, indent, level+1); err != nil {
+				return err
+			}
+
+			if i < len(val.Elements)-1 {
+				b.WriteString(",")
+			}
+
+			if comments != nil {
+				writeTrailingComments(b, comments.Trailing)
+			}
+		}
+
+		writeCommentLines(b, footer, currentIndent+indent)
+		b.WriteString("\n" + currentIndent + "]")
+
+	default:
+		return e.writeScalar(b, v)
+	}
+
+	return nil
+}
+
+// writeCommentLines writes each comment on a new line with the given indentation.
+func writeCommentLines(b jsonWriter, comments []string, indent string) {
+	for _, c := range comments {
+		b.WriteString("\n" + indent + c)
+	}
+}
+
+// writeTrailingComments writes comments after the value on the current line. A line
+// comment ends the line, so it is always the last one in the input.
+func writeTrailingComments(b jsonWriter, comments []string) {
+	for _, c := range comments {
+		b.WriteString(" " + c)
+	}
+}
+
+// writeScalar writes a string, number, boolean or null value.
+func (e *encodeState) writeScalar(b jsonWriter, v parser.Value) error {
+	switch val := v.(type) {
 	case *parser.StringLiteral:
-		fmt.Fprintf(b, "%q", val.Value)
+		e.writeString(b, val.Value)
 
 	case *parser.NumberLiteral:
-		b.WriteString(val.String())
+		if e.options.CanonicalNumbers {
+			b.WriteString(canonicalNumber(val))
+		} else {
+			b.WriteString(val.String())
+		}
 
 	case *parser.Boolean:
 		if val.Value {
@@ -584,8 +1638,98 @@ func writeValue(b *strings.Builder, v parser.Value) error {
 		b.WriteString("null")
 
 	default:
-		return fmt.Errorf("unknown value type: %T", v)
+		return NewJSONError(ErrUnexpectedType, fmt.Sprintf("unknown value type: %T", v))
 	}
 
 	return nil
 }
+
+// objectKeys returns the keys of obj in output order.
+func (e *encodeState) objectKeys(obj *parser.Object) []string {
+	keys := obj.OrderedKeys()
+
+	if e.options.SortKeys {
+		keys = append([]string(nil), keys...)
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
+// writeString writes s as a quoted JSON string. Quotes, backslashes and control characters
+// are escaped, every other character is written verbatim unless WithASCIIOnly or
+// WithScriptSafe ask for more escaping, and invalid UTF-8 is replaced with U+FFFD.
+func (e *encodeState) writeString(b jsonWriter, s string) {
+	const hex = "0123456789abcdef"
+
+	asciiOnly := e.options.ASCIIOnly && !e.options.FrozenOutput
+	scriptSafe := e.options.ScriptSafe && !e.options.FrozenOutput
+
+	b.WriteByte('"')
+
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			b.WriteString(`\u00`)
+			b.WriteByte(hex[r>>4])
+			b.WriteByte(hex[r&0xF])
+		case scriptSafe && r == '/' && i > 0 && s[i-1] == '<':
+			// "</" would close a <script> element, whatever tag name follows
+			b.WriteString(`\/`)
+		case scriptSafe && r == '<' && strings.HasPrefix(s[i:], "<!--"),
+			scriptSafe && (r == '\u2028' || r == '\u2029'),
+			asciiOnly && r >= utf8.RuneSelf:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				b.WriteString(`\u`)
+				b.WriteByte(hex[unit>>12])
+				b.WriteByte(hex[unit>>8&0xF])
+				b.WriteByte(hex[unit>>4&0xF])
+				b.WriteByte(hex[unit&0xF])
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteByte('"')
+}
+
+// canonicalNumber formats a number the way ECMAScript's Number.prototype.toString does,
+// which is also the number format of the JSON Canonicalization Scheme (RFC 8785).
+func canonicalNumber(n *parser.NumberLiteral) string {
+	// A number out of the range of float64 has no ECMAScript form; its digits are kept
+	if n.IsBig {
+		return n.Value
+	}
+
+	if n.IsInt {
+		return strconv.FormatInt(n.Int, 10)
+	}
+
+	if n.Float == 0 {
+		return "0"
+	}
+
+	if abs := math.Abs(n.Float); abs < 1e21 && abs >= 1e-6 {
+		return strconv.FormatFloat(n.Float, 'f', -1, 64)
+	}
+
+	// ECMAScript drops leading zeros from the exponent: 1e-07 becomes 1e-7
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(n.Float, 'e', -1, 64), "e")
+
+	return mantissa + "e" + exp[:1] + strings.TrimLeft(exp[1:], "0")
+}
@@ -1,12 +1,16 @@
 package encoding_test
 
 import (
+	"errors"
+	"io"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
 
 	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
 )
 
 func TestUnmarshalWithByteInput(t *testing.T) {
@@ -453,6 +457,75 @@ func TestMarshalIndent(t *testing.T) {
 	}
 }
 
+func TestUnmarshalJSONC(t *testing.T) {
+	input := []byte(`{
+		// compiler settings
+		"compilerOptions": {"strict": true, "paths": ["src/*",],},
+	}`)
+
+	var config struct {
+		CompilerOptions struct {
+			Strict bool     `json:"strict"`
+			Paths  []string `json:"paths"`
+		} `json:"compilerOptions"`
+	}
+
+	if err := encoding.Unmarshal(input, &config); err == nil {
+		t.Error("Expected strict parsing to reject JSONC")
+	}
+
+	err := encoding.Unmarshal(input, &config, encoding.WithAllowComments(), encoding.WithAllowTrailingCommas())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !config.CompilerOptions.Strict || len(config.CompilerOptions.Paths) != 1 {
+		t.Errorf("Unexpected result: %+v", config)
+	}
+}
+
+func TestPreserveComments(t *testing.T) {
+	input := `// service config
+{
+  // listen address
+  "host": "localhost",
+  "port": 80, // default port
+  "tags": [
+    "a" /* first */
+  ],
+  "extra": {
+    // reserved
+  }
+}`
+
+	v, err := encoding.Parse([]byte(input), encoding.WithPreserveComments())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	v.(*parser.Object).Set("port", parser.NewNumber(8080))
+
+	data, err := encoding.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.Replace(input, "80,", "8080,", 1)
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	// The output parses back with the same comments
+	again, err := encoding.Parse(data, encoding.WithPreserveComments())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if out, _ := encoding.MarshalIndent(again, "", "  "); string(out) != string(data) {
+		t.Errorf("Expected a stable round trip, got:\n%s", out)
+	}
+}
+
 func checkJSONError(t *testing.T, err error, expectedCode encoding.ErrorCode, expectedMsg string) {
 	t.Helper()
 
@@ -468,3 +541,960 @@ func checkJSONError(t *testing.T, err error, expectedCode encoding.ErrorCode, ex
 		t.Errorf("expected JSONError, got %T: %v", err, err)
 	}
 }
+
+func TestMarshalFrozenOutput(t *testing.T) {
+	input := map[string]interface{}{
+		"zeta":  []interface{}{1.5, 100.0, 1e21, 1e-7},
+		"alpha": "tab\there \"quoted\" \x01",
+		"mid":   map[string]interface{}{"b": true, "a": nil},
+	}
+
+	expected := `{"alpha":"tab\there \"quoted\" \u0001","mid":{"a":null,"b":true},"zeta":[1.5,100,1e+21,1e-7]}`
+
+	for i := 0; i < 10; i++ {
+		data, err := encoding.MarshalIndent(input, "", "  ", encoding.WithFrozenOutput())
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+
+		if string(data) != expected {
+			t.Fatalf("Run %d: expected %s, got %s", i, expected, data)
+		}
+	}
+}
+
+func TestStringEscapeRoundTrip(t *testing.T) {
+	input := []byte(`{"s": "line1\nline2 \"q\" \\ \/ é 🚀"}`)
+
+	var result map[string]string
+	if err := encoding.Unmarshal(input, &result); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if result["s"] != "line1\nline2 \"q\" \\ / é 🚀" {
+		t.Fatalf("Unexpected decoded string: %q", result["s"])
+	}
+
+	data, err := encoding.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if string(data) != `{"s":"line1\nline2 \"q\" \\ / é 🚀"}` {
+		t.Fatalf("Unexpected encoded string: %s", data)
+	}
+}
+
+func TestMarshalEscaping(t *testing.T) {
+	input := map[string]string{"é": "</script><!-- a/b \u2028 🚀 \xff"}
+
+	tests := []struct {
+		name     string
+		opts     []encoding.Option
+		expected string
+	}{
+		{
+			name:     "Default",
+			expected: "{\"é\":\"</script><!-- a/b \u2028 🚀 \ufffd\"}",
+		},
+		{
+			name:     "ASCII only",
+			opts:     []encoding.Option{encoding.WithASCIIOnly()},
+			expected: `{"\u00e9":"</script><!-- a/b \u2028 \ud83d\ude80 \ufffd"}`,
+		},
+		{
+			name:     "Script safe",
+			opts:     []encoding.Option{encoding.WithScriptSafe()},
+			expected: `{"é":"<\/script>\u003c!-- a/b \u2028 🚀 ` + "\ufffd" + `"}`,
+		},
+		{
+			name:     "Frozen output ignores escaping modes",
+			opts:     []encoding.Option{encoding.WithASCIIOnly(), encoding.WithFrozenOutput()},
+			expected: "{\"é\":\"</script><!-- a/b \u2028 🚀 \ufffd\"}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+
+			var decoded map[string]string
+			if err := encoding.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+
+			if decoded["é"] != strings.ToValidUTF8(input["é"], "\ufffd") {
+				t.Errorf("Round trip changed the string: %q", decoded["é"])
+			}
+		})
+	}
+}
+
+func TestMarshalParserValue(t *testing.T) {
+	v, err := parser.NewParser(parser.NewLexerBytes([]byte(`{"z": 1, "a": [true, null]}`))).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := v.(*parser.Object)
+	obj.Set("m", parser.NewString("new"))
+
+	tags, err := parser.FromInterface([]string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj.Set("tags", tags)
+
+	data, err := encoding.Marshal(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{"z":1,"a":[true,null],"m":"new","tags":["x","y"]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	data, err = encoding.Marshal(struct {
+		B int `json:"b"`
+		A int `json:"a"`
+	}{1, 2})
+	if err != nil || string(data) != `{"b":1,"a":2}` {
+		t.Errorf("Expected struct fields in declaration order, got %s (%v)", data, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	v, err := encoding.Parse([]byte(`{"a": [1, 2], // comment
+	}`), encoding.WithAllowComments(), encoding.WithAllowTrailingCommas())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if obj, ok := v.(*parser.Object); !ok || len(obj.Pairs) != 1 {
+		t.Errorf("Unexpected value: %v", v)
+	}
+
+	_, err = encoding.Parse([]byte(`{} []`))
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "unexpected data after top-level value")
+
+	_, err = encoding.Parse([]byte(`{"a": "`+strings.Repeat("x", 2048)+`"}`), encoding.WithMaxSize(1024))
+	checkJSONError(t, err, encoding.ErrSizeExceeded, "")
+}
+
+func TestSyntaxErrorOffset(t *testing.T) {
+	var v map[string]interface{}
+
+	err := encoding.Unmarshal([]byte(`{"a": 1, "b": }`), &v)
+	if jsonErr, ok := err.(*encoding.JSONError); !ok || jsonErr.Offset != 14 {
+		t.Errorf("Expected a JSONError at offset 14, got %#v", err)
+	}
+
+	dec, err := encoding.NewDecoder(strings.NewReader(`{"a": 1} {"b" 2}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = dec.Decode(&v)
+	if jsonErr, ok := err.(*encoding.JSONError); !ok || jsonErr.Offset != 14 {
+		t.Errorf("Expected a JSONError at stream offset 14, got %#v", err)
+	}
+}
+
+func TestUnmarshalPartialDecode(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var v struct {
+		Name  string `json:"name"`
+		Age   int    `json:"age"`
+		Items []item `json:"items"`
+		Meta  struct {
+			Active bool `json:"active"`
+		} `json:"a/b"`
+	}
+
+	input := `{
+		"name": "x",
+		"age": "ten",
+		"items": [{"id": 1, "name": "a"}, {"id": 2.5, "name": "b"}, {"id": 3, "name": 4}],
+		"a/b": {"active": true}
+	}`
+
+	err := encoding.Unmarshal([]byte(input), &v, encoding.WithPartialDecode())
+	checkJSONError(t, err, encoding.ErrUnmarshalFailure, "3 values could not be decoded")
+
+	var fieldErrs encoding.FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("Expected FieldErrors, got %T: %v", err, err)
+	}
+
+	var paths []string
+	for _, fe := range fieldErrs {
+		paths = append(paths, fe.Path)
+	}
+
+	if want := []string{"/age", "/items/1/id", "/items/2/name"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected failed paths %v, got %v", want, paths)
+	}
+
+	if v.Name != "x" || v.Age != 0 || !v.Meta.Active {
+		t.Errorf("Unexpected result: %+v", v)
+	}
+
+	if want := []item{{1, "a"}, {0, "b"}, {3, ""}}; !reflect.DeepEqual(v.Items, want) {
+		t.Errorf("Expected items %+v, got %+v", want, v.Items)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"a/b": {"active": 1}}`), &v, encoding.WithPartialDecode())
+	if !errors.As(err, &fieldErrs) || fieldErrs[0].Path != "/a~1b/active" {
+		t.Errorf("Expected failure at /a~1b/active, got %v", err)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"age": "ten"}`), &v)
+	if errors.As(err, &fieldErrs) {
+		t.Errorf("Expected the first failure to abort without WithPartialDecode, got %v", err)
+	}
+}
+
+func TestErrorPath(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	type list struct {
+		Items []item `json:"items"`
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		target interface{}
+		path   string
+	}{
+		{
+			name:   "Struct field in slice",
+			input:  `{"items": [{"name": "a"}, {"name": "b"}, {"name": 3}]}`,
+			target: &list{},
+			path:   "/items/2/name",
+		},
+		{
+			name:   "Escaped map key",
+			input:  `{"a/b": {"c~d": true}}`,
+			target: &map[string]map[string]int{},
+			path:   "/a~1b/c~0d",
+		},
+		{
+			name:   "Top-level value",
+			input:  `[1, 2]`,
+			target: &map[string]int{},
+			path:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.Unmarshal([]byte(tt.input), tt.target)
+			checkJSONError(t, err, encoding.ErrUnexpectedType, "")
+
+			if jsonErr, ok := err.(*encoding.JSONError); ok && jsonErr.Path != tt.path {
+				t.Errorf("Expected path %q, got %q", tt.path, jsonErr.Path)
+			}
+		})
+	}
+
+	_, err := encoding.Marshal(map[string]interface{}{
+		"items": []interface{}{1, map[string]interface{}{"ch": make(chan int)}},
+	})
+	checkJSONError(t, err, encoding.ErrUnsupportedType, "(at /items/1/ch)")
+}
+
+func TestErrorCodes(t *testing.T) {
+	type target struct {
+		N     int8              `json:"n"`
+		Extra map[int]int       `json:"-,extra"`
+		List  [2]int            `json:"list"`
+		Tags  map[string]string `json:"tags"`
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []encoding.Option
+		code  encoding.ErrorCode
+	}{
+		{name: "String into int", input: `{"n": "1"}`, code: encoding.ErrUnexpectedType},
+		{name: "Object into string", input: `{"tags": {"a": {}}}`, code: encoding.ErrUnexpectedType},
+		{name: "Overflow", input: `{"n": 300}`, code: encoding.ErrInvalidValue},
+		{name: "Array length", input: `{"list": [1, 2, 3]}`, code: encoding.ErrInvalidValue},
+		{name: "Bad catch-all field", input: `{"x": 1}`, code: encoding.ErrInvalidTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.Unmarshal([]byte(tt.input), &target{}, tt.opts...)
+			checkJSONError(t, err, tt.code, "")
+		})
+	}
+
+	type strict struct {
+		A int `json:"a"`
+	}
+
+	for _, opt := range []encoding.Option{encoding.WithDisallowUnknownFields(), encoding.WithStrictMode()} {
+		err := encoding.Unmarshal([]byte(`{"a": 1, "b": 2}`), &strict{}, opt)
+		checkJSONError(t, err, encoding.ErrInvalidValue, "unknown field")
+
+		dec, err := encoding.NewDecoder(strings.NewReader(`{"a": 1, "b": 2}`), opt)
+		if err != nil {
+			t.Fatalf("NewDecoder failed: %v", err)
+		}
+
+		checkJSONError(t, dec.Decode(&strict{}), encoding.ErrInvalidValue, "unknown field")
+	}
+
+	if err := encoding.Unmarshal([]byte(`{"a": 1, "b": 2}`), &strict{}); err != nil {
+		t.Errorf("Expected unknown fields to be ignored by default, got %v", err)
+	}
+
+	_, err := encoding.Marshal(map[float64]string{1: "a"})
+	checkJSONError(t, err, encoding.ErrUnsupportedType, "")
+}
+
+func TestErrorsIs(t *testing.T) {
+	var v interface{}
+
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+	}{
+		{name: "Syntax", err: encoding.Unmarshal([]byte(`{"a": }`), &v), sentinel: encoding.ErrSyntax},
+		{name: "Size limit", err: encoding.Unmarshal([]byte("["+strings.Repeat("1,", 1024)+"1]"), &v, encoding.WithMaxSize(1024)), sentinel: encoding.ErrSizeLimit},
+		{name: "Max depth", err: encoding.Unmarshal([]byte(`[[[1]]]`), &v, encoding.WithMaxDepth(2)), sentinel: encoding.ErrMaxDepth},
+		{name: "Depth is a limit", err: encoding.Unmarshal([]byte(`[[[1]]]`), &v, encoding.WithMaxDepth(2)), sentinel: encoding.ErrLimitExceeded},
+		{name: "Unsupported type", err: encoding.MarshalWrite(io.Discard, make(chan int)), sentinel: encoding.ErrUnsupportedType},
+		{name: "Nested code", err: encoding.Unmarshal([]byte(`{"a": "x"}`), &map[string]int{}), sentinel: encoding.ErrUnexpectedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Errorf("Expected %v to match %v", tt.err, tt.sentinel)
+			}
+		})
+	}
+
+	err := encoding.Unmarshal([]byte(`["abcdef"]`), &v, encoding.WithMaxStringLength(2))
+	if !errors.Is(err, encoding.ErrLimitExceeded) || errors.Is(err, encoding.ErrMaxDepth) {
+		t.Errorf("Expected a string limit error only, got %v", err)
+	}
+
+	if errors.Is(encoding.Unmarshal([]byte(`{"a": }`), &v), encoding.ErrSizeLimit) {
+		t.Error("Expected a syntax error not to match ErrSizeLimit")
+	}
+}
+
+func TestUnexportedFields(t *testing.T) {
+	type inner struct {
+		A    int    `json:"a"`
+		Name string `json:"inner_name"`
+		b    int
+	}
+
+	type plain struct {
+		Name    string `json:"name"`
+		secret  string
+		ignored int `json:"-"`
+		inner
+	}
+
+	data, err := encoding.Marshal(plain{Name: "n", secret: "s", ignored: 1, inner: inner{A: 2, Name: "i", b: 3}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// The exported fields of the embedded unexported struct are promoted, except Name,
+	// which the outer field hides
+	if string(data) != `{"name":"n","a":2}` {
+		t.Errorf("Expected unexported fields to be skipped, got %s", data)
+	}
+
+	var p plain
+	if err := encoding.Unmarshal([]byte(`{"name": "n", "secret": "s", "a": 1, "inner_name": "i", "inner": {"a": 2}}`), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if p.Name != "n" || p.secret != "" || p.A != 1 || p.inner.Name != "" {
+		t.Errorf("Unexpected result %+v", p)
+	}
+
+	type outer struct {
+		inner
+		Y int
+	}
+
+	data, err = encoding.Marshal(outer{inner{A: 1}, 2})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(data) != `{"a":1,"inner_name":"","Y":2}` {
+		t.Errorf("Expected the promoted fields, got %s", data)
+	}
+
+	var o outer
+	if err := encoding.Unmarshal(data, &o, encoding.WithDisallowUnknownFields()); err != nil || o.A != 1 || o.Y != 2 {
+		t.Errorf("Expected the promoted fields to round-trip, got %+v, %v", o, err)
+	}
+
+	// A json tag on an unexported field is reported as ErrUnsupportedType, but go vet
+	// rejects such a struct, so it cannot be declared here
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type Base struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	type meta struct {
+		Tag string `json:"tag"`
+	}
+
+	type Other struct {
+		Kind string `json:"kind"`
+	}
+
+	type tagged struct {
+		Base `json:"base"`
+	}
+
+	type record struct {
+		Base
+		*meta
+		*Other
+		Name string `json:"title"`
+	}
+
+	// An exported embedded struct is promoted like an unexported one, tagged embedding
+	// keeps it nested, and Name of the outer struct hides the promoted one
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "Promoted", value: record{Base: Base{ID: 1, Name: "hidden"}, Name: "r"}, want: `{"id":1,"title":"r"}`},
+		{name: "Embedded pointers", value: record{meta: &meta{Tag: "t"}, Other: &Other{Kind: "o"}}, want: `{"id":0,"tag":"t","kind":"o","title":""}`},
+		{name: "Tagged", value: tagged{Base{ID: 1}}, want: `{"base":{"id":1,"name":""}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(tt.value)
+			if err != nil || string(data) != tt.want {
+				t.Errorf("Expected %s, got %s (%v)", tt.want, data, err)
+			}
+		})
+	}
+
+	// The exported embedded pointer is allocated, the unexported one must be set already
+	var r record
+	err := encoding.Unmarshal([]byte(`{"id": 1, "title": "r", "kind": "o"}`), &r, encoding.WithDisallowUnknownFields())
+	if err != nil || r.ID != 1 || r.Name != "r" || r.Base.Name != "" || r.Other == nil || r.Other.Kind != "o" || r.meta != nil {
+		t.Errorf("Unexpected result %+v (%v)", r, err)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"tag": "t"}`), &r)
+	checkJSONError(t, err, encoding.ErrInvalidTarget, "cannot set embedded pointer to unexported struct")
+
+	r = record{meta: &meta{}}
+	if err := encoding.Unmarshal([]byte(`{"tag": "t"}`), &r); err != nil || r.meta.Tag != "t" {
+		t.Errorf("Expected the set embedded pointer to be decoded, got %+v (%v)", r, err)
+	}
+
+	// Fields of the same name at the same depth hide each other
+	type left struct {
+		Value int
+	}
+
+	type right struct {
+		Value int
+	}
+
+	type ambiguous struct {
+		left
+		right
+		Other int
+	}
+
+	data, err := encoding.Marshal(ambiguous{left{1}, right{2}, 3})
+	if err != nil || string(data) != `{"Other":3}` {
+		t.Errorf("Expected the conflicting fields to be dropped, got %s (%v)", data, err)
+	}
+}
+
+func TestMarshalUnsupportedKinds(t *testing.T) {
+	type handler struct {
+		Name     string        `json:"name"`
+		Done     chan struct{} `json:"done"`
+		Callback func()        `json:"callback"`
+		Phase    complex128    `json:"phase"`
+		Meta     interface{}   `json:"meta"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		path  string
+	}{
+		{name: "Channel", value: handler{Done: make(chan struct{})}, path: "/done"},
+		{name: "Function", value: map[string]interface{}{"f": func() {}}, path: "/f"},
+		{name: "Complex", value: []interface{}{1, complex64(1i)}, path: "/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encoding.Marshal(tt.value)
+			checkJSONError(t, err, encoding.ErrUnsupportedType, "has no JSON representation")
+
+			if jsonErr, ok := err.(*encoding.JSONError); ok && jsonErr.Path != tt.path {
+				t.Errorf("Expected path %q, got %q", tt.path, jsonErr.Path)
+			}
+		})
+	}
+
+	h := handler{Name: "h", Callback: func() {}, Meta: map[string]interface{}{"f": func() {}, "a": 1}}
+
+	data, err := encoding.Marshal(h, encoding.WithSkipUnsupported())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if expected := `{"name":"h","meta":{"a":1}}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	_, err = encoding.Marshal([]interface{}{func() {}}, encoding.WithSkipUnsupported())
+	checkJSONError(t, err, encoding.ErrUnsupportedType, "")
+}
+
+func TestUnmarshalInvalidUTF8(t *testing.T) {
+	input := []byte("{\"a\": \"x\xffy\"}")
+
+	var v map[string]string
+	if err := encoding.Unmarshal(input, &v); err != nil || v["a"] != "x�y" {
+		t.Errorf("Expected replacement by default, got %q, %v", v["a"], err)
+	}
+
+	err := encoding.Unmarshal(input, &v, encoding.WithInvalidUTF8Policy(parser.InvalidUTF8Pass))
+	if err != nil || v["a"] != "x\xffy" {
+		t.Errorf("Expected bytes to pass through, got %q, %v", v["a"], err)
+	}
+
+	err = encoding.Unmarshal(input, &v, encoding.WithInvalidUTF8Policy(parser.InvalidUTF8Error))
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+
+	err = encoding.Unmarshal(input, &v, encoding.WithInvalidUTF8Policy(parser.InvalidUTF8Policy(9)))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "unknown invalid UTF-8 policy InvalidUTF8Policy(9)")
+}
+
+func TestUnmarshalTranscoding(t *testing.T) {
+	utf16be := "\xFE\xFF\x00{\x00\"\x00a\x00\"\x00:\x00\"\x00\xe9\x00\"\x00}"
+
+	var v map[string]string
+	if err := encoding.Unmarshal([]byte("\xEF\xBB\xBF{\"a\": \"x\"}"), &v); err != nil || v["a"] != "x" {
+		t.Errorf("Expected the UTF-8 BOM to be skipped, got %v, %v", v, err)
+	}
+
+	err := encoding.Unmarshal([]byte(utf16be), &v)
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "UTF-16BE input must be transcoded to UTF-8")
+
+	if err := encoding.Unmarshal([]byte(utf16be), &v, encoding.WithTranscoding()); err != nil || v["a"] != "é" {
+		t.Errorf("Expected UTF-16BE input to be transcoded, got %v, %v", v, err)
+	}
+
+	v = nil
+	if err := encoding.UnmarshalReader(strings.NewReader(utf16be), &v, encoding.WithTranscoding()); err != nil || v["a"] != "é" {
+		t.Errorf("Expected UTF-16BE stream to be transcoded, got %v, %v", v, err)
+	}
+}
+
+func TestProfileLabels(t *testing.T) {
+	var v map[string]interface{}
+	if err := encoding.Unmarshal([]byte(`{"a": [1, "x"]}`), &v, encoding.WithProfileLabels()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := encoding.Marshal(v, encoding.WithProfileLabels())
+	if err != nil || string(data) != `{"a":[1,"x"]}` {
+		t.Errorf("Unexpected result %s, %v", data, err)
+	}
+}
+
+func TestUnmarshalLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opt    encoding.Option
+		limit  string
+		offset int64
+	}{
+		{"Depth", `{"a": [[1]]}`, encoding.WithMaxDepth(2), "MaxDepth", 7},
+		{"String", `{"a": "abcdef"}`, encoding.WithMaxStringLength(5), "MaxStringLength", 6},
+		{"Key", `{"abcdef": 1}`, encoding.WithMaxStringLength(5), "MaxStringLength", 1},
+		{"Array", `[1, 2, 3, 4]`, encoding.WithMaxArrayElements(3), "MaxArrayElements", 10},
+		{"Object", `{"a": 1, "b": 2}`, encoding.WithMaxObjectKeys(1), "MaxObjectKeys", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+
+			err := encoding.Unmarshal([]byte(tt.input), &v, tt.opt)
+			checkJSONError(t, err, encoding.ErrLimitExceeded, tt.limit+" limit")
+
+			if jsonErr := err.(*encoding.JSONError); jsonErr.Offset != tt.offset {
+				t.Errorf("Expected offset %d, got %d", tt.offset, jsonErr.Offset)
+			}
+
+			var limitErr *parser.LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.limit {
+				t.Errorf("Expected a %s *parser.LimitError, got %v", tt.limit, err)
+			}
+
+			dec, err := encoding.NewDecoder(strings.NewReader(tt.input), tt.opt)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			checkJSONError(t, dec.Decode(&v), encoding.ErrLimitExceeded, tt.limit+" limit")
+			checkJSONError(t, encoding.ValidateStream(strings.NewReader(tt.input), tt.opt), encoding.ErrLimitExceeded, tt.limit+" limit")
+		})
+	}
+
+	if _, err := encoding.NewDecoder(strings.NewReader(`{}`), encoding.WithMaxDepth(0)); err == nil {
+		t.Error("Expected an error for a non-positive limit")
+	}
+}
+
+type extensible struct {
+	Kind  string                 `json:"kind"`
+	Extra map[string]interface{} `json:",inline"`
+}
+
+type extensibleTyped struct {
+	Name  string         `json:"name"`
+	Extra map[string]int `json:"-,extra"`
+}
+
+func TestCatchAllField(t *testing.T) {
+	var v extensible
+	if err := encoding.Unmarshal([]byte(`{"kind": "event", "x-trace": "abc", "x-retry": {"max": 3}}`), &v, encoding.WithDisallowUnknownFields()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{"x-trace": "abc", "x-retry": map[string]interface{}{"max": int64(3)}}
+	if v.Kind != "event" || !reflect.DeepEqual(v.Extra, expected) {
+		t.Errorf("Expected kind event and extra %v, got %+v", expected, v)
+	}
+
+	data, err := encoding.Marshal(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := `{"kind":"event","x-retry":{"max":3},"x-trace":"abc"}`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	// Struct fields win over catch-all entries with the same key
+	data, err = encoding.Marshal(extensible{Kind: "a", Extra: map[string]interface{}{"kind": "b"}})
+	if err != nil || string(data) != `{"kind":"a"}` {
+		t.Errorf("Expected the struct field to win, got %s, %v", data, err)
+	}
+
+	var typed extensibleTyped
+	if err := encoding.Unmarshal([]byte(`{"name": "n", "a": 1, "b": 2}`), &typed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if typed.Name != "n" || !reflect.DeepEqual(typed.Extra, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("Unexpected result %+v", typed)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"name": "n", "a": "x"}`), &typed)
+	checkJSONError(t, err, encoding.ErrUnexpectedType, "")
+}
+
+type credentials struct {
+	User     string            `json:"user"`
+	Password string            `json:"password"`
+	Timeout  int               `json:"timeout_ms"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func TestEncodeHook(t *testing.T) {
+	redact := encoding.WithEncodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		if strings.HasSuffix(path, "password") {
+			return "***", true
+		}
+
+		return nil, false
+	})
+
+	seconds := encoding.WithEncodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		if path == "[0].timeout_ms" {
+			return v.Int() / 1000, true
+		}
+
+		return nil, false
+	})
+
+	value := []credentials{{User: "a", Password: "secret", Timeout: 2000, Labels: map[string]string{"password": "x"}}}
+
+	data, err := encoding.Marshal(value, redact, seconds)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `[{"user":"a","password":"***","timeout_ms":2,"labels":{"password":"***"}}]`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	var buf strings.Builder
+	if err := encoding.MarshalWrite(&buf, value[0], redact); err != nil || !strings.Contains(buf.String(), `"password":"***"`) {
+		t.Errorf("Expected MarshalWrite to apply the hook, got %s, %v", buf.String(), err)
+	}
+
+	if _, err := encoding.Marshal(value, encoding.WithEncodeHook(nil)); err == nil {
+		t.Error("Expected an error for a nil hook")
+	}
+}
+
+func TestDecodeHook(t *testing.T) {
+	var paths []string
+
+	hook := encoding.WithDecodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		paths = append(paths, path)
+
+		switch path {
+		case "user":
+			return strings.ToLower(v.String()), true
+		case "timeout_ms":
+			return v.Int() * 1000, true
+		}
+
+		return nil, false
+	})
+
+	var c credentials
+	if err := encoding.Unmarshal([]byte(`{"user": "ANA", "timeout_ms": 2}`), &c, hook); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if c.User != "ana" || c.Timeout != 2000 {
+		t.Errorf("Expected the hooks to transform the values, got %+v", c)
+	}
+
+	sort.Strings(paths)
+
+	if expected := []string{"", "timeout_ms", "user"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected hooks at %v, got %v", expected, paths)
+	}
+
+	bad := encoding.WithDecodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		return 5, path == "user"
+	})
+
+	err := encoding.Unmarshal([]byte(`{"user": "ana"}`), &c, bad)
+	checkJSONError(t, err, encoding.ErrUnexpectedType, "")
+
+	if jsonErr := err.(*encoding.JSONError); jsonErr.Path != "/user" {
+		t.Errorf("Expected the failure at /user, got %q", jsonErr.Path)
+	}
+}
+
+type loginRequest struct {
+	User    string            `json:"user"`
+	Secret  string            `json:"secret,redact"`
+	Headers map[string]string `json:"headers"`
+}
+
+func TestRedactFields(t *testing.T) {
+	value := []loginRequest{{
+		User:    "ana",
+		Secret:  "s3cr3t",
+		Headers: map[string]string{"Authorization": "Bearer x", "Accept": "*/*"},
+	}}
+
+	data, err := encoding.Marshal(value, encoding.WithRedactFields("authorization", "user"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `[{"user":"***","secret":"***","headers":{"Accept":"*/*","Authorization":"***"}}]`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	// The redact tag applies without the option
+	data, err = encoding.Marshal(value[0])
+	if err != nil || !strings.Contains(string(data), `"secret":"***"`) || !strings.Contains(string(data), `"user":"ana"`) {
+		t.Errorf("Expected only the tagged field to be redacted, got %s, %v", data, err)
+	}
+
+	if _, err := encoding.Marshal(value, encoding.WithRedactFields()); err == nil {
+		t.Error("Expected an error without field names")
+	}
+}
+
+func TestUnmarshalMergeInto(t *testing.T) {
+	type server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+
+	type config struct {
+		Servers map[string]server      `json:"servers"`
+		Hosts   []server               `json:"hosts"`
+		Extra   map[string]interface{} `json:"extra"`
+	}
+
+	layers := []string{
+		`{"servers": {"a": {"host": "a.local", "port": 80}}, "hosts": [{"host": "x", "port": 1}, {"host": "y"}], "extra": {"log": {"level": "info", "json": true}}}`,
+		`{"servers": {"a": {"port": 8080}, "b": {"host": "b.local"}}, "hosts": [{"port": 2}], "extra": {"log": {"level": "debug"}}}`,
+	}
+
+	var merged, replaced config
+
+	for _, layer := range layers {
+		if err := encoding.Unmarshal([]byte(layer), &merged, encoding.WithMergeInto()); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if err := encoding.Unmarshal([]byte(layer), &replaced); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+	}
+
+	expected := config{
+		Servers: map[string]server{"a": {Host: "a.local", Port: 8080}, "b": {Host: "b.local"}},
+		Hosts:   []server{{Host: "x", Port: 2}},
+		Extra:   map[string]interface{}{"log": map[string]interface{}{"level": "debug", "json": true}},
+	}
+
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, merged)
+	}
+
+	if replaced.Servers["a"].Host != "" || replaced.Hosts[0].Host != "" {
+		t.Errorf("Expected values to be replaced without WithMergeInto, got %+v", replaced)
+	}
+}
+
+func TestUnmarshalZeroMissingFields(t *testing.T) {
+	type user struct {
+		Name   string            `json:"name"`
+		Email  string            `json:"email"`
+		Role   string            `json:"role" default:"member"`
+		Labels map[string]string `json:"labels"`
+		Cache  string            `json:"-"`
+	}
+
+	u := user{Name: "ana", Email: "ana@example.com", Role: "admin", Labels: map[string]string{"a": "1"}, Cache: "kept"}
+
+	input := `{"name": "Ana", "labels": {"b": "2"}}`
+	if err := encoding.Unmarshal([]byte(input), &u, encoding.WithZeroMissingFields(), encoding.WithDefaults()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	expected := user{Name: "Ana", Role: "member", Labels: map[string]string{"b": "2"}, Cache: "kept"}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, u)
+	}
+
+	err := encoding.Unmarshal([]byte(input), &u, encoding.WithZeroMissingFields(), encoding.WithMergeInto())
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "")
+}
+
+func TestUnmarshalAll(t *testing.T) {
+	values, err := encoding.UnmarshalAll([]byte("{\"a\": 1}\n[true, null]\n{}\n"))
+	if err != nil {
+		t.Fatalf("UnmarshalAll failed: %v", err)
+	}
+
+	want := []interface{}{map[string]interface{}{"a": int64(1)}, []interface{}{true, nil}, map[string]interface{}{}}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %v, got %v", want, values)
+	}
+
+	if values, err := encoding.UnmarshalAll([]byte(" \n")); err != nil || len(values) != 0 {
+		t.Errorf("Expected no values, got %v (%v)", values, err)
+	}
+
+	_, err = encoding.UnmarshalAll([]byte(`{"a": 1} {"b": }`))
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "failed to parse JSON")
+
+	_, err = encoding.UnmarshalAll([]byte(`{} 1`))
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "expected { or [")
+}
+
+func TestUnmarshalErrorPosition(t *testing.T) {
+	var v struct {
+		Items []struct {
+			Count int `json:"count"`
+		} `json:"items"`
+	}
+
+	input := "{\n  \"items\": [\n    {\"count\": 1},\n    {\"count\": \"two\"}\n  ]\n}"
+
+	err := encoding.Unmarshal([]byte(input), &v)
+
+	var jsonErr *encoding.JSONError
+	if !errors.As(err, &jsonErr) {
+		t.Fatalf("Expected a JSONError, got %v", err)
+	}
+
+	if jsonErr.Path != "/items/1/count" || jsonErr.Line != 4 || input[jsonErr.Offset:jsonErr.Offset+5] != `"two"` {
+		t.Errorf("Expected the position of \"two\", got %s at line %d, offset %d", jsonErr.Path, jsonErr.Line, jsonErr.Offset)
+	}
+
+	if want := "(at /items/1/count, line 4, column 15)"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected the message to contain %q, got %q", want, err.Error())
+	}
+
+	// The root value and syntax errors are located too
+	var n int
+
+	err = encoding.Unmarshal([]byte("\n\n[1]"), &n)
+	if !errors.As(err, &jsonErr) || jsonErr.Line != 3 || jsonErr.Offset != 2 {
+		t.Errorf("Expected the root to be located at line 3, got %+v", err)
+	}
+
+	err = encoding.Unmarshal([]byte("{\"a\": 1,\n\"b\" 2}"), &v)
+	if !errors.As(err, &jsonErr) || jsonErr.Code != encoding.ErrInvalidJSON || jsonErr.Line != 2 {
+		t.Errorf("Expected a syntax error on line 2, got %+v", err)
+	}
+
+	// Values that were not parsed have no position
+	err = encoding.UnmarshalValue(parser.NewString("x"), &n)
+	if !errors.As(err, &jsonErr) || jsonErr.Line != 0 {
+		t.Errorf("Expected no position, got %+v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package encoding
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Leaf is a value found by DecodeDepthFirst together with the path that leads to it.
+type Leaf struct {
+	// Path is the dotted path of the value, e.g. "items[2].name"; the root has path ""
+	Path string
+
+	// Value is a string, int64, float64, bool or nil, or an empty map[string]interface{}
+	// or []interface{} for an empty container
+	Value interface{}
+}
+
+// DecodeDepthFirst walks the JSON document read from r and calls fn with the path and
+// value of every leaf in document order, without building a tree or requiring recursion
+// from the caller: a document is flattened into pairs such as ("items[0].id", 1). Empty
+// objects and arrays are reported as leaves too, so that no part of the document is
+// lost. Numbers are decoded like Unmarshal into an interface{}.
+//
+// MaxSize is enforced like in UnmarshalReader, and the syntax options and limits apply.
+// The first syntax error, or the first error returned by fn, stops the walk and is
+// returned; errors from fn are returned as is.
+func DecodeDepthFirst(r io.Reader, fn func(path string, value interface{}) error, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	return decodeLeaves(r, options, func(path []parser.PathElement, value interface{}) error {
+		return fn(leafPath(path), value)
+	})
+}
+
+// decodeLeaves implements DecodeDepthFirst, passing the path of each leaf as elements.
+// The path is reused between calls.
+func decodeLeaves(r io.Reader, options *Options, fn func(path []parser.PathElement, value interface{}) error) error {
+	lr := &sizeLimitReader{r: r}
+	if !options.DisableSizeLimit {
+		lr.limit = int64(options.MaxSize)
+	}
+
+	var input io.Reader = lr
+	if options.Transcode {
+		input = parser.NewTranscodingReader(lr)
+	}
+
+	p := parser.NewParser(parser.NewLexerSize(input, options.BufferSize), options.parserOptions()...)
+
+	var (
+		fnErr error
+		// open is set between the start of a container and its first child
+		open bool
+	)
+
+	err := p.Walk(func(e parser.Event) error {
+		var value interface{}
+
+		switch e.Kind {
+		case parser.EventObjectStart, parser.EventArrayStart:
+			open = true
+			return nil
+		case parser.EventObjectEnd, parser.EventArrayEnd:
+			if !open {
+				return nil
+			}
+
+			value = []interface{}{}
+			if e.Kind == parser.EventObjectEnd {
+				value = map[string]interface{}{}
+			}
+		default:
+			value = e.Value().ToInterface()
+		}
+
+		open = false
+		fnErr = fn(e.Path, value)
+
+		return fnErr
+	})
+
+	if lr.exceeded {
+		return NewJSONError(ErrSizeExceeded, fmt.Sprintf("input exceeds limit %d", options.MaxSize))
+	}
+
+	if fnErr != nil {
+		return fnErr
+	}
+
+	if err == nil && !options.AllowTrailingData && p.More() {
+		err = errTrailingData
+	}
+
+	if err != nil {
+		return newParseError(p, "failed to parse JSON", err)
+	}
+
+	return nil
+}
+
+// DecodeDepthFirstChan runs DecodeDepthFirst in a new goroutine and sends the leaves on
+// the returned channel, which is closed when the walk ends. The error channel then
+// receives the outcome, nil on success, and is closed too. Canceling ctx stops the walk
+// with an ErrCanceled error; the leaves channel must be drained until it is closed or
+// ctx canceled.
+func DecodeDepthFirstChan(ctx context.Context, r io.Reader, opts ...Option) (<-chan Leaf, <-chan error) {
+	leaves := make(chan Leaf)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(leaves)
+
+		errc <- DecodeDepthFirst(r, func(path string, value interface{}) error {
+			select {
+			case leaves <- Leaf{Path: path, Value: value}:
+				return nil
+			case <-ctx.Done():
+				return NewCanceledError(ctx.Err())
+			}
+		}, opts...)
+	}()
+
+	return leaves, errc
+}
+
+// leafPath renders a walk path in the dotted form used by GetField.
+func leafPath(path []parser.PathElement) string {
+	segments := make([]pathSegment, len(path))
+
+	for i, el := range path {
+		if el.IsIndex {
+			segments[i] = indexSegment(el.Index)
+		} else {
+			segments[i] = keySegment(el.Key)
+		}
+	}
+
+	return formatPath(segments)
+}
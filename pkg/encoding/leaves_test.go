@@ -0,0 +1,86 @@
+package encoding_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestDecodeDepthFirst(t *testing.T) {
+	input := `{"host": "a", "cpu": [0.5, 2], "disk": {"free": true, "tags": {}, "mounts": []}, "x": null}`
+
+	expected := []encoding.Leaf{
+		{Path: "host", Value: "a"},
+		{Path: "cpu[0]", Value: 0.5},
+		{Path: "cpu[1]", Value: int64(2)},
+		{Path: "disk.free", Value: true},
+		{Path: "disk.tags", Value: map[string]interface{}{}},
+		{Path: "disk.mounts", Value: []interface{}{}},
+		{Path: "x", Value: nil},
+	}
+
+	var leaves []encoding.Leaf
+
+	err := encoding.DecodeDepthFirst(strings.NewReader(input), func(path string, value interface{}) error {
+		leaves = append(leaves, encoding.Leaf{Path: path, Value: value})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Object members are walked in input order
+	if !reflect.DeepEqual(leaves, expected) {
+		t.Errorf("Expected %v, got %v", expected, leaves)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+
+	err = encoding.DecodeDepthFirst(strings.NewReader(input), func(string, interface{}) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected the handler error after one call, got %v after %d", err, calls)
+	}
+
+	err = encoding.DecodeDepthFirst(strings.NewReader(`{"a": [1, }`), func(string, interface{}) error { return nil })
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+
+	err = encoding.DecodeDepthFirst(strings.NewReader(`[[1]]`), func(string, interface{}) error { return nil }, encoding.WithMaxDepth(1))
+	checkJSONError(t, err, encoding.ErrLimitExceeded, "")
+}
+
+func TestDecodeDepthFirstChan(t *testing.T) {
+	leaves, errc := encoding.DecodeDepthFirstChan(context.Background(), strings.NewReader(`[{"a": 1}, {"a": 2}]`))
+
+	var paths []string
+	for leaf := range leaves {
+		paths = append(paths, leaf.Path)
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if expected := []string{"[0].a", "[1].a"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	leaves, errc = encoding.DecodeDepthFirstChan(ctx, strings.NewReader(`[1, 2, 3]`))
+	<-leaves
+	cancel()
+
+	// Nothing receives the remaining leaves, so the walk can only stop on ctx
+	err := <-errc
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to wrap context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// KeyEncoder returns the object key written for key, a map key of the type it is
+// registered for.
+type KeyEncoder func(key interface{}) (string, error)
+
+// KeyDecoder returns the map key, of the type it is registered for, read from an object key.
+type KeyDecoder func(key string) (interface{}, error)
+
+var (
+	keyCodecsMu sync.RWMutex
+	keyEncoders = map[reflect.Type]KeyEncoder{}
+	keyDecoders = map[reflect.Type]KeyDecoder{}
+)
+
+// RegisterKeyEncoder makes Marshal and the encoders write the maps whose keys have type t,
+// such as a UUID or a struct of several fields, with the object keys returned by enc. It
+// replaces any encoder registered for t, and a nil enc removes it.
+//
+//	encoding.RegisterKeyEncoder(reflect.TypeOf(Point{}), func(key interface{}) (string, error) {
+//		p := key.(Point)
+//		return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+//	})
+func RegisterKeyEncoder(t reflect.Type, enc KeyEncoder) {
+	keyCodecsMu.Lock()
+	defer keyCodecsMu.Unlock()
+
+	if enc == nil {
+		delete(keyEncoders, t)
+		return
+	}
+
+	keyEncoders[t] = enc
+}
+
+// RegisterKeyDecoder makes Unmarshal and the decoders read the keys of maps whose keys have
+// type t with dec, which must return a value of type t. It takes precedence over the
+// UnmarshalText method of t, replaces any decoder registered for t, and a nil dec
+// removes it.
+func RegisterKeyDecoder(t reflect.Type, dec KeyDecoder) {
+	keyCodecsMu.Lock()
+	defer keyCodecsMu.Unlock()
+
+	if dec == nil {
+		delete(keyDecoders, t)
+		return
+	}
+
+	keyDecoders[t] = dec
+}
+
+// mapEntry is a key of a map being marshaled together with its object key.
+type mapEntry struct {
+	name string
+	key  reflect.Value
+}
+
+// textMarshaler is implemented by map key types that write themselves as an object key,
+// like encoding.TextMarshaler of the standard library.
+type textMarshaler interface {
+	MarshalText() ([]byte, error)
+}
+
+var textMarshalerType = reflect.TypeOf((*textMarshaler)(nil)).Elem()
+
+// mapEntries returns the keys of the map v with their object keys, sorted by object key.
+// Keys are written by the KeyEncoder registered for their type, as strings, through their
+// MarshalText method, or as base 10 integers, in that order like encoding/json.
+func mapEntries(v reflect.Value) ([]mapEntry, error) {
+	t := v.Type().Key()
+
+	keyCodecsMu.RLock()
+	enc := keyEncoders[t]
+	keyCodecsMu.RUnlock()
+
+	switch {
+	case enc != nil:
+	case t.Kind() == reflect.String:
+		enc = func(key interface{}) (string, error) { return reflect.ValueOf(key).String(), nil }
+	case t.Implements(textMarshalerType):
+		enc = marshalTextKey
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		enc = func(key interface{}) (string, error) { return strconv.FormatInt(reflect.ValueOf(key).Int(), 10), nil }
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+		enc = func(key interface{}) (string, error) { return strconv.FormatUint(reflect.ValueOf(key).Uint(), 10), nil }
+	default:
+		return nil, NewUnsupportedTypeError(fmt.Sprintf(
+			"%v: map key must be a string, an integer, implement MarshalText or have a KeyEncoder registered", v.Type()))
+	}
+
+	entries := make([]mapEntry, 0, v.Len())
+
+	for _, k := range v.MapKeys() {
+		name, err := enc(k.Interface())
+		if err != nil {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot encode map key %v of %v", k, v.Type())).
+				WithCause(err)
+		}
+
+		entries = append(entries, mapEntry{name: name, key: k})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	// Distinct keys must not be written as the same object key
+	for i := 1; i < len(entries); i++ {
+		if entries[i].name == entries[i-1].name {
+			return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("map keys %v and %v of %v are both encoded as %q",
+				entries[i-1].key, entries[i].key, v.Type(), entries[i].name))
+		}
+	}
+
+	return entries, nil
+}
+
+// marshalTextKey writes a map key through its MarshalText method. A nil pointer key is
+// written as the empty string.
+func marshalTextKey(key interface{}) (string, error) {
+	if rv := reflect.ValueOf(key); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "", nil
+	}
+
+	text, err := key.(textMarshaler).MarshalText()
+
+	return string(text), err
+}
+
+// textUnmarshaler is implemented by map key types that parse themselves from an object
+// key, like encoding.TextUnmarshaler of the standard library.
+type textUnmarshaler interface {
+	UnmarshalText(text []byte) error
+}
+
+var textUnmarshalerType = reflect.TypeOf((*textUnmarshaler)(nil)).Elem()
+
+// mapKey converts the object key k into a key of type t: through the KeyDecoder
+// registered for t or its UnmarshalText method, as a string, or as a base 10 integer.
+func mapKey(k string, t reflect.Type) (reflect.Value, error) {
+	keyCodecsMu.RLock()
+	dec := keyDecoders[t]
+	keyCodecsMu.RUnlock()
+
+	switch {
+	case dec != nil:
+		key, err := dec(k)
+		if err != nil {
+			return reflect.Value{}, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid map key %q for %v", k, t)).
+				WithCause(err)
+		}
+
+		if key == nil || reflect.TypeOf(key) != t {
+			return reflect.Value{}, NewInvalidTargetError(
+				fmt.Sprintf("key decoder for %v returned %T for map key %q", t, key, k))
+		}
+
+		return reflect.ValueOf(key), nil
+
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		key := reflect.New(t)
+		if err := key.Interface().(textUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+			return reflect.Value{}, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid map key %q for %v", k, t)).
+				WithCause(err)
+		}
+
+		return key.Elem(), nil
+
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(k).Convert(t), nil
+
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowInt(n) {
+			return reflect.Value{}, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid map key %q for %v", k, t))
+		}
+
+		return reflect.ValueOf(n).Convert(t), nil
+
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+		n, err := strconv.ParseUint(k, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowUint(n) {
+			return reflect.Value{}, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid map key %q for %v", k, t))
+		}
+
+		return reflect.ValueOf(n).Convert(t), nil
+
+	default:
+		return reflect.Value{}, NewInvalidTargetError(
+			fmt.Sprintf("map key type %v must be a string, an integer, implement UnmarshalText or have a KeyDecoder registered", t))
+	}
+}
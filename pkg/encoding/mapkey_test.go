@@ -0,0 +1,233 @@
+package encoding_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// point is a map key parsed from "x,y".
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	if _, err := fmt.Sscanf(string(text), "%d,%d", &p.X, &p.Y); err != nil {
+		return fmt.Errorf("invalid point %q", text)
+	}
+
+	return nil
+}
+
+func (p point) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func TestMapKeysRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{name: "Int", in: map[int]string{1: "a", -2: "b", 10: "c"}, want: `{"-2":"b","1":"a","10":"c"}`},
+		{name: "Uint8", in: map[uint8]bool{255: true}, want: `{"255":true}`},
+		{name: "TextMarshaler", in: map[point]int{{1, 2}: 3}, want: `{"1,2":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+
+			out := reflect.New(reflect.TypeOf(tt.in))
+			if err := encoding.Unmarshal(data, out.Interface()); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(out.Elem().Interface(), tt.in) {
+				t.Errorf("Expected %v after the round trip, got %v", tt.in, out.Elem().Interface())
+			}
+		})
+	}
+
+	_, err := encoding.Marshal(map[float64]int{1.5: 1})
+	checkJSONError(t, err, encoding.ErrUnsupportedType, "map key must be a string, an integer")
+}
+
+func TestUnmarshalMapKeys(t *testing.T) {
+	t.Run("Int", func(t *testing.T) {
+		var m map[int]string
+		if err := encoding.Unmarshal([]byte(`{"1": "a", "-2": "b"}`), &m); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if want := map[int]string{1: "a", -2: "b"}; !reflect.DeepEqual(m, want) {
+			t.Errorf("Expected %v, got %v", want, m)
+		}
+	})
+
+	t.Run("Int64 and uint8", func(t *testing.T) {
+		var m map[int64]map[uint8]bool
+		if err := encoding.Unmarshal([]byte(`{"9007199254740993": {"255": true}}`), &m); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if !m[9007199254740993][255] {
+			t.Errorf("Unexpected result %v", m)
+		}
+	})
+
+	t.Run("TextUnmarshaler", func(t *testing.T) {
+		var m map[point]int
+		if err := encoding.Unmarshal([]byte(`{"1,2": 3}`), &m); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if m[point{1, 2}] != 3 {
+			t.Errorf("Unexpected result %v", m)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		input  string
+		target interface{}
+		code   encoding.ErrorCode
+		msg    string
+	}{
+		{name: "Not an integer", input: `{"a": 1}`, target: &map[int]int{}, code: encoding.ErrInvalidValue, msg: `invalid map key "a" for int`},
+		{name: "Overflow", input: `{"256": 1}`, target: &map[uint8]int{}, code: encoding.ErrInvalidValue, msg: `invalid map key "256"`},
+		{name: "Negative unsigned", input: `{"-1": 1}`, target: &map[uint]int{}, code: encoding.ErrInvalidValue, msg: `invalid map key "-1"`},
+		{name: "Bad text", input: `{"1;2": 1}`, target: &map[point]int{}, code: encoding.ErrInvalidValue, msg: "invalid point"},
+		{name: "Unsupported key", input: `{"1.5": 1}`, target: &map[float64]int{}, code: encoding.ErrInvalidTarget, msg: "map key type float64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.Unmarshal([]byte(tt.input), tt.target)
+			checkJSONError(t, err, tt.code, "")
+
+			if err != nil && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected the error to mention %q, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+// cell is a composite map key written as "row:col" by a registered key codec.
+type cell struct {
+	Row, Col int
+}
+
+// encodeCell writes a cell as "row:col".
+func encodeCell(key interface{}) (string, error) {
+	c := key.(cell)
+	if c.Row < 0 {
+		return "", fmt.Errorf("negative row %d", c.Row)
+	}
+
+	return fmt.Sprintf("%d:%d", c.Row, c.Col), nil
+}
+
+// decodeCell reads a cell written by encodeCell.
+func decodeCell(key string) (interface{}, error) {
+	var c cell
+	if _, err := fmt.Sscanf(key, "%d:%d", &c.Row, &c.Col); err != nil {
+		return nil, fmt.Errorf("invalid cell %q", key)
+	}
+
+	return c, nil
+}
+
+// registerCellKeys registers enc and dec for cell keys until the end of the test.
+func registerCellKeys(t *testing.T, enc encoding.KeyEncoder, dec encoding.KeyDecoder) {
+	t.Helper()
+
+	typ := reflect.TypeOf(cell{})
+	encoding.RegisterKeyEncoder(typ, enc)
+	encoding.RegisterKeyDecoder(typ, dec)
+	t.Cleanup(func() {
+		encoding.RegisterKeyEncoder(typ, nil)
+		encoding.RegisterKeyDecoder(typ, nil)
+	})
+}
+
+func TestRegisterKeyCodecs(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		registerCellKeys(t, encodeCell, decodeCell)
+
+		in := map[cell]string{{1, 2}: "b", {0, 5}: "a"}
+
+		data, err := encoding.Marshal(in)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+
+		if want := `{"0:5":"a","1:2":"b"}`; string(data) != want {
+			t.Errorf("Expected %s, got %s", want, data)
+		}
+
+		var out map[cell]string
+		if err := encoding.Unmarshal(data, &out); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("Expected %v, got %v", in, out)
+		}
+	})
+
+	collide := func(key interface{}) (string, error) {
+		return fmt.Sprint(key.(cell).Row), nil
+	}
+	wrongType := func(key string) (interface{}, error) {
+		return key, nil
+	}
+
+	tests := []struct {
+		name string
+		enc  encoding.KeyEncoder
+		dec  encoding.KeyDecoder
+		// marshal is the value marshaled, or nil to unmarshal input into a map[cell]int
+		marshal interface{}
+		input   string
+		code    encoding.ErrorCode
+		msg     string
+	}{
+		{name: "No encoder", marshal: map[cell]int{{1, 1}: 1}, code: encoding.ErrUnsupportedType, msg: "KeyEncoder"},
+		{name: "Encoder error", enc: encodeCell, marshal: map[cell]int{{-1, 0}: 1}, code: encoding.ErrInvalidValue, msg: "negative row -1"},
+		{name: "Collision", enc: collide, marshal: map[cell]int{{1, 2}: 1, {1, 3}: 2}, code: encoding.ErrInvalidValue, msg: `are both encoded as "1"`},
+		{name: "No decoder", input: `{"1:1": 1}`, code: encoding.ErrInvalidTarget, msg: "KeyDecoder"},
+		{name: "Decoder error", dec: decodeCell, input: `{"x": 1}`, code: encoding.ErrInvalidValue, msg: `invalid cell "x"`},
+		{name: "Decoder returns wrong type", dec: wrongType, input: `{"1:1": 1}`, code: encoding.ErrInvalidTarget, msg: "returned string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registerCellKeys(t, tt.enc, tt.dec)
+
+			var err error
+			if tt.marshal != nil {
+				_, err = encoding.Marshal(tt.marshal)
+			} else {
+				var m map[cell]int
+				err = encoding.Unmarshal([]byte(tt.input), &m)
+			}
+
+			checkJSONError(t, err, tt.code, "")
+
+			if err != nil && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expected the error to mention %q, got %v", tt.msg, err)
+			}
+		})
+	}
+}
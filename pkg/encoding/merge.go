@@ -0,0 +1,160 @@
+package encoding
+
+import (
+	"fmt"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// arrayMerge identifies how Merge combines two arrays.
+type arrayMerge int
+
+const (
+	arrayMergeIndex arrayMerge = iota
+	arrayMergeAppend
+	arrayMergeReplace
+	arrayMergeKey
+)
+
+// MergeStrategy tells Merge how to combine arrays; objects are always merged member by
+// member.
+type MergeStrategy struct {
+	arrays arrayMerge
+	key    string
+}
+
+var (
+	// MergeDeep merges arrays element by element, keeping the extra elements of the
+	// longer one
+	MergeDeep = MergeStrategy{arrays: arrayMergeIndex}
+	// MergeAppend appends the elements of the source array to the destination array
+	MergeAppend = MergeStrategy{arrays: arrayMergeAppend}
+	// MergeReplace replaces the destination array with the source array
+	MergeReplace = MergeStrategy{arrays: arrayMergeReplace}
+)
+
+// MergeByKey merges the objects of two arrays that have the same value under key, e.g.
+// "id", and appends the source elements that match no destination element.
+func MergeByKey(key string) MergeStrategy {
+	return MergeStrategy{arrays: arrayMergeKey, key: key}
+}
+
+// String returns the name of the strategy
+func (s MergeStrategy) String() string {
+	switch s.arrays {
+	case arrayMergeIndex:
+		return "deep"
+	case arrayMergeAppend:
+		return "append"
+	case arrayMergeReplace:
+		return "replace"
+	default:
+		return fmt.Sprintf("by key %q", s.key)
+	}
+}
+
+// Merge layers the JSON document in src over the one in dst, e.g. overrides over
+// defaults, and returns the result as compact JSON. Objects are merged recursively, with
+// the members of src winning, and arrays are combined according to strategy. Any other
+// value of src, null included, replaces the value of dst.
+func Merge(dst, src []byte, strategy MergeStrategy) ([]byte, error) {
+	if strategy.arrays == arrayMergeKey && strategy.key == "" {
+		return nil, NewJSONError(ErrInvalidOptions, "merge key must not be empty")
+	}
+
+	left, err := parseBytes(dst, defaultOptions())
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, "failed to parse destination document").WithCause(err)
+	}
+
+	right, err := parseBytes(src, defaultOptions())
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, "failed to parse source document").WithCause(err)
+	}
+
+	return Marshal(MergeValues(left, right, strategy), WithDisableSizeLimit())
+}
+
+// MergeValues merges two parsed documents like Merge. dst is modified in place and the
+// result may share values with src.
+func MergeValues(dst, src parser.Value, strategy MergeStrategy) parser.Value {
+	switch s := src.(type) {
+	case *parser.Object:
+		d, ok := dst.(*parser.Object)
+		if !ok {
+			return src
+		}
+
+		for _, key := range s.OrderedKeys() {
+			value := s.Pairs[key]
+			if existing, ok := d.Pairs[key]; ok {
+				value = MergeValues(existing, value, strategy)
+			}
+
+			d.Set(key, value)
+		}
+
+		return d
+	case *parser.Array:
+		d, ok := dst.(*parser.Array)
+		if !ok {
+			return src
+		}
+
+		return mergeArrays(d, s, strategy)
+	default:
+		return src
+	}
+}
+
+// mergeArrays combines two arrays according to strategy.
+func mergeArrays(dst, src *parser.Array, strategy MergeStrategy) *parser.Array {
+	switch strategy.arrays {
+	case arrayMergeAppend:
+		dst.Elements = append(dst.Elements, src.Elements...)
+	case arrayMergeReplace:
+		return src
+	case arrayMergeKey:
+		for _, elem := range src.Elements {
+			if i := findByKey(dst.Elements, elem, strategy.key); i >= 0 {
+				dst.Elements[i] = MergeValues(dst.Elements[i], elem, strategy)
+			} else {
+				dst.Elements = append(dst.Elements, elem)
+			}
+		}
+	default:
+		for i, elem := range src.Elements {
+			if i < len(dst.Elements) {
+				dst.Elements[i] = MergeValues(dst.Elements[i], elem, strategy)
+			} else {
+				dst.Elements = append(dst.Elements, elem)
+			}
+		}
+	}
+
+	return dst
+}
+
+// findByKey returns the index of the object in elems that has the same value under key
+// as elem, or -1 if elem is not an object with that key or none matches.
+func findByKey(elems []parser.Value, elem parser.Value, key string) int {
+	obj, ok := elem.(*parser.Object)
+	if !ok {
+		return -1
+	}
+
+	want, ok := obj.Pairs[key]
+	if !ok {
+		return -1
+	}
+
+	for i, candidate := range elems {
+		if c, ok := candidate.(*parser.Object); ok {
+			if got, ok := c.Pairs[key]; ok && parser.Equal(got, want) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
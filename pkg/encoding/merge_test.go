@@ -0,0 +1,72 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      string
+		src      string
+		strategy encoding.MergeStrategy
+		expected string
+	}{
+		{
+			name:     "Deep",
+			dst:      `{"db": {"host": "localhost", "port": 5432}, "tags": [{"a": 1}, 2], "debug": true}`,
+			src:      `{"db": {"port": 6543, "user": "app"}, "tags": [{"b": 2}], "debug": null}`,
+			strategy: encoding.MergeDeep,
+			expected: `{"db":{"host":"localhost","port":6543,"user":"app"},"tags":[{"a":1,"b":2},2],"debug":null}`,
+		},
+		{
+			name:     "Append",
+			dst:      `{"tags": ["a", "b"]}`,
+			src:      `{"tags": ["c"], "level": 2}`,
+			strategy: encoding.MergeAppend,
+			expected: `{"tags":["a","b","c"],"level":2}`,
+		},
+		{
+			name:     "Replace",
+			dst:      `{"tags": ["a", "b"], "db": {"host": "x"}}`,
+			src:      `{"tags": ["c"], "db": {"port": 1}}`,
+			strategy: encoding.MergeReplace,
+			expected: `{"tags":["c"],"db":{"host":"x","port":1}}`,
+		},
+		{
+			name:     "By key",
+			dst:      `{"users": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]}`,
+			src:      `{"users": [{"id": 2, "role": "admin"}, {"id": 3}, "x"]}`,
+			strategy: encoding.MergeByKey("id"),
+			expected: `{"users":[{"id":1,"name":"a"},{"id":2,"name":"b","role":"admin"},{"id":3},"x"]}`,
+		},
+		{
+			name:     "Type mismatch",
+			dst:      `{"a": {"b": 1}}`,
+			src:      `{"a": [1]}`,
+			strategy: encoding.MergeDeep,
+			expected: `{"a":[1]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := encoding.Merge([]byte(tt.dst), []byte(tt.src), tt.strategy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(merged) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, merged)
+			}
+		})
+	}
+
+	_, err := encoding.Merge([]byte(`{}`), []byte(`{"a": `), encoding.MergeDeep)
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "source document")
+
+	_, err = encoding.Merge([]byte(`[]`), []byte(`[]`), encoding.MergeByKey(""))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "")
+}
@@ -0,0 +1,476 @@
+// Package msgpack encodes values as MessagePack and decodes them back, using the
+// parser.Value syntax tree as its data model. Go values are converted by the encoding
+// package, so struct tags, hooks and options work as they do for JSON, and a document
+// can move between JSON and MessagePack without losing anything.
+//
+//	data, err := msgpack.Marshal(user)
+//	...
+//	err = msgpack.Unmarshal(data, &user)
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// maxDepth bounds the nesting of decoded arrays and maps.
+const maxDepth = 10000
+
+// timestampType is the extension type of MessagePack timestamps.
+const timestampType = -1
+
+// Marshal returns the MessagePack encoding of v, converted as encoding.Marshal converts
+// it to JSON. Numbers are written as integers when they are integral JSON literals, and
+// as 64-bit floats otherwise.
+func Marshal(v interface{}, opts ...encoding.Option) ([]byte, error) {
+	value, err := encoding.MarshalValue(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return AppendValue(nil, value)
+}
+
+// Unmarshal decodes the MessagePack value in data and stores it in the value pointed to
+// by v, as encoding.Unmarshal does for JSON.
+func Unmarshal(data []byte, v interface{}, opts ...encoding.Option) error {
+	value, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	return encoding.UnmarshalValue(value, v, opts...)
+}
+
+// AppendValue appends the MessagePack encoding of v to dst and returns the extended
+// buffer.
+func AppendValue(dst []byte, v parser.Value) ([]byte, error) {
+	switch val := v.(type) {
+	case nil, *parser.Null:
+		return append(dst, 0xc0), nil
+	case *parser.Boolean:
+		if val.Value {
+			return append(dst, 0xc3), nil
+		}
+
+		return append(dst, 0xc2), nil
+	case *parser.StringLiteral:
+		return appendString(dst, val.Value), nil
+	case *parser.NumberLiteral:
+		n := val
+		if !n.IsValid {
+			n = parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: val.String()})
+			if !n.IsValid {
+				return nil, encoding.NewJSONError(encoding.ErrInvalidValue, "invalid number").
+					WithValue(val.String())
+			}
+		}
+
+		if n.IsInt {
+			return appendInt(dst, n.Int), nil
+		}
+
+		dst = append(dst, 0xcb)
+
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(n.Float)), nil
+	case *parser.Array:
+		dst = appendHeader(dst, len(val.Elements), 0x90, 0xdc)
+
+		var err error
+
+		for _, elem := range val.Elements {
+			if dst, err = AppendValue(dst, elem); err != nil {
+				return nil, err
+			}
+		}
+
+		return dst, nil
+	case *parser.Object:
+		keys := val.OrderedKeys()
+		dst = appendHeader(dst, len(keys), 0x80, 0xde)
+
+		var err error
+
+		for _, k := range keys {
+			dst = appendString(dst, k)
+			if dst, err = AppendValue(dst, val.Pairs[k]); err != nil {
+				return nil, err
+			}
+		}
+
+		return dst, nil
+	default:
+		return nil, encoding.NewUnsupportedTypeError(v.TokenLiteral())
+	}
+}
+
+// appendInt appends n in its shortest integer format.
+func appendInt(dst []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128, n < 0 && n >= -32:
+		return append(dst, byte(n))
+	case n >= 0 && n <= math.MaxUint8:
+		return append(dst, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xce), uint32(n))
+	case n >= 0:
+		return binary.BigEndian.AppendUint64(append(dst, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		return append(dst, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(n))
+	}
+}
+
+// appendString appends s as a MessagePack str.
+func appendString(dst []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xda), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xdb), uint32(n))
+	}
+
+	return append(dst, s...)
+}
+
+// appendHeader appends the header of an array or map of n entries, given the first byte
+// of its fix format and of its 16-bit format.
+func appendHeader(dst []byte, n int, fix, code byte) []byte {
+	switch {
+	case n < 16:
+		return append(dst, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, code), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, code+1), uint32(n))
+	}
+}
+
+// Parse decodes the single MessagePack value that makes up data into a syntax tree.
+//
+// Binary data is decoded as an array of bytes, as encoding.Marshal writes a []byte, and
+// timestamps as RFC 3339 strings. Map keys must be strings, other extension types are
+// not supported, and so are unsigned integers above math.MaxInt64, which JSON numbers in
+// the syntax tree cannot hold. Errors are *encoding.JSONError values carrying the offset.
+func Parse(data []byte) (parser.Value, error) {
+	d := &decoder{data: data}
+
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos < len(d.data) {
+		return nil, d.errorf("unexpected data after the value")
+	}
+
+	return v, nil
+}
+
+// decoder reads MessagePack values from a buffer.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// errorf returns an error located at the current offset.
+func (d *decoder) errorf(msg string) *encoding.JSONError {
+	return encoding.NewJSONError(encoding.ErrInvalidValue, "msgpack: "+msg).WithOffset(int64(d.pos))
+}
+
+// next returns the next n bytes.
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, d.errorf("unexpected end of data")
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+
+	return n, nil
+}
+
+// length reads a length of size bytes, checking that each of the entries it counts,
+// which take at least minSize bytes, fits in the rest of the data.
+func (d *decoder) length(size, minSize int) (int, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > uint64(len(d.data)-d.pos)/uint64(minSize) {
+		return 0, d.errorf("length exceeds the data")
+	}
+
+	return int(n), nil
+}
+
+// value reads a value nested depth levels deep.
+func (d *decoder) value(depth int) (parser.Value, error) {
+	if depth > maxDepth {
+		return nil, encoding.NewJSONError(encoding.ErrLimitExceeded, "msgpack: nesting too deep").
+			WithOffset(int64(d.pos))
+	}
+
+	start := d.pos
+
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return parser.NewNumber(int64(c)), nil
+	case c >= 0xe0:
+		return parser.NewNumber(int64(int8(c))), nil
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(int(c & 0x1f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.array(int(c&0x0f), depth)
+	case c >= 0x80 && c <= 0x8f:
+		return d.object(int(c&0x0f), depth)
+	}
+
+	switch c := b[0]; c {
+	case 0xc0:
+		return parser.NewNull(), nil
+	case 0xc2, 0xc3:
+		return parser.NewBoolean(c == 0xc3), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+
+		if n > math.MaxInt64 {
+			d.pos = start
+			return nil, d.errorf("integer " + strconv.FormatUint(n, 10) + " is out of range")
+		}
+
+		return parser.NewNumber(int64(n)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+
+		// Sign-extend from the size of the integer
+		shift := 64 - 8*size
+
+		return parser.NewNumber(int64(n<<shift) >> shift), nil
+	case 0xca, 0xcb:
+		return d.float(c == 0xca)
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1<<(c-0xd9), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.str(n)
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1<<(c-0xc4), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.bin(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2<<(c-0xdc), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2<<(c-0xde), 2)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.object(n, depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1<<(c-0xc7), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ext(n)
+	default:
+		d.pos = start
+		return nil, d.errorf("invalid type byte 0x" + strconv.FormatUint(uint64(c), 16))
+	}
+}
+
+// float reads a 32- or 64-bit float.
+func (d *decoder) float(single bool) (parser.Value, error) {
+	size, bitSize := 8, 64
+	if single {
+		size, bitSize = 4, 32
+	}
+
+	bits, err := d.uint(size)
+	if err != nil {
+		return nil, err
+	}
+
+	f := math.Float64frombits(bits)
+	if single {
+		f = float64(math.Float32frombits(uint32(bits)))
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		d.pos -= size + 1
+		return nil, d.errorf("NaN and infinite floats cannot be represented in JSON")
+	}
+
+	literal := strconv.FormatFloat(f, 'g', -1, bitSize)
+
+	return parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: literal}), nil
+}
+
+// str reads a string of n bytes, which must be valid UTF-8.
+func (d *decoder) str(n int) (parser.Value, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utf8.Valid(b) {
+		d.pos -= n
+		return nil, d.errorf("invalid UTF-8 in string")
+	}
+
+	return parser.NewString(string(b)), nil
+}
+
+// bin reads binary data of n bytes as an array of numbers.
+func (d *decoder) bin(n int) (parser.Value, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := parser.NewArray()
+	arr.Elements = make([]parser.Value, n)
+
+	for i, c := range b {
+		arr.Elements[i] = parser.NewNumber(int64(c))
+	}
+
+	return arr, nil
+}
+
+// array reads an array of n elements.
+func (d *decoder) array(n, depth int) (parser.Value, error) {
+	arr := parser.NewArray()
+	arr.Elements = make([]parser.Value, 0, n)
+
+	for range n {
+		elem, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		arr.Elements = append(arr.Elements, elem)
+	}
+
+	return arr, nil
+}
+
+// object reads a map of n entries, whose keys must be strings.
+func (d *decoder) object(n, depth int) (parser.Value, error) {
+	obj := parser.NewObject()
+
+	for range n {
+		start := d.pos
+
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		str, ok := key.(*parser.StringLiteral)
+		if !ok {
+			d.pos = start
+			return nil, d.errorf("map keys must be strings")
+		}
+
+		value, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(str.Value, value)
+	}
+
+	return obj, nil
+}
+
+// ext reads an extension value of n bytes. Only timestamps are supported.
+func (d *decoder) ext(n int) (parser.Value, error) {
+	start := d.pos - 1
+
+	b, err := d.next(n + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	typ, payload := int8(b[0]), b[1:]
+
+	var t time.Time
+
+	switch {
+	case typ != timestampType:
+		d.pos = start
+		return nil, d.errorf("unsupported extension type " + strconv.Itoa(int(typ)))
+	case n == 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(payload)), 0)
+	case n == 8:
+		v := binary.BigEndian.Uint64(payload)
+		t = time.Unix(int64(v&(1<<34-1)), int64(v>>34))
+	case n == 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(payload[4:])), int64(binary.BigEndian.Uint32(payload)))
+	default:
+		d.pos = start
+		return nil, d.errorf("invalid timestamp length")
+	}
+
+	return parser.NewString(t.UTC().Format(time.RFC3339Nano)), nil
+}
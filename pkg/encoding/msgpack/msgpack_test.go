@@ -0,0 +1,177 @@
+package msgpack_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/encoding/msgpack"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type user struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Score   float64           `json:"score"`
+	Admin   bool              `json:"admin"`
+	Tags    []string          `json:"tags"`
+	Address address           `json:"address"`
+	Meta    map[string]string `json:"meta"`
+	Secret  string            `json:"-"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := user{
+		Name:    "Ana",
+		Age:     -40000,
+		Score:   9.5,
+		Admin:   true,
+		Tags:    []string{"a", strings.Repeat("x", 300)},
+		Address: address{City: "Recife"},
+		Meta:    map[string]string{"k": "v"},
+		Secret:  "hidden",
+	}
+
+	data, err := msgpack.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var out user
+	if err := msgpack.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	in.Secret = ""
+	if out.Name != in.Name || out.Age != in.Age || out.Score != in.Score || !out.Admin ||
+		len(out.Tags) != 2 || out.Tags[1] != in.Tags[1] || out.Address != in.Address ||
+		out.Meta["k"] != "v" || out.Secret != "" {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+
+	// The document matches the JSON encoding of the same value
+	value, err := msgpack.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected, err := encoding.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value.String() != string(expected) {
+		t.Errorf("Expected %s, got %s", expected, value)
+	}
+}
+
+func TestAppendValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []byte
+	}{
+		{name: "Fixed formats", json: `[0, 127, -1, -32, "ab", true, false, null, {}]`,
+			expected: []byte{0x99, 0x00, 0x7f, 0xff, 0xe0, 0xa2, 'a', 'b', 0xc3, 0xc2, 0xc0, 0x80}},
+		{name: "Sized integers", json: `[128, 65536, -33, -129, -2147483649]`,
+			expected: []byte{
+				0x95, 0xcc, 0x80, 0xce, 0x00, 0x01, 0x00, 0x00, 0xd0, 0xdf, 0xd1, 0xff, 0x7f,
+				0xd3, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff,
+			}},
+		{name: "Float", json: `[1.5]`, expected: []byte{0x91, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "Member order", json: `{"b": 1, "a": 2}`, expected: []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x02}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := encoding.Parse([]byte(tt.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := msgpack.AppendValue(nil, v)
+			if err != nil {
+				t.Fatalf("AppendValue failed: %v", err)
+			}
+
+			if !bytes.Equal(data, tt.expected) {
+				t.Errorf("Expected % x, got % x", tt.expected, data)
+			}
+
+			back, err := msgpack.Parse(data)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if !parser.Equal(v, back) {
+				t.Errorf("Round trip changed %s into %s", v, back)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{name: "Float32", data: []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, expected: "1.5"},
+		{name: "Binary", data: []byte{0xc4, 0x02, 0x01, 0xff}, expected: "[1,255]"},
+		{name: "Timestamp", data: []byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x3c}, expected: `"1970-01-01T00:01:00Z"`},
+		{name: "Map16", data: []byte{0xde, 0x00, 0x01, 0xa1, 'k', 0x90}, expected: `{"k":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := msgpack.Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if v.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int64
+	}{
+		{name: "Empty", data: nil, offset: 0},
+		{name: "Truncated string", data: []byte{0xa3, 'a'}, offset: 1},
+		{name: "Huge array", data: []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, offset: 5},
+		{name: "Integer key", data: []byte{0x81, 0x01, 0x01}, offset: 1},
+		{name: "Invalid byte", data: []byte{0xc1}, offset: 0},
+		{name: "Unsupported extension", data: []byte{0xd4, 0x05, 0x00}, offset: 0},
+		{name: "Uint64 overflow", data: []byte{0xcf, 0xff, 0, 0, 0, 0, 0, 0, 0}, offset: 0},
+		{name: "NaN", data: []byte{0xcb, 0x7f, 0xf8, 0, 0, 0, 0, 0, 1}, offset: 0},
+		{name: "Trailing data", data: []byte{0xc0, 0xc0}, offset: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := msgpack.Parse(tt.data)
+
+			var jsonErr *encoding.JSONError
+			if !errors.As(err, &jsonErr) {
+				t.Fatalf("Expected a JSONError, got %v", err)
+			}
+
+			if jsonErr.Code != encoding.ErrInvalidValue || jsonErr.Offset != tt.offset {
+				t.Errorf("Expected invalid value at offset %d, got %v at offset %d", tt.offset, err, jsonErr.Offset)
+			}
+		})
+	}
+}
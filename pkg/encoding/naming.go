@@ -0,0 +1,204 @@
+package encoding
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// KeyNamingStrategy identifies a convention for object keys
+type KeyNamingStrategy int
+
+const (
+	// SnakeCase writes keys as user_id
+	SnakeCase KeyNamingStrategy = iota + 1
+	// CamelCase writes keys as userId
+	CamelCase
+	// KebabCase writes keys as user-id
+	KebabCase
+	// PascalCase writes keys as UserId
+	PascalCase
+)
+
+// String returns the name of the strategy
+func (s KeyNamingStrategy) String() string {
+	switch s {
+	case SnakeCase:
+		return "snake_case"
+	case CamelCase:
+		return "camelCase"
+	case KebabCase:
+		return "kebab-case"
+	case PascalCase:
+		return "PascalCase"
+	default:
+		return fmt.Sprintf("KeyNamingStrategy(%d)", int(s))
+	}
+}
+
+// Convert rewrites name, in any of the supported conventions or as a Go identifier, in
+// the convention of s. Words are split at '_', '-', ' ' and '.', and where the case
+// changes, keeping acronyms together: "HTTPServerID" has the words HTTP, Server and ID.
+func (s KeyNamingStrategy) Convert(name string) string {
+	words := splitWords(name)
+
+	var b strings.Builder
+
+	sep := "_"
+	if s == KebabCase {
+		sep = "-"
+	}
+
+	for i, word := range words {
+		switch s {
+		case SnakeCase, KebabCase:
+			if i > 0 {
+				b.WriteString(sep)
+			}
+
+			b.WriteString(strings.ToLower(word))
+		case CamelCase, PascalCase:
+			if i == 0 && s == CamelCase {
+				b.WriteString(strings.ToLower(word))
+				continue
+			}
+
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			b.WriteString(string(runes))
+		default:
+			return name
+		}
+	}
+
+	return b.String()
+}
+
+// splitWords splits an identifier into its words.
+func splitWords(name string) []string {
+	var (
+		words []string
+		start = -1
+	)
+
+	runes := []rune(name)
+
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' || r == '.' {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = -1
+
+			continue
+		}
+
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+
+			// A new word starts at an upper case letter after a lower case letter or a
+			// digit, and at the last letter of an acronym followed by a lower case one
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// WithKeyNamingStrategy derives the key of the struct fields that have no name in their
+// json tag from the field name in the given convention, when encoding and decoding:
+// with SnakeCase, field UserID is written and read as "user_id".
+func WithKeyNamingStrategy(strategy KeyNamingStrategy) Option {
+	return func(o *Options) error {
+		if strategy < SnakeCase || strategy > PascalCase {
+			return fmt.Errorf("invalid key naming strategy %v", strategy)
+		}
+
+		o.KeyNaming = strategy
+
+		return nil
+	}
+}
+
+// fieldKey returns the key of a struct field named name whose json tag names it tagName,
+// which may be empty.
+func fieldKey(name, tagName string, strategy KeyNamingStrategy) string {
+	if tagName != "" {
+		return tagName
+	}
+
+	if strategy != 0 {
+		return strategy.Convert(name)
+	}
+
+	return name
+}
+
+// ConvertKeys rewrites every object key of the JSON document in data in the convention of
+// strategy and returns the document as compact JSON, keeping the order of the members.
+// Two keys of the same object that convert to the same key are reported as an
+// ErrInvalidValue error.
+func ConvertKeys(data []byte, strategy KeyNamingStrategy) ([]byte, error) {
+	if strategy < SnakeCase || strategy > PascalCase {
+		return nil, NewJSONError(ErrInvalidOptions, fmt.Sprintf("invalid key naming strategy %v", strategy))
+	}
+
+	value, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := convertKeys(value, strategy); err != nil {
+		return nil, NewJSONError(ErrInvalidValue, "failed to convert keys").WithCause(err)
+	}
+
+	return Marshal(value, WithDisableSizeLimit())
+}
+
+// convertKeys rewrites the keys of the objects in v in place.
+func convertKeys(v parser.Value, strategy KeyNamingStrategy) error {
+	switch v := v.(type) {
+	case *parser.Object:
+		pairs := make(map[string]parser.Value, len(v.Pairs))
+		keys := make([]string, len(v.Keys))
+
+		for i, key := range v.Keys {
+			converted := strategy.Convert(key)
+			if _, ok := pairs[converted]; ok {
+				return fmt.Errorf("key %q converts to %q, which is taken by another key", key, converted)
+			}
+
+			if err := convertKeys(v.Pairs[key], strategy); err != nil {
+				return withSegment(keySegment(converted), err)
+			}
+
+			pairs[converted] = v.Pairs[key]
+			keys[i] = converted
+		}
+
+		v.Pairs, v.Keys = pairs, keys
+	case *parser.Array:
+		for i, elem := range v.Elements {
+			if err := convertKeys(elem, strategy); err != nil {
+				return withSegment(indexSegment(i), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestKeyNamingStrategyConvert(t *testing.T) {
+	tests := []struct {
+		name                        string
+		snake, camel, kebab, pascal string
+	}{
+		{"UserID", "user_id", "userId", "user-id", "UserId"},
+		{"HTTPServerURL", "http_server_url", "httpServerUrl", "http-server-url", "HttpServerUrl"},
+		{"created_at", "created_at", "createdAt", "created-at", "CreatedAt"},
+		{"base64-data", "base64_data", "base64Data", "base64-data", "Base64Data"},
+		{"Name", "name", "name", "name", "Name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for strategy, want := range map[encoding.KeyNamingStrategy]string{
+				encoding.SnakeCase:  tt.snake,
+				encoding.CamelCase:  tt.camel,
+				encoding.KebabCase:  tt.kebab,
+				encoding.PascalCase: tt.pascal,
+			} {
+				if got := strategy.Convert(tt.name); got != want {
+					t.Errorf("%v: expected %q, got %q", strategy, want, got)
+				}
+			}
+		})
+	}
+}
+
+type profile struct {
+	UserID    int
+	FirstName string
+	Email     string `json:"mail"`
+	Active    bool   `json:",omitempty"`
+}
+
+func TestWithKeyNamingStrategy(t *testing.T) {
+	opt := encoding.WithKeyNamingStrategy(encoding.SnakeCase)
+
+	data, err := encoding.Marshal(profile{UserID: 1, FirstName: "Ana", Email: "a@b.c", Active: true}, opt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{"user_id":1,"first_name":"Ana","mail":"a@b.c","active":true}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	var p profile
+	if err := encoding.Unmarshal(data, &p, opt); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if p != (profile{UserID: 1, FirstName: "Ana", Email: "a@b.c", Active: true}) {
+		t.Errorf("Unexpected round trip %+v", p)
+	}
+
+	if _, err := encoding.Marshal(p, encoding.WithKeyNamingStrategy(0)); err == nil {
+		t.Error("Expected an error for an invalid strategy")
+	}
+}
+
+func TestConvertKeys(t *testing.T) {
+	data, err := encoding.ConvertKeys([]byte(`{"user_id": 1, "home_address": {"zip_code": "x"}, "tags": [{"tag_name": "a"}]}`), encoding.CamelCase)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{"userId":1,"homeAddress":{"zipCode":"x"},"tags":[{"tagName":"a"}]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	_, err = encoding.ConvertKeys([]byte(`{"a": {"user_id": 1, "userId": 2}}`), encoding.SnakeCase)
+	checkJSONError(t, err, encoding.ErrInvalidValue, `"userId" converts to "user_id"`)
+
+	_, err = encoding.ConvertKeys([]byte(`{"a": }`), encoding.SnakeCase)
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+}
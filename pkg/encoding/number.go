@@ -0,0 +1,135 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// NumberDecodePolicy determines the Go type of the numbers decoded into interface{}.
+type NumberDecodePolicy int
+
+const (
+	// NumberIntWhenPossible decodes integers as int64 and other numbers as float64. This
+	// is the default.
+	NumberIntWhenPossible NumberDecodePolicy = iota
+	// NumberAlwaysFloat64 decodes every number as a float64, like encoding/json
+	NumberAlwaysFloat64
+	// NumberString decodes every number as a Number holding its literal, without loss
+	NumberString
+	// NumberBig decodes integers as *big.Int and other numbers as *big.Float, without
+	// loss
+	NumberBig
+)
+
+// String returns the name of the policy.
+func (p NumberDecodePolicy) String() string {
+	switch p {
+	case NumberIntWhenPossible:
+		return "int when possible"
+	case NumberAlwaysFloat64:
+		return "always float64"
+	case NumberString:
+		return "number"
+	case NumberBig:
+		return "big"
+	default:
+		return fmt.Sprintf("NumberDecodePolicy(%d)", int(p))
+	}
+}
+
+// numberType is the reflect.Type of Number.
+var numberType = reflect.TypeOf(Number(""))
+
+// Number is a JSON number kept as the text of its literal, which interface{} values hold
+// with WithNumberDecodePolicy(NumberString). It marshals as the number it holds, and the
+// empty Number as 0.
+type Number string
+
+// String returns the literal of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// marshalNumber writes n as a number, or reports an error if it is not a valid JSON number.
+func marshalNumber(n Number) (parser.Value, error) {
+	if n == "" {
+		n = "0"
+	}
+
+	if !validNumber(string(n)) {
+		return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("invalid number literal %q", string(n)))
+	}
+
+	return parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: string(n)}), nil
+}
+
+// unmarshalNumberText stores the literal of the number v in the Number rv.
+func unmarshalNumberText(v parser.Value, rv reflect.Value) error {
+	num, ok := v.(*parser.NumberLiteral)
+	if !ok {
+		return newTypeError(valueKind(v), rv.Type())
+	}
+
+	rv.SetString(num.String())
+
+	return nil
+}
+
+// valueKind names the kind of v in error messages.
+func valueKind(v parser.Value) string {
+	switch v.(type) {
+	case *parser.Object:
+		return "object"
+	case *parser.Array:
+		return "array"
+	case *parser.StringLiteral:
+		return "string"
+	case *parser.NumberLiteral:
+		return "number"
+	case *parser.Boolean:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// validNumber reports whether s is a JSON number, of any magnitude.
+func validNumber(s string) bool {
+	num := parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: s})
+
+	return num.IsValid || num.IsBig
+}
+
+// numberInterface returns the value an interface{} holds for num under policy.
+func numberInterface(num *parser.NumberLiteral, policy NumberDecodePolicy) (interface{}, error) {
+	switch {
+	case policy == NumberString:
+		return Number(num.String()), nil
+	case policy == NumberBig || num.IsBig && policy == NumberIntWhenPossible:
+		return bigInterface(num)
+	case num.IsBig:
+		f, err := strconv.ParseFloat(num.String(), 64)
+		if err != nil {
+			return nil, NewJSONError(ErrInvalidValue, "cannot unmarshal "+num.String()+" into float64").WithCause(err)
+		}
+
+		return f, nil
+	case num.IsInt && policy == NumberIntWhenPossible:
+		return num.Int, nil
+	default:
+		return num.Float, nil
+	}
+}
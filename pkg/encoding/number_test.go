@@ -0,0 +1,125 @@
+package encoding_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestNumberDecodePolicy(t *testing.T) {
+	input := []byte(`[1, -2.5, 1e2, 12345678901234567890]`)
+
+	tests := []struct {
+		policy   encoding.NumberDecodePolicy
+		expected []interface{}
+	}{
+		{
+			policy:   encoding.NumberAlwaysFloat64,
+			expected: []interface{}{float64(1), -2.5, float64(100), 12345678901234567890.0},
+		},
+		{
+			policy:   encoding.NumberString,
+			expected: []interface{}{encoding.Number("1"), encoding.Number("-2.5"), encoding.Number("1e2"), encoding.Number("12345678901234567890")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			var v interface{}
+			if err := encoding.Unmarshal(input, &v, encoding.WithBigNumbers(), encoding.WithNumberDecodePolicy(tt.policy)); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(v, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, v)
+			}
+		})
+	}
+
+	t.Run("int when possible", func(t *testing.T) {
+		var v []interface{}
+		if err := encoding.Unmarshal([]byte(`[1, 1.5]`), &v); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(v, []interface{}{int64(1), 1.5}) {
+			t.Errorf("Expected int64 and float64, got %#v", v)
+		}
+	})
+
+	t.Run("big", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := encoding.Unmarshal([]byte(`{"i": 12345678901234567890, "f": 0.1}`), &v, encoding.WithNumberDecodePolicy(encoding.NumberBig)); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		i, ok := v["i"].(*big.Int)
+		if !ok || i.String() != "12345678901234567890" {
+			t.Errorf("Expected a *big.Int, got %#v", v["i"])
+		}
+
+		if f, ok := v["f"].(*big.Float); !ok || f.Text('g', -1) != "0.1" {
+			t.Errorf("Expected a *big.Float, got %#v", v["f"])
+		}
+	})
+
+	var v interface{}
+
+	err := encoding.Unmarshal(input, &v, encoding.WithNumberDecodePolicy(encoding.NumberDecodePolicy(9)))
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "unknown number decode policy NumberDecodePolicy(9)")
+
+	// Numbers out of range are still rejected unless requested
+	err = encoding.Unmarshal(input, &v, encoding.WithNumberDecodePolicy(encoding.NumberAlwaysFloat64))
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+}
+
+func TestNumber(t *testing.T) {
+	type price struct {
+		Amount encoding.Number `json:"amount"`
+		Empty  encoding.Number `json:"empty"`
+	}
+
+	var p price
+	if err := encoding.Unmarshal([]byte(`{"amount": 10.50}`), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if p.Amount != "10.50" {
+		t.Errorf("Expected the literal to be kept, got %q", p.Amount)
+	}
+
+	if f, err := p.Amount.Float64(); err != nil || f != 10.5 {
+		t.Errorf("Expected 10.5, got %v (%v)", f, err)
+	}
+
+	if _, err := p.Amount.Int64(); err == nil {
+		t.Error("Expected 10.50 not to be an int64")
+	}
+
+	data, err := encoding.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(data) != `{"amount":10.50,"empty":0}` {
+		t.Errorf("Unexpected output %s", data)
+	}
+
+	// A decoded interface{} is written back with the same literals
+	var v interface{}
+	if err := encoding.Unmarshal([]byte(`[1.50, 1e2]`), &v, encoding.WithNumberDecodePolicy(encoding.NumberString)); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if data, err := encoding.Marshal(v); err != nil || string(data) != `[1.50,1e2]` {
+		t.Errorf("Expected the literals to be kept, got %s (%v)", data, err)
+	}
+
+	err = encoding.Unmarshal([]byte(`{"amount": "10"}`), &p)
+	checkJSONError(t, err, encoding.ErrUnexpectedType, "into encoding.Number")
+
+	_, err = encoding.Marshal(price{Amount: "ten"})
+	checkJSONError(t, err, encoding.ErrInvalidValue, `invalid number literal "ten"`)
+}
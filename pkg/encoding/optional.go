@@ -0,0 +1,80 @@
+package encoding
+
+import "reflect"
+
+// Optional holds a value that distinguishes a member missing from a JSON object, one set
+// to null and one set to a value, for partial update payloads. The zero value is
+// undefined. Marshal leaves undefined struct fields out of the object and writes null
+// for them elsewhere; Unmarshal leaves the fields of missing members undefined.
+//
+//	type UserPatch struct {
+//		Email encoding.Optional[string] `json:"email"`
+//	}
+type Optional[T any] struct {
+	value   T
+	defined bool
+	null    bool
+}
+
+// Some returns an Optional that holds v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{value: v, defined: true}
+}
+
+// Null returns an Optional that is explicitly null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{defined: true, null: true}
+}
+
+// Defined reports whether o is null or holds a value, i.e. whether the member was present.
+func (o Optional[T]) Defined() bool {
+	return o.defined
+}
+
+// IsNull reports whether o is explicitly null.
+func (o Optional[T]) IsNull() bool {
+	return o.defined && o.null
+}
+
+// Value returns the value held by o and whether it holds one.
+func (o Optional[T]) Value() (T, bool) {
+	return o.value, o.defined && !o.null
+}
+
+// optionalState returns the value held by o and its state, for Marshal.
+func (o Optional[T]) optionalState() (value reflect.Value, defined, null bool) {
+	return reflect.ValueOf(&o.value).Elem(), o.defined, o.null
+}
+
+// setOptional marks o as defined, and null if null is set, and returns its value for
+// Unmarshal to decode into.
+func (o *Optional[T]) setOptional(null bool) reflect.Value {
+	var zero T
+
+	o.value, o.defined, o.null = zero, true, null
+
+	return reflect.ValueOf(&o.value).Elem()
+}
+
+// optional is implemented by Optional for Marshal.
+type optional interface {
+	optionalState() (value reflect.Value, defined, null bool)
+}
+
+// optionalTarget is implemented by *Optional for Unmarshal.
+type optionalTarget interface {
+	setOptional(null bool) reflect.Value
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// isUndefinedOptional reports whether v is an Optional that is undefined.
+func isUndefinedOptional(v reflect.Value) bool {
+	if !v.Type().Implements(optionalType) || !v.CanInterface() {
+		return false
+	}
+
+	_, defined, _ := v.Interface().(optional).optionalState()
+
+	return !defined
+}
@@ -0,0 +1,74 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type userPatch struct {
+	Name  encoding.Optional[string]            `json:"name"`
+	Email encoding.Optional[string]            `json:"email"`
+	Age   encoding.Optional[int]               `json:"age"`
+	Tags  encoding.Optional[[]string]          `json:"tags"`
+	Meta  encoding.Optional[map[string]string] `json:"meta"`
+}
+
+func TestOptionalUnmarshal(t *testing.T) {
+	var p userPatch
+	if err := encoding.Unmarshal([]byte(`{"email": null, "age": 0, "tags": ["a"]}`), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if p.Name.Defined() {
+		t.Error("Expected name to be undefined")
+	}
+
+	if !p.Email.Defined() || !p.Email.IsNull() {
+		t.Error("Expected email to be null")
+	}
+
+	if age, ok := p.Age.Value(); !ok || age != 0 {
+		t.Errorf("Expected age 0, got %v %v", age, ok)
+	}
+
+	if tags, ok := p.Tags.Value(); !ok || len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("Expected tags [a], got %v %v", tags, ok)
+	}
+
+	if err := encoding.Unmarshal([]byte(`{"age": "x"}`), &p); err == nil {
+		t.Error("Expected an error for a value of the wrong type")
+	}
+}
+
+func TestOptionalMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "Undefined fields are omitted",
+			input:    userPatch{Email: encoding.Null[string](), Age: encoding.Some(30)},
+			expected: `{"email":null,"age":30}`,
+		},
+		{
+			name:     "Undefined values elsewhere are null",
+			input:    []encoding.Optional[int]{encoding.Some(1), {}, encoding.Null[int]()},
+			expected: `[1,null,null]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
@@ -1,6 +1,14 @@
 package encoding
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
 
 // Size constants for better readability and configuration
 const (
@@ -12,6 +20,12 @@ const (
 
 	// MaximumMaxSize is the absolute maximum allowed size (1GB)
 	MaximumMaxSize = 1024 * 1024 * 1024
+
+	// DefaultBufferSize is the default size of the stream buffers (4KB)
+	DefaultBufferSize = parser.DefaultBufferSize
+
+	// MaximumBufferSize is the maximum allowed buffer size (16MB)
+	MaximumBufferSize = 1024 * 1024 * 16
 )
 
 // Options holds all configuration options for the JSON parser
@@ -22,10 +36,17 @@ type Options struct {
 	// DisableSizeLimit allows bypassing size limit checks when set to true
 	DisableSizeLimit bool
 
-	// StrictMode enables additional validation during parsing
+	// StrictMode enables additional validation during decoding: object members that match
+	// no field of the struct they are decoded into are rejected, as with
+	// DisallowUnknownFields
 	StrictMode bool
 
-	// BufferSize defines the size of the internal buffer
+	// DisallowUnknownFields rejects object members that match no field of the struct
+	// they are decoded into
+	DisallowUnknownFields bool
+
+	// BufferSize defines the size of the buffers used by the streaming functions: the
+	// chunks read by the lexer and the bufio readers and writers (DefaultBufferSize)
 	BufferSize int
 
 	// Indent defines the string used for indentation
@@ -33,10 +54,162 @@ type Options struct {
 
 	// Prefix defines the string used for indentation prefix
 	Prefix string
+
+	// SortKeys writes object keys in lexicographic order
+	SortKeys bool
+
+	// CanonicalNumbers writes numbers in their shortest ECMAScript form (RFC 8785)
+	CanonicalNumbers bool
+
+	// FloatDecimals is the number of digits written after the decimal point of floats, or
+	// -1 (the default) for the shortest form that reads back as the same float
+	FloatDecimals int
+
+	// ASCIIOnly escapes every character outside ASCII as \uXXXX
+	ASCIIOnly bool
+
+	// ScriptSafe escapes "</", "<!--", U+2028 and U+2029 so that the output can be
+	// embedded in an HTML <script> element
+	ScriptSafe bool
+
+	// FrozenOutput guarantees byte-identical output across runs and versions
+	FrozenOutput bool
+
+	// DuplicateKeyCheck rejects objects that contain the same key twice
+	DuplicateKeyCheck bool
+
+	// DuplicateKeyFilterBits makes the duplicate key check screen keys with a bloom
+	// filter of this many bits per object, confirming hits against the earlier keys
+	DuplicateKeyFilterBits int
+
+	// AllowComments accepts // and /* */ comments in the input (JSONC)
+	AllowComments bool
+
+	// PreserveComments accepts comments and keeps them on the nodes returned by Parse,
+	// so that MarshalIndent writes them back
+	PreserveComments bool
+
+	// AllowTrailingCommas accepts a comma after the last object member or array element
+	AllowTrailingCommas bool
+
+	// AllowTrailingData accepts data after the top-level value instead of reporting an
+	// ErrTrailingData syntax error
+	AllowTrailingData bool
+
+	// TransparentDecompression makes NewDecoder detect and decompress gzip input, and the
+	// formats added with RegisterDecompressor
+	TransparentDecompression bool
+
+	// Transcode converts UTF-16 and UTF-32 input to UTF-8 before parsing
+	Transcode bool
+
+	// InvalidUTF8 determines how bytes that are not valid UTF-8 are handled in strings
+	InvalidUTF8 parser.InvalidUTF8Policy
+
+	// BigNumbers accepts numbers out of the range of int64 and float64, which decode into
+	// *big.Int, *big.Float or Decimal
+	BigNumbers bool
+
+	// NumberPolicy determines the Go type of the numbers decoded into interface{}
+	NumberPolicy NumberDecodePolicy
+
+	// Int64AsString writes integers beyond ±(2^53-1) as strings and decodes integers
+	// from strings
+	Int64AsString bool
+
+	// MergeInto decodes into the existing contents of maps and slices instead of replacing
+	// them
+	MergeInto bool
+
+	// ZeroMissingFields resets the struct fields missing from the input to their zero
+	// values and replaces maps instead of adding to them
+	ZeroMissingFields bool
+
+	// MapType and SliceType are the types that objects and arrays decoded into interface{}
+	// get, instead of map[string]interface{} and []interface{}
+	MapType   reflect.Type
+	SliceType reflect.Type
+
+	// OrderedMaps decodes objects into interface{} as *OrderedMap
+	OrderedMaps bool
+
+	// SkipUnsupported leaves out the struct fields and map entries holding channels,
+	// functions and complex numbers instead of failing
+	SkipUnsupported bool
+
+	// PartialDecode keeps decoding after a value fails and reports all failures at the end
+	PartialDecode bool
+
+	// PreserveOrder makes DecodeParallel hand values to the handler in input order
+	PreserveOrder bool
+
+	// ArrayElements makes DecodeParallel decode the elements of a single top-level array
+	ArrayElements bool
+
+	// ProfileLabels labels the parse, decode and encode phases for CPU profiles
+	ProfileLabels bool
+
+	// MaxDepth limits the nesting of objects and arrays (0 means no limit)
+	MaxDepth int
+
+	// MaxStringLength limits the length in bytes of strings and keys (0 means no limit)
+	MaxStringLength int
+
+	// MaxArrayElements limits the number of elements of an array (0 means no limit)
+	MaxArrayElements int
+
+	// MaxObjectKeys limits the number of members of an object (0 means no limit)
+	MaxObjectKeys int
+
+	// DecodeTimeout bounds the time spent parsing a document (0 means no limit)
+	DecodeTimeout time.Duration
+
+	// Defaults fills struct fields missing from the input from their default tag and
+	// calls SetDefaults on structs that implement Defaulter
+	Defaults bool
+
+	// KeyNaming derives the key of struct fields without a name in their json tag
+	KeyNaming KeyNamingStrategy
+
+	// ProtoJSON follows the protobuf JSON mapping when encoding generated messages
+	ProtoJSON bool
+
+	// coercions holds the compiled per-path coercion rules applied during decoding
+	coercions []coercionRule
+
+	// encodeHooks and decodeHooks transform values while encoding and decoding
+	encodeHooks []EncodeHook
+	decodeHooks []DecodeHook
+
+	// redactFields holds the lowercased member names redacted while encoding
+	redactFields map[string]bool
+
+	// includeFields and excludeFields select the object members written while encoding
+	includeFields [][]string
+	excludeFields [][]string
+
+	// numberFormatter writes the floats encoded, if set
+	numberFormatter func(f float64, bits int) []byte
 }
 
 // Validate checks if the options are valid
 func (o *Options) Validate() error {
+	if o.BufferSize < 1 || o.BufferSize > MaximumBufferSize {
+		return fmt.Errorf("buffer size %d is outside the allowed range 1 to %d", o.BufferSize, MaximumBufferSize)
+	}
+
+	if o.MergeInto && o.ZeroMissingFields {
+		return fmt.Errorf("merge into and zero missing fields cannot be combined")
+	}
+
+	if o.CanonicalNumbers && o.FloatDecimals >= 0 {
+		return fmt.Errorf("canonical numbers and float decimals cannot be combined")
+	}
+
+	if o.numberFormatter != nil && (o.CanonicalNumbers || o.FloatDecimals >= 0) {
+		return fmt.Errorf("a number formatter cannot be combined with canonical numbers or float decimals")
+	}
+
 	if o.DisableSizeLimit {
 		return nil
 	}
@@ -61,6 +234,8 @@ func defaultOptions() *Options {
 		MaxSize:          DefaultMaxSize,
 		DisableSizeLimit: false,
 		StrictMode:       false,
+		BufferSize:       DefaultBufferSize,
+		FloatDecimals:    -1,
 	}
 }
 
@@ -86,7 +261,9 @@ func WithDisableSizeLimit() Option {
 	}
 }
 
-// WithStrictMode enables strict parsing mode
+// WithStrictMode enables strict decoding mode, which includes the checks of
+// WithDisallowUnknownFields. Data after the top-level value is rejected in any mode unless
+// WithAllowTrailingData is set.
 func WithStrictMode() Option {
 	return func(o *Options) error {
 		o.StrictMode = true
@@ -95,19 +272,47 @@ func WithStrictMode() Option {
 	}
 }
 
-// WithBufferSize sets the buffer size for encoding/decoding
+// WithDisallowUnknownFields rejects object members that match no field of the struct they
+// are decoded into, like Decoder.DisallowUnknownFields in encoding/json. A struct with a
+// catch-all field keeps unknown members there instead.
+func WithDisallowUnknownFields() Option {
+	return func(o *Options) error {
+		o.DisallowUnknownFields = true
+
+		return nil
+	}
+}
+
+// WithBufferSize sets the buffer size for streaming encoding/decoding, up to
+// MaximumBufferSize. Larger buffers mean fewer reads and writes on the underlying stream.
 func WithBufferSize(size int) Option {
 	return func(o *Options) error {
 		if size <= 0 {
 			return fmt.Errorf("buffer size must be positive, got %d", size)
 		}
 
+		if size > MaximumBufferSize {
+			return fmt.Errorf("buffer size %d exceeds maximum allowed size %d", size, MaximumBufferSize)
+		}
+
 		o.BufferSize = size
 
 		return nil
 	}
 }
 
+// WithProfileLabels sets the pprof label ProfileLabel to the phase being run, so that a
+// CPU profile can be split into parsing, decoding and encoding with
+// `go tool pprof -tagfocus`. The labels replace any goroutine labels set by the caller for
+// the duration of the call and clear them afterwards.
+func WithProfileLabels() Option {
+	return func(o *Options) error {
+		o.ProfileLabels = true
+
+		return nil
+	}
+}
+
 // WithIndent sets the indentation string
 func WithIndent(prefix, indent string) Option {
 	return func(o *Options) error {
@@ -117,6 +322,605 @@ func WithIndent(prefix, indent string) Option {
 	}
 }
 
+// WithSortKeys writes object keys in lexicographic order
+func WithSortKeys() Option {
+	return func(o *Options) error {
+		o.SortKeys = true
+
+		return nil
+	}
+}
+
+// WithCanonicalNumbers writes numbers in their shortest ECMAScript form, so that e.g.
+// 1e2, 100.0 and 100 are all written as 100
+func WithCanonicalNumbers() Option {
+	return func(o *Options) error {
+		o.CanonicalNumbers = true
+
+		return nil
+	}
+}
+
+// WithFloatDecimals writes every float32 and float64 with exactly places digits after the
+// decimal point, rounding half to even, e.g. 2.5 as 2.50 with 2 places. By default floats
+// are written like encoding/json does, with the fewest digits that read back as the same
+// float.
+func WithFloatDecimals(places int) Option {
+	return func(o *Options) error {
+		if places < 0 {
+			return fmt.Errorf("float decimals %d must not be negative", places)
+		}
+
+		o.FloatDecimals = places
+
+		return nil
+	}
+}
+
+// WithNumberFormatter writes every float32 and float64 with format, which is given the
+// value and its bit size, 32 or 64, and returns its JSON number literal, e.g. to write
+// amounts with two decimals or to trim trailing zeros. A literal that is not a valid JSON
+// number fails the encoding. NaN and infinities are rejected before format is called.
+func WithNumberFormatter(format func(f float64, bits int) []byte) Option {
+	return func(o *Options) error {
+		if format == nil {
+			return fmt.Errorf("number formatter must not be nil")
+		}
+
+		o.numberFormatter = format
+
+		return nil
+	}
+}
+
+// WithASCIIOnly escapes every character outside ASCII as \uXXXX, using a surrogate
+// pair beyond the Basic Multilingual Plane, for systems that mangle UTF-8
+func WithASCIIOnly() Option {
+	return func(o *Options) error {
+		o.ASCIIOnly = true
+
+		return nil
+	}
+}
+
+// WithScriptSafe makes the output safe to embed in an HTML <script> element: "</" is
+// written as "<\/" so that no string closes the element, "<!--" as "\u003c!--", and the
+// line terminators U+2028 and U+2029, which older JavaScript engines reject in strings,
+// as \u2028 and \u2029
+func WithScriptSafe() Option {
+	return func(o *Options) error {
+		o.ScriptSafe = true
+
+		return nil
+	}
+}
+
+// WithFrozenOutput guarantees byte-identical output for equal values across runs and
+// versions, for signed artifacts and reproducible builds. It sorts keys, writes canonical
+// numbers, uses the fixed escaping of Marshal and the compact "," and ":" separators,
+// ignoring any indentation, WithASCIIOnly and WithScriptSafe.
+func WithFrozenOutput() Option {
+	return func(o *Options) error {
+		o.SortKeys = true
+		o.CanonicalNumbers = true
+		o.FrozenOutput = true
+
+		return nil
+	}
+}
+
+// WithDuplicateKeyCheck rejects input in which an object contains the same key twice
+func WithDuplicateKeyCheck() Option {
+	return func(o *Options) error {
+		o.DuplicateKeyCheck = true
+		o.DuplicateKeyFilterBits = 0
+
+		return nil
+	}
+}
+
+// WithApproximateDuplicateKeyCheck rejects input in which an object contains the same key
+// twice, screening keys with a bloom filter of the given number of bits per object. Most
+// new keys are settled by the filter alone, and a filter hit is confirmed by comparing
+// the key with the earlier keys of the object, so a distinct key is never reported as a
+// duplicate. Size the filter at roughly 10 bits per expected key for a ~1% rate of hits
+// that need confirming. This is meant for streaming huge objects with NewDecoder.
+func WithApproximateDuplicateKeyCheck(bits int) Option {
+	return func(o *Options) error {
+		if bits <= 0 {
+			return fmt.Errorf("duplicate key filter size must be positive, got %d", bits)
+		}
+
+		o.DuplicateKeyCheck = true
+		o.DuplicateKeyFilterBits = bits
+
+		return nil
+	}
+}
+
+// WithAllowComments accepts // line and /* block */ comments in the input, as used by
+// VS Code style configuration files (tsconfig.json, settings.json)
+func WithAllowComments() Option {
+	return func(o *Options) error {
+		o.AllowComments = true
+
+		return nil
+	}
+}
+
+// WithPreserveComments accepts comments like WithAllowComments and keeps them on the
+// nodes returned by Parse. MarshalIndent writes them back around the values they were
+// attached to, so that a commented configuration file can be edited without losing its
+// comments:
+//
+//	v, _ := encoding.Parse(data, encoding.WithPreserveComments())
+//	v.(*parser.Object).Set("port", parser.NewNumber(8080))
+//	data, _ = encoding.MarshalIndent(v, "", "  ")
+//
+// Compact output has no room for line comments and leaves them out.
+func WithPreserveComments() Option {
+	return func(o *Options) error {
+		o.AllowComments = true
+		o.PreserveComments = true
+
+		return nil
+	}
+}
+
+// WithAllowTrailingCommas accepts a comma after the last member of an object or the last
+// element of an array
+func WithAllowTrailingCommas() Option {
+	return func(o *Options) error {
+		o.AllowTrailingCommas = true
+
+		return nil
+	}
+}
+
+// WithAllowTrailingData accepts input in which the JSON value is followed by other data,
+// such as JSON embedded at the start of a log line. By default Unmarshal, UnmarshalReader,
+// ValidateStream and decoders reject such data with an ErrInvalidJSON error that also
+// matches ErrTrailingData and is located at the data. With this option Unmarshal and
+// UnmarshalReader ignore it, and UnmarshalPrefix returns where the value ends. A decoder
+// stops at data that does not start another value: More reports false and Decode returns
+// an ErrTrailingData error whose Offset is InputOffset, where the last value ended, while
+// Buffered holds the rest of the input.
+func WithAllowTrailingData() Option {
+	return func(o *Options) error {
+		o.AllowTrailingData = true
+
+		return nil
+	}
+}
+
+// WithTransparentDecompression makes NewDecoder sniff the magic bytes of the reader and
+// decompress gzip input on the fly. zstd is not built in, as the standard library does
+// not implement it; it and other formats are detected once a Decompressor is registered
+// for them through RegisterDecompressor. Other input is read as is.
+// NewDecoder blocks until the first bytes of the reader are available.
+func WithTransparentDecompression() Option {
+	return func(o *Options) error {
+		o.TransparentDecompression = true
+
+		return nil
+	}
+}
+
+// WithTranscoding accepts UTF-16 and UTF-32 input, as written by some Windows tools,
+// and converts it to UTF-8 before parsing. The encoding is detected from the byte order
+// mark or, without one, from the zero bytes of the first characters. A UTF-8 byte order
+// mark is always skipped.
+func WithTranscoding() Option {
+	return func(o *Options) error {
+		o.Transcode = true
+
+		return nil
+	}
+}
+
+// WithInvalidUTF8Policy sets how bytes that are not valid UTF-8 are handled in input
+// strings: parser.InvalidUTF8Replace substitutes U+FFFD (the default),
+// parser.InvalidUTF8Error rejects the input and parser.InvalidUTF8Pass keeps the bytes.
+func WithInvalidUTF8Policy(policy parser.InvalidUTF8Policy) Option {
+	return func(o *Options) error {
+		switch policy {
+		case parser.InvalidUTF8Replace, parser.InvalidUTF8Error, parser.InvalidUTF8Pass:
+		default:
+			return fmt.Errorf("unknown invalid UTF-8 policy %v", policy)
+		}
+
+		o.InvalidUTF8 = policy
+
+		return nil
+	}
+}
+
+// WithBigNumbers accepts numbers that do not fit an int64 or a float64, such as 128-bit
+// IDs, instead of rejecting them as invalid. They decode into big.Int, big.Float and
+// Decimal fields without loss, and into interface{} as a *big.Int for an integer and a
+// *big.Float otherwise. Without this option these types still marshal with all of their
+// digits and decode the numbers that are in range.
+func WithBigNumbers() Option {
+	return func(o *Options) error {
+		o.BigNumbers = true
+
+		return nil
+	}
+}
+
+// WithNumberDecodePolicy sets the Go type of the numbers decoded into interface{}:
+// NumberIntWhenPossible gives an int64 for an integer and a float64 otherwise (the
+// default), NumberAlwaysFloat64 a float64 like encoding/json, NumberString a Number that
+// keeps the literal, and NumberBig a *big.Int or *big.Float. With NumberString and
+// NumberBig, numbers out of the range of int64 and float64 are accepted as with
+// WithBigNumbers.
+func WithNumberDecodePolicy(policy NumberDecodePolicy) Option {
+	return func(o *Options) error {
+		switch policy {
+		case NumberIntWhenPossible, NumberAlwaysFloat64, NumberString, NumberBig:
+		default:
+			return fmt.Errorf("unknown number decode policy %v", policy)
+		}
+
+		o.NumberPolicy = policy
+
+		return nil
+	}
+}
+
+// WithInt64AsString writes integers whose magnitude exceeds 2^53-1, the largest that a
+// JavaScript number holds exactly, as quoted strings such as "9007199254740993", so that
+// browsers do not silently round them. Integer and big.Int fields also decode from such
+// strings. Smaller integers are written as numbers.
+func WithInt64AsString() Option {
+	return func(o *Options) error {
+		o.Int64AsString = true
+
+		return nil
+	}
+}
+
+// WithMergeInto decodes into the existing contents of the target, so that only the keys
+// present in the input are overridden, for configuration loaded in layers by repeated
+// Unmarshal calls. Map entries and slice elements are decoded into their current values,
+// so that structs and maps nested in them are merged too. A map[string]interface{} held by
+// an interface{} is merged as well. Slices take the length of the input array. Struct
+// fields are always merged, since fields missing from the input are left as they are.
+func WithMergeInto() Option {
+	return func(o *Options) error {
+		o.MergeInto = true
+
+		return nil
+	}
+}
+
+// WithZeroMissingFields resets the struct fields missing from the input to their zero
+// values, so that the decoded value reflects exactly the payload, as the full replace of a
+// PUT request expects. Maps are replaced rather than added to. Fields that are unexported
+// or tagged "-" are kept, and WithDefaults still fills the missing fields that have a
+// default. It cannot be combined with WithMergeInto.
+func WithZeroMissingFields() Option {
+	return func(o *Options) error {
+		o.ZeroMissingFields = true
+
+		return nil
+	}
+}
+
+// WithMapType sets the type of the objects decoded into interface{}, which must be a map
+// with string keys, such as map[string]any or a named map type. Its values are decoded as
+// its element type, so that nested objects use it too when that is interface{}.
+func WithMapType(t reflect.Type) Option {
+	return func(o *Options) error {
+		if t == nil || t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+			return fmt.Errorf("map type %v must be a map with string keys", t)
+		}
+
+		o.MapType = t
+
+		return nil
+	}
+}
+
+// WithSliceType sets the type of the arrays decoded into interface{}, which must be a
+// slice, such as a named []interface{} type.
+func WithSliceType(t reflect.Type) Option {
+	return func(o *Options) error {
+		if t == nil || t.Kind() != reflect.Slice {
+			return fmt.Errorf("slice type %v must be a slice", t)
+		}
+
+		o.SliceType = t
+
+		return nil
+	}
+}
+
+// WithOrderedMaps decodes the objects decoded into interface{} as *OrderedMap, which keeps
+// the order of their members, instead of map[string]interface{}. It takes precedence over
+// WithMapType.
+func WithOrderedMaps() Option {
+	return func(o *Options) error {
+		o.OrderedMaps = true
+
+		return nil
+	}
+}
+
+// WithSkipUnsupported makes Marshal leave out the struct fields and map entries that hold
+// channels, functions or complex numbers, which have no JSON representation, instead of
+// failing with ErrUnsupportedType. Such values in arrays still fail.
+func WithSkipUnsupported() Option {
+	return func(o *Options) error {
+		o.SkipUnsupported = true
+
+		return nil
+	}
+}
+
+// WithPartialDecode makes Unmarshal and Decode store every value they can and skip the
+// ones that fail, such as a string found where a number is expected. The skipped values
+// are left unchanged and reported together at the end as FieldErrors, with the JSON
+// Pointer of each, instead of aborting on the first failure. Syntax errors still abort.
+func WithPartialDecode() Option {
+	return func(o *Options) error {
+		o.PartialDecode = true
+
+		return nil
+	}
+}
+
+// WithPreserveOrder makes DecodeParallel call the handler from one goroutine, with the
+// values in the order they appear in the input.
+func WithPreserveOrder() Option {
+	return func(o *Options) error {
+		o.PreserveOrder = true
+
+		return nil
+	}
+}
+
+// WithArrayElements makes DecodeParallel read a single top-level array and decode its
+// elements, instead of a stream of values.
+func WithArrayElements() Option {
+	return func(o *Options) error {
+		o.ArrayElements = true
+
+		return nil
+	}
+}
+
+// WithMaxDepth rejects input that nests objects and arrays more than depth levels deep.
+// The limit is checked while parsing, so a deeply nested document fails before it uses
+// much memory or stack.
+func WithMaxDepth(depth int) Option {
+	return func(o *Options) error {
+		if depth <= 0 {
+			return fmt.Errorf("max depth must be positive, got %d", depth)
+		}
+
+		o.MaxDepth = depth
+
+		return nil
+	}
+}
+
+// WithMaxStringLength rejects input containing a string or key longer than length bytes
+func WithMaxStringLength(length int) Option {
+	return func(o *Options) error {
+		if length <= 0 {
+			return fmt.Errorf("max string length must be positive, got %d", length)
+		}
+
+		o.MaxStringLength = length
+
+		return nil
+	}
+}
+
+// WithMaxArrayElements rejects input containing an array with more than count elements
+func WithMaxArrayElements(count int) Option {
+	return func(o *Options) error {
+		if count <= 0 {
+			return fmt.Errorf("max array elements must be positive, got %d", count)
+		}
+
+		o.MaxArrayElements = count
+
+		return nil
+	}
+}
+
+// WithMaxObjectKeys rejects input containing an object with more than count members
+func WithMaxObjectKeys(count int) Option {
+	return func(o *Options) error {
+		if count <= 0 {
+			return fmt.Errorf("max object keys must be positive, got %d", count)
+		}
+
+		o.MaxObjectKeys = count
+
+		return nil
+	}
+}
+
+// WithDecodeTimeout aborts a parse that runs longer than d, for callers that do not pass
+// a context. The deadline starts with each call to Unmarshal, UnmarshalReader,
+// ValidateStream or Decode; the error has code ErrCanceled and wraps
+// context.DeadlineExceeded. Like a canceled context, it does not interrupt a read that
+// is already blocked.
+func WithDecodeTimeout(d time.Duration) Option {
+	return func(o *Options) error {
+		if d <= 0 {
+			return fmt.Errorf("decode timeout must be positive, got %s", d)
+		}
+
+		o.DecodeTimeout = d
+
+		return nil
+	}
+}
+
+// WithDefaults makes decoding fill the struct fields whose key is missing from the input
+// with the value of their `default:"..."` tag, and call SetDefaults on structs that
+// implement Defaulter before decoding them. Nested structs missing from the input get
+// their defaults too. The tag of a string field is the string itself, and any other tag
+// is a JSON value, e.g. `default:"8080"` or `default:"[\"a\"]"`.
+func WithDefaults() Option {
+	return func(o *Options) error {
+		o.Defaults = true
+
+		return nil
+	}
+}
+
+// EncodeHook may replace the value at path, a dotted path such as "users[0].password"
+// ("" for the top-level value), before it is encoded. It returns the replacement and
+// true, or false to encode v as usual. v is invalid for a nil interface.
+type EncodeHook func(path string, v reflect.Value) (interface{}, bool)
+
+// DecodeHook may replace the value at path, a dotted path such as "users[0].password"
+// ("" for the top-level value), after it was decoded into v. It returns the replacement,
+// which must be assignable to the type of v or a number of another numeric type, and
+// true; or false to keep v.
+type DecodeHook func(path string, v reflect.Value) (interface{}, bool)
+
+// WithProtoJSON makes Marshal and the encoders follow the protobuf JSON mapping for
+// messages generated by protoc-gen-go, as grpc-gateway does: fields are named by their
+// JSON name and omitted when unset, 64-bit integers are written as strings, enums by
+// name, bytes in base64, and the well-known types Timestamp, Duration, Struct, Value,
+// ListValue, FieldMask and the wrappers in their special forms.
+func WithProtoJSON() Option {
+	return func(o *Options) error {
+		o.ProtoJSON = true
+
+		return nil
+	}
+}
+
+// WithEncodeHook registers a hook called for every value encoded by Marshal and the
+// encoders, to redact secrets, rename keys or convert units without writing a Marshaler
+// for each type. Hooks are called in the order they were registered and the first one
+// that returns true wins; the replacement is encoded without calling the hooks on it.
+func WithEncodeHook(hook EncodeHook) Option {
+	return func(o *Options) error {
+		if hook == nil {
+			return fmt.Errorf("encode hook must not be nil")
+		}
+
+		o.encodeHooks = append(o.encodeHooks, hook)
+
+		return nil
+	}
+}
+
+// WithDecodeHook registers a hook called for every value decoded by Unmarshal and the
+// decoders, after the value and its children are stored and before ValidateJSON is
+// called on it. Hooks are called in the order they were registered and the first one
+// that returns true wins.
+func WithDecodeHook(hook DecodeHook) Option {
+	return func(o *Options) error {
+		if hook == nil {
+			return fmt.Errorf("decode hook must not be nil")
+		}
+
+		o.decodeHooks = append(o.decodeHooks, hook)
+
+		return nil
+	}
+}
+
+// RedactedValue replaces the values of redacted fields in the output
+const RedactedValue = "***"
+
+// WithRedactFields makes Marshal and the encoders write RedactedValue instead of the value
+// of any object member or struct field with one of the given names, compared without
+// regard to case and at any depth, so that request and response objects can be logged
+// safely. A struct field can also be redacted on its own with a `json:"name,redact"` tag.
+func WithRedactFields(names ...string) Option {
+	return func(o *Options) error {
+		if len(names) == 0 {
+			return fmt.Errorf("at least one field name must be given")
+		}
+
+		if o.redactFields == nil {
+			o.redactFields = make(map[string]bool, len(names))
+		}
+
+		for _, name := range names {
+			o.redactFields[strings.ToLower(name)] = true
+		}
+
+		return nil
+	}
+}
+
+// rejectUnknownFields reports whether object members that match no struct field are an
+// error
+func (o *Options) rejectUnknownFields() bool {
+	return o.StrictMode || o.DisallowUnknownFields
+}
+
+// parserOptions translates the options that affect parsing into parser options
+func (o *Options) parserOptions() []parser.Option {
+	var opts []parser.Option
+
+	if o.DuplicateKeyCheck {
+		newSet := parser.NewExactKeySet
+
+		if bits := o.DuplicateKeyFilterBits; bits > 0 {
+			newSet = func() parser.KeySet { return parser.NewBloomKeySet(bits) }
+		}
+
+		opts = append(opts, parser.WithDuplicateKeyCheck(newSet))
+	}
+
+	if o.PreserveComments {
+		opts = append(opts, parser.WithPreserveComments())
+	} else if o.AllowComments {
+		opts = append(opts, parser.WithAllowComments())
+	}
+
+	if o.AllowTrailingCommas {
+		opts = append(opts, parser.WithAllowTrailingCommas())
+	}
+
+	if o.BigNumbers || o.NumberPolicy == NumberString || o.NumberPolicy == NumberBig {
+		opts = append(opts, parser.WithBigNumbers())
+	}
+
+	if o.InvalidUTF8 != parser.InvalidUTF8Replace {
+		opts = append(opts, parser.WithInvalidUTF8Policy(o.InvalidUTF8))
+	}
+
+	limits := parser.Limits{
+		MaxDepth:         o.MaxDepth,
+		MaxStringLength:  o.MaxStringLength,
+		MaxArrayElements: o.MaxArrayElements,
+		MaxObjectKeys:    o.MaxObjectKeys,
+	}
+
+	if limits != (parser.Limits{}) {
+		opts = append(opts, parser.WithLimits(limits))
+	}
+
+	if o.DecodeTimeout > 0 {
+		// The options are translated when a parse starts; NewDecoder checks the context
+		// of each Decode call instead
+		deadline := time.Now().Add(o.DecodeTimeout)
+
+		opts = append(opts, parser.WithInterrupt(func() error {
+			if time.Now().After(deadline) {
+				return context.DeadlineExceeded
+			}
+
+			return nil
+		}))
+	}
+
+	return opts
+}
+
 // applyOptions applies the given options to the default options
 func applyOptions(opts ...Option) (*Options, error) {
 	options := defaultOptions()
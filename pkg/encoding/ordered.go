@@ -0,0 +1,105 @@
+package encoding
+
+import (
+	"reflect"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// OrderedMap is a JSON object that keeps the order of its members. WithOrderedMaps makes
+// objects decoded into interface{} OrderedMaps instead of map[string]interface{}, and
+// Marshal writes the members of an OrderedMap in order.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: map[string]interface{}{}}
+}
+
+// Len returns the number of members of m.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys of m in order.
+func (m *OrderedMap) Keys() []string {
+	return append([]string(nil), m.keys...)
+}
+
+// Get returns the value of key and whether m has it.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	value, ok := m.values[key]
+
+	return value, ok
+}
+
+// Set sets the value of key, which is added after the other keys if m does not have it.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+// Delete removes key from m.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+
+			break
+		}
+	}
+}
+
+var orderedMapType = reflect.TypeOf(OrderedMap{})
+
+// marshalOrderedMap writes the members of m in order.
+func (m *marshalState) marshalOrderedMap(om *OrderedMap) (parser.Value, error) {
+	obj := parser.NewObject()
+
+	for _, k := range om.keys {
+		value, ok, err := m.member(k, reflect.ValueOf(om.values[k]))
+		if err != nil {
+			return nil, withSegment(keySegment(k), err)
+		}
+
+		if ok {
+			obj.Set(k, value)
+		}
+	}
+
+	return obj, nil
+}
+
+// unmarshalOrderedMap decodes obj into a new *OrderedMap stored in the interface rv.
+func (d *decodeState) unmarshalOrderedMap(obj *parser.Object, rv reflect.Value) error {
+	om := NewOrderedMap()
+
+	for _, k := range obj.OrderedKeys() {
+		var value interface{}
+		if err := d.unmarshalChild(keySegment(k), obj.Pairs[k], reflect.ValueOf(&value).Elem()); err != nil {
+			return err
+		}
+
+		om.Set(k, value)
+	}
+
+	rv.Set(reflect.ValueOf(om))
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package encoding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestOrderedMaps(t *testing.T) {
+	input := `{"z":1,"a":{"y":true,"b":null},"m":[{"k":"v"}]}`
+
+	var v interface{}
+	if err := encoding.Unmarshal([]byte(input), &v, encoding.WithOrderedMaps()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	om, ok := v.(*encoding.OrderedMap)
+	if !ok {
+		t.Fatalf("Expected an *OrderedMap, got %T", v)
+	}
+
+	if keys := om.Keys(); !reflect.DeepEqual(keys, []string{"z", "a", "m"}) {
+		t.Errorf("Expected keys in input order, got %v", keys)
+	}
+
+	data, err := encoding.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(data) != input {
+		t.Errorf("Expected %s, got %s", input, data)
+	}
+
+	om.Delete("a")
+	om.Set("b", "new")
+
+	if keys := om.Keys(); !reflect.DeepEqual(keys, []string{"z", "m", "b"}) {
+		t.Errorf("Expected keys [z m b], got %v", keys)
+	}
+}
+
+func TestMapAndSliceType(t *testing.T) {
+	type object map[string]interface{}
+	type list []interface{}
+
+	var v interface{}
+
+	err := encoding.Unmarshal([]byte(`{"a":[{"b":1}]}`), &v,
+		encoding.WithMapType(reflect.TypeOf(object{})), encoding.WithSliceType(reflect.TypeOf(list{})))
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	expected := object{"a": list{object{"b": int64(1)}}}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, v)
+	}
+
+	for _, opt := range []encoding.Option{
+		encoding.WithMapType(reflect.TypeOf(map[int]interface{}{})),
+		encoding.WithSliceType(reflect.TypeOf([2]int{})),
+		encoding.WithMapType(nil),
+	} {
+		if err := encoding.Unmarshal([]byte(`{}`), &v, opt); err == nil {
+			t.Error("Expected an invalid options error")
+		}
+	}
+}
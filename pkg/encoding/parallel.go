@@ -0,0 +1,242 @@
+package encoding
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// parallelJob is one raw value handed from the splitter to the workers.
+type parallelJob struct {
+	seq    int
+	offset int64
+	data   []byte
+}
+
+// parallelResult is a decoded value waiting to be handed to the handler in input order.
+type parallelResult struct {
+	seq int
+	v   interface{}
+	err error
+}
+
+// parallelDecoder holds the state shared by the goroutines of DecodeParallel.
+type parallelDecoder struct {
+	options *Options
+	handler func(v interface{}) error
+	// window bounds the values in flight, so that a slow value does not make the
+	// decoded values behind it pile up when the order is preserved
+	window chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// DecodeParallel decodes a stream of JSON values read from r, such as NDJSON, on a pool of
+// workers goroutines and calls handler with each value decoded into an interface{}. One
+// goroutine finds the value boundaries with a parser.Splitter, so only the decoding runs
+// in parallel. With WithArrayElements the input is a single top-level array whose
+// elements are decoded instead.
+//
+// By default handler is called concurrently from the workers, in no particular order.
+// With WithPreserveOrder it is called from the calling goroutine in input order. The first
+// error, from the input or from handler, stops decoding and is returned; values decoded
+// before it may not all have been handed to handler. Each value must be an object or an
+// array and is checked against MaxSize.
+func DecodeParallel(r io.Reader, workers int, handler func(v interface{}) error, opts ...Option) error {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return NewJSONError(ErrInvalidOptions, "invalid options configuration").
+			WithCause(err)
+	}
+
+	if workers <= 0 {
+		return NewJSONError(ErrInvalidOptions, fmt.Sprintf("workers must be positive, got %d", workers))
+	}
+
+	s := parser.NewSplitter(r)
+
+	if options.ArrayElements {
+		if err := s.EnterArray(); err != nil {
+			return NewJSONError(ErrInvalidJSON, "failed to split JSON stream").WithCause(err)
+		}
+	}
+
+	d := &parallelDecoder{
+		options: options,
+		handler: handler,
+		window:  make(chan struct{}, 4*workers),
+		done:    make(chan struct{}),
+	}
+
+	jobs := make(chan parallelJob, workers)
+	go d.split(s, jobs)
+
+	var results chan parallelResult
+	if options.PreserveOrder {
+		results = make(chan parallelResult, workers)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			d.work(jobs, results)
+		}()
+	}
+
+	if results == nil {
+		wg.Wait()
+		return d.err
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	d.collect(results)
+
+	return d.err
+}
+
+// fail records the first error and stops all goroutines.
+func (d *parallelDecoder) fail(err error) {
+	d.once.Do(func() {
+		d.err = err
+		close(d.done)
+	})
+}
+
+// split sends the raw values found by s to the workers and closes jobs at the end.
+func (d *parallelDecoder) split(s *parser.Splitter, jobs chan<- parallelJob) {
+	defer close(jobs)
+
+	for seq := 0; s.Next(); seq++ {
+		select {
+		case d.window <- struct{}{}:
+		case <-d.done:
+			return
+		}
+
+		job := parallelJob{seq: seq, offset: s.Offset(), data: append([]byte(nil), s.Bytes()...)}
+
+		select {
+		case jobs <- job:
+		case <-d.done:
+			return
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		d.fail(NewJSONError(ErrInvalidJSON, "failed to split JSON stream").WithCause(err))
+	}
+}
+
+// work decodes jobs until there are none left or decoding stopped. Without results the
+// handler is called right away.
+func (d *parallelDecoder) work(jobs <-chan parallelJob, results chan<- parallelResult) {
+	for job := range jobs {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+
+		v, err := d.decode(job)
+
+		if results != nil {
+			select {
+			case results <- parallelResult{seq: job.seq, v: v, err: err}:
+			case <-d.done:
+				return
+			}
+
+			continue
+		}
+
+		if err == nil {
+			err = d.handler(v)
+		}
+
+		if err != nil {
+			d.fail(err)
+			return
+		}
+
+		<-d.window
+	}
+}
+
+// collect calls the handler with the results in input order.
+func (d *parallelDecoder) collect(results <-chan parallelResult) {
+	pending := make(map[int]parallelResult)
+	next := 0
+
+	for res := range results {
+		pending[res.seq] = res
+
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			next++
+
+			if r.err == nil {
+				r.err = d.handler(r.v)
+			}
+
+			if r.err != nil {
+				d.fail(r.err)
+
+				// Let the workers finish so that results gets closed
+				for range results {
+				}
+
+				return
+			}
+
+			<-d.window
+		}
+	}
+}
+
+// decode decodes one raw value into an interface{}. Syntax error offsets are made
+// relative to the whole input.
+func (d *parallelDecoder) decode(job parallelJob) (interface{}, error) {
+	var v interface{}
+
+	err := d.unmarshal(job.data, &v)
+	if jsonErr, ok := err.(*JSONError); ok && jsonErr.Code == ErrInvalidJSON {
+		jsonErr.Offset += job.offset
+	}
+
+	return v, stats.recordDecode(len(job.data), err)
+}
+
+// unmarshal implements Unmarshal with already validated options.
+func (d *parallelDecoder) unmarshal(data []byte, v *interface{}) error {
+	if !d.options.DisableSizeLimit && len(data) > d.options.MaxSize {
+		return NewSizeExceededError(len(data), d.options.MaxSize)
+	}
+
+	value, err := parseBytes(data, d.options)
+	if err != nil {
+		return err
+	}
+
+	if err := newDecodeState(d.options).decode(value, reflect.ValueOf(v).Elem()); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package encoding_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func ndjson(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "{\"i\": %d, \"tags\": [\"a\", \"b\"]}\n", i)
+	}
+
+	return b.String()
+}
+
+func TestDecodeParallel(t *testing.T) {
+	const n = 1000
+
+	t.Run("Preserve order", func(t *testing.T) {
+		var got []int64
+
+		err := encoding.DecodeParallel(strings.NewReader(ndjson(n)), 8, func(v interface{}) error {
+			got = append(got, v.(map[string]interface{})["i"].(int64))
+			return nil
+		}, encoding.WithPreserveOrder())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for i, v := range got {
+			if v != int64(i) {
+				t.Fatalf("Expected value %d at position %d, got %d", i, i, v)
+			}
+		}
+
+		if len(got) != n {
+			t.Errorf("Expected %d values, got %d", n, len(got))
+		}
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		var (
+			mu   sync.Mutex
+			seen = make(map[int64]bool)
+		)
+
+		err := encoding.DecodeParallel(strings.NewReader(ndjson(n)), 4, func(v interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			seen[v.(map[string]interface{})["i"].(int64)] = true
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(seen) != n {
+			t.Errorf("Expected %d distinct values, got %d", n, len(seen))
+		}
+	})
+
+	t.Run("Array elements", func(t *testing.T) {
+		var got []interface{}
+
+		err := encoding.DecodeParallel(strings.NewReader(` [{"a": 1}, [2], {"c": [3]}] `), 2, func(v interface{}) error {
+			got = append(got, v)
+			return nil
+		}, encoding.WithArrayElements(), encoding.WithPreserveOrder())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(got) != 3 {
+			t.Errorf("Expected 3 elements, got %v", got)
+		}
+	})
+}
+
+func TestDecodeParallelErrors(t *testing.T) {
+	handle := func(interface{}) error { return nil }
+
+	err := encoding.DecodeParallel(strings.NewReader(`{}`), 0, handle)
+	checkJSONError(t, err, encoding.ErrInvalidOptions, "workers must be positive")
+
+	err = encoding.DecodeParallel(strings.NewReader("{\"a\": 1}\n{\"a\": }\n"), 2, handle, encoding.WithPreserveOrder())
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+
+	if jsonErr, ok := err.(*encoding.JSONError); ok && jsonErr.Offset != 15 {
+		t.Errorf("Expected the error at input offset 15, got %d", jsonErr.Offset)
+	}
+
+	err = encoding.DecodeParallel(strings.NewReader(`{"a": 1} {"b": [`), 2, handle)
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "failed to split JSON stream")
+
+	err = encoding.DecodeParallel(strings.NewReader(`[{"a": 1}`), 2, handle, encoding.WithArrayElements())
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "failed to split JSON stream")
+
+	errStop := errors.New("stop")
+
+	for _, opts := range [][]encoding.Option{nil, {encoding.WithPreserveOrder()}} {
+		err := encoding.DecodeParallel(strings.NewReader(ndjson(500)), 4, func(interface{}) error {
+			return errStop
+		}, opts...)
+		if !errors.Is(err, errStop) {
+			t.Errorf("Expected the handler error, got %v", err)
+		}
+	}
+}
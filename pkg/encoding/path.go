@@ -0,0 +1,191 @@
+package encoding
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// pathSegment is a single step of a path into a document: an object key or an array index.
+// In path patterns a segment can also be a wildcard matching any key or any index.
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// keySegment returns a segment addressing an object member.
+func keySegment(key string) pathSegment {
+	return pathSegment{key: key}
+}
+
+// indexSegment returns a segment addressing an array element.
+func indexSegment(i int) pathSegment {
+	return pathSegment{index: i, isIndex: true}
+}
+
+// parsePath parses a dotted path such as "a.b[2].c". In patterns, "*" matches any object key
+// and "[*]" matches any array index.
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var segments []pathSegment
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			if i == 0 || i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, fmt.Errorf("invalid path %q: empty key at offset %d", path, i)
+			}
+
+			i++
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated index at offset %d", path, i)
+			}
+
+			inner := path[i+1 : i+end]
+			if inner == "*" {
+				segments = append(segments, pathSegment{isIndex: true, wildcard: true})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid path %q: bad index %q", path, inner)
+				}
+
+				segments = append(segments, indexSegment(n))
+			}
+
+			i += end + 1
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+
+			key := path[i : i+end]
+			if key == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+			} else {
+				segments = append(segments, keySegment(key))
+			}
+
+			i += end
+		}
+	}
+
+	return segments, nil
+}
+
+// ParsePath parses a dotted path such as "a.b[2].c" into its object keys and array
+// indexes, as GetField and Index read it. The empty path addresses the root and has no
+// elements. Wildcards only make sense in patterns and are rejected.
+func ParsePath(path string) ([]parser.PathElement, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]parser.PathElement, len(segments))
+
+	for i, s := range segments {
+		if s.wildcard {
+			return nil, fmt.Errorf("invalid path %q: wildcards are not supported", path)
+		}
+
+		elements[i] = parser.PathElement{Key: s.key, Index: s.index, IsIndex: s.isIndex}
+	}
+
+	return elements, nil
+}
+
+// FormatPath formats elements back into a dotted path.
+func FormatPath(elements []parser.PathElement) string {
+	segments := make([]pathSegment, len(elements))
+
+	for i, el := range elements {
+		segments[i] = pathSegment{key: el.Key, index: el.Index, isIndex: el.IsIndex}
+	}
+
+	return formatPath(segments)
+}
+
+// matchPath reports whether the concrete path matches the pattern segment by segment.
+func matchPath(pattern, path []pathSegment) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+
+	for i, p := range pattern {
+		s := path[i]
+		if p.isIndex != s.isIndex {
+			return false
+		}
+
+		if p.wildcard {
+			continue
+		}
+
+		if p.isIndex && p.index != s.index || !p.isIndex && p.key != s.key {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatPath renders path segments in dotted notation.
+func formatPath(path []pathSegment) string {
+	var b strings.Builder
+
+	for i, s := range path {
+		switch {
+		case s.isIndex && s.wildcard:
+			b.WriteString("[*]")
+		case s.isIndex:
+			fmt.Fprintf(&b, "[%d]", s.index)
+		default:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+
+			if s.wildcard {
+				b.WriteByte('*')
+			} else {
+				b.WriteString(s.key)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// pointerPath renders path segments as a JSON Pointer (RFC 6901), e.g. "/items/2/name".
+func pointerPath(path []pathSegment) string {
+	var b strings.Builder
+
+	for _, s := range path {
+		b.WriteByte('/')
+
+		if s.isIndex {
+			b.WriteString(strconv.Itoa(s.index))
+		} else {
+			b.WriteString(pointerEscaper.Replace(s.key))
+		}
+	}
+
+	return b.String()
+}
+
+// pointerEscaper escapes the characters that have a special meaning in JSON Pointer tokens.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
@@ -0,0 +1,99 @@
+package encoding
+
+import (
+	"io"
+	"sync"
+)
+
+// DecoderPool is a pool of decoders that share one set of options, so that a server
+// decoding a value per request reuses their buffers, lexers and parsers instead of
+// creating them each time. Use one pool per set of options. It is safe for concurrent use.
+type DecoderPool struct {
+	options *Options
+	pool    sync.Pool
+}
+
+// NewDecoderPool creates a DecoderPool whose decoders are configured with opts.
+func NewDecoderPool(opts ...Option) (*DecoderPool, error) {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid decoder options").WithCause(err)
+	}
+
+	return &DecoderPool{options: options}, nil
+}
+
+// Get returns a decoder reading from r, reused from the pool when one is available. With
+// WithTransparentDecompression a failure to open the compressed input is returned by the
+// first call to the decoder.
+func (p *DecoderPool) Get(r io.Reader) ResettableDecoder {
+	d, ok := p.pool.Get().(*streamDecoder)
+	stats.recordPoolGet(ok)
+
+	if !ok {
+		d = newStreamDecoder(p.options)
+	}
+
+	d.Reset(r)
+
+	return d
+}
+
+// Put returns a decoder obtained from Get to the pool. The decoder must not be used
+// afterwards. Decoders from other pools, or whose buffer size was changed, are dropped.
+func (p *DecoderPool) Put(dec ResettableDecoder) {
+	d, ok := dec.(*streamDecoder)
+	if !ok || d.options != p.options || d.bufferSize != p.options.BufferSize {
+		return
+	}
+
+	// Release the input of the last request
+	d.Reset(eofReader{})
+	p.pool.Put(d)
+}
+
+// EncoderPool is a pool of encoders that share one set of options, so that a server
+// encoding a response per request reuses their buffers instead of creating them each
+// time. Use one pool per set of options. It is safe for concurrent use.
+type EncoderPool struct {
+	options *Options
+	pool    sync.Pool
+}
+
+// NewEncoderPool creates an EncoderPool whose encoders are configured with opts.
+func NewEncoderPool(opts ...Option) (*EncoderPool, error) {
+	options, err := applyOptions(opts...)
+	if err != nil {
+		return nil, NewJSONError(ErrInvalidOptions, "invalid encoder options").WithCause(err)
+	}
+
+	return &EncoderPool{options: options}, nil
+}
+
+// Get returns an encoder writing to w, reused from the pool when one is available.
+func (p *EncoderPool) Get(w io.Writer) ResettableEncoder {
+	e, ok := p.pool.Get().(*streamEncoder)
+	stats.recordPoolGet(ok)
+
+	if !ok {
+		return newStreamEncoder(w, p.options)
+	}
+
+	e.Reset(w)
+
+	return e
+}
+
+// Put returns an encoder obtained from Get to the pool. Output that was not flushed is
+// discarded, and the encoder must not be used afterwards. Encoders from other pools, or
+// whose buffer size was changed, are dropped.
+func (p *EncoderPool) Put(enc ResettableEncoder) {
+	e, ok := enc.(*streamEncoder)
+	if !ok || e.options != p.options || e.bufferSize != p.options.BufferSize {
+		return
+	}
+
+	e.SetIndent("", "")
+	e.Reset(io.Discard)
+	p.pool.Put(e)
+}
@@ -0,0 +1,75 @@
+package encoding_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestDecoderPool(t *testing.T) {
+	pool, err := encoding.NewDecoderPool(encoding.WithAllowComments())
+	if err != nil {
+		t.Fatalf("NewDecoderPool failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				dec := pool.Get(strings.NewReader(fmt.Sprintf(`/* c */ {"n": %d}`, i*100+j)))
+
+				var v struct {
+					N int `json:"n"`
+				}
+
+				if err := dec.Decode(&v); err != nil || v.N != i*100+j {
+					t.Errorf("Expected %d, got %d (%v)", i*100+j, v.N, err)
+				}
+
+				pool.Put(dec)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if _, err := encoding.NewDecoderPool(encoding.WithBufferSize(-1)); err == nil {
+		t.Error("Expected an error for invalid options")
+	}
+}
+
+func TestEncoderPool(t *testing.T) {
+	pool, err := encoding.NewEncoderPool(encoding.WithIndent("", " "))
+	if err != nil {
+		t.Fatalf("NewEncoderPool failed: %v", err)
+	}
+
+	var first, second bytes.Buffer
+
+	enc := pool.Get(&first)
+	if err := enc.Encode([]int{1}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	pool.Put(enc)
+
+	enc = pool.Get(&second)
+	if err := enc.Encode([]int{2}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	pool.Put(enc)
+
+	if first.String() != "[\n 1\n]\n" || second.String() != "[\n 2\n]\n" {
+		t.Errorf("Unexpected output: %q %q", first.String(), second.String())
+	}
+}
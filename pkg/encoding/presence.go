@@ -0,0 +1,36 @@
+package encoding
+
+import (
+	"reflect"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Presence records the keys of the JSON object a struct was decoded from, so that a PATCH
+// handler can tell a member that was left out from one explicitly set to null or to a
+// zero value. An exported field of this type, usually tagged `json:"-"`, is filled by
+// Unmarshal and never encoded:
+//
+//	type UserPatch struct {
+//		Email   string            `json:"email"`
+//		Present encoding.Presence `json:"-"`
+//	}
+type Presence map[string]bool
+
+// Has reports whether key was a member of the input object.
+func (p Presence) Has(key string) bool {
+	return p[key]
+}
+
+var presenceType = reflect.TypeOf(Presence(nil))
+
+// newPresence returns the Presence of the members of obj.
+func newPresence(obj *parser.Object) Presence {
+	p := make(Presence, len(obj.Pairs))
+
+	for k := range obj.Pairs {
+		p[k] = true
+	}
+
+	return p
+}
@@ -0,0 +1,36 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestPresence(t *testing.T) {
+	type patch struct {
+		Name    string            `json:"name"`
+		Email   interface{}       `json:"email"`
+		Age     int               `json:"age"`
+		Present encoding.Presence `json:"-"`
+	}
+
+	var p patch
+	if err := encoding.Unmarshal([]byte(`{"email": null, "age": 0}`), &p, encoding.WithDisallowUnknownFields()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	for key, expected := range map[string]bool{"name": false, "email": true, "age": true} {
+		if p.Present.Has(key) != expected {
+			t.Errorf("Expected Has(%q) to be %v", key, expected)
+		}
+	}
+
+	data, err := encoding.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(data) != `{"name":"","email":null,"age":0}` {
+		t.Errorf("Expected the presence field to be omitted, got %s", data)
+	}
+}
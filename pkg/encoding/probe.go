@@ -0,0 +1,139 @@
+package encoding
+
+import (
+	"io"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// recordSeparator is the ASCII RS byte that prefixes every record in a JSON text sequence
+// (RFC 7464), passed to parser.Scanner.SkipSeparators.
+const recordSeparator = "\x1e"
+
+// Kind identifies the JSON type of a value
+type Kind string
+
+const (
+	KindInvalid Kind = "invalid"
+	KindObject  Kind = "object"
+	KindArray   Kind = "array"
+	KindString  Kind = "string"
+	KindNumber  Kind = "number"
+	KindBool    Kind = "boolean"
+	KindNull    Kind = "null"
+)
+
+// Format identifies how top-level values are framed in an input stream
+type Format string
+
+const (
+	// FormatJSON is a single JSON document
+	FormatJSON Format = "json"
+	// FormatNDJSON is newline-delimited JSON, one value per line
+	FormatNDJSON Format = "ndjson"
+	// FormatJSONSeq is a JSON text sequence where every value is prefixed by an RS byte (RFC 7464)
+	FormatJSONSeq Format = "json-seq"
+	// FormatConcatenated is several values written back to back without newlines
+	FormatConcatenated Format = "concatenated"
+)
+
+// ProbeResult describes the shape of an input as reported by Probe
+type ProbeResult struct {
+	// Kind is the kind of the first top-level value
+	Kind Kind
+
+	// Elements is the number of array elements or object members of the first top-level value
+	Elements int
+
+	// Values is the number of top-level values in the input
+	Values int
+
+	// Format is the detected framing of the top-level values
+	Format Format
+
+	// Bytes is the number of bytes consumed from the reader
+	Bytes int64
+}
+
+// Probe cheaply inspects r and reports the kind of its first top-level value, its element
+// count and the framing of the input. It only tracks nesting and string boundaries, so the
+// input is consumed in constant memory but is not validated; counts for malformed input are
+// approximate.
+func Probe(r io.Reader) (ProbeResult, error) {
+	s := parser.NewScanner(r)
+
+	_, rs, err := s.SkipSeparators(recordSeparator)
+	if err == io.EOF {
+		return ProbeResult{}, NewJSONError(ErrInvalidJSON, "empty input")
+	}
+
+	if err != nil {
+		return ProbeResult{}, NewJSONError(ErrInvalidJSON, "failed to read input").WithCause(err)
+	}
+
+	c, _ := s.Peek()
+
+	elements, err := s.ScanValue()
+	if err != nil {
+		return ProbeResult{}, NewJSONError(ErrInvalidJSON, "failed to scan value").WithCause(err)
+	}
+
+	result := ProbeResult{
+		Kind:     kindOf(c),
+		Elements: elements,
+		Values:   1,
+		Format:   FormatJSON,
+	}
+
+	if rs {
+		result.Format = FormatJSONSeq
+	}
+
+	for {
+		newline, _, err := s.SkipSeparators(recordSeparator)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return result, NewJSONError(ErrInvalidJSON, "failed to read input").WithCause(err)
+		}
+
+		if result.Format == FormatJSON {
+			result.Format = FormatConcatenated
+			if newline {
+				result.Format = FormatNDJSON
+			}
+		}
+
+		if _, err := s.ScanValue(); err != nil {
+			return result, NewJSONError(ErrInvalidJSON, "failed to scan value").WithCause(err)
+		}
+
+		result.Values++
+	}
+
+	result.Bytes = s.Offset()
+
+	return result, nil
+}
+
+// kindOf returns the kind of the value starting with c.
+func kindOf(c byte) Kind {
+	switch {
+	case c == '{':
+		return KindObject
+	case c == '[':
+		return KindArray
+	case c == '"':
+		return KindString
+	case c == 't' || c == 'f':
+		return KindBool
+	case c == 'n':
+		return KindNull
+	case c == '-' || ('0' <= c && c <= '9'):
+		return KindNumber
+	default:
+		return KindInvalid
+	}
+}
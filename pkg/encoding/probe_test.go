@@ -0,0 +1,113 @@
+package encoding_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		kind     encoding.Kind
+		elements int
+		values   int
+		format   encoding.Format
+	}{
+		{
+			name:     "Array of objects",
+			input:    `[{"a": [1, 2]}, {"b": "x,y"}, 3]`,
+			kind:     encoding.KindArray,
+			elements: 3,
+			values:   1,
+			format:   encoding.FormatJSON,
+		},
+		{
+			name:     "Empty array",
+			input:    ` [ ] `,
+			kind:     encoding.KindArray,
+			elements: 0,
+			values:   1,
+			format:   encoding.FormatJSON,
+		},
+		{
+			name:     "Object members",
+			input:    `{"a": 1, "b": {"c": 2}, "d\"": [3]}`,
+			kind:     encoding.KindObject,
+			elements: 3,
+			values:   1,
+			format:   encoding.FormatJSON,
+		},
+		{
+			name:   "Scalar",
+			input:  `-12.5e3`,
+			kind:   encoding.KindNumber,
+			values: 1,
+			format: encoding.FormatJSON,
+		},
+		{
+			name:     "NDJSON",
+			input:    "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n",
+			kind:     encoding.KindObject,
+			elements: 1,
+			values:   3,
+			format:   encoding.FormatNDJSON,
+		},
+		{
+			name:     "JSON text sequence",
+			input:    "\x1e[1]\n\x1e[2, 3]\n",
+			kind:     encoding.KindArray,
+			elements: 1,
+			values:   2,
+			format:   encoding.FormatJSONSeq,
+		},
+		{
+			name:   "Concatenated",
+			input:  `"a""b" true`,
+			kind:   encoding.KindString,
+			values: 3,
+			format: encoding.FormatConcatenated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := encoding.Probe(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result.Kind != tt.kind {
+				t.Errorf("Kind = %q, want %q", result.Kind, tt.kind)
+			}
+
+			if result.Elements != tt.elements {
+				t.Errorf("Elements = %d, want %d", result.Elements, tt.elements)
+			}
+
+			if result.Values != tt.values {
+				t.Errorf("Values = %d, want %d", result.Values, tt.values)
+			}
+
+			if result.Format != tt.format {
+				t.Errorf("Format = %q, want %q", result.Format, tt.format)
+			}
+		})
+	}
+}
+
+func TestProbeErrors(t *testing.T) {
+	inputs := []string{"", "   ", `[1, 2`, `{"a": "b`, `?`}
+
+	for _, input := range inputs {
+		_, err := encoding.Probe(strings.NewReader(input))
+		if err == nil {
+			t.Errorf("Probe(%q): expected error but got none", input)
+			continue
+		}
+
+		checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+	}
+}
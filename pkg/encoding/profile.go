@@ -0,0 +1,20 @@
+package encoding
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// ProfileLabel is the pprof label set by WithProfileLabels. Its value is the phase being
+// run: "parse", "decode" or "encode".
+const ProfileLabel = "jingo"
+
+// profilePhase labels the current goroutine with phase for CPU profiles and returns a
+// function that removes the label again.
+func profilePhase(phase string) func() {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels(ProfileLabel, phase)))
+
+	return func() {
+		pprof.SetGoroutineLabels(context.Background())
+	}
+}
@@ -0,0 +1,310 @@
+package encoding
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// protoWellKnown lists the Go fields of the well-known types written in a special form
+// by WithProtoJSON. They are recognized by their type name and fields, so that this
+// package does not depend on the protobuf module.
+var protoWellKnown = map[string]string{
+	"Timestamp":   "Seconds,Nanos",
+	"Duration":    "Seconds,Nanos",
+	"Struct":      "Fields",
+	"Value":       "Kind",
+	"ListValue":   "Values",
+	"FieldMask":   "Paths",
+	"Any":         "TypeUrl,Value",
+	"DoubleValue": "Value",
+	"FloatValue":  "Value",
+	"Int64Value":  "Value",
+	"UInt64Value": "Value",
+	"Int32Value":  "Value",
+	"UInt32Value": "Value",
+	"BoolValue":   "Value",
+	"StringValue": "Value",
+	"BytesValue":  "Value",
+}
+
+// protoValue converts v following the protobuf JSON mapping and reports whether the
+// mapping differs from the default one for v.
+func (m *marshalState) protoValue(v reflect.Value) (parser.Value, bool, error) {
+	switch v.Kind() {
+	case reflect.Int64:
+		return parser.NewString(strconv.FormatInt(v.Int(), 10)), true, nil
+	case reflect.Uint64:
+		return parser.NewString(strconv.FormatUint(v.Uint(), 10)), true, nil
+	case reflect.Uint32:
+		return parser.NewNumber(int64(v.Uint())), true, nil
+	case reflect.Int32:
+		if isProtoEnum(v.Type()) {
+			return protoEnum(v), true, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return parser.NewString(protoNonFinite(f)), true, nil
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return parser.NewString(base64.StdEncoding.EncodeToString(v.Bytes())), true, nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			value, err := m.protoMap(v)
+			return value, true, err
+		}
+	case reflect.Struct:
+		if isProtoMessage(v.Type()) {
+			value, err := m.protoMessage(v)
+			return value, true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// isProtoMessage reports whether t is a struct generated for a protobuf message.
+func isProtoMessage(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		if tag.Get("protobuf") != "" || tag.Get("protobuf_oneof") != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProtoEnum reports whether t is a type generated for a protobuf enum, which has a
+// String and a Number method.
+func isProtoEnum(t reflect.Type) bool {
+	_, hasNumber := t.MethodByName("Number")
+
+	return hasNumber && t.Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+}
+
+// protoEnum returns the name of an enum value, or its number when it has no name. The
+// NullValue enum of google.protobuf.Value is null.
+func protoEnum(v reflect.Value) parser.Value {
+	if v.Type().Name() == "NullValue" {
+		return parser.NewNull()
+	}
+
+	name := v.Interface().(fmt.Stringer).String()
+	if name == strconv.FormatInt(v.Int(), 10) {
+		return parser.NewNumber(v.Int())
+	}
+
+	return parser.NewString(name)
+}
+
+// protoMap converts a map with non-string keys, whose keys are written as strings.
+func (m *marshalState) protoMap(v reflect.Value) (parser.Value, error) {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+
+	for iter := v.MapRange(); iter.Next(); {
+		key := fmt.Sprint(iter.Key().Interface())
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+
+	sort.Strings(keys)
+
+	obj := parser.NewObject()
+
+	for _, key := range keys {
+		value, ok, err := m.member(key, values[key])
+		if err != nil {
+			return nil, withSegment(keySegment(key), err)
+		}
+
+		if ok {
+			obj.Set(key, value)
+		}
+	}
+
+	return obj, nil
+}
+
+// protoFields returns the names of the Go fields of t that hold message fields.
+func protoFields(t reflect.Type) string {
+	var names []string
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		if tag.Get("protobuf") != "" || tag.Get("protobuf_oneof") != "" {
+			names = append(names, t.Field(i).Name)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+// protoName returns the JSON name of a field from its protobuf tag.
+func protoName(tag string) string {
+	name := ""
+
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "json=") {
+			return part[len("json="):]
+		}
+
+		if strings.HasPrefix(part, "name=") {
+			name = part[len("name="):]
+		}
+	}
+
+	return name
+}
+
+// protoMessage converts a generated message: the fields that are set, by their JSON
+// name, or the special form of a well-known type.
+func (m *marshalState) protoMessage(v reflect.Value) (parser.Value, error) {
+	t := v.Type()
+
+	if fields, ok := protoWellKnown[t.Name()]; ok && protoFields(t) == fields {
+		return m.protoWellKnown(v)
+	}
+
+	obj := parser.NewObject()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+
+		if field.Tag.Get("protobuf_oneof") != "" {
+			if fv.IsNil() {
+				continue
+			}
+
+			// The interface holds a pointer to a wrapper struct with the field that is set
+			wrapper := fv.Elem().Elem()
+			field, fv = wrapper.Type().Field(0), wrapper.Field(0)
+		} else if field.Tag.Get("protobuf") == "" || fv.IsZero() || isEmptyCollection(fv) {
+			continue
+		}
+
+		name := protoName(field.Tag.Get("protobuf"))
+
+		value, ok, err := m.member(name, fv)
+		if err != nil {
+			return nil, withSegment(keySegment(name), err)
+		}
+
+		if ok {
+			obj.Set(name, value)
+		}
+	}
+
+	return obj, nil
+}
+
+// isEmptyCollection reports whether v is an empty slice or map.
+func isEmptyCollection(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0
+}
+
+// protoWellKnown converts a well-known type.
+func (m *marshalState) protoWellKnown(v reflect.Value) (parser.Value, error) {
+	switch v.Type().Name() {
+	case "Timestamp":
+		seconds, nanos := v.FieldByName("Seconds").Int(), v.FieldByName("Nanos").Int()
+		t := time.Unix(seconds, 0).UTC()
+
+		if t.Year() < 1 || t.Year() > 9999 || nanos < 0 || nanos > 999999999 {
+			return nil, fmt.Errorf("timestamp %d.%09d is out of range", seconds, nanos)
+		}
+
+		return parser.NewString(t.Format("2006-01-02T15:04:05") + protoFraction(nanos) + "Z"), nil
+	case "Duration":
+		seconds, nanos := v.FieldByName("Seconds").Int(), v.FieldByName("Nanos").Int()
+		if seconds < 0 && nanos > 0 || seconds > 0 && nanos < 0 || nanos <= -1e9 || nanos >= 1e9 {
+			return nil, fmt.Errorf("duration %ds %dns has inconsistent signs or nanos out of range", seconds, nanos)
+		}
+
+		sign := ""
+		if seconds < 0 || nanos < 0 {
+			sign = "-"
+		}
+
+		return parser.NewString(fmt.Sprintf("%s%d%ss", sign, abs(seconds), protoFraction(abs(nanos)))), nil
+	case "Struct":
+		return m.protoValueOf(v.FieldByName("Fields"))
+	case "ListValue":
+		return m.protoValueOf(v.FieldByName("Values"))
+	case "Value":
+		kind := v.FieldByName("Kind")
+		if kind.IsNil() {
+			return parser.NewNull(), nil
+		}
+
+		return m.protoValueOf(kind.Elem().Elem().Field(0))
+	case "FieldMask":
+		paths := v.FieldByName("Paths")
+		camel := make([]string, paths.Len())
+
+		for i := range camel {
+			camel[i] = CamelCase.Convert(paths.Index(i).String())
+		}
+
+		return parser.NewString(strings.Join(camel, ",")), nil
+	case "Any":
+		return nil, fmt.Errorf("google.protobuf.Any is not supported")
+	default:
+		// The wrappers are written as the value they wrap
+		return m.protoValueOf(v.FieldByName("Value"))
+	}
+}
+
+// protoValueOf converts a field of a well-known type, where a nil message is null.
+func (m *marshalState) protoValueOf(v reflect.Value) (parser.Value, error) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return parser.NewNull(), nil
+	}
+
+	return m.value(v)
+}
+
+// protoFraction returns the fractional seconds of nanos with 0, 3, 6 or 9 digits.
+func protoFraction(nanos int64) string {
+	if nanos == 0 {
+		return ""
+	}
+
+	s := fmt.Sprintf("%09d", nanos)
+	for len(s) > 3 && strings.HasSuffix(s, "000") {
+		s = s[:len(s)-3]
+	}
+
+	return "." + s
+}
+
+// protoNonFinite returns the string form of an infinite or NaN float.
+func protoNonFinite(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return "NaN"
+	}
+}
+
+// abs returns the absolute value of n.
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,186 @@
+package encoding_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// The types below have the shape of the code protoc-gen-go generates.
+
+type Status int32
+
+func (s Status) String() string {
+	switch s {
+	case 0:
+		return "STATUS_UNSPECIFIED"
+	case 1:
+		return "STATUS_ACTIVE"
+	default:
+		return strconv.Itoa(int(s))
+	}
+}
+
+func (s Status) Number() int32 { return int32(s) }
+
+type NullValue int32
+
+func (NullValue) String() string { return "NULL_VALUE" }
+func (NullValue) Number() int32  { return 0 }
+
+type Timestamp struct {
+	Seconds int64 `protobuf:"varint,1,opt,name=seconds,proto3" json:"seconds,omitempty"`
+	Nanos   int32 `protobuf:"varint,2,opt,name=nanos,proto3" json:"nanos,omitempty"`
+}
+
+type Duration struct {
+	Seconds int64 `protobuf:"varint,1,opt,name=seconds,proto3" json:"seconds,omitempty"`
+	Nanos   int32 `protobuf:"varint,2,opt,name=nanos,proto3" json:"nanos,omitempty"`
+}
+
+type Struct struct {
+	Fields map[string]*Value `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+}
+
+type Value struct {
+	Kind isValue_Kind `protobuf_oneof:"kind"`
+}
+
+type isValue_Kind interface{ isValue_Kind() }
+
+type Value_NullValue struct {
+	NullValue NullValue `protobuf:"varint,1,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type Value_NumberValue struct {
+	NumberValue float64 `protobuf:"fixed64,2,opt,name=number_value,json=numberValue,proto3,oneof"`
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,3,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_ListValue struct {
+	ListValue *ListValue `protobuf:"bytes,6,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+func (*Value_NullValue) isValue_Kind()   {}
+func (*Value_NumberValue) isValue_Kind() {}
+func (*Value_StringValue) isValue_Kind() {}
+func (*Value_ListValue) isValue_Kind()   {}
+
+type ListValue struct {
+	Values []*Value `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+type Int64Value struct {
+	Value int64 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+type FieldMask struct {
+	Paths []string `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+}
+
+type Account struct {
+	AccountId uint64            `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Name      string            `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Status    Status            `protobuf:"varint,3,opt,name=status,proto3,enum=test.Status" json:"status,omitempty"`
+	History   []Status          `protobuf:"varint,4,rep,packed,name=history,proto3,enum=test.Status" json:"history,omitempty"`
+	Avatar    []byte            `protobuf:"bytes,5,opt,name=avatar,proto3" json:"avatar,omitempty"`
+	Balance   int64             `protobuf:"varint,6,opt,name=balance,proto3" json:"balance,omitempty"`
+	Ratio     float64           `protobuf:"fixed64,7,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Scores    map[int32]uint32  `protobuf:"bytes,8,rep,name=scores,proto3" json:"scores,omitempty"`
+	CreatedAt *Timestamp        `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Timeout   *Duration         `protobuf:"bytes,10,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Metadata  *Struct           `protobuf:"bytes,11,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Limit     *Int64Value       `protobuf:"bytes,12,opt,name=limit,proto3" json:"limit,omitempty"`
+	Mask      *FieldMask        `protobuf:"bytes,13,opt,name=mask,proto3" json:"mask,omitempty"`
+	Contact   isAccount_Contact `protobuf_oneof:"contact"`
+	Labels    map[string]string `protobuf:"bytes,15,rep,name=labels,proto3" json:"labels,omitempty"`
+}
+
+type isAccount_Contact interface{ isAccount_Contact() }
+
+type Account_Email struct {
+	Email string `protobuf:"bytes,14,opt,name=email,proto3,oneof"`
+}
+
+func (*Account_Email) isAccount_Contact() {}
+
+func TestMarshalProtoJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "Unset fields are omitted",
+			input:    &Account{},
+			expected: `{}`,
+		},
+		{
+			name: "Scalars and well-known types",
+			input: &Account{
+				AccountId: math.MaxUint64,
+				Name:      "ana",
+				Status:    1,
+				History:   []Status{0, 1, 7},
+				Avatar:    []byte("hi"),
+				Balance:   -5,
+				Ratio:     math.Inf(1),
+				Scores:    map[int32]uint32{2: 20, 1: 10},
+				CreatedAt: &Timestamp{Seconds: 1700000000, Nanos: 10000000},
+				Timeout:   &Duration{Seconds: -1, Nanos: -500000000},
+				Metadata: &Struct{Fields: map[string]*Value{
+					"n":    {Kind: &Value_NumberValue{NumberValue: 1.5}},
+					"none": {Kind: &Value_NullValue{}},
+					"list": {Kind: &Value_ListValue{ListValue: &ListValue{Values: []*Value{
+						{Kind: &Value_StringValue{StringValue: "x"}},
+					}}}},
+				}},
+				Limit:   &Int64Value{Value: 9},
+				Mask:    &FieldMask{Paths: []string{"user_id", "name"}},
+				Contact: &Account_Email{Email: ""},
+				Labels:  map[string]string{"team": "core"},
+			},
+			expected: `{"accountId":"18446744073709551615","name":"ana","status":"STATUS_ACTIVE",` +
+				`"history":["STATUS_UNSPECIFIED","STATUS_ACTIVE",7],"avatar":"aGk=","balance":"-5",` +
+				`"ratio":"Infinity","scores":{"1":10,"2":20},"createdAt":"2023-11-14T22:13:20.010Z",` +
+				`"timeout":"-1.500s","metadata":{"list":["x"],"n":1.5,"none":null},"limit":"9",` +
+				`"mask":"userId,name","email":"","labels":{"team":"core"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(tt.input, encoding.WithProtoJSON())
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestMarshalProtoJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{name: "Timestamp out of range", input: &Timestamp{Seconds: math.MaxInt64}},
+		{name: "Duration with mixed signs", input: &Duration{Seconds: 1, Nanos: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := encoding.Marshal(tt.input, encoding.WithProtoJSON()); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package encoding
+
+import "errors"
+
+// RawMessage is a raw encoded JSON value. It can be used to delay decoding part of a
+// document or to embed precomputed JSON when marshaling.
+type RawMessage []byte
+
+// MarshalJSON returns m as the JSON encoding of m.
+func (m RawMessage) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	return m, nil
+}
+
+// UnmarshalJSON sets *m to a copy of data.
+func (m *RawMessage) UnmarshalJSON(data []byte) error {
+	if m == nil {
+		return errors.New("RawMessage: UnmarshalJSON on nil pointer")
+	}
+
+	*m = append((*m)[0:0], data...)
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// maxSafeInteger is the largest integer a JavaScript number holds exactly, 2^53-1.
+const maxSafeInteger = 1<<53 - 1
+
+// quoteUnsafeInteger returns num as a string when it is an integer that a JavaScript
+// number cannot hold exactly, and num otherwise.
+func quoteUnsafeInteger(num *parser.NumberLiteral) parser.Value {
+	switch {
+	case num.IsInt && (num.Int > maxSafeInteger || num.Int < -maxSafeInteger),
+		num.IsBig && !strings.ContainsAny(num.Value, ".eE"):
+		return parser.NewString(num.String())
+	default:
+		return num
+	}
+}
+
+// unquoteInteger returns the number written in str, for an integer field decoded with
+// WithInt64AsString.
+func unquoteInteger(str *parser.StringLiteral, rv reflect.Value) (*parser.NumberLiteral, error) {
+	num := parser.NewNumberLiteral(parser.Token{Type: parser.TokenNumber, Literal: str.Value})
+	if strings.ContainsAny(str.Value, ".eE") || !num.IsValid && !num.IsBig {
+		return nil, NewJSONError(ErrInvalidValue, fmt.Sprintf("cannot unmarshal string %q into %v", str.Value, rv.Type()))
+	}
+
+	return num, nil
+}
+
+// isIntegerTarget reports whether rv holds an integer or a big.Int, or points to a big.Int.
+func isIntegerTarget(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return rv.Type() == bigIntType || rv.Type() == reflect.PointerTo(bigIntType)
+}
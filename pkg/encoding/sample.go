@@ -0,0 +1,89 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// SampleReader wraps a reader and yields only a prefix of its top-level values: the first
+// maxValues complete values, or as many complete values as fit in maxBytes. It is meant
+// for schema inference and previews of huge streams, since reading stops at the sample
+// boundary instead of consuming the whole input. The sample keeps the separators between
+// values, so an NDJSON input yields valid NDJSON.
+type SampleReader struct {
+	scanner   *parser.Scanner
+	maxValues int
+	values    int
+	pending   bytes.Buffer
+	scratch   bytes.Buffer
+	done      bool
+	err       error
+}
+
+// NewSampleReader creates a SampleReader. A maxValues or maxBytes of zero or less means no
+// limit on that dimension. Values are never split: a value that would cross maxBytes ends
+// the sample before it.
+func NewSampleReader(r io.Reader, maxValues int, maxBytes int64) *SampleReader {
+	s := &SampleReader{
+		scanner:   parser.NewScanner(r),
+		maxValues: maxValues,
+	}
+
+	if maxBytes > 0 {
+		s.scanner.SetLimit(maxBytes)
+	}
+
+	s.scanner.SetSink(&s.scratch)
+
+	return s
+}
+
+// Read implements io.Reader
+func (s *SampleReader) Read(p []byte) (int, error) {
+	for s.pending.Len() == 0 && !s.done {
+		s.fill()
+	}
+
+	if s.pending.Len() > 0 {
+		return s.pending.Read(p)
+	}
+
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	return 0, io.EOF
+}
+
+// Values returns the number of complete values sampled so far
+func (s *SampleReader) Values() int {
+	return s.values
+}
+
+// fill scans the next value and moves it to the pending output once it is complete.
+func (s *SampleReader) fill() {
+	if s.maxValues > 0 && s.values >= s.maxValues {
+		s.done = true
+		return
+	}
+
+	s.scratch.Reset()
+
+	_, _, err := s.scanner.SkipSeparators(recordSeparator)
+	if err == nil {
+		_, err = s.scanner.ScanValue()
+	}
+
+	switch err {
+	case nil:
+		s.pending.Write(s.scratch.Bytes())
+		s.values++
+	case io.EOF, parser.ErrScanLimit:
+		s.done = true
+	default:
+		s.done = true
+		s.err = NewJSONError(ErrInvalidJSON, "failed to sample value").WithCause(err)
+	}
+}
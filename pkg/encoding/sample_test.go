@@ -0,0 +1,91 @@
+package encoding_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestSampleReader(t *testing.T) {
+	ndjson := "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 3}\n{\"id\": 4}\n"
+
+	tests := []struct {
+		name      string
+		input     string
+		maxValues int
+		maxBytes  int64
+		expected  string
+		values    int
+	}{
+		{
+			name:      "First N values",
+			input:     ndjson,
+			maxValues: 2,
+			expected:  "{\"id\": 1}\n{\"id\": 2}",
+			values:    2,
+		},
+		{
+			name:     "Byte limit rounded down to a value boundary",
+			input:    ndjson,
+			maxBytes: 25,
+			expected: "{\"id\": 1}\n{\"id\": 2}",
+			values:   2,
+		},
+		{
+			name:      "Both limits",
+			input:     ndjson,
+			maxValues: 1,
+			maxBytes:  1000,
+			expected:  `{"id": 1}`,
+			values:    1,
+		},
+		{
+			name:     "Input smaller than limit",
+			input:    `[1, 2] "x" 3`,
+			maxBytes: 1000,
+			expected: `[1, 2] "x" 3`,
+			values:   3,
+		},
+		{
+			name:     "First value larger than limit",
+			input:    `{"big": "` + strings.Repeat("x", 100) + `"}`,
+			maxBytes: 10,
+			expected: "",
+			values:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := encoding.NewSampleReader(strings.NewReader(tt.input), tt.maxValues, tt.maxBytes)
+
+			data, err := io.ReadAll(sr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+
+			if sr.Values() != tt.values {
+				t.Errorf("Expected %d values, got %d", tt.values, sr.Values())
+			}
+		})
+	}
+}
+
+func TestSampleReaderInvalidInput(t *testing.T) {
+	sr := encoding.NewSampleReader(strings.NewReader(`{"a": 1} ?`), 0, 0)
+
+	data, err := io.ReadAll(sr)
+	if err == nil {
+		t.Fatal("Expected error for invalid input")
+	}
+
+	if string(data) != `{"a": 1}` {
+		t.Errorf("Expected the complete value before the error, got %q", data)
+	}
+}
@@ -0,0 +1,66 @@
+package encoding
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// SQLJSON stores a value of type T in a JSON database column. It implements
+// driver.Valuer and sql.Scanner with Marshal and Unmarshal, so the options given to
+// NewSQLJSON (size limits, duplicate key checks, ...) apply to database values as well.
+// A NULL column scans as Valid == false and a value that is not Valid is written as NULL.
+//
+//	col := encoding.NewSQLJSON(Settings{}, encoding.WithMaxSize(64*1024))
+//	err := db.QueryRow(`SELECT settings FROM users WHERE id = $1`, id).Scan(&col)
+type SQLJSON[T any] struct {
+	Data  T
+	Valid bool
+
+	opts []Option
+}
+
+// NewSQLJSON returns a valid SQLJSON holding data that encodes and decodes with opts
+func NewSQLJSON[T any](data T, opts ...Option) SQLJSON[T] {
+	return SQLJSON[T]{Data: data, Valid: true, opts: opts}
+}
+
+// Value implements driver.Valuer
+func (s SQLJSON[T]) Value() (driver.Value, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	return Marshal(s.Data, s.opts...)
+}
+
+// Scan implements sql.Scanner
+func (s *SQLJSON[T]) Scan(src interface{}) error {
+	var zero T
+
+	s.Data = zero
+
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		s.Valid = false
+
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return NewJSONError(ErrUnexpectedType, fmt.Sprintf("cannot scan %T into SQLJSON", src))
+	}
+
+	if err := Unmarshal(data, &s.Data, s.opts...); err != nil {
+		s.Valid = false
+
+		return err
+	}
+
+	s.Valid = true
+
+	return nil
+}
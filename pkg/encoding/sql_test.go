@@ -0,0 +1,76 @@
+package encoding_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type settings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags"`
+}
+
+var (
+	_ driver.Valuer = encoding.SQLJSON[settings]{}
+	_ sql.Scanner   = &encoding.SQLJSON[settings]{}
+)
+
+func TestSQLJSONRoundTrip(t *testing.T) {
+	in := encoding.NewSQLJSON(settings{Theme: "dark", Tags: []string{"a"}}, encoding.WithSortKeys())
+
+	value, err := in.Value()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(value.([]byte)) != `{"tags":["a"],"theme":"dark"}` {
+		t.Errorf("Unexpected column value: %s", value)
+	}
+
+	for _, src := range []interface{}{value, string(value.([]byte))} {
+		var out encoding.SQLJSON[settings]
+		if err := out.Scan(src); err != nil {
+			t.Fatalf("Unexpected scan error: %v", err)
+		}
+
+		if !out.Valid || out.Data.Theme != "dark" || len(out.Data.Tags) != 1 {
+			t.Errorf("Unexpected scanned value: %+v", out)
+		}
+	}
+}
+
+func TestSQLJSONNull(t *testing.T) {
+	out := encoding.NewSQLJSON(settings{Theme: "stale"})
+	if err := out.Scan(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if out.Valid || out.Data.Theme != "" {
+		t.Errorf("Expected NULL to reset the value, got %+v", out)
+	}
+
+	value, err := out.Value()
+	if err != nil || value != nil {
+		t.Errorf("Expected NULL column value, got %v, %v", value, err)
+	}
+}
+
+func TestSQLJSONScanErrors(t *testing.T) {
+	var out encoding.SQLJSON[settings]
+
+	err := out.Scan(42)
+	checkJSONError(t, err, encoding.ErrUnexpectedType, "cannot scan int")
+
+	strict := encoding.NewSQLJSON(settings{}, encoding.WithDuplicateKeyCheck())
+
+	if err := strict.Scan([]byte(`{"theme": "a", "theme": "b"}`)); err == nil {
+		t.Error("Expected duplicate key error")
+	}
+
+	if strict.Valid {
+		t.Error("Expected value to be invalid after a failed scan")
+	}
+}
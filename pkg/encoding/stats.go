@@ -0,0 +1,191 @@
+package encoding
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the package-level counters collected while EnableStats is in
+// effect. Documents and bytes count Marshal, MarshalIndent, Unmarshal and the stream
+// encoder and decoder.
+type Stats struct {
+	// DocumentsDecoded is the number of documents decoded successfully
+	DocumentsDecoded uint64
+	// DocumentsEncoded is the number of documents encoded successfully
+	DocumentsEncoded uint64
+	// BytesDecoded is the number of input bytes read by decoders
+	BytesDecoded uint64
+	// BytesEncoded is the number of output bytes produced by encoders
+	BytesEncoded uint64
+	// Errors counts the returned errors by their ErrorCode
+	Errors map[ErrorCode]uint64
+	// PoolHits is the number of codecs reused from a pool
+	PoolHits uint64
+	// PoolMisses is the number of codecs a pool had to create
+	PoolMisses uint64
+}
+
+// PoolHitRate returns the fraction of pool requests served by a reused codec, or 0 when
+// no pool was used.
+func (s Stats) PoolHitRate() float64 {
+	total := s.PoolHits + s.PoolMisses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.PoolHits) / float64(total)
+}
+
+// statsCollector holds the package-level counters.
+type statsCollector struct {
+	enabled      atomic.Bool
+	decoded      atomic.Uint64
+	encoded      atomic.Uint64
+	bytesDecoded atomic.Uint64
+	bytesEncoded atomic.Uint64
+	poolHits     atomic.Uint64
+	poolMisses   atomic.Uint64
+
+	mu     sync.Mutex
+	errors map[ErrorCode]uint64
+}
+
+// stats is the package-level collector. It is disabled by default so that it costs a
+// single atomic load per operation.
+var stats = &statsCollector{errors: map[ErrorCode]uint64{}}
+
+// EnableStats starts collecting package-level statistics
+func EnableStats() {
+	stats.enabled.Store(true)
+}
+
+// DisableStats stops collecting package-level statistics. Collected values are kept.
+func DisableStats() {
+	stats.enabled.Store(false)
+}
+
+// ReadStats returns a snapshot of the collected statistics
+func ReadStats() Stats {
+	s := Stats{
+		DocumentsDecoded: stats.decoded.Load(),
+		DocumentsEncoded: stats.encoded.Load(),
+		BytesDecoded:     stats.bytesDecoded.Load(),
+		BytesEncoded:     stats.bytesEncoded.Load(),
+		PoolHits:         stats.poolHits.Load(),
+		PoolMisses:       stats.poolMisses.Load(),
+		Errors:           map[ErrorCode]uint64{},
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	for code, n := range stats.errors {
+		s.Errors[code] = n
+	}
+
+	return s
+}
+
+// ResetStats sets all collected statistics back to zero
+func ResetStats() {
+	stats.decoded.Store(0)
+	stats.encoded.Store(0)
+	stats.bytesDecoded.Store(0)
+	stats.bytesEncoded.Store(0)
+	stats.poolHits.Store(0)
+	stats.poolMisses.Store(0)
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.errors = map[ErrorCode]uint64{}
+}
+
+// PublishStats publishes the statistics as an expvar variable with the given name, so
+// that they are served on /debug/vars. Like expvar.Publish, it panics if the name is
+// already in use.
+func PublishStats(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return ReadStats() }))
+}
+
+// recordDecode counts a decoded document of n bytes, or the error that stopped it, and
+// returns err unchanged.
+func (c *statsCollector) recordDecode(n int, err error) error {
+	if !c.enabled.Load() {
+		return err
+	}
+
+	if err != nil {
+		c.recordError(err)
+		return err
+	}
+
+	c.decoded.Add(1)
+	c.bytesDecoded.Add(uint64(n))
+
+	return nil
+}
+
+// recordEncode counts an encoded document of n bytes, or the error that stopped it, and
+// returns err unchanged.
+func (c *statsCollector) recordEncode(n int, err error) error {
+	if !c.enabled.Load() {
+		return err
+	}
+
+	if err != nil {
+		c.recordError(err)
+		return err
+	}
+
+	c.encoded.Add(1)
+	c.bytesEncoded.Add(uint64(n))
+
+	return nil
+}
+
+// recordPoolGet counts a codec taken from a pool, which was reused when hit is true and
+// had to be created otherwise.
+func (c *statsCollector) recordPoolGet(hit bool) {
+	if !c.enabled.Load() {
+		return
+	}
+
+	if hit {
+		c.poolHits.Add(1)
+	} else {
+		c.poolMisses.Add(1)
+	}
+}
+
+// recordError counts err by the code of the JSONError it wraps, if any.
+func (c *statsCollector) recordError(err error) {
+	var jsonErr *JSONError
+	if !errors.As(err, &jsonErr) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errors[jsonErr.Code]++
+}
+
+// statsReader counts the bytes a stream decoder reads from its source.
+type statsReader struct {
+	r io.Reader
+}
+
+// Read implements io.Reader
+func (s statsReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+
+	if n > 0 && stats.enabled.Load() {
+		stats.bytesDecoded.Add(uint64(n))
+	}
+
+	return n, err
+}
@@ -0,0 +1,137 @@
+package encoding_test
+
+import (
+	"bytes"
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+func TestStats(t *testing.T) {
+	encoding.ResetStats()
+	encoding.EnableStats()
+
+	defer encoding.DisableStats()
+
+	data, err := encoding.Marshal(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var out map[string]int
+	if err := encoding.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_ = encoding.Unmarshal([]byte(`{"a":`), &out)
+	_ = encoding.Unmarshal([]byte(`{}`), out)
+	_, _ = encoding.Marshal(1, encoding.WithMaxSize(-1))
+
+	var buf bytes.Buffer
+
+	enc, _ := encoding.NewEncoder(&buf)
+	if err := enc.Encode([]int{1, 2}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	dec, _ := encoding.NewDecoder(strings.NewReader(`[1, 2] `))
+
+	var list []int
+	if err := dec.Decode(&list); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	s := encoding.ReadStats()
+
+	if s.DocumentsEncoded != 2 || s.BytesEncoded != uint64(len(data))+6 {
+		t.Errorf("Unexpected encode stats: %+v", s)
+	}
+
+	if s.DocumentsDecoded != 2 || s.BytesDecoded != uint64(len(data))+7 {
+		t.Errorf("Unexpected decode stats: %+v", s)
+	}
+
+	expectedErrors := map[encoding.ErrorCode]uint64{
+		encoding.ErrInvalidJSON:    1,
+		encoding.ErrInvalidTarget:  1,
+		encoding.ErrInvalidOptions: 1,
+	}
+
+	if len(s.Errors) != len(expectedErrors) {
+		t.Errorf("Expected errors %v, got %v", expectedErrors, s.Errors)
+	}
+
+	for code, n := range expectedErrors {
+		if s.Errors[code] != n {
+			t.Errorf("Expected %d %s errors, got %d", n, code, s.Errors[code])
+		}
+	}
+
+	encoding.DisableStats()
+
+	_, _ = encoding.Marshal(1)
+
+	if encoding.ReadStats().DocumentsEncoded != 2 {
+		t.Error("Expected disabled stats to stop counting")
+	}
+
+	encoding.ResetStats()
+
+	if s := encoding.ReadStats(); s.DocumentsEncoded != 0 || len(s.Errors) != 0 {
+		t.Errorf("Expected reset stats, got %+v", s)
+	}
+}
+
+func TestPublishStats(t *testing.T) {
+	encoding.PublishStats("jingo_test")
+
+	v := expvar.Get("jingo_test")
+	if v == nil {
+		t.Fatal("Expected published variable")
+	}
+
+	if !strings.Contains(v.String(), `"DocumentsDecoded"`) {
+		t.Errorf("Unexpected expvar output: %s", v.String())
+	}
+
+	if rate := (encoding.Stats{PoolHits: 3, PoolMisses: 1}).PoolHitRate(); rate != 0.75 {
+		t.Errorf("Expected hit rate 0.75, got %v", rate)
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	encoding.ResetStats()
+	encoding.EnableStats()
+
+	defer encoding.DisableStats()
+
+	pool, err := encoding.NewEncoderPool()
+	if err != nil {
+		t.Fatalf("NewEncoderPool failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	// The first encoder has to be created; later ones are reused, although sync.Pool may
+	// drop an encoder now and then
+	enc := pool.Get(&buf)
+	if s := encoding.ReadStats(); s.PoolHits != 0 || s.PoolMisses != 1 {
+		t.Fatalf("Expected a single miss, got %+v", s)
+	}
+
+	for i := 0; i < 10; i++ {
+		pool.Put(enc)
+		enc = pool.Get(&buf)
+	}
+
+	s := encoding.ReadStats()
+	if s.PoolHits+s.PoolMisses != 11 || s.PoolHits == 0 {
+		t.Fatalf("Expected 11 pool requests with hits, got %+v", s)
+	}
+
+	if rate := s.PoolHitRate(); rate != float64(s.PoolHits)/11 {
+		t.Errorf("Expected hit rate %v, got %v", float64(s.PoolHits)/11, rate)
+	}
+}
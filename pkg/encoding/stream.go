@@ -0,0 +1,201 @@
+package encoding
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// ErrStreamClosed is returned by the methods of a Stream after Close.
+var ErrStreamClosed = errors.New("stream is closed")
+
+// defaultStreamIndent is the indentation used by FormatJSON(true) when no WithIndent
+// option was given.
+const defaultStreamIndent = "  "
+
+// jsonStream provides a concrete implementation of the Stream interface. Values are read
+// with a parser.Splitter, which stops reading at the end of each value instead of reading
+// ahead like the streaming decoder, so that a request can be answered before the peer
+// sends the next one.
+type jsonStream struct {
+	rw       io.ReadWriter
+	splitter *parser.Splitter
+	encoder  *streamEncoder
+	options  *Options
+	// compact and pretty are the encoder options selected by FormatJSON
+	compact *Options
+	pretty  *Options
+	// readMutex serializes ReadJSON and Skip
+	readMutex sync.Mutex
+	mutex     sync.Mutex
+	closed    bool
+}
+
+// NewStream creates a Stream that reads and writes JSON values on rw, such as a network
+// connection. Values written with WriteJSON are followed by a newline and flushed right
+// away, so the peer can decode them as they arrive. The options apply to both
+// directions, except for WithTranscoding and WithTransparentDecompression, which are
+// not supported on streams.
+func NewStream(rw io.ReadWriter, opts ...Option) (Stream, error) {
+	enc, err := NewEncoder(rw, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	options := enc.(*streamEncoder).options
+
+	compact := *options
+	compact.Prefix, compact.Indent = "", ""
+
+	pretty := *options
+	if pretty.Indent == "" {
+		pretty.Indent = defaultStreamIndent
+	}
+
+	s := &jsonStream{
+		rw:       rw,
+		splitter: parser.NewSplitter(rw),
+		encoder:  enc.(*streamEncoder),
+		options:  options,
+		compact:  &compact,
+		pretty:   &pretty,
+	}
+
+	s.encoder.options = s.compact
+	if options.Prefix != "" || options.Indent != "" {
+		s.encoder.options = s.pretty
+	}
+
+	return s, nil
+}
+
+// Read implements io.Reader by reading raw bytes from the connection. ReadJSON buffers
+// input, so Read must not be mixed with ReadJSON on the same stream.
+func (s *jsonStream) Read(p []byte) (int, error) {
+	if s.isClosed() {
+		return 0, ErrStreamClosed
+	}
+
+	return s.rw.Read(p)
+}
+
+// Write implements io.Writer by writing raw bytes to the connection. Buffered JSON
+// output is flushed first so that the bytes keep their order.
+func (s *jsonStream) Write(p []byte) (int, error) {
+	if s.isClosed() {
+		return 0, ErrStreamClosed
+	}
+
+	s.encoder.mutex.Lock()
+	defer s.encoder.mutex.Unlock()
+
+	if err := s.encoder.Flush(); err != nil {
+		return 0, err
+	}
+
+	return s.rw.Write(p)
+}
+
+// WriteJSON implements Writer.WriteJSON
+func (s *jsonStream) WriteJSON(v interface{}) error {
+	if s.isClosed() {
+		return NewJSONError(ErrMarshalFailure, "failed to write JSON value").WithCause(ErrStreamClosed)
+	}
+
+	return s.encoder.Encode(v)
+}
+
+// FormatJSON implements Writer.FormatJSON. Pretty output uses the indentation set with
+// WithIndent, or two spaces.
+func (s *jsonStream) FormatJSON(pretty bool) {
+	s.encoder.mutex.Lock()
+	defer s.encoder.mutex.Unlock()
+
+	if pretty {
+		s.encoder.options = s.pretty
+	} else {
+		s.encoder.options = s.compact
+	}
+}
+
+// ReadJSON implements Reader.ReadJSON. io.EOF is returned as is when the peer closed the
+// connection between values.
+func (s *jsonStream) ReadJSON(v interface{}) error {
+	if s.isClosed() {
+		return NewJSONError(ErrUnmarshalFailure, "failed to read JSON value").WithCause(ErrStreamClosed)
+	}
+
+	s.readMutex.Lock()
+	defer s.readMutex.Unlock()
+
+	data, err := s.next()
+	if err != nil {
+		return stats.recordDecode(0, err)
+	}
+
+	return stats.recordDecode(len(data), unmarshalOptions(data, v, s.options))
+}
+
+// Skip implements Reader.Skip
+func (s *jsonStream) Skip() error {
+	if s.isClosed() {
+		return NewJSONError(ErrUnmarshalFailure, "failed to skip value").WithCause(ErrStreamClosed)
+	}
+
+	s.readMutex.Lock()
+	defer s.readMutex.Unlock()
+
+	_, err := s.next()
+
+	return err
+}
+
+// next reads the raw bytes of the next value.
+func (s *jsonStream) next() ([]byte, error) {
+	if s.splitter.Next() {
+		return s.splitter.Bytes(), nil
+	}
+
+	if err := s.splitter.Err(); err != nil {
+		return nil, NewJSONError(ErrInvalidJSON, "failed to read JSON stream").WithCause(err)
+	}
+
+	return nil, io.EOF
+}
+
+// Close implements Stream.Close. It flushes pending output and closes the connection if
+// it implements io.Closer. Closing a closed stream returns ErrStreamClosed.
+func (s *jsonStream) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+
+	s.closed = true
+
+	s.encoder.mutex.Lock()
+	err := s.encoder.Flush()
+	s.encoder.mutex.Unlock()
+
+	if c, ok := s.rw.(io.Closer); ok {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// isClosed reports whether Close has been called.
+func (s *jsonStream) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.closed
+}
+
+var _ Stream = (*jsonStream)(nil)
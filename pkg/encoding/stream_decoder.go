@@ -3,6 +3,8 @@ package encoding
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"io"
 	"reflect"
 	"sync"
@@ -16,9 +18,11 @@ type streamDecoder struct {
 	lexer      *parser.Lexer
 	parser     *parser.Parser
 	options    *Options
+	source     *contextReader
 	mutex      sync.Mutex
-	buffer     []byte
-	bufferSize int // Added to track buffer size
+	bufferSize int
+	// err is a failure to open the input given to Reset, returned by the next call
+	err error
 }
 
 // NewDecoder creates a new JSONDecoder implementation
@@ -28,61 +32,184 @@ func NewDecoder(r io.Reader, opts ...Option) (JSONDecoder, error) {
 		return nil, NewJSONError(ErrInvalidOptions, "invalid decoder options").WithCause(err)
 	}
 
-	bufferSize := 4096
-	if options.BufferSize > 0 {
-		bufferSize = options.BufferSize
+	d := newStreamDecoder(options)
+	if d.Reset(r); d.err != nil {
+		return nil, d.err
 	}
 
-	reader := bufio.NewReader(r)
-	lexer := parser.NewLexer(reader)
-	parser := parser.NewParser(lexer)
+	return d, nil
+}
+
+// newStreamDecoder creates a decoder with an empty input, to be given its input by Reset.
+func newStreamDecoder(options *Options) *streamDecoder {
+	source := &contextReader{r: eofReader{}}
+	reader := bufio.NewReaderSize(statsReader{r: source}, options.BufferSize)
+
+	var input io.Reader = reader
+	if options.Transcode {
+		input = parser.NewTranscodingReader(reader)
+	}
+
+	// The context of the running decode is also checked between tokens, which replaces
+	// the deadline set by parserOptions
+	lexer := parser.NewLexerSize(input, options.BufferSize)
+	parser := parser.NewParser(lexer, append(options.parserOptions(), parser.WithInterrupt(source.err))...)
 
 	return &streamDecoder{
 		reader:     reader,
 		lexer:      lexer,
 		parser:     parser,
 		options:    options,
-		buffer:     make([]byte, bufferSize),
-		bufferSize: bufferSize,
-	}, nil
+		source:     source,
+		bufferSize: options.BufferSize,
+	}
 }
 
 // Decode implements JSONDecoder.Decode
 func (d *streamDecoder) Decode(v interface{}) error {
+	return d.DecodeContext(context.Background(), v)
+}
+
+// DecodeContext implements ContextDecoder.DecodeContext. Cancellation is checked whenever
+// the lexer needs the next chunk of input and periodically while parsing; a read that is
+// already blocked is not interrupted. The decoder must not be used after a canceled
+// decode. With WithDecodeTimeout, ctx is also canceled once the timeout elapses.
+func (d *streamDecoder) DecodeContext(ctx context.Context, v interface{}) error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	value, err := d.parser.ParseJSON()
+	if d.options.DecodeTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, d.options.DecodeTimeout)
+		defer cancel()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return stats.recordDecode(0, NewCanceledError(err))
+	}
+
+	d.source.ctx = ctx
+	defer func() { d.source.ctx = nil }()
+
+	err := d.decode(v)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		err = NewCanceledError(ctxErr)
+	}
+
+	return stats.recordDecode(0, err)
+}
+
+// decode implements Decode; the decoded bytes are counted as they are read.
+func (d *streamDecoder) decode(v interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if d.options.ProfileLabels {
+		defer profilePhase("parse")()
+	}
+
+	if d.trailing() {
+		if d.options.AllowTrailingData {
+			return NewJSONError(ErrTrailingData, errTrailingData.Message).WithOffset(d.parser.InputOffset())
+		}
+
+		return newParseError(d.parser, "failed to parse JSON stream", errTrailingData)
+	}
+
+	value, err := d.parser.ParseNext()
+	if err == io.EOF {
+		return err
+	}
+
 	if err != nil {
-		return NewJSONError(ErrInvalidJSON, "failed to parse JSON stream").WithCause(err)
+		return newParseError(d.parser, "failed to parse JSON stream", err)
+	}
+
+	if d.options.ProfileLabels {
+		profilePhase("decode")
+	}
+
+	if err := newDecodeState(d.options).decode(value, reflect.ValueOf(v).Elem()); err != nil {
+		return err.WithValue(v)
 	}
 
-	return unmarshalValue(value, reflect.ValueOf(v).Elem())
+	return nil
 }
 
-// More implements JSONDecoder.More
-func (d *streamDecoder) More() bool {
+// trailing reports whether the input after the values parsed so far holds data that does
+// not start another value. Such data before the first value is a syntax error instead.
+func (d *streamDecoder) trailing() bool {
+	if d.parser.InputOffset() == 0 {
+		return false
+	}
+
+	switch d.parser.CurrentToken().Type {
+	case parser.TokenEOF, parser.TokenBraceOpen, parser.TokenBracketOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// Skip implements JSONDecoder.Skip
+func (d *streamDecoder) Skip() error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	b, err := d.reader.Peek(1)
-	if err != nil {
-		return false
+	if d.err != nil {
+		return d.err
 	}
 
-	// Skip whitespace
-	for len(b) > 0 && isWhitespace(b[0]) {
-		if _, err := d.reader.ReadByte(); err != nil {
-			return false
-		}
+	if err := d.parser.SkipValue(); err != nil {
+		return newParseError(d.parser, "failed to skip value", err)
+	}
 
-		b, err = d.reader.Peek(1)
-		if err != nil {
-			return false
+	return nil
+}
+
+// DecodeArrayStream implements JSONDecoder.DecodeArrayStream. Elements are parsed one by
+// one as fn consumes them, so an array of any length is decoded in constant memory. Each
+// element is passed as compact JSON in a new slice that fn may keep. An error returned by
+// fn stops decoding and is returned as is.
+func (d *streamDecoder) DecodeArrayStream(fn func(elem RawMessage) error) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return stats.recordDecode(0, d.err)
+	}
+
+	var fnErr error
+
+	err := d.parser.ParseArray(func(v parser.Value) error {
+		var b bytes.Buffer
+		if err := writeValue(&b, v); err != nil {
+			fnErr = NewJSONError(ErrMarshalFailure, "failed to write array element").WithCause(err)
+			return fnErr
 		}
+
+		fnErr = fn(RawMessage(b.Bytes()))
+
+		return fnErr
+	})
+
+	if err != nil && fnErr == nil {
+		err = newParseError(d.parser, "failed to parse JSON array stream", err)
 	}
 
-	return len(b) > 0
+	return stats.recordDecode(0, err)
+}
+
+// More implements JSONDecoder.More. The lexer reads ahead of the value being decoded,
+// so the parser rather than the reader knows whether input is left. With
+// WithAllowTrailingData, data that does not start another value is not reported.
+func (d *streamDecoder) More() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.parser.More() && !(d.options.AllowTrailingData && d.trailing())
 }
 
 // BufferSize implements JSONDecoder.BufferSize
@@ -90,9 +217,103 @@ func (d *streamDecoder) BufferSize() int {
 	return d.bufferSize
 }
 
-// isWhitespace helper function
-func isWhitespace(b byte) bool {
-	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+// InputOffset implements JSONDecoder.InputOffset. It is the offset just past the last
+// value decoded or skipped, before the whitespace that follows it, like the InputOffset of
+// encoding/json, so that a caller mixing JSON with other data in one stream knows where
+// the next part starts. Offsets count the decompressed or transcoded input when
+// WithTransparentDecompression or WithTranscoding convert it.
+func (d *streamDecoder) InputOffset() int64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.parser.InputOffset()
+}
+
+// Buffered implements JSONDecoder.Buffered. The reader returns the input after
+// InputOffset that the decoder has already read from the underlying reader, so that a
+// caller can read the data that follows a JSON value on the same connection with
+// io.MultiReader(dec.Buffered(), conn). It is valid until the next call
+// to the decoder. With WithTranscoding, the input held by the transcoder is not included.
+func (d *streamDecoder) Buffered() io.Reader {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// The lexer reuses the buffered reader of the decoder, so the parser holds
+	// all of the buffered input
+	return d.parser.Buffered()
+}
+
+// Reset implements ResettableDecoder.Reset. The buffers, lexer and parser are reused, so a
+// pool of decoders can serve many connections without reallocating them. The options
+// are kept; a nil r, or with WithTransparentDecompression a failure to open the compressed
+// input, is returned by the next call.
+func (d *streamDecoder) Reset(r io.Reader) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.err = nil
+
+	if r == nil || reflect.ValueOf(r).Kind() == reflect.Ptr && reflect.ValueOf(r).IsNil() {
+		d.err = NewJSONError(ErrInvalidValue, "decoder input must be a non-nil io.Reader").
+			WithCause(parser.ErrInvalidInput)
+		r = eofReader{}
+	}
+
+	if d.options.TransparentDecompression && d.err == nil {
+		dr, err := decompressReader(r)
+		if err != nil {
+			d.err = err
+			dr = eofReader{}
+		}
+
+		r = dr
+	}
+
+	d.source.r = r
+	d.reader.Reset(statsReader{r: d.source})
+
+	var input io.Reader = d.reader
+	if d.options.Transcode {
+		input = parser.NewTranscodingReader(d.reader)
+	}
+
+	d.lexer.Reset(input)
+	d.parser.Reset(nil)
+}
+
+// eofReader is an empty input.
+type eofReader struct{}
+
+// Read implements io.Reader
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// contextReader fails reads once the context of the running decode is done.
+type contextReader struct {
+	r   io.Reader
+	ctx context.Context
+}
+
+// Read implements io.Reader
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
+
+// err returns the error of the context of the running decode, if it is done.
+func (c *contextReader) err() error {
+	if c.ctx == nil {
+		return nil
+	}
+
+	return c.ctx.Err()
 }
 
-var _ JSONDecoder = (*streamDecoder)(nil)
+var (
+	_ ResettableDecoder = (*streamDecoder)(nil)
+	_ ContextDecoder    = (*streamDecoder)(nil)
+)
@@ -2,7 +2,10 @@ package encoding
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
+	"strings"
 	"sync"
 )
 
@@ -10,12 +13,24 @@ import (
 // It handles the encoding of JSON values to an output stream with support
 // for pretty printing and proper error handling.
 type streamEncoder struct {
+	w          io.Writer
 	writer     *bufio.Writer
 	options    *Options
 	mutex      sync.Mutex
 	prefix     string
 	indent     string
 	bufferSize int
+	// containers holds the arrays and objects opened with BeginArray and BeginObject
+	containers []container
+}
+
+// container is an array or object being written incrementally.
+type container struct {
+	object bool
+	// count is the number of elements or members written so far
+	count int
+	// keyWritten is set between WriteKey and the member value
+	keyWritten bool
 }
 
 // NewEncoder creates a new JSONEncoder implementation.
@@ -26,53 +41,86 @@ func NewEncoder(w io.Writer, opts ...Option) (JSONEncoder, error) {
 		return nil, NewJSONError(ErrInvalidOptions, "invalid encoder options").WithCause(err)
 	}
 
-	bufferSize := 4096
-	if options.BufferSize > 0 {
-		bufferSize = options.BufferSize
-	}
+	return newStreamEncoder(w, options), nil
+}
 
+// newStreamEncoder creates an encoder writing to w with options.
+func newStreamEncoder(w io.Writer, options *Options) *streamEncoder {
 	return &streamEncoder{
-		writer:     bufio.NewWriterSize(w, bufferSize),
+		w:          w,
+		writer:     bufio.NewWriterSize(w, options.BufferSize),
 		options:    options,
-		bufferSize: bufferSize,
-	}, nil
+		bufferSize: options.BufferSize,
+	}
 }
 
 // Encode implements JSONEncoder.Encode.
 // It writes the JSON encoding of v to the stream.
 func (e *streamEncoder) Encode(v interface{}) error {
+	return e.EncodeContext(context.Background(), v)
+}
+
+// EncodeContext implements ContextEncoder.EncodeContext. The encoded value is written in
+// chunks of the buffer size and cancellation is checked before each chunk, so a canceled
+// call may leave a partial value in the stream.
+func (e *streamEncoder) EncodeContext(ctx context.Context, v interface{}) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
+	n, err := e.encode(ctx, v)
+
+	return stats.recordEncode(n, err)
+}
+
+// encode implements EncodeContext and returns the number of bytes written.
+func (e *streamEncoder) encode(ctx context.Context, v interface{}) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, NewCanceledError(err)
+	}
+
+	if len(e.containers) > 0 {
+		return 0, NewJSONError(ErrMarshalFailure, "cannot encode a value while a container is open; use WriteValue")
+	}
+
 	var data []byte
 
 	var err error
 
 	if e.options.Prefix != "" || e.options.Indent != "" {
-		data, err = MarshalIndent(v, e.options.Prefix, e.options.Indent)
+		data, err = marshalIndent(v, e.options.Prefix, e.options.Indent, e.options)
 	} else {
-		data, err = Marshal(v)
+		data, err = marshal(v, e.options)
 	}
 
 	if err != nil {
-		return NewJSONError(ErrMarshalFailure, "failed to marshal value for stream").
+		return 0, NewJSONError(codeOf(err, ErrMarshalFailure), "failed to marshal value for stream").
 			WithCause(err).
 			WithValue(v)
 	}
 
 	if !e.options.DisableSizeLimit && len(data) > e.options.MaxSize {
-		return NewSizeExceededError(len(data), e.options.MaxSize)
+		return 0, NewSizeExceededError(len(data), e.options.MaxSize)
 	}
 
-	if _, err := e.writer.Write(data); err != nil {
-		return NewJSONError(ErrMarshalFailure, "failed to write to stream").WithCause(err)
+	for chunk := data; len(chunk) > 0; {
+		if err := ctx.Err(); err != nil {
+			return 0, NewCanceledError(err)
+		}
+
+		n := min(len(chunk), e.bufferSize)
+
+		if _, err := e.writer.Write(chunk[:n]); err != nil {
+			return 0, NewJSONError(ErrMarshalFailure, "failed to write to stream").WithCause(err)
+		}
+
+		chunk = chunk[n:]
 	}
 
 	if err := e.writer.WriteByte('\n'); err != nil {
-		return NewJSONError(ErrMarshalFailure, "failed to write newline to stream").WithCause(err)
+		return 0, NewJSONError(ErrMarshalFailure, "failed to write newline to stream").WithCause(err)
 	}
 
-	return e.Flush()
+	return len(data) + 1, e.Flush()
 }
 
 // SetIndent implements JSONEncoder.SetIndent.
@@ -101,20 +149,218 @@ func (e *streamEncoder) BufferSize() int {
 }
 
 // SetBufferSize allows changing the encoder's buffer size.
-// It returns an error if the new size is invalid.
+// It returns an error if the new size is invalid. Buffered output is flushed first.
 func (e *streamEncoder) SetBufferSize(size int) error {
-	if size <= 0 {
-		return NewJSONError(ErrInvalidOptions, "buffer size must be positive")
+	if size <= 0 || size > MaximumBufferSize {
+		return NewJSONError(ErrInvalidOptions,
+			fmt.Sprintf("buffer size %d is outside the allowed range 1 to %d", size, MaximumBufferSize))
 	}
 
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	e.writer = bufio.NewWriterSize(e.writer, size)
+	if err := e.writer.Flush(); err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to flush encoder").WithCause(err)
+	}
+
+	e.writer = bufio.NewWriterSize(e.w, size)
 	e.bufferSize = size
 
 	return nil
 }
 
+// Reset implements ResettableEncoder.Reset. The buffer is reused, so a pool of encoders can
+// serve many connections without reallocating it. Unflushed output and open containers
+// are discarded; the options are kept.
+func (e *streamEncoder) Reset(w io.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.w = w
+	e.writer.Reset(w)
+	e.containers = e.containers[:0]
+}
+
+// BeginArray implements JSONEncoder.BeginArray. The elements are written with
+// WriteValue or with nested Begin and End calls, and are not indented. The output is
+// flushed, followed by a newline, once the outermost container is closed.
+func (e *streamEncoder) BeginArray() error {
+	return e.begin(false)
+}
+
+// EndArray implements JSONEncoder.EndArray
+func (e *streamEncoder) EndArray() error {
+	return e.end(false)
+}
+
+// BeginObject implements JSONEncoder.BeginObject. Each member is written as a WriteKey
+// call followed by its value.
+func (e *streamEncoder) BeginObject() error {
+	return e.begin(true)
+}
+
+// EndObject implements JSONEncoder.EndObject
+func (e *streamEncoder) EndObject() error {
+	return e.end(true)
+}
+
+// WriteKey implements JSONEncoder.WriteKey
+func (e *streamEncoder) WriteKey(key string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.containers) == 0 || !e.containers[len(e.containers)-1].object {
+		return NewJSONError(ErrMarshalFailure, "WriteKey called outside of an object")
+	}
+
+	top := &e.containers[len(e.containers)-1]
+	if top.keyWritten {
+		return NewJSONError(ErrMarshalFailure, fmt.Sprintf("key %q written before the previous member value", key))
+	}
+
+	var b strings.Builder
+	if top.count > 0 {
+		b.WriteByte(',')
+	}
+
+	newEncodeState(e.options).writeString(&b, key)
+	b.WriteByte(':')
+
+	if err := e.writeString(b.String()); err != nil {
+		return err
+	}
+
+	top.keyWritten = true
+
+	return nil
+}
+
+// WriteValue implements JSONEncoder.WriteValue. The value is encoded compactly; outside
+// of a container it is written like Encode.
+func (e *streamEncoder) WriteValue(v interface{}) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	data, err := marshal(v, e.options)
+	if err != nil {
+		return stats.recordEncode(0, err)
+	}
+
+	if err := e.beginValue(); err != nil {
+		return err
+	}
+
+	if err := e.writeString(string(data)); err != nil {
+		return err
+	}
+
+	return stats.recordEncode(len(data), e.endValue())
+}
+
+// begin opens an array or object.
+func (e *streamEncoder) begin(object bool) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.beginValue(); err != nil {
+		return err
+	}
+
+	opener := "["
+	if object {
+		opener = "{"
+	}
+
+	if err := e.writeString(opener); err != nil {
+		return err
+	}
+
+	e.containers = append(e.containers, container{object: object})
+
+	return nil
+}
+
+// end closes the current container, which must be an object when object is set and an
+// array otherwise.
+func (e *streamEncoder) end(object bool) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	kind, closer := "array", "]"
+	if object {
+		kind, closer = "object", "}"
+	}
+
+	if len(e.containers) == 0 || e.containers[len(e.containers)-1].object != object {
+		return NewJSONError(ErrMarshalFailure, fmt.Sprintf("no open %s to end", kind))
+	}
+
+	if e.containers[len(e.containers)-1].keyWritten {
+		return NewJSONError(ErrMarshalFailure, "object ended after a key without a value")
+	}
+
+	if err := e.writeString(closer); err != nil {
+		return err
+	}
+
+	e.containers = e.containers[:len(e.containers)-1]
+
+	return e.endValue()
+}
+
+// beginValue writes the separator in front of a value and checks that a value may be
+// written at this point.
+func (e *streamEncoder) beginValue() error {
+	if len(e.containers) == 0 {
+		return nil
+	}
+
+	top := &e.containers[len(e.containers)-1]
+
+	if top.object {
+		if !top.keyWritten {
+			return NewJSONError(ErrMarshalFailure, "object member value written without a key")
+		}
+
+		top.keyWritten = false
+		top.count++
+
+		return nil
+	}
+
+	top.count++
+
+	if top.count > 1 {
+		return e.writeString(",")
+	}
+
+	return nil
+}
+
+// endValue finishes a top-level value with a newline and flushes it, like Encode.
+func (e *streamEncoder) endValue() error {
+	if len(e.containers) > 0 {
+		return nil
+	}
+
+	if err := e.writeString("\n"); err != nil {
+		return err
+	}
+
+	return e.Flush()
+}
+
+// writeString writes s to the buffered stream.
+func (e *streamEncoder) writeString(s string) error {
+	if _, err := e.writer.WriteString(s); err != nil {
+		return NewJSONError(ErrMarshalFailure, "failed to write to stream").WithCause(err)
+	}
+
+	return nil
+}
+
 // Verify interface implementation at compile time
-var _ JSONEncoder = (*streamEncoder)(nil)
+var (
+	_ ResettableEncoder = (*streamEncoder)(nil)
+	_ ContextEncoder    = (*streamEncoder)(nil)
+)
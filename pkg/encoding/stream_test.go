@@ -2,12 +2,19 @@ package encoding_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -33,6 +40,12 @@ func TestNewDecoder(t *testing.T) {
 			input:   `{"key": "value"}`,
 			options: []encoding.Option{encoding.WithBufferSize(8192)},
 		},
+		{
+			name:        "Buffer size too large",
+			input:       `{"key": "value"}`,
+			options:     []encoding.Option{encoding.WithBufferSize(encoding.MaximumBufferSize + 1)},
+			expectedErr: "exceeds maximum allowed size",
+		},
 	}
 
 	for _, tt := range tests {
@@ -190,3 +203,733 @@ func TestOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoderDuplicateKeyCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		options     []encoding.Option
+		expectedErr string
+	}{
+		{
+			name:    "Duplicates allowed by default",
+			input:   `{"a": 1, "a": 2}`,
+			options: nil,
+		},
+		{
+			name:        "Exact check",
+			input:       `{"a": 1, "b": {"c": 1, "c": 2}}`,
+			options:     []encoding.Option{encoding.WithDuplicateKeyCheck()},
+			expectedErr: `duplicate key "c"`,
+		},
+		{
+			name:        "Bloom check",
+			input:       `{"a": 1, "b": 2, "a": 3}`,
+			options:     []encoding.Option{encoding.WithApproximateDuplicateKeyCheck(1024)},
+			expectedErr: `duplicate key "a"`,
+		},
+		{
+			name:    "Bloom check without duplicates",
+			input:   `{"a": 1, "b": 2, "c": {"a": 3}}`,
+			options: []encoding.Option{encoding.WithApproximateDuplicateKeyCheck(1024)},
+		},
+		{
+			name:    "Bloom check with a saturated filter",
+			input:   `{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8, "i": 9, "j": 10}`,
+			options: []encoding.Option{encoding.WithApproximateDuplicateKeyCheck(1)},
+		},
+		{
+			name:        "Invalid filter size",
+			input:       `{}`,
+			options:     []encoding.Option{encoding.WithApproximateDuplicateKeyCheck(0)},
+			expectedErr: "duplicate key filter size must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := encoding.NewDecoder(strings.NewReader(tt.input), tt.options...)
+			if err == nil {
+				var result map[string]interface{}
+				err = decoder.Decode(&result)
+			}
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDecoderTransparentDecompression(t *testing.T) {
+	var compressed bytes.Buffer
+
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(`{"id": 1} {"id": 2}`))
+	_ = zw.Close()
+
+	zstdMagic := []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "gzip", input: compressed.Bytes()},
+		{name: "plain", input: []byte(`{"id": 1} {"id": 2}`)},
+		{name: "registered zstd", input: append(append([]byte{}, zstdMagic...), `{"id": 1} {"id": 2}`...)},
+	}
+
+	// A stand-in for a real zstd implementation that only strips the magic bytes
+	encoding.RegisterDecompressor("zstd", zstdMagic, func(r io.Reader) (io.Reader, error) {
+		_, err := io.ReadFull(r, make([]byte, 4))
+		return r, err
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := encoding.NewDecoder(bytes.NewReader(tt.input), encoding.WithTransparentDecompression())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for want := 1; want <= 2; want++ {
+				var v struct {
+					ID int `json:"id"`
+				}
+
+				if err := decoder.Decode(&v); err != nil {
+					t.Fatalf("Failed to decode: %v", err)
+				}
+
+				if v.ID != want {
+					t.Errorf("Expected id %d, got %d", want, v.ID)
+				}
+			}
+
+			if decoder.More() {
+				t.Error("Expected no more values")
+			}
+		})
+	}
+
+	// Without a registered decompressor, zstd input is read as is
+	encoding.RegisterDecompressor("zstd", zstdMagic, nil)
+
+	decoder, err := encoding.NewDecoder(bytes.NewReader(zstdMagic), encoding.WithTransparentDecompression())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	checkJSONError(t, decoder.Decode(&struct{}{}), encoding.ErrInvalidJSON, "failed to parse JSON stream")
+
+	_, err = encoding.NewDecoder(bytes.NewReader([]byte{0x1f, 0x8b, 0}), encoding.WithTransparentDecompression())
+	checkJSONError(t, err, encoding.ErrInvalidJSON, "failed to open gzip stream")
+
+	if _, err := encoding.NewDecoder(bytes.NewReader(compressed.Bytes())); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// cancelingReader returns its data in one read and cancels the context on the next.
+type cancelingReader struct {
+	data   []byte
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		r.cancel()
+		return 0, nil
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func TestDecodeContext(t *testing.T) {
+	decoder, _ := encoding.NewDecoder(strings.NewReader(`{"a": 1}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var v map[string]int
+
+	err := decoder.(encoding.ContextDecoder).DecodeContext(ctx, &v)
+	checkJSONError(t, err, encoding.ErrCanceled, "operation canceled")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to wrap context.Canceled, got %v", err)
+	}
+
+	if err := decoder.(encoding.ContextDecoder).DecodeContext(context.Background(), &v); err != nil || v["a"] != 1 {
+		t.Errorf("Expected decode to succeed after a canceled call that read nothing, got %v, %v", v, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	decoder, _ = encoding.NewDecoder(&cancelingReader{data: []byte(`[1, 2, `), cancel: cancel})
+
+	var list []int
+
+	err = decoder.(encoding.ContextDecoder).DecodeContext(ctx, &list)
+	checkJSONError(t, err, encoding.ErrCanceled, "")
+}
+
+func TestDecodeTimeout(t *testing.T) {
+	input := "[" + strings.Repeat("1, ", 5000) + "1]"
+
+	var list []int
+
+	if err := encoding.Unmarshal([]byte(input), &list, encoding.WithDecodeTimeout(time.Minute)); err != nil || len(list) != 5001 {
+		t.Fatalf("Expected the decode to finish in time, got %d elements, %v", len(list), err)
+	}
+
+	opt := encoding.WithDecodeTimeout(time.Nanosecond)
+
+	decoder, err := encoding.NewDecoder(strings.NewReader(input), opt)
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	errs := map[string]error{
+		"Unmarshal":       encoding.Unmarshal([]byte(input), &list, opt),
+		"UnmarshalReader": encoding.UnmarshalReader(strings.NewReader(input), &list, opt),
+		"ValidateStream":  encoding.ValidateStream(strings.NewReader(input), opt),
+		"Decode":          decoder.Decode(&list),
+	}
+
+	for name, err := range errs {
+		checkJSONError(t, err, encoding.ErrCanceled, "")
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected error to wrap context.DeadlineExceeded, got %v", name, err)
+		}
+	}
+
+	if _, err := encoding.NewDecoder(strings.NewReader(input), encoding.WithDecodeTimeout(0)); err == nil {
+		t.Error("Expected an error for a non-positive timeout")
+	}
+}
+
+// cancelingWriter cancels the context on the first write.
+type cancelingWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.Buffer.Write(p)
+}
+
+func TestEncodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+
+	encoder, _ := encoding.NewEncoder(&buf)
+
+	err := encoder.(encoding.ContextEncoder).EncodeContext(ctx, map[string]int{"a": 1})
+	checkJSONError(t, err, encoding.ErrCanceled, "operation canceled")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written, got %q", buf.String())
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &cancelingWriter{cancel: cancel}
+	encoder, _ = encoding.NewEncoder(w, encoding.WithBufferSize(16))
+
+	err = encoder.(encoding.ContextEncoder).EncodeContext(ctx, strings.Repeat("x", 100))
+	checkJSONError(t, err, encoding.ErrCanceled, "")
+
+	if w.Len() == 0 || w.Len() >= 100 {
+		t.Errorf("Expected a partial write, got %d bytes", w.Len())
+	}
+}
+
+func TestDecoderSkip(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader(`{"skip": [1, 2, {"deep": true}]} {"name": "kept"}`))
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	if err := dec.Skip(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var v map[string]string
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if v["name"] != "kept" {
+		t.Errorf("Expected the value after the skipped one, got %v", v)
+	}
+
+	if dec.More() {
+		t.Error("Expected no more values")
+	}
+
+	dec, _ = encoding.NewDecoder(strings.NewReader(`{"a": [1}`))
+	checkJSONError(t, dec.Skip(), encoding.ErrInvalidJSON, "failed to skip value")
+}
+
+func TestDecoderEOF(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader("{\"n\": 1}\n{\"n\": 2}\n"))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+
+	var sum int
+
+	for {
+		var v struct {
+			N int `json:"n"`
+		}
+
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+
+		sum += v.N
+	}
+
+	if sum != 3 {
+		t.Errorf("Expected a sum of 3, got %d", sum)
+	}
+}
+
+func TestNewDecoderNilReader(t *testing.T) {
+	var r *strings.Reader
+
+	for _, input := range []io.Reader{nil, r} {
+		if _, err := encoding.NewDecoder(input); !errors.Is(err, parser.ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput, got %v", err)
+		}
+	}
+
+	dec, err := encoding.NewDecoder(strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+
+	dec.(encoding.ResettableDecoder).Reset(nil)
+
+	var v interface{}
+	if err := dec.Decode(&v); !errors.Is(err, parser.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput after Reset(nil), got %v", err)
+	}
+}
+
+func TestDecoderInputOffset(t *testing.T) {
+	input := `{"a": "é"}  [1, 2]` + "\n" + `{"b": {}}`
+
+	dec, err := encoding.NewDecoder(strings.NewReader(input), encoding.WithBufferSize(4))
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	if dec.InputOffset() != 0 {
+		t.Errorf("Expected offset 0 before decoding, got %d", dec.InputOffset())
+	}
+
+	var v interface{}
+
+	for _, end := range []string{`{"a": "é"}`, `[1, 2]`, `{"b": {}}`} {
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := int64(strings.Index(input, end) + len(end))
+		if dec.InputOffset() != expected {
+			t.Errorf("After %s: expected offset %d, got %d", end, expected, dec.InputOffset())
+		}
+	}
+}
+
+func TestDecoderBuffered(t *testing.T) {
+	trailer := "\r\nHELLO " + strings.Repeat("x", 100)
+
+	for _, size := range []int{1, 4, 4096} {
+		r := strings.NewReader(`{"name": "frame", "n": [1, 2, 3]}` + trailer)
+
+		dec, err := encoding.NewDecoder(r, encoding.WithBufferSize(size))
+		if err != nil {
+			t.Fatalf("Failed to create decoder: %v", err)
+		}
+
+		var v map[string]interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Size %d: unexpected error: %v", size, err)
+		}
+
+		rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), r))
+		if err != nil {
+			t.Fatalf("Failed to read the rest: %v", err)
+		}
+
+		if string(rest) != trailer {
+			t.Errorf("Size %d: expected %q, got %q", size, trailer, rest)
+		}
+	}
+}
+
+func TestDecoderBufferSize(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	if dec.BufferSize() != encoding.DefaultBufferSize {
+		t.Errorf("Expected default buffer size %d, got %d", encoding.DefaultBufferSize, dec.BufferSize())
+	}
+
+	items := make([]string, 200)
+	for i := range items {
+		items[i] = strings.Repeat("é", i)
+	}
+
+	input, err := encoding.Marshal(map[string]interface{}{"items": items, "n": 12345.5})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Small chunks split strings, numbers and multi-byte characters between reads
+	for _, size := range []int{1, 7, 16} {
+		dec, err := encoding.NewDecoder(bytes.NewReader(input), encoding.WithBufferSize(size), encoding.WithDisableSizeLimit())
+		if err != nil {
+			t.Fatalf("Failed to create decoder: %v", err)
+		}
+
+		var v struct {
+			Items []string `json:"items"`
+			N     float64  `json:"n"`
+		}
+
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Buffer size %d: unexpected error: %v", size, err)
+		}
+
+		if !reflect.DeepEqual(v.Items, items) || v.N != 12345.5 {
+			t.Errorf("Buffer size %d: unexpected result", size)
+		}
+	}
+}
+
+func TestDecodeArrayStream(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader(`[{"id": 1}, {"id": 2, "tags": ["a"]}, 3, "x"] {"next": true}`))
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	var elems []string
+
+	err = dec.DecodeArrayStream(func(elem encoding.RawMessage) error {
+		elems = append(elems, string(elem))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{`{"id":1}`, `{"id":2,"tags":["a"]}`, `3`, `"x"`}
+	if !reflect.DeepEqual(elems, want) {
+		t.Errorf("Expected %q, got %q", want, elems)
+	}
+
+	var next map[string]bool
+	if err := dec.Decode(&next); err != nil || !next["next"] {
+		t.Errorf("Expected the following value to be decoded, got %v, %v", next, err)
+	}
+
+	errStop := errors.New("stop")
+
+	dec, _ = encoding.NewDecoder(strings.NewReader(`[1, 2, 3]`))
+	if err := dec.DecodeArrayStream(func(encoding.RawMessage) error { return errStop }); !errors.Is(err, errStop) {
+		t.Errorf("Expected the handler error, got %v", err)
+	}
+
+	for _, input := range []string{`{"a": 1}`, `[1, 2`, `[1 2]`} {
+		dec, _ = encoding.NewDecoder(strings.NewReader(input))
+		err := dec.DecodeArrayStream(func(encoding.RawMessage) error { return nil })
+		checkJSONError(t, err, encoding.ErrInvalidJSON, "")
+	}
+}
+
+func TestEncoderIncremental(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, err := encoding.NewEncoder(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	steps := []func() error{
+		enc.BeginObject,
+		func() error { return enc.WriteKey("count") },
+		func() error { return enc.WriteValue(2) },
+		func() error { return enc.WriteKey("items") },
+		enc.BeginArray,
+		func() error { return enc.WriteValue(map[string]string{"a": "b"}) },
+		enc.BeginArray,
+		enc.EndArray,
+		func() error { return enc.WriteValue("x") },
+		enc.EndArray,
+		enc.EndObject,
+	}
+
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if err := enc.WriteValue(true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "{\"count\":2,\"items\":[{\"a\":\"b\"},[],\"x\"]}\ntrue\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestEncoderIncrementalErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       func(enc encoding.JSONEncoder) error
+		expectedErr string
+	}{
+		{
+			name:        "End without begin",
+			steps:       func(enc encoding.JSONEncoder) error { return enc.EndArray() },
+			expectedErr: "no open array to end",
+		},
+		{
+			name: "Mismatched end",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginArray()
+				return enc.EndObject()
+			},
+			expectedErr: "no open object to end",
+		},
+		{
+			name: "Key in array",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginArray()
+				return enc.WriteKey("a")
+			},
+			expectedErr: "WriteKey called outside of an object",
+		},
+		{
+			name: "Value without key",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginObject()
+				return enc.WriteValue(1)
+			},
+			expectedErr: "object member value written without a key",
+		},
+		{
+			name: "Two keys",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginObject()
+				_ = enc.WriteKey("a")
+				return enc.WriteKey("b")
+			},
+			expectedErr: "written before the previous member value",
+		},
+		{
+			name: "Key without value",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginObject()
+				_ = enc.WriteKey("a")
+				return enc.EndObject()
+			},
+			expectedErr: "object ended after a key without a value",
+		},
+		{
+			name: "Encode inside container",
+			steps: func(enc encoding.JSONEncoder) error {
+				_ = enc.BeginArray()
+				return enc.Encode(1)
+			},
+			expectedErr: "cannot encode a value while a container is open",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := encoding.NewEncoder(io.Discard)
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+
+			err = tt.steps(enc)
+			checkJSONError(t, err, encoding.ErrMarshalFailure, tt.expectedErr)
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	client, server := net.Pipe()
+
+	serverStream, err := encoding.NewStream(server)
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+
+	// Echo requests back until the client closes its end
+	go func() {
+		defer serverStream.Close()
+
+		for {
+			var req map[string]interface{}
+			if err := serverStream.ReadJSON(&req); err != nil {
+				return
+			}
+
+			if err := serverStream.Skip(); err != nil {
+				return
+			}
+
+			if err := serverStream.WriteJSON(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	stream, err := encoding.NewStream(client)
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		stream.FormatJSON(i == 2)
+
+		if err := stream.WriteJSON(map[string]int{"id": i}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := stream.Write([]byte("\"skipped\"\n")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var resp map[string]int
+		if err := stream.ReadJSON(&resp); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if resp["id"] != i {
+			t.Errorf("Expected id %d, got %v", i, resp)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := stream.Close(); !errors.Is(err, encoding.ErrStreamClosed) {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+
+	if err := stream.WriteJSON(1); !errors.Is(err, encoding.ErrStreamClosed) {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+
+	var v interface{}
+	if err := stream.ReadJSON(&v); !errors.Is(err, encoding.ErrStreamClosed) {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+}
+
+func TestStreamFormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	stream, err := encoding.NewStream(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+
+	_ = stream.WriteJSON(map[string]int{"a": 1})
+	stream.FormatJSON(true)
+	_ = stream.WriteJSON(map[string]int{"a": 1})
+
+	want := "{\"a\":1}\n{\n  \"a\": 1\n}\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader(`{"a": 1} {"a": 2}`), encoding.WithAllowComments())
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+
+	var v map[string]int
+	if err := dec.Decode(&v); err != nil || v["a"] != 1 {
+		t.Fatalf("Unexpected result %v, %v", v, err)
+	}
+
+	rd := dec.(encoding.ResettableDecoder)
+	rd.Reset(strings.NewReader(`/* next */ {"b": 3}`))
+
+	v = nil
+	if err := rd.Decode(&v); err != nil || v["b"] != 3 || len(v) != 1 {
+		t.Fatalf("Unexpected result after reset %v, %v", v, err)
+	}
+
+	if rd.More() {
+		t.Error("Expected no more values after the reset input")
+	}
+
+	dec, _ = encoding.NewDecoder(strings.NewReader(`{}`), encoding.WithTransparentDecompression())
+	rd = dec.(encoding.ResettableDecoder)
+	rd.Reset(bytes.NewReader([]byte{0x1f, 0x8b, 0}))
+
+	checkJSONError(t, rd.Decode(&v), encoding.ErrInvalidJSON, "failed to open gzip stream")
+}
+
+func TestEncoderReset(t *testing.T) {
+	var first, second bytes.Buffer
+
+	enc, err := encoding.NewEncoder(&first)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	re := enc.(encoding.ResettableEncoder)
+
+	_ = re.Encode(map[string]int{"a": 1})
+	_ = re.BeginArray()
+
+	re.Reset(&second)
+
+	if err := re.Encode([]int{1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if first.String() != "{\"a\":1}\n" || second.String() != "[1]\n" {
+		t.Errorf("Unexpected output %q, %q", first.String(), second.String())
+	}
+}
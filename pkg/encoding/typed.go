@@ -0,0 +1,64 @@
+package encoding
+
+import "io"
+
+// MarshalT converts a value of type T into JSON. It behaves like Marshal but keeps the
+// static type of v, which leaves room for encoders specialized per type.
+func MarshalT[T any](v T, opts ...Option) ([]byte, error) {
+	return Marshal(v, opts...)
+}
+
+// UnmarshalT parses JSON data into a new value of type T and returns it.
+//
+//	cfg, err := encoding.UnmarshalT[Config](data)
+func UnmarshalT[T any](data []byte, opts ...Option) (T, error) {
+	var v T
+
+	if err := Unmarshal(data, &v, opts...); err != nil {
+		var zero T
+
+		return zero, err
+	}
+
+	return v, nil
+}
+
+// DecodeAll reads every remaining JSON value from dec into new values of type T and
+// appends them to out. The values decoded before an error are kept in out.
+//
+//	var events []Event
+//	err := encoding.DecodeAll(dec, &events)
+func DecodeAll[T any](dec JSONDecoder, out *[]T) error {
+	for {
+		var v T
+
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		*out = append(*out, v)
+	}
+}
+
+// DecodeT reads the next JSON value from dec into a new value of type T and returns it.
+//
+//	for dec.More() {
+//		event, err := encoding.DecodeT[Event](dec)
+//		...
+//	}
+func DecodeT[T any](dec JSONDecoder) (T, error) {
+	var v T
+
+	if err := dec.Decode(&v); err != nil {
+		var zero T
+
+		return zero, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,92 @@
+package encoding_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type typedPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func TestTypedRoundTrip(t *testing.T) {
+	data, err := encoding.MarshalT(typedPoint{X: 1, Y: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p, err := encoding.UnmarshalT[typedPoint](data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if p != (typedPoint{X: 1, Y: 2}) {
+		t.Errorf("Expected {1 2}, got %+v", p)
+	}
+
+	list, err := encoding.UnmarshalT[[]string]([]byte(`["a", "b"]`))
+	if err != nil || len(list) != 2 || list[1] != "b" {
+		t.Errorf("Unexpected result %v: %v", list, err)
+	}
+}
+
+func TestUnmarshalTError(t *testing.T) {
+	p, err := encoding.UnmarshalT[typedPoint]([]byte(`{"x": "one"}`))
+	checkJSONError(t, err, encoding.ErrUnexpectedType, "")
+
+	if p != (typedPoint{}) {
+		t.Errorf("Expected zero value on error, got %+v", p)
+	}
+}
+
+func TestDecodeT(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader(`{"x": 1, "y": 2} {"x": 3, "y": 4}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []typedPoint
+
+	for dec.More() {
+		p, err := encoding.DecodeT[typedPoint](dec)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		got = append(got, p)
+	}
+
+	if len(got) != 2 || got[1] != (typedPoint{X: 3, Y: 4}) {
+		t.Errorf("Unexpected result: %+v", got)
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	dec, err := encoding.NewDecoder(strings.NewReader("{\"x\": 1, \"y\": 2}\n{\"x\": 3, \"y\": 4}\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := []typedPoint{{X: 0, Y: 0}}
+	if err := encoding.DecodeAll(dec, &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := []typedPoint{{0, 0}, {1, 2}, {3, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// The values before an error are kept
+	dec, _ = encoding.NewDecoder(strings.NewReader(`{"x": 5} {"x": "a"}`))
+
+	got = nil
+	checkJSONError(t, encoding.DecodeAll(dec, &got), encoding.ErrUnexpectedType, "")
+
+	if len(got) != 1 || got[0].X != 5 {
+		t.Errorf("Expected the first value, got %+v", got)
+	}
+}
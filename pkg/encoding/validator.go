@@ -0,0 +1,67 @@
+package encoding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Validator is the interface implemented by types that check their own invariants once
+// decoded. Unmarshal and Decode call ValidateJSON on every value decoded from the input
+// whose type or pointer type implements Validator, nested values before the values that
+// contain them. All failures are reported together as ValidationErrors in an
+// ErrValidationFailure error.
+type Validator interface {
+	ValidateJSON() error
+}
+
+// validatorType is the reflect.Type of Validator.
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// ValidationErrors lists every value whose ValidateJSON method failed, with its JSON
+// Pointer.
+type ValidationErrors []*FieldError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+
+	return fmt.Sprintf("%d values are invalid: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual field errors, so errors.Is and errors.As inspect each one
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// validate calls ValidateJSON on the decoded value rv and records a failure at the
+// current path. The check on the type comes first, so that values that do not implement
+// Validator cost no allocation.
+func (d *decodeState) validate(rv reflect.Value) {
+	var validator Validator
+
+	switch {
+	case rv.Type().Implements(validatorType):
+		if rv.Kind() == reflect.Ptr && rv.IsNil() || rv.Kind() == reflect.Interface {
+			return
+		}
+
+		validator = rv.Interface().(Validator)
+	case rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(validatorType):
+		validator = rv.Addr().Interface().(Validator)
+	default:
+		return
+	}
+
+	if err := validator.ValidateJSON(); err != nil {
+		d.invalid = append(d.invalid, &FieldError{Path: pointerPath(d.path), Err: err})
+	}
+}
@@ -0,0 +1,83 @@
+package encoding_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+type port int
+
+// ValidateJSON implements encoding.Validator
+func (p port) ValidateJSON() error {
+	if p <= 0 || p > 65535 {
+		return errors.New("port out of range")
+	}
+
+	return nil
+}
+
+type endpoint struct {
+	Host string `json:"host"`
+	Port port   `json:"port"`
+}
+
+// ValidateJSON implements encoding.Validator
+func (e *endpoint) ValidateJSON() error {
+	if e.Host == "" {
+		return errors.New("host is required")
+	}
+
+	return nil
+}
+
+type cluster struct {
+	Name      string     `json:"name"`
+	Endpoints []endpoint `json:"endpoints"`
+}
+
+func TestValidator(t *testing.T) {
+	var c cluster
+	if err := encoding.Unmarshal([]byte(`{"name": "a", "endpoints": [{"host": "h", "port": 80}]}`), &c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	input := `{"name": "a", "endpoints": [{"host": "h", "port": 80}, {"port": 0}, {"host": "x", "port": 70000}]}`
+
+	err := encoding.Unmarshal([]byte(input), &c)
+	checkJSONError(t, err, encoding.ErrValidationFailure, "3 values are invalid")
+
+	var invalid encoding.ValidationErrors
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	// Nested values are validated before the values that contain them
+	expected := []string{
+		"/endpoints/1/port: port out of range",
+		"/endpoints/1: host is required",
+		"/endpoints/2/port: port out of range",
+	}
+
+	if len(invalid) != len(expected) {
+		t.Fatalf("Expected %d failures, got %v", len(expected), invalid)
+	}
+
+	for i, fe := range invalid {
+		if fe.Error() != expected[i] {
+			t.Errorf("Failure %d: expected %q, got %q", i, expected[i], fe.Error())
+		}
+	}
+
+	dec, err := encoding.NewDecoder(strings.NewReader(`{"port": 1}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var e endpoint
+
+	err = dec.Decode(&e)
+	checkJSONError(t, err, encoding.ErrValidationFailure, ": host is required")
+}
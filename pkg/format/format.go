@@ -0,0 +1,258 @@
+// Package format pretty-prints JSON documents in a configurable style, like gofmt does for
+// Go source. The output only depends on the document and the style, so formatting is
+// stable: formatting the output again yields the same bytes.
+//
+//	out, err := format.Format(src, format.Style{Indent: "  ", SpaceAfterColon: true, TrailingNewline: true})
+//
+// Comments (JSONC) are accepted and kept next to the values they were written with.
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Style configures the layout written by Format.
+type Style struct {
+	// Indent is written once per nesting level before each member and element, which
+	// are written on lines of their own. An empty Indent writes containers on a single
+	// line instead, unless they hold comments.
+	Indent string
+	// SpaceAfterColon writes a space between an object key and its value.
+	SpaceAfterColon bool
+	// SortKeys writes object members in lexicographic key order instead of input order.
+	SortKeys bool
+	// TrailingNewline ends the output with a newline.
+	TrailingNewline bool
+	// MaxInlineWidth keeps a container on a single line, with its members separated by
+	// ", ", when that line, indentation included, is at most this many bytes long. Zero
+	// disables inlining.
+	MaxInlineWidth int
+}
+
+// Format parses the JSON document in src, which must be an object or an array, and
+// writes it in the given style. Syntax errors are returned as a *parser.ParseError.
+func Format(src []byte, style Style) ([]byte, error) {
+	p := parser.NewParser(parser.NewLexerBytes(src), parser.WithPreserveComments())
+
+	value, err := p.ParseJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.More() {
+		return nil, fmt.Errorf("unexpected data after the document at offset %d", p.Offset())
+	}
+
+	f := &formatter{style: style}
+
+	comments := parser.NodeComments(value)
+	if comments != nil {
+		for _, c := range comments.Leading {
+			f.b.WriteString(c + "\n")
+		}
+	}
+
+	f.value(value, 0, 0)
+
+	if comments != nil {
+		for _, c := range comments.Trailing {
+			f.b.WriteString("\n" + c)
+		}
+	}
+
+	if style.TrailingNewline {
+		f.b.WriteByte('\n')
+	}
+
+	return []byte(f.b.String()), nil
+}
+
+// formatter writes a document in a style.
+type formatter struct {
+	style Style
+	b     strings.Builder
+}
+
+// value writes v at the given nesting level; column is the length of the line before v.
+func (f *formatter) value(v parser.Value, level, column int) {
+	var (
+		keys         []string
+		elems        []parser.Value
+		open, closer string
+	)
+
+	switch val := v.(type) {
+	case *parser.Object:
+		keys = f.keys(val)
+		elems = make([]parser.Value, len(keys))
+		open, closer = "{", "}"
+
+		for i, k := range keys {
+			elems[i] = val.Pairs[k]
+		}
+	case *parser.Array:
+		elems = val.Elements
+		open, closer = "[", "]"
+	default:
+		f.b.WriteString(v.String())
+		return
+	}
+
+	if !holdsComments(v) {
+		if f.style.Indent == "" {
+			f.inline(v, ",")
+			return
+		}
+
+		if len(elems) == 0 {
+			f.b.WriteString(open + closer)
+			return
+		}
+
+		if width := f.style.MaxInlineWidth; width > 0 {
+			line := formatter{style: f.style}
+			if line.inline(v, ", "); column+line.b.Len() <= width {
+				f.b.WriteString(line.b.String())
+				return
+			}
+		}
+	}
+
+	indent := strings.Repeat(f.style.Indent, level+1)
+
+	f.b.WriteString(open)
+
+	for i, elem := range elems {
+		comments := parser.NodeComments(elem)
+		if comments != nil {
+			for _, c := range comments.Leading {
+				f.b.WriteString("\n" + indent + c)
+			}
+		}
+
+		f.b.WriteString("\n" + indent)
+
+		column := len(indent)
+		if keys != nil {
+			column += f.key(keys[i])
+		}
+
+		f.value(elem, level+1, column)
+
+		if i < len(elems)-1 {
+			f.b.WriteByte(',')
+		}
+
+		if comments != nil {
+			for _, c := range comments.Trailing {
+				f.b.WriteString(" " + c)
+			}
+		}
+	}
+
+	if comments := parser.NodeComments(v); comments != nil {
+		for _, c := range comments.Footer {
+			f.b.WriteString("\n" + indent + c)
+		}
+	}
+
+	f.b.WriteString("\n" + strings.Repeat(f.style.Indent, level) + closer)
+}
+
+// inline writes v, which holds no comments, on a single line with sep between the
+// members and elements of containers.
+func (f *formatter) inline(v parser.Value, sep string) {
+	switch val := v.(type) {
+	case *parser.Object:
+		f.b.WriteByte('{')
+
+		for i, k := range f.keys(val) {
+			if i > 0 {
+				f.b.WriteString(sep)
+			}
+
+			f.key(k)
+			f.inline(val.Pairs[k], sep)
+		}
+
+		f.b.WriteByte('}')
+	case *parser.Array:
+		f.b.WriteByte('[')
+
+		for i, elem := range val.Elements {
+			if i > 0 {
+				f.b.WriteString(sep)
+			}
+
+			f.inline(elem, sep)
+		}
+
+		f.b.WriteByte(']')
+	default:
+		f.b.WriteString(v.String())
+	}
+}
+
+// key writes an object key and its colon, and returns the number of bytes written.
+func (f *formatter) key(k string) int {
+	n := f.b.Len()
+
+	f.b.WriteString(parser.NewString(k).String())
+	f.b.WriteByte(':')
+
+	if f.style.SpaceAfterColon {
+		f.b.WriteByte(' ')
+	}
+
+	return f.b.Len() - n
+}
+
+// keys returns the keys of obj in output order.
+func (f *formatter) keys(obj *parser.Object) []string {
+	keys := obj.OrderedKeys()
+
+	if f.style.SortKeys {
+		keys = append([]string(nil), keys...)
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
+// holdsComments reports whether the container v has comments after its last member or
+// element, or whether any value in it has comments.
+func holdsComments(v parser.Value) bool {
+	if c := parser.NodeComments(v); c != nil && len(c.Footer) > 0 {
+		return true
+	}
+
+	switch val := v.(type) {
+	case *parser.Object:
+		for _, member := range val.Pairs {
+			if hasComments(member) {
+				return true
+			}
+		}
+	case *parser.Array:
+		for _, elem := range val.Elements {
+			if hasComments(elem) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasComments reports whether v or any value in it has comments.
+func hasComments(v parser.Value) bool {
+	if c := parser.NodeComments(v); c != nil && (len(c.Leading) > 0 || len(c.Trailing) > 0) {
+		return true
+	}
+
+	return holdsComments(v)
+}
@@ -0,0 +1,114 @@
+package format_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/format"
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+func TestFormat(t *testing.T) {
+	input := `{"name":"api","tags":["a","b"],"db":{"port":5432,"hosts":[]},"empty":{}}`
+
+	tests := []struct {
+		name     string
+		input    string
+		style    format.Style
+		expected string
+	}{
+		{
+			name:     "Compact",
+			input:    input,
+			style:    format.Style{},
+			expected: `{"name":"api","tags":["a","b"],"db":{"port":5432,"hosts":[]},"empty":{}}`,
+		},
+		{
+			name:     "Compact with spaces and sorted keys",
+			input:    input,
+			style:    format.Style{SpaceAfterColon: true, SortKeys: true, TrailingNewline: true},
+			expected: "{\"db\": {\"hosts\": [],\"port\": 5432},\"empty\": {},\"name\": \"api\",\"tags\": [\"a\",\"b\"]}\n",
+		},
+		{
+			name:  "Indented",
+			input: input,
+			style: format.Style{Indent: "  ", SpaceAfterColon: true},
+			expected: `{
+  "name": "api",
+  "tags": [
+    "a",
+    "b"
+  ],
+  "db": {
+    "port": 5432,
+    "hosts": []
+  },
+  "empty": {}
+}`,
+		},
+		{
+			name:  "Inline width",
+			input: input,
+			style: format.Style{Indent: "\t", MaxInlineWidth: 20},
+			expected: `{
+	"name":"api",
+	"tags":["a", "b"],
+	"db":{
+		"port":5432,
+		"hosts":[]
+	},
+	"empty":{}
+}`,
+		},
+		{
+			name:  "Comments",
+			input: "// head\n[1, /* one */ 2, {\"a\": [3] // three\n}]",
+			style: format.Style{Indent: "  ", SpaceAfterColon: true, MaxInlineWidth: 80},
+			expected: `// head
+[
+  1, /* one */
+  2,
+  {
+    "a": [3] // three
+  }
+]`,
+		},
+		{
+			name:     "Numbers and strings",
+			input:    `[1.50e3, -0, "A\t\/"]`,
+			style:    format.Style{},
+			expected: `[1.50e3,-0,"A\t/"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := format.Format([]byte(tt.input), tt.style)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(out) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, out)
+			}
+
+			// Formatting is stable
+			again, err := format.Format(out, tt.style)
+			if err != nil || string(again) != string(out) {
+				t.Errorf("Expected formatting the output to keep it, got %s, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	var pe *parser.ParseError
+
+	if _, err := format.Format([]byte(`{"a": }`), format.Style{}); !errors.As(err, &pe) {
+		t.Errorf("Expected a *parser.ParseError, got %v", err)
+	}
+
+	if _, err := format.Format([]byte(`{} []`), format.Style{}); err == nil {
+		t.Error("Expected trailing data to be rejected")
+	}
+}
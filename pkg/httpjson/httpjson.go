@@ -0,0 +1,105 @@
+// Package httpjson provides helpers for decoding JSON request bodies and writing JSON
+// responses with jingo's encoding package.
+package httpjson
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// ContentType is the media type written by WriteResponse
+const ContentType = "application/json; charset=utf-8"
+
+// ErrUnsupportedMediaType is wrapped by the errors DecodeRequest returns for a request
+// without a JSON Content-Type
+var ErrUnsupportedMediaType = errors.New("httpjson: unsupported media type")
+
+// DecodeRequest decodes the JSON body of r into the value pointed to by v. The request
+// must have an application/json (or +json) Content-Type. The body is decoded in strict
+// mode, so unknown struct fields are rejected like data after the document, and MaxSize
+// is enforced while the body is read. opts are applied after the defaults, so a
+// different size limit can be set with encoding.WithMaxSize.
+func DecodeRequest(r *http.Request, v interface{}, opts ...encoding.Option) error {
+	if err := checkContentType(r.Header.Get("Content-Type")); err != nil {
+		return err
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return encoding.NewJSONError(encoding.ErrInvalidJSON, "request body is empty")
+	}
+
+	opts = append([]encoding.Option{encoding.WithStrictMode()}, opts...)
+
+	return encoding.UnmarshalReader(r.Body, v, opts...)
+}
+
+// WriteResponse writes v as a JSON response with the given status code. The value is
+// encoded before anything is written, so when encoding fails the response is left
+// untouched and the caller can still report the error.
+func WriteResponse(w http.ResponseWriter, status int, v interface{}, opts ...encoding.Option) error {
+	data, err := encoding.Marshal(v, opts...)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+
+	if _, err := w.Write(data); err != nil {
+		return encoding.NewJSONError(encoding.ErrMarshalFailure, "failed to write response").
+			WithCause(err)
+	}
+
+	return nil
+}
+
+// StatusCode returns the HTTP status that fits an error returned by DecodeRequest:
+// 413 when the body is too large or exceeds a structural limit, 415 for a wrong
+// Content-Type, 422 when a decoded value fails its ValidateJSON method, 500 for mistakes
+// on the server side such as invalid options, a non-pointer target or a Go type that
+// cannot be decoded, and 400 otherwise. Wrapped errors are unwrapped.
+func StatusCode(err error) int {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return http.StatusUnsupportedMediaType
+	}
+
+	var jsonErr *encoding.JSONError
+	if !errors.As(err, &jsonErr) {
+		return http.StatusBadRequest
+	}
+
+	switch jsonErr.Code {
+	case encoding.ErrSizeExceeded, encoding.ErrLimitExceeded:
+		return http.StatusRequestEntityTooLarge
+	case encoding.ErrValidationFailure:
+		return http.StatusUnprocessableEntity
+	case encoding.ErrInvalidOptions, encoding.ErrInvalidTarget, encoding.ErrUnsupportedType:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// checkContentType accepts application/json and structured syntax suffixes such as
+// application/problem+json.
+func checkContentType(header string) error {
+	if header == "" {
+		return fmt.Errorf("%w: missing Content-Type, expected application/json", ErrUnsupportedMediaType)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fmt.Errorf("%w: invalid Content-Type %q: %v", ErrUnsupportedMediaType, header, err)
+	}
+
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return fmt.Errorf("%w: unsupported Content-Type %q, expected application/json", ErrUnsupportedMediaType, mediaType)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package httpjson_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/httpjson"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// ValidateJSON implements encoding.Validator
+func (u user) ValidateJSON() error {
+	if u.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+
+	return nil
+}
+
+func newRequest(contentType, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	return r
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []encoding.Option
+		want        user
+		status      int
+		msg         string
+	}{
+		{
+			name:        "Valid request",
+			contentType: "application/json",
+			body:        `{"name": "Ana", "age": 30}`,
+			want:        user{Name: "Ana", Age: 30},
+		},
+		{
+			name:        "Structured syntax suffix",
+			contentType: "application/vnd.api+json; charset=utf-8",
+			body:        `{"name": "Ana"}`,
+			want:        user{Name: "Ana"},
+		},
+		{
+			name:   "Missing content type",
+			body:   `{"name": "Ana"}`,
+			status: http.StatusUnsupportedMediaType,
+			msg:    "missing Content-Type",
+		},
+		{
+			name:        "Wrong content type",
+			contentType: "text/plain",
+			body:        `{"name": "Ana"}`,
+			status:      http.StatusUnsupportedMediaType,
+			msg:         `unsupported Content-Type "text/plain"`,
+		},
+		{
+			name:        "Unknown field",
+			contentType: "application/json",
+			body:        `{"name": "Ana", "admin": true}`,
+			status:      http.StatusBadRequest,
+			msg:         `unknown field "admin"`,
+		},
+		{
+			name:        "Trailing data",
+			contentType: "application/json",
+			body:        `{"name": "Ana"} {"name": "Bob"}`,
+			status:      http.StatusBadRequest,
+			msg:         "unexpected data after top-level value",
+		},
+		{
+			name:        "Invalid value",
+			contentType: "application/json",
+			body:        `{"name": "Ana", "age": -1}`,
+			status:      http.StatusUnprocessableEntity,
+			msg:         "age must not be negative",
+		},
+		{
+			name:        "Too large",
+			contentType: "application/json",
+			body:        `{"name": "` + strings.Repeat("a", 2048) + `"}`,
+			opts:        []encoding.Option{encoding.WithMaxSize(1024)},
+			status:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "Too deep",
+			contentType: "application/json",
+			body:        `{"name": [[["Ana"]]]}`,
+			opts:        []encoding.Option{encoding.WithMaxDepth(3)},
+			status:      http.StatusRequestEntityTooLarge,
+			msg:         "MaxDepth limit of 3 exceeded",
+		},
+		{
+			name:        "Empty body",
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest(tt.contentType, tt.body)
+			if tt.body == "" {
+				r.Body = http.NoBody
+			}
+
+			var got user
+
+			err := httpjson.DecodeRequest(r, &got, tt.opts...)
+			if tt.status != 0 {
+				if err == nil {
+					t.Fatal("Expected error, got none")
+				}
+
+				if status := httpjson.StatusCode(err); status != tt.status {
+					t.Errorf("Expected status %d, got %d (%v)", tt.status, status, err)
+				}
+
+				if !strings.Contains(err.Error(), tt.msg) {
+					t.Errorf("Expected error to contain %q, got %q", tt.msg, err.Error())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	if err := httpjson.WriteResponse(w, http.StatusCreated, user{Name: "Ana", Age: 30}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != httpjson.ContentType {
+		t.Errorf("Expected Content-Type %q, got %q", httpjson.ContentType, ct)
+	}
+
+	var got user
+	if err := encoding.Unmarshal(w.Body.Bytes(), &got); err != nil || got != (user{Name: "Ana", Age: 30}) {
+		t.Errorf("Unexpected body %s: %v", w.Body.String(), err)
+	}
+
+	w = httptest.NewRecorder()
+	if err := httpjson.WriteResponse(w, http.StatusOK, make(chan int)); err == nil {
+		t.Fatal("Expected error for unsupported type")
+	}
+
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Error("Expected response to be left untouched after an encoding error")
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "Unsupported media type", err: httpjson.ErrUnsupportedMediaType, status: http.StatusUnsupportedMediaType},
+		{name: "Syntax error", err: encoding.NewJSONError(encoding.ErrInvalidJSON, "bad"), status: http.StatusBadRequest},
+		{name: "Unsupported Go type", err: encoding.NewUnsupportedTypeError("chan int"), status: http.StatusInternalServerError},
+		{
+			name:   "Wrapped error",
+			err:    fmt.Errorf("decoding user: %w", encoding.NewSizeExceededError(2048, 1024)),
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{name: "Other error", err: errors.New("connection reset"), status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := httpjson.StatusCode(tt.err); status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// ErrClosed is returned by calls on a connection that was closed by either side
+var ErrClosed = errors.New("jsonrpc: connection closed")
+
+// Handler answers the requests received on a Conn. The returned value is encoded as the
+// result. A returned *Error is sent as is and any other error with CodeInternalError.
+// The result of a notification is discarded.
+type Handler func(ctx context.Context, req *Request) (interface{}, error)
+
+// MethodNotFound returns the error a Handler reports for a method it does not know
+func MethodNotFound(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+}
+
+// Conn is a JSON-RPC 2.0 connection over an encoding.Stream. Both sides can call: the
+// requests received are answered by the handler, each in its own goroutine, and the
+// responses received are matched with the pending calls by id. A Conn is safe for
+// concurrent use.
+type Conn struct {
+	stream  encoding.Stream
+	handler Handler
+	// ctx is passed to the handler and canceled when the connection stops
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mutex   sync.Mutex
+	pending map[string]chan Response
+	nextID  int64
+
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+// NewConn starts serving stream and returns the connection. handler may be nil for a
+// connection that only makes calls; requests are then answered with CodeMethodNotFound.
+func NewConn(stream encoding.Stream, handler Handler) *Conn {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Conn{
+		stream:  stream,
+		handler: handler,
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]chan Response),
+		done:    make(chan struct{}),
+	}
+
+	go c.read()
+
+	return c
+}
+
+// Call sends a request for method with params and waits for the response, whose result
+// is decoded into result unless it is nil. A failed call returns the *Error sent by the
+// peer.
+func (c *Conn) Call(ctx context.Context, method string, params, result interface{}) error {
+	req, err := newRequest(method, params)
+	if err != nil {
+		return err
+	}
+
+	ch, err := c.register(&req)
+	if err != nil {
+		return err
+	}
+
+	defer c.unregister(req.ID)
+
+	if err := c.stream.WriteJSON(req); err != nil {
+		return err
+	}
+
+	resp, err := c.wait(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	return resp.decode(result)
+}
+
+// Notify sends a notification for method with params. No response is expected.
+func (c *Conn) Notify(method string, params interface{}) error {
+	req, err := newRequest(method, params)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Err(); err != nil {
+		return err
+	}
+
+	return c.stream.WriteJSON(req)
+}
+
+// BatchCall is one request of a batch sent with Batch
+type BatchCall struct {
+	Method string
+	Params interface{}
+	// Result receives the decoded result unless it is nil
+	Result interface{}
+	// Notify sends the request as a notification
+	Notify bool
+	// Error is set by Batch when the call failed
+	Error error
+}
+
+// Batch sends calls as a single batch and waits for all of their responses. The outcome
+// of each call is reported in its Error field; the returned error is about the batch as
+// a whole, such as a closed connection or ctx being done.
+func (c *Conn) Batch(ctx context.Context, calls []*BatchCall) error {
+	if len(calls) == 0 {
+		return &Error{Code: CodeInvalidRequest, Message: "empty batch"}
+	}
+
+	reqs := make([]Request, len(calls))
+	chans := make([]chan Response, len(calls))
+
+	for i, call := range calls {
+		req, err := newRequest(call.Method, call.Params)
+		if err != nil {
+			return err
+		}
+
+		if !call.Notify {
+			if chans[i], err = c.register(&req); err != nil {
+				return err
+			}
+
+			defer c.unregister(req.ID)
+		}
+
+		reqs[i] = req
+	}
+
+	if err := c.stream.WriteJSON(reqs); err != nil {
+		return err
+	}
+
+	for i, call := range calls {
+		if chans[i] == nil {
+			continue
+		}
+
+		resp, err := c.wait(ctx, chans[i])
+		if err != nil {
+			return err
+		}
+
+		call.Error = resp.decode(call.Result)
+	}
+
+	return nil
+}
+
+// Done returns a channel that is closed when the connection stops
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that stopped the connection, or nil while it is running
+func (c *Conn) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the connection and closes the stream. Pending calls return ErrClosed.
+func (c *Conn) Close() error {
+	c.stop(ErrClosed)
+
+	if err := c.stream.Close(); err != nil && !errors.Is(err, encoding.ErrStreamClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// stop records the first error and wakes up the pending calls.
+func (c *Conn) stop(err error) {
+	c.once.Do(func() {
+		c.err = err
+		c.cancel()
+		close(c.done)
+	})
+}
+
+// register assigns the next id to req and returns the channel its response is sent on.
+func (c *Conn) register(req *Request) (chan Response, error) {
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.nextID++
+	req.ID = encoding.RawMessage(strconv.FormatInt(c.nextID, 10))
+
+	ch := make(chan Response, 1)
+	c.pending[string(req.ID)] = ch
+
+	return ch, nil
+}
+
+// unregister forgets a call that is no longer waiting.
+func (c *Conn) unregister(id encoding.RawMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.pending, string(id))
+}
+
+// wait waits for the response on ch.
+func (c *Conn) wait(ctx context.Context, ch chan Response) (Response, error) {
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case <-c.done:
+		return Response{}, c.err
+	}
+}
+
+// read dispatches the messages received until the stream fails. After a syntax error the
+// connection is stopped, since the stream can no longer be split into messages reliably.
+func (c *Conn) read() {
+	for {
+		var raw encoding.RawMessage
+
+		if err := c.stream.ReadJSON(&raw); err != nil {
+			var jsonErr *encoding.JSONError
+			if errors.As(err, &jsonErr) && jsonErr.Code == encoding.ErrInvalidJSON {
+				c.write(Response{Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			}
+
+			if err == io.EOF || c.Err() != nil {
+				err = ErrClosed
+			}
+
+			c.stop(err)
+
+			return
+		}
+
+		if raw[0] == '[' {
+			c.readBatch(raw)
+		} else {
+			c.readMessage(raw)
+		}
+	}
+}
+
+// readMessage handles a single request or response.
+func (c *Conn) readMessage(raw encoding.RawMessage) {
+	var msg message
+
+	if err := msg.decode(raw); err != nil {
+		c.write(Response{ID: msg.ID, Error: toError(err)})
+		return
+	}
+
+	if !msg.isRequest() {
+		c.deliver(msg)
+		return
+	}
+
+	go func() {
+		if resp := c.serve(msg.request()); resp != nil {
+			c.write(*resp)
+		}
+	}()
+}
+
+// readBatch handles a batch. Its requests are served in order and answered with one
+// batch of responses; responses are delivered right away.
+func (c *Conn) readBatch(raw encoding.RawMessage) {
+	var elems []encoding.RawMessage
+
+	if err := encoding.Unmarshal(raw, &elems, encoding.WithDisableSizeLimit()); err != nil || len(elems) == 0 {
+		c.write(Response{Error: &Error{Code: CodeInvalidRequest, Message: "invalid batch"}})
+		return
+	}
+
+	var (
+		reqs    []Request
+		replies []Response
+	)
+
+	for _, elem := range elems {
+		var msg message
+
+		switch err := msg.decode(elem); {
+		case err != nil:
+			replies = append(replies, Response{ID: msg.ID, Error: toError(err)})
+		case msg.isRequest():
+			reqs = append(reqs, msg.request())
+		default:
+			c.deliver(msg)
+		}
+	}
+
+	if len(reqs) == 0 && len(replies) == 0 {
+		return
+	}
+
+	go func() {
+		for _, req := range reqs {
+			if resp := c.serve(req); resp != nil {
+				replies = append(replies, *resp)
+			}
+		}
+
+		if len(replies) > 0 {
+			c.write(replies)
+		}
+	}()
+}
+
+// deliver hands a response to the call waiting for it. Responses to unknown ids are
+// dropped.
+func (c *Conn) deliver(msg message) {
+	resp, err := msg.response()
+	if err != nil {
+		resp = Response{ID: msg.ID, Error: toError(err)}
+	}
+
+	c.mutex.Lock()
+	ch, ok := c.pending[string(resp.ID)]
+	delete(c.pending, string(resp.ID))
+	c.mutex.Unlock()
+
+	if ok {
+		ch <- resp
+	}
+}
+
+// serve calls the handler and returns the response to send, or nil for a notification.
+func (c *Conn) serve(req Request) *Response {
+	var (
+		result interface{}
+		err    error
+	)
+
+	if c.handler == nil {
+		err = MethodNotFound(req.Method)
+	} else {
+		result, err = c.handler(c.ctx, &req)
+	}
+
+	if req.IsNotification() {
+		return nil
+	}
+
+	resp := &Response{ID: req.ID}
+
+	if err != nil {
+		resp.Error = toError(err)
+		return resp
+	}
+
+	data, err := encoding.Marshal(result, encoding.WithDisableSizeLimit())
+	if err != nil {
+		resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		return resp
+	}
+
+	resp.Result = data
+
+	return resp
+}
+
+// write sends a response or a batch of responses. Failures stop the connection.
+func (c *Conn) write(v interface{}) {
+	if err := c.stream.WriteJSON(v); err != nil {
+		c.stop(err)
+	}
+}
+
+// decode decodes the result of a successful response into v.
+func (r Response) decode(v interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	if v == nil || r.Result == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return encoding.NewInvalidTargetError("result must be a non-nil pointer")
+	}
+
+	// The decoder only accepts an object or an array at the top level, so the result is
+	// decoded as the only element of an array
+	slice := reflect.New(reflect.SliceOf(rv.Type().Elem()))
+
+	data := make([]byte, 0, len(r.Result)+2)
+	data = append(append(append(data, '['), r.Result...), ']')
+
+	if err := encoding.Unmarshal(data, slice.Interface(), encoding.WithDisableSizeLimit()); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(slice.Elem().Index(0))
+
+	return nil
+}
+
+// newRequest builds a request without an id.
+func newRequest(method string, params interface{}) (Request, error) {
+	req := Request{Method: method}
+
+	if params != nil {
+		data, err := encoding.Marshal(params, encoding.WithDisableSizeLimit())
+		if err != nil {
+			return Request{}, err
+		}
+
+		req.Params = data
+	}
+
+	return req, nil
+}
+
+// toError converts an error returned by a handler into an error object.
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	return &Error{Code: CodeInternalError, Message: err.Error()}
+}
@@ -0,0 +1,258 @@
+// Package jsonrpc implements JSON-RPC 2.0 over a jingo encoding.Stream: the message
+// types, a connection that correlates responses with calls by id, and batches.
+package jsonrpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// Version is the value of the "jsonrpc" member of every message
+const Version = "2.0"
+
+// Error codes defined by the JSON-RPC 2.0 specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Request is a call or, when ID is nil, a notification that expects no response. Params
+// and ID hold raw JSON; a nil Params is omitted.
+type Request struct {
+	Method string
+	Params encoding.RawMessage
+	ID     encoding.RawMessage
+}
+
+// IsNotification reports whether the request expects no response
+func (r *Request) IsNotification() bool {
+	return r.ID == nil
+}
+
+// DecodeParams decodes the parameters of the request into v. A failure is returned as
+// an *Error with CodeInvalidParams, so that a handler can return it as is.
+func (r *Request) DecodeParams(v interface{}) error {
+	if r.Params == nil {
+		return &Error{Code: CodeInvalidParams, Message: "missing params"}
+	}
+
+	if err := encoding.Unmarshal(r.Params, v, encoding.WithDisableSizeLimit()); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// MarshalJSON implements encoding.Marshaler
+func (r Request) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString(`{"jsonrpc":"2.0","method":`)
+
+	if err := writeJSON(&b, r.Method); err != nil {
+		return nil, err
+	}
+
+	if r.Params != nil {
+		b.WriteString(`,"params":`)
+		b.Write(r.Params)
+	}
+
+	if r.ID != nil {
+		b.WriteString(`,"id":`)
+		b.Write(r.ID)
+	}
+
+	b.WriteByte('}')
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements encoding.Unmarshaler
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var msg message
+	if err := msg.decode(data); err != nil {
+		return err
+	}
+
+	if msg.Method == "" {
+		return &Error{Code: CodeInvalidRequest, Message: "missing method"}
+	}
+
+	*r = msg.request()
+
+	return nil
+}
+
+// Response is the reply to a call. Exactly one of Result and Error is set.
+type Response struct {
+	ID     encoding.RawMessage
+	Result encoding.RawMessage
+	Error  *Error
+}
+
+// MarshalJSON implements encoding.Marshaler. A nil ID is written as null, as required for
+// errors about requests whose id could not be read.
+func (r Response) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString(`{"jsonrpc":"2.0",`)
+
+	if r.Error != nil {
+		b.WriteString(`"error":`)
+
+		if err := writeJSON(&b, r.Error); err != nil {
+			return nil, err
+		}
+	} else {
+		b.WriteString(`"result":`)
+		b.Write(rawOrNull(r.Result))
+	}
+
+	b.WriteString(`,"id":`)
+	b.Write(rawOrNull(r.ID))
+	b.WriteByte('}')
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements encoding.Unmarshaler
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var msg message
+	if err := msg.decode(data); err != nil {
+		return err
+	}
+
+	resp, err := msg.response()
+	if err != nil {
+		return err
+	}
+
+	*r = resp
+
+	return nil
+}
+
+// Error is the error object of a response. It implements error, so a handler can return
+// it to choose the code sent to the caller, and Call returns it when the call failed.
+type Error struct {
+	Code    int
+	Message string
+	Data    encoding.RawMessage
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// MarshalJSON implements encoding.Marshaler
+func (e Error) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `{"code":%d,"message":`, e.Code)
+
+	if err := writeJSON(&b, e.Message); err != nil {
+		return nil, err
+	}
+
+	if e.Data != nil {
+		b.WriteString(`,"data":`)
+		b.Write(e.Data)
+	}
+
+	b.WriteByte('}')
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON implements encoding.Unmarshaler
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Code    int                 `json:"code"`
+		Message string              `json:"message"`
+		Data    encoding.RawMessage `json:"data"`
+	}
+
+	if err := encoding.Unmarshal(data, &aux, encoding.WithDisableSizeLimit()); err != nil {
+		return err
+	}
+
+	e.Code, e.Message, e.Data = aux.Code, aux.Message, aux.Data
+
+	return nil
+}
+
+// message holds the members of any JSON-RPC message, before it is told apart as a
+// request or a response.
+type message struct {
+	Version string              `json:"jsonrpc"`
+	Method  string              `json:"method"`
+	Params  encoding.RawMessage `json:"params"`
+	ID      encoding.RawMessage `json:"id"`
+	Result  encoding.RawMessage `json:"result"`
+	Error   encoding.RawMessage `json:"error"`
+}
+
+// decode decodes and checks the version of a single message.
+func (m *message) decode(data []byte) error {
+	if err := encoding.Unmarshal(data, m, encoding.WithDisableSizeLimit()); err != nil {
+		return &Error{Code: CodeInvalidRequest, Message: err.Error()}
+	}
+
+	if m.Version != Version {
+		return &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("unsupported jsonrpc version %q", m.Version)}
+	}
+
+	return nil
+}
+
+// isRequest reports whether the message is a request or a notification.
+func (m *message) isRequest() bool {
+	return m.Method != ""
+}
+
+// request returns the message as a request.
+func (m *message) request() Request {
+	return Request{Method: m.Method, Params: m.Params, ID: m.ID}
+}
+
+// response returns the message as a response.
+func (m *message) response() (Response, error) {
+	resp := Response{ID: m.ID, Result: m.Result}
+
+	if m.Error != nil {
+		resp.Error = &Error{}
+		if err := resp.Error.UnmarshalJSON(m.Error); err != nil {
+			return Response{}, &Error{Code: CodeInvalidRequest, Message: err.Error()}
+		}
+	}
+
+	return resp, nil
+}
+
+// writeJSON writes the encoding of a scalar or Marshaler value.
+func writeJSON(b *strings.Builder, v interface{}) error {
+	data, err := encoding.Marshal(v, encoding.WithDisableSizeLimit())
+	if err != nil {
+		return err
+	}
+
+	b.Write(data)
+
+	return nil
+}
+
+// rawOrNull returns raw, or null when it is nil.
+func rawOrNull(raw encoding.RawMessage) []byte {
+	if raw == nil {
+		return []byte("null")
+	}
+
+	return raw
+}
@@ -0,0 +1,217 @@
+package jsonrpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/jsonrpc"
+)
+
+func TestMessageEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "Request",
+			value: jsonrpc.Request{Method: "sum", Params: encoding.RawMessage(`[1,2]`), ID: encoding.RawMessage(`1`)},
+			want:  `{"jsonrpc":"2.0","method":"sum","params":[1,2],"id":1}`,
+		},
+		{
+			name:  "Notification",
+			value: jsonrpc.Request{Method: "ping"},
+			want:  `{"jsonrpc":"2.0","method":"ping"}`,
+		},
+		{
+			name:  "Result",
+			value: jsonrpc.Response{ID: encoding.RawMessage(`"a"`), Result: encoding.RawMessage(`3`)},
+			want:  `{"jsonrpc":"2.0","result":3,"id":"a"}`,
+		},
+		{
+			name:  "Error",
+			value: jsonrpc.Response{Error: &jsonrpc.Error{Code: jsonrpc.CodeParseError, Message: "bad"}},
+			want:  `{"jsonrpc":"2.0","error":{"code":-32700,"message":"bad"},"id":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, data)
+			}
+		})
+	}
+
+	var resp jsonrpc.Response
+	if err := encoding.Unmarshal([]byte(`{"jsonrpc": "2.0", "error": {"code": -32601, "message": "no"}, "id": 7}`), &resp); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(resp.ID) != "7" || resp.Error == nil || resp.Error.Code != jsonrpc.CodeMethodNotFound {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+
+	var req jsonrpc.Request
+	if err := encoding.Unmarshal([]byte(`{"jsonrpc": "1.0", "method": "x"}`), &req); err == nil {
+		t.Error("Expected an error for an unsupported version")
+	}
+}
+
+// newPair returns a client connection and a server connection that serves handler.
+func newPair(t *testing.T, handler jsonrpc.Handler) *jsonrpc.Conn {
+	t.Helper()
+
+	client, server := net.Pipe()
+
+	clientStream, err := encoding.NewStream(client)
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+
+	serverStream, err := encoding.NewStream(server)
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+
+	serverConn := jsonrpc.NewConn(serverStream, handler)
+	clientConn := jsonrpc.NewConn(clientStream, nil)
+
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+
+	return clientConn
+}
+
+func TestConn(t *testing.T) {
+	var notified atomic.Int32
+
+	conn := newPair(t, func(ctx context.Context, req *jsonrpc.Request) (interface{}, error) {
+		switch req.Method {
+		case "sum":
+			var args []int
+			if err := req.DecodeParams(&args); err != nil {
+				return nil, err
+			}
+
+			total := 0
+			for _, n := range args {
+				total += n
+			}
+
+			return total, nil
+		case "greet":
+			var args struct {
+				Name string `json:"name"`
+			}
+
+			if err := req.DecodeParams(&args); err != nil {
+				return nil, err
+			}
+
+			return map[string]string{"greeting": "hello " + args.Name}, nil
+		case "notify":
+			notified.Add(1)
+			return nil, nil
+		case "fail":
+			return nil, errors.New("boom")
+		default:
+			return nil, jsonrpc.MethodNotFound(req.Method)
+		}
+	})
+
+	ctx := context.Background()
+
+	var sum int
+	if err := conn.Call(ctx, "sum", []int{1, 2, 3}, &sum); err != nil || sum != 6 {
+		t.Errorf("Expected 6, got %d, %v", sum, err)
+	}
+
+	var greeting map[string]string
+	if err := conn.Call(ctx, "greet", map[string]string{"name": "Ana"}, &greeting); err != nil || greeting["greeting"] != "hello Ana" {
+		t.Errorf("Unexpected greeting %v, %v", greeting, err)
+	}
+
+	var rpcErr *jsonrpc.Error
+
+	err := conn.Call(ctx, "missing", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeMethodNotFound {
+		t.Errorf("Expected method not found, got %v", err)
+	}
+
+	err = conn.Call(ctx, "fail", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeInternalError || rpcErr.Message != "boom" {
+		t.Errorf("Expected internal error, got %v", err)
+	}
+
+	err = conn.Call(ctx, "sum", map[string]int{"a": 1}, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeInvalidParams {
+		t.Errorf("Expected invalid params, got %v", err)
+	}
+
+	if err := conn.Notify("notify", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var a, b int
+
+	calls := []*jsonrpc.BatchCall{
+		{Method: "sum", Params: []int{1, 1}, Result: &a},
+		{Method: "notify", Notify: true},
+		{Method: "missing"},
+		{Method: "sum", Params: []int{2, 2}, Result: &b},
+	}
+
+	if err := conn.Batch(ctx, calls); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if a != 2 || b != 4 || calls[0].Error != nil || calls[3].Error != nil {
+		t.Errorf("Unexpected batch results %d, %d, %v, %v", a, b, calls[0].Error, calls[3].Error)
+	}
+
+	if !errors.As(calls[2].Error, &rpcErr) || rpcErr.Code != jsonrpc.CodeMethodNotFound {
+		t.Errorf("Expected method not found, got %v", calls[2].Error)
+	}
+
+	// The batch response is sent after the notifications of the batch are handled
+	if n := notified.Load(); n < 1 {
+		t.Errorf("Expected the batch notification to be handled, got %d", n)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := conn.Call(ctx, "sum", []int{1}, nil); !errors.Is(err, jsonrpc.ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestConnCanceledCall(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	conn := newPair(t, func(ctx context.Context, req *jsonrpc.Request) (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := conn.Call(ctx, "slow", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
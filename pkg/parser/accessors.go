@@ -0,0 +1,143 @@
+package parser
+
+import "math"
+
+// GetString returns the string stored under key; ok is false if the key is missing or
+// its value is not a string.
+func (o *Object) GetString(key string) (s string, ok bool) {
+	v, _ := o.Get(key)
+	return asString(v)
+}
+
+// GetInt returns the integer stored under key. A number with a fractional part, or out
+// of the range of int64, is not an integer, while 2.0 and 2e0 are.
+func (o *Object) GetInt(key string) (n int64, ok bool) {
+	v, _ := o.Get(key)
+	return asInt(v)
+}
+
+// GetFloat returns the number stored under key as a float64.
+func (o *Object) GetFloat(key string) (f float64, ok bool) {
+	v, _ := o.Get(key)
+	return asFloat(v)
+}
+
+// GetBool returns the boolean stored under key.
+func (o *Object) GetBool(key string) (b bool, ok bool) {
+	v, _ := o.Get(key)
+	return asBool(v)
+}
+
+// GetObject returns the object stored under key.
+func (o *Object) GetObject(key string) (obj *Object, ok bool) {
+	v, _ := o.Get(key)
+	obj, ok = v.(*Object)
+
+	return obj, ok
+}
+
+// GetArray returns the array stored under key.
+func (o *Object) GetArray(key string) (arr *Array, ok bool) {
+	v, _ := o.Get(key)
+	arr, ok = v.(*Array)
+
+	return arr, ok
+}
+
+// Get returns the element at index i; ok is false if i is out of range.
+func (a *Array) Get(i int) (Value, bool) {
+	if i < 0 || i >= len(a.Elements) {
+		return nil, false
+	}
+
+	return a.Elements[i], true
+}
+
+// GetString returns the string at index i, like Object.GetString.
+func (a *Array) GetString(i int) (s string, ok bool) {
+	v, _ := a.Get(i)
+	return asString(v)
+}
+
+// GetInt returns the integer at index i, like Object.GetInt.
+func (a *Array) GetInt(i int) (n int64, ok bool) {
+	v, _ := a.Get(i)
+	return asInt(v)
+}
+
+// GetFloat returns the number at index i as a float64.
+func (a *Array) GetFloat(i int) (f float64, ok bool) {
+	v, _ := a.Get(i)
+	return asFloat(v)
+}
+
+// GetBool returns the boolean at index i.
+func (a *Array) GetBool(i int) (b bool, ok bool) {
+	v, _ := a.Get(i)
+	return asBool(v)
+}
+
+// GetObject returns the object at index i.
+func (a *Array) GetObject(i int) (obj *Object, ok bool) {
+	v, _ := a.Get(i)
+	obj, ok = v.(*Object)
+
+	return obj, ok
+}
+
+// GetArray returns the array at index i.
+func (a *Array) GetArray(i int) (arr *Array, ok bool) {
+	v, _ := a.Get(i)
+	arr, ok = v.(*Array)
+
+	return arr, ok
+}
+
+// asString returns the value of a string.
+func asString(v Value) (string, bool) {
+	s, ok := v.(*StringLiteral)
+	if !ok {
+		return "", false
+	}
+
+	return s.Value, true
+}
+
+// asInt returns the value of a number that is a whole int64.
+func asInt(v Value) (int64, bool) {
+	n, ok := v.(*NumberLiteral)
+	if !ok || !n.IsValid {
+		return 0, false
+	}
+
+	if n.IsInt {
+		return n.Int, true
+	}
+
+	// 2^63 is the first float64 above the range of int64
+	if n.Float != math.Trunc(n.Float) || n.Float < math.MinInt64 || n.Float >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(n.Float), true
+}
+
+// asFloat returns the value of a number as a float64.
+func asFloat(v Value) (float64, bool) {
+	n, ok := v.(*NumberLiteral)
+	if !ok || !n.IsValid {
+		return 0, false
+	}
+
+	return n.Float, true
+}
+
+// asBool returns the value of a boolean.
+func asBool(v Value) (bool, bool) {
+	b, ok := v.(*Boolean)
+	if !ok {
+		return false, false
+	}
+
+	return b.Value, true
+}
@@ -0,0 +1,141 @@
+package parser
+
+// arenaSlabSize is the number of nodes of one type allocated at once by an Arena.
+const arenaSlabSize = 256
+
+// Arena allocates the nodes of the documents parsed by a Parser created with
+// NewArenaParser from slabs, instead of one by one. Release frees them all at once and
+// makes the slabs, with the maps and slices of their objects and arrays, available to the
+// next documents. An application that parses many small documents, and is done with each
+// before the next, then produces almost no garbage.
+//
+// The values parsed with an arena must not be used after Release. An Arena is not safe
+// for concurrent use; a Parser using one must not parse concurrently with Release.
+type Arena struct {
+	objects  slab[Object]
+	arrays   slab[Array]
+	strings  slab[StringLiteral]
+	numbers  slab[NumberLiteral]
+	booleans slab[Boolean]
+	nulls    slab[Null]
+}
+
+// NewArena creates an empty arena.
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// NewArenaParser creates a Parser like NewParser that allocates the nodes it parses from
+// arena. A nil arena allocates them as NewParser does.
+func NewArenaParser(lexer *Lexer, arena *Arena, opts ...Option) *Parser {
+	p := NewParser(lexer, opts...)
+	p.arena = arena
+
+	return p
+}
+
+// Release frees every node allocated from the arena since the last Release. The nodes are
+// cleared so that they do not keep the values they referenced alive, and are reused by
+// the next parses.
+func (a *Arena) Release() {
+	a.objects.release(func(o *Object) {
+		clear(o.Pairs)
+		clear(o.Keys)
+		*o = Object{Pairs: o.Pairs, Keys: o.Keys[:0]}
+	})
+	a.arrays.release(func(arr *Array) {
+		clear(arr.Elements)
+		*arr = Array{Elements: arr.Elements[:0]}
+	})
+	a.strings.release(nil)
+	a.numbers.release(nil)
+	a.booleans.release(nil)
+	a.nulls.release(nil)
+}
+
+// Len returns the number of nodes allocated from the arena since the last Release.
+func (a *Arena) Len() int {
+	return a.objects.len() + a.arrays.len() + a.strings.len() + a.numbers.len() +
+		a.booleans.len() + a.nulls.len()
+}
+
+// newObject returns an empty object for the '{' token.
+func (a *Arena) newObject(token Token) *Object {
+	o := a.objects.alloc()
+	if o.Pairs == nil {
+		o.Pairs = make(map[string]Value)
+	}
+
+	o.Token = token
+
+	return o
+}
+
+// newArray returns an empty array for the '[' token.
+func (a *Arena) newArray(token Token) *Array {
+	arr := a.arrays.alloc()
+	if arr.Elements == nil {
+		arr.Elements = []Value{}
+	}
+
+	arr.Token = token
+
+	return arr
+}
+
+// slab hands out the elements of fixed-size chunks of T in order.
+type slab[T any] struct {
+	chunks [][]T
+	// chunk is the index of the chunk elements are taken from
+	chunk int
+	// used is the number of elements taken from that chunk
+	used int
+}
+
+// alloc returns the next unused element.
+func (s *slab[T]) alloc() *T {
+	if s.chunk < len(s.chunks) && s.used == len(s.chunks[s.chunk]) {
+		s.chunk++
+		s.used = 0
+	}
+
+	if s.chunk == len(s.chunks) {
+		s.chunks = append(s.chunks, make([]T, arenaSlabSize))
+	}
+
+	s.used++
+
+	return &s.chunks[s.chunk][s.used-1]
+}
+
+// len returns the number of elements taken.
+func (s *slab[T]) len() int {
+	if s.chunk == len(s.chunks) {
+		return s.chunk * arenaSlabSize
+	}
+
+	return s.chunk*arenaSlabSize + s.used
+}
+
+// release resets the elements taken, with reset or to their zero value when reset is
+// nil, and makes them available again.
+func (s *slab[T]) release(reset func(*T)) {
+	var zero T
+
+	for i := 0; i <= s.chunk && i < len(s.chunks); i++ {
+		chunk := s.chunks[i]
+		if i == s.chunk {
+			chunk = chunk[:s.used]
+		}
+
+		for j := range chunk {
+			if reset != nil {
+				reset(&chunk[j])
+			} else {
+				chunk[j] = zero
+			}
+		}
+	}
+
+	s.chunk, s.used = 0, 0
+}
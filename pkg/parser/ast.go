@@ -1,7 +1,9 @@
 package parser
 
 import (
-	"fmt"
+	"errors"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -12,31 +14,114 @@ type Object struct {
 	Token Token
 	// Pairs are the key-value pairs in the object.
 	Pairs map[string]Value
+	// Keys lists the keys of Pairs in insertion order, without duplicates. It is kept
+	// up to date by Set and Delete.
+	Keys []string
+	// End is the byte offset just past the closing '}'
+	End int64
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
-// TokenLiteral returns the literal value of the token that defines the object.
-func (o *Object) TokenLiteral() string { return o.Token.Literal }
+// NewObject creates an empty object.
+func NewObject() *Object {
+	return &Object{
+		Token: Token{Type: TokenBraceOpen, Literal: "{"},
+		Pairs: make(map[string]Value),
+	}
+}
 
-// String returns a simplified string representation of the object.
-func (o *Object) String() string {
-	var b strings.Builder
+// Get returns the value stored under key.
+func (o *Object) Get(key string) (Value, bool) {
+	v, ok := o.Pairs[key]
+	return v, ok
+}
 
-	b.WriteString("{")
+// Set stores v under key. A new key is appended to Keys, an existing one keeps its
+// position, and its comments when v has none.
+func (o *Object) Set(key string, v Value) {
+	if o.Pairs == nil {
+		o.Pairs = make(map[string]Value)
+	}
 
-	i := 0
-	for k, v := range o.Pairs {
-		if i > 0 {
-			b.WriteString(", ")
+	if old, ok := o.Pairs[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	} else if comments := NodeComments(old); comments != nil {
+		if field := commentsField(v); field != nil && *field == nil {
+			*field = comments
 		}
+	}
 
-		b.WriteString(k)
-		b.WriteString(": ")
-		b.WriteString(v.String())
+	o.Pairs[key] = v
+}
+
+// Delete removes key from the object.
+func (o *Object) Delete(key string) {
+	if _, ok := o.Pairs[key]; !ok {
+		return
+	}
+
+	delete(o.Pairs, key)
+
+	for i, k := range o.Keys {
+		if k == key {
+			o.Keys = append(o.Keys[:i:i], o.Keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// OrderedKeys returns the keys of the object in insertion order. Keys of Pairs that are
+// missing from Keys, as in objects built without Set, follow in lexicographic order.
+// The returned slice must not be modified.
+func (o *Object) OrderedKeys() []string {
+	if len(o.Keys) == len(o.Pairs) && o.keysInSync() {
+		return o.Keys
+	}
+
+	keys := make([]string, 0, len(o.Pairs))
+	seen := make(map[string]bool, len(o.Pairs))
+
+	for _, k := range o.Keys {
+		if _, ok := o.Pairs[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
 
-		i++
+	rest := make([]string, 0, len(o.Pairs)-len(keys))
+
+	for k := range o.Pairs {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// keysInSync reports whether every entry of Keys is present in Pairs.
+func (o *Object) keysInSync() bool {
+	for _, k := range o.Keys {
+		if _, ok := o.Pairs[k]; !ok {
+			return false
+		}
 	}
 
-	b.WriteString("}")
+	return true
+}
+
+// TokenLiteral returns the literal value of the token that defines the object.
+func (o *Object) TokenLiteral() string { return o.Token.Literal }
+
+// String returns the object as compact JSON, with its members in the order of
+// OrderedKeys.
+func (o *Object) String() string {
+	var b strings.Builder
+
+	writeValue(&b, o)
 
 	return b.String()
 }
@@ -50,13 +135,23 @@ type Array struct {
 	Token Token
 	// Elements are the values in the array.
 	Elements []Value
+	// End is the byte offset just past the closing ']'
+	End int64
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
 // TokenLiteral returns the literal value of the token that defines the array.
 func (a *Array) TokenLiteral() string { return a.Token.Literal }
 
-// String returns a simplified string representation of the array.
-func (a *Array) String() string { return "[]" } // Simplified for now
+// String returns the array as compact JSON.
+func (a *Array) String() string {
+	var b strings.Builder
+
+	writeValue(&b, a)
+
+	return b.String()
+}
 
 // valueNode is a placeholder method to ensure type safety within the Value interface.
 func (a *Array) valueNode() {}
@@ -67,13 +162,21 @@ type StringLiteral struct {
 	Token Token
 	// Value is the actual string value.
 	Value string
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
 // TokenLiteral returns the literal value of the token that defines the string.
 func (s *StringLiteral) TokenLiteral() string { return s.Token.Literal }
 
-// String returns the actual string value.
-func (s *StringLiteral) String() string { return s.Value }
+// String returns the string as a quoted JSON string; the unquoted text is in Value.
+func (s *StringLiteral) String() string {
+	var b strings.Builder
+
+	writeQuoted(&b, s.Value)
+
+	return b.String()
+}
 
 // valueNode is a placeholder method to ensure type safety within the Value interface.
 func (s *StringLiteral) valueNode() {}
@@ -92,11 +195,21 @@ type NumberLiteral struct {
 	IsInt bool
 	// IsValid is a flag to indicate if the number is valid JSON number.
 	IsValid bool
+	// IsBig is set, with IsValid unset, for a valid JSON number out of the range of Int
+	// for an integer or of Float otherwise. Only Value holds it exactly.
+	IsBig bool
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
 // NewNumberLiteral creates a new NumberLiteral with proper validation and parsing
 func NewNumberLiteral(token Token) *NumberLiteral {
-	n := &NumberLiteral{
+	return initNumberLiteral(&NumberLiteral{}, token)
+}
+
+// initNumberLiteral sets n to the number of token and returns it.
+func initNumberLiteral(n *NumberLiteral, token Token) *NumberLiteral {
+	*n = NumberLiteral{
 		Token: token,
 		Value: token.Literal,
 	}
@@ -131,6 +244,10 @@ func NewNumberLiteral(token Token) *NumberLiteral {
 
 	if isInt {
 		i, err := strconv.ParseInt(token.Literal, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return setBigNumberLiteral(n)
+		}
+
 		if err != nil {
 			return setInvalidNumberLiteral(n)
 		}
@@ -139,6 +256,10 @@ func NewNumberLiteral(token Token) *NumberLiteral {
 		n.Float = float64(i)
 	} else {
 		f, err := strconv.ParseFloat(token.Literal, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return setBigNumberLiteral(n)
+		}
+
 		if err != nil {
 			return setInvalidNumberLiteral(n)
 		}
@@ -161,21 +282,47 @@ func setInvalidNumberLiteral(n *NumberLiteral) *NumberLiteral {
 	return n
 }
 
+// setBigNumberLiteral marks n as a valid number that does not fit Int or Float.
+func setBigNumberLiteral(n *NumberLiteral) *NumberLiteral {
+	setInvalidNumberLiteral(n)
+	n.IsBig = true
+
+	return n
+}
+
 // TokenLiteral returns the literal value of the token that defines the number.
 func (n *NumberLiteral) TokenLiteral() string { return n.Token.Literal }
 
-// String returns the number value as a string.
+// String returns the number as written in its literal, or formatted from Int or Float
+// for a number built without one.
 func (n *NumberLiteral) String() string {
+	if n.Value != "" {
+		return n.Value
+	}
+
 	if n.IsInt {
-		return fmt.Sprintf("%d", n.Int)
+		return strconv.FormatInt(n.Int, 10)
 	}
 
-	return fmt.Sprintf("%f", n.Float)
+	return strconv.FormatFloat(n.Float, 'g', -1, 64)
 }
 
 // valueNode is a placeholder method to ensure type safety within the Value interface.
 func (n *NumberLiteral) valueNode() {}
 
+// Rat returns the exact value of the number as a rational, parsed from its literal text.
+func (n *NumberLiteral) Rat() *big.Rat {
+	if r, ok := new(big.Rat).SetString(n.Value); ok {
+		return r
+	}
+
+	if r := new(big.Rat).SetFloat64(n.Float); r != nil {
+		return r
+	}
+
+	return new(big.Rat)
+}
+
 // IsValidNumber returns whether the number is a valid JSON number
 func (n *NumberLiteral) IsValidNumber() bool {
 	return n.IsValid
@@ -187,13 +334,15 @@ type Boolean struct {
 	Token Token
 	// Value is the actual boolean value.
 	Value bool
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
 // TokenLiteral returns the literal value of the token that defines the boolean.
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 
-// String returns the boolean value as a string.
-func (b *Boolean) String() string { return b.Token.Literal }
+// String returns "true" or "false".
+func (b *Boolean) String() string { return strconv.FormatBool(b.Value) }
 
 // valueNode is a placeholder method to ensure type safety within the Value interface.
 func (b *Boolean) valueNode() {}
@@ -202,6 +351,8 @@ func (b *Boolean) valueNode() {}
 type Null struct {
 	// Token is the null token.
 	Token Token
+	// Comments are the comments attached by WithPreserveComments, if any
+	Comments *Comments
 }
 
 // TokenLiteral returns the literal value of the token that defines the null value.
@@ -212,3 +363,116 @@ func (n *Null) String() string { return "null" }
 
 // valueNode is a placeholder method to ensure type safety within the Value interface.
 func (n *Null) valueNode() {}
+
+// Span returns the byte range of v in the parsed input, input[start:end], so that a
+// tool can rewrite the source of a single value and keep the formatting of the rest. A
+// value that was built instead of parsed has the empty span 0, 0; for a modified
+// container the span is still that of its source.
+func Span(v Value) (start, end int64) {
+	switch val := v.(type) {
+	case *Object:
+		return val.Token.Offset, val.End
+	case *Array:
+		return val.Token.Offset, val.End
+	case *StringLiteral:
+		return val.Token.Offset, val.Token.End
+	case *NumberLiteral:
+		return val.Token.Offset, val.Token.End
+	case *Boolean:
+		return val.Token.Offset, val.Token.End
+	case *Null:
+		return val.Token.Offset, val.Token.End
+	default:
+		return 0, 0
+	}
+}
+
+// StartToken returns the token v starts with, whose Line, Column and Offset locate v in
+// the parsed input. The token of a value that was built instead of parsed has Line 0.
+func StartToken(v Value) Token {
+	switch val := v.(type) {
+	case *Object:
+		return val.Token
+	case *Array:
+		return val.Token
+	case *StringLiteral:
+		return val.Token
+	case *NumberLiteral:
+		return val.Token
+	case *Boolean:
+		return val.Token
+	case *Null:
+		return val.Token
+	default:
+		return Token{}
+	}
+}
+
+// writeValue writes v to b as compact JSON.
+func writeValue(b *strings.Builder, v Value) {
+	switch val := v.(type) {
+	case *Object:
+		b.WriteByte('{')
+
+		for i, k := range val.OrderedKeys() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			writeQuoted(b, k)
+			b.WriteByte(':')
+			writeValue(b, val.Pairs[k])
+		}
+
+		b.WriteByte('}')
+	case *Array:
+		b.WriteByte('[')
+
+		for i, elem := range val.Elements {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			writeValue(b, elem)
+		}
+
+		b.WriteByte(']')
+	case nil:
+		b.WriteString("null")
+	default:
+		b.WriteString(v.String())
+	}
+}
+
+// writeQuoted writes s to b as a quoted JSON string, escaping quotes, backslashes and
+// control characters.
+func writeQuoted(b *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+
+	b.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[r>>4])
+				b.WriteByte(hex[r&0xF])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	b.WriteByte('"')
+}
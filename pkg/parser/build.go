@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// FromInterface builds a Value from plain Go values: nil, booleans, strings, integers,
+// floats, maps with string keys, slices and arrays, pointers to any of these, and Values
+// themselves, which are used as is. Map keys are added in lexicographic order. Structs
+// are not supported; encode them with the encoding package instead.
+//
+//	v, _ := parser.FromInterface(map[string]interface{}{"tags": []string{"a", "b"}})
+//	obj := v.(*parser.Object)
+//	obj.Set("count", parser.NewNumber(2))
+func FromInterface(v interface{}) (Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return NewNull(), nil
+	case Value:
+		return val, nil
+	case bool:
+		return NewBoolean(val), nil
+	case string:
+		return NewString(val), nil
+	case int:
+		return NewNumber(int64(val)), nil
+	case int64:
+		return NewNumber(val), nil
+	case map[string]interface{}:
+		return objectFromMap(reflect.ValueOf(val))
+	case []interface{}:
+		arr := NewArray()
+
+		for i, elem := range val {
+			value, err := FromInterface(elem)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+
+			arr.Elements = append(arr.Elements, value)
+		}
+
+		return arr, nil
+	}
+
+	return fromReflect(reflect.ValueOf(v))
+}
+
+// fromReflect handles the kinds FromInterface does not match directly.
+func fromReflect(rv reflect.Value) (Value, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return NewBoolean(rv.Bool()), nil
+	case reflect.String:
+		return NewString(rv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewNumber(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("number %d overflows int64", rv.Uint())
+		}
+
+		return NewNumber(int64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		n, err := newFloat(rv.Float(), rv.Type().Bits())
+		if err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %v", rv.Type().Key())
+		}
+
+		return objectFromMap(rv)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return NewNull(), nil
+		}
+
+		arr := NewArray()
+
+		for i := 0; i < rv.Len(); i++ {
+			value, err := FromInterface(rv.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+
+			arr.Elements = append(arr.Elements, value)
+		}
+
+		return arr, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return NewNull(), nil
+		}
+
+		return FromInterface(rv.Elem().Interface())
+	default:
+		return nil, fmt.Errorf("unsupported type %v", rv.Type())
+	}
+}
+
+// objectFromMap builds an Object from a map with string keys.
+func objectFromMap(rv reflect.Value) (Value, error) {
+	if rv.IsNil() {
+		return NewNull(), nil
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	obj := NewObject()
+
+	for _, k := range keys {
+		value, err := FromInterface(rv.MapIndex(k).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.String(), err)
+		}
+
+		obj.Set(k.String(), value)
+	}
+
+	return obj, nil
+}
+
+// NewArray creates an empty array.
+func NewArray() *Array {
+	return &Array{Token: Token{Type: TokenBracketOpen, Literal: "["}}
+}
+
+// NewString creates a string value.
+func NewString(s string) *StringLiteral {
+	return &StringLiteral{Token: Token{Type: TokenString, Literal: s}, Value: s}
+}
+
+// NewNumber creates an integer number value.
+func NewNumber(n int64) *NumberLiteral {
+	return NewNumberLiteral(Token{Type: TokenNumber, Literal: strconv.FormatInt(n, 10)})
+}
+
+// NewFloat creates a number value from f, which must be finite.
+func NewFloat(f float64) (*NumberLiteral, error) {
+	return newFloat(f, 64)
+}
+
+// newFloat creates a number value from f in its shortest form for the given bit size.
+func newFloat(f float64, bitSize int) (*NumberLiteral, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unsupported number %v", f)
+	}
+
+	return NewNumberLiteral(Token{Type: TokenNumber, Literal: strconv.FormatFloat(f, 'g', -1, bitSize)}), nil
+}
+
+// NewBoolean creates a boolean value.
+func NewBoolean(b bool) *Boolean {
+	if b {
+		return &Boolean{Token: Token{Type: TokenTrue, Literal: "true"}, Value: true}
+	}
+
+	return &Boolean{Token: Token{Type: TokenFalse, Literal: "false"}}
+}
+
+// NewNull creates a null value.
+func NewNull() *Null {
+	return &Null{Token: Token{Type: TokenNull, Literal: "null"}}
+}
@@ -0,0 +1,135 @@
+package parser
+
+// Comments holds the comments attached to a node by WithPreserveComments, each as written
+// in the input with its // or /* */ delimiters.
+type Comments struct {
+	// Leading are the comments before the node, or before the key of an object member.
+	Leading []string
+	// Trailing are the comments after the node on the line where it ends, after the
+	// comma that follows it if any. The comments after the top-level value are trailing
+	// comments too.
+	Trailing []string
+	// Footer are the comments of a container after its last member or element.
+	Footer []string
+}
+
+// comment is a comment skipped by the lexer.
+type comment struct {
+	text   string
+	line   int
+	offset int64
+}
+
+// NodeComments returns the comments attached to v, or nil if it has none.
+func NodeComments(v Value) *Comments {
+	if field := commentsField(v); field != nil {
+		return *field
+	}
+
+	return nil
+}
+
+// commentsField returns the address of the Comments field of v.
+func commentsField(v Value) **Comments {
+	switch val := v.(type) {
+	case *Object:
+		return &val.Comments
+	case *Array:
+		return &val.Comments
+	case *StringLiteral:
+		return &val.Comments
+	case *NumberLiteral:
+		return &val.Comments
+	case *Boolean:
+		return &val.Comments
+	case *Null:
+		return &val.Comments
+	default:
+		return nil
+	}
+}
+
+// attachComments adds the texts of comments to the comments of v selected by part.
+func attachComments(v Value, comments []comment, part func(*Comments) *[]string) {
+	field := commentsField(v)
+	if field == nil || len(comments) == 0 {
+		return
+	}
+
+	if *field == nil {
+		*field = &Comments{}
+	}
+
+	texts := part(*field)
+	for _, c := range comments {
+		*texts = append(*texts, c.text)
+	}
+}
+
+// leadingComments takes the comments read before offset. Those on the line of the
+// current token trail the previous value of the container, p.prev, if any; the others
+// are returned.
+func (p *Parser) leadingComments(before int64) []comment {
+	comments := p.lexer.comments
+	n := 0
+
+	for n < len(comments) && comments[n].offset < before {
+		n++
+	}
+
+	taken := comments[:n:n]
+	p.lexer.comments = comments[n:]
+
+	if p.prev == nil {
+		return taken
+	}
+
+	i := 0
+	for i < len(taken) && taken[i].line == p.currentToken.Line {
+		i++
+	}
+
+	attachComments(p.prev, taken[:i], trailing)
+
+	return taken[i:]
+}
+
+// parsed records value as the last value of the current container and attaches the
+// comments before it, when comments are kept.
+func (p *Parser) parsed(value Value, leadingComments []comment) {
+	if p.lexer.keepComments {
+		attachComments(value, leadingComments, leading)
+		p.prev = value
+	}
+}
+
+// footerComments attaches the comments before the closing bracket, the next token, to
+// container, when comments are kept.
+func (p *Parser) footerComments(container Value) {
+	if p.lexer.keepComments {
+		attachComments(container, p.leadingComments(p.peekToken.Offset), footer)
+	}
+}
+
+// rootComments attaches to the top-level value the comments before it and those after
+// it on its last line, or up to the end of the input.
+func (p *Parser) rootComments(value Value, leadingComments []comment) {
+	attachComments(value, leadingComments, leading)
+
+	p.prev = value
+	rest := p.leadingComments(p.peekToken.Offset)
+
+	if p.peekToken.Type == TokenEOF {
+		attachComments(value, rest, trailing)
+	} else {
+		// The other comments lead the next value of the stream
+		p.lexer.comments = append(rest, p.lexer.comments...)
+	}
+
+	p.prev = nil
+}
+
+// leading, trailing and footer select a part of Comments for attachComments.
+func leading(c *Comments) *[]string  { return &c.Leading }
+func trailing(c *Comments) *[]string { return &c.Trailing }
+func footer(c *Comments) *[]string   { return &c.Footer }
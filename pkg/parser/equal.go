@@ -0,0 +1,59 @@
+package parser
+
+// Equal reports whether two values are semantically equal: object key order
+// is ignored and numbers are compared by value.
+func Equal(a, b Value) bool {
+	switch left := a.(type) {
+	case *Object:
+		right, ok := b.(*Object)
+		if !ok || len(left.Pairs) != len(right.Pairs) {
+			return false
+		}
+
+		for k, v := range left.Pairs {
+			other, ok := right.Pairs[k]
+			if !ok || !Equal(v, other) {
+				return false
+			}
+		}
+
+		return true
+	case *Array:
+		right, ok := b.(*Array)
+		if !ok || len(left.Elements) != len(right.Elements) {
+			return false
+		}
+
+		for i := range left.Elements {
+			if !Equal(left.Elements[i], right.Elements[i]) {
+				return false
+			}
+		}
+
+		return true
+	case *StringLiteral:
+		right, ok := b.(*StringLiteral)
+		return ok && left.Value == right.Value
+	case *NumberLiteral:
+		right, ok := b.(*NumberLiteral)
+		return ok && numbersEqual(left, right)
+	case *Boolean:
+		right, ok := b.(*Boolean)
+		return ok && left.Value == right.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	default:
+		return false
+	}
+}
+
+// numbersEqual compares two numbers exactly using their literal text, so that 1e2, 100.0
+// and 100 are equal while large integers do not lose precision.
+func numbersEqual(a, b *NumberLiteral) bool {
+	if a.IsInt && b.IsInt {
+		return a.Int == b.Int
+	}
+
+	return a.Rat().Cmp(b.Rat()) == 0
+}
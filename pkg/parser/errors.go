@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidInput reports a lexer input that is neither a string, a []byte nor a non-nil
+// io.Reader.
+var ErrInvalidInput = errors.New("parser: lexer input must be a string, []byte or non-nil io.Reader")
+
+// snippetWidth is the number of bytes kept on each side of an error in ParseError.Snippet.
+const snippetWidth = 40
+
+// ParseError is a syntax error together with its location in the input, so that tools can
+// highlight it.
+type ParseError struct {
+	// Offset is the byte offset of the failing token in the whole input.
+	Offset int64
+	// Line is the 1-based line of the failing token.
+	Line int
+	// Column is the column of the failing token on its line.
+	Column int
+	// Msg describes the error.
+	Msg string
+	// Snippet is the input line around the failing token, shortened to at most 40 bytes
+	// on each side. It is empty when the input is no longer buffered.
+	Snippet string
+	// Err is the typed error behind Msg for errors other than plain syntax errors, such
+	// as a *LimitError.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Line %d, Column %d, Offset %d: %s", e.Line, e.Column, e.Offset, e.Msg)
+}
+
+// Unwrap returns Err.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// LimitError reports a document that exceeds one of the Limits.
+type LimitError struct {
+	// Limit is the name of the Limits field that was exceeded, e.g. "MaxDepth".
+	Limit string
+	// Max is the value of the limit.
+	Max int
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// ErrorList holds every syntax error found by a parser in error recovery mode, in input
+// order. It unwraps to the individual *ParseError values.
+type ErrorList []*ParseError
+
+// Error implements the error interface, describing the first error.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", l[0].Error(), len(l)-1)
+	}
+}
+
+// Unwrap returns the errors in the list.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+
+	return errs
+}
+
+// errorList returns the recorded errors as an ErrorList.
+func (p *Parser) errorList() ErrorList {
+	list := make(ErrorList, len(p.errors))
+	for i := range p.errors {
+		err := p.errors[i]
+		list[i] = &err
+	}
+
+	return list
+}
+
+// newError creates a ParseError located at tok.
+func (p *Parser) newError(tok Token, format string, a ...interface{}) *ParseError {
+	err := &ParseError{
+		Offset: tok.Offset,
+		Line:   tok.Line,
+		Column: tok.Column,
+		Msg:    fmt.Sprintf(format, a...),
+	}
+
+	// The input is owned by the lexer goroutine of a pipelined parser
+	if p.source == nil {
+		err.Snippet = p.lexer.snippet(tok.Offset)
+	}
+
+	return err
+}
+
+// snippet returns the line of the buffered input around offset, shortened to at most
+// snippetWidth bytes on each side.
+func (l *Lexer) snippet(offset int64) string {
+	pos := offset - l.base
+	if pos < 0 || pos > int64(len(l.input)) {
+		return ""
+	}
+
+	i := int(pos)
+
+	start := strings.LastIndexByte(l.input[:i], '\n') + 1
+	if i-start > snippetWidth {
+		start = i - snippetWidth
+		for start < i && !utf8.RuneStart(l.input[start]) {
+			start++
+		}
+	}
+
+	end := len(l.input)
+	if n := strings.IndexByte(l.input[i:], '\n'); n >= 0 {
+		end = i + n
+	}
+
+	if end-i > snippetWidth {
+		end = i + snippetWidth
+		for end > i && !utf8.RuneStart(l.input[end]) {
+			end--
+		}
+	}
+
+	return strings.TrimRight(l.input[start:end], "\r")
+}
@@ -0,0 +1,337 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EventKind identifies the type of an Event.
+type EventKind int
+
+const (
+	// EventObjectStart is emitted for the opening '{' of an object
+	EventObjectStart EventKind = iota
+	// EventObjectEnd is emitted for the closing '}' of an object
+	EventObjectEnd
+	// EventArrayStart is emitted for the opening '[' of an array
+	EventArrayStart
+	// EventArrayEnd is emitted for the closing ']' of an array
+	EventArrayEnd
+	// EventValue is emitted for a string, number, boolean or null
+	EventValue
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventObjectStart:
+		return "ObjectStart"
+	case EventObjectEnd:
+		return "ObjectEnd"
+	case EventArrayStart:
+		return "ArrayStart"
+	case EventArrayEnd:
+		return "ArrayEnd"
+	case EventValue:
+		return "Value"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// PathElement is one step from the document root to a value: an object key or an
+// array index.
+type PathElement struct {
+	// Key is the object member name when IsIndex is false
+	Key string
+	// Index is the array position when IsIndex is true
+	Index int
+	// IsIndex reports whether the element is an array index
+	IsIndex bool
+}
+
+// String returns the key, or the index in decimal.
+func (e PathElement) String() string {
+	if e.IsIndex {
+		return strconv.Itoa(e.Index)
+	}
+
+	return e.Key
+}
+
+// Event is a single step of a Walk over a JSON document.
+type Event struct {
+	// Kind is the type of the event
+	Kind EventKind
+	// Key is the member name when the value belongs to an object, and empty otherwise
+	Key string
+	// Token is the value token for EventValue, or the delimiter token for containers
+	Token Token
+	// Depth is the nesting level of the value; the top-level value has depth 0 and
+	// start and end events of a container share its depth
+	Depth int
+	// Path leads from the root to the value. It is reused between events, so copy it
+	// to keep it after the handler returns.
+	Path []PathElement
+}
+
+// Pointer returns the path of the event as a JSON Pointer (RFC 6901), e.g. "/items/0/id".
+func (e Event) Pointer() string {
+	var b strings.Builder
+
+	for _, el := range e.Path {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(el.String()))
+	}
+
+	return b.String()
+}
+
+// Value returns the scalar value of an EventValue event, and nil for container events.
+func (e Event) Value() Value {
+	if e.Kind != EventValue {
+		return nil
+	}
+
+	switch e.Token.Type {
+	case TokenString:
+		return &StringLiteral{Token: e.Token, Value: e.Token.Literal}
+	case TokenNumber:
+		return NewNumberLiteral(e.Token)
+	case TokenTrue:
+		return &Boolean{Token: e.Token, Value: true}
+	case TokenFalse:
+		return &Boolean{Token: e.Token, Value: false}
+	default:
+		return &Null{Token: e.Token}
+	}
+}
+
+// walker holds the state of a Walk.
+type walker struct {
+	p    *Parser
+	fn   func(Event) error
+	path []PathElement
+	err  error
+}
+
+// Walk parses the document and calls fn for every value and container boundary in
+// document order, without building a tree. It accepts the same input as ParseJSON and
+// returns the first syntax error, or the first error returned by fn, which stops the walk.
+func (p *Parser) Walk(fn func(Event) error) error {
+	switch p.currentToken.Type {
+	case TokenBraceOpen, TokenBracketOpen:
+	case TokenIllegal:
+		return p.newError(p.currentToken, "%s", p.currentToken.Literal)
+	default:
+		return p.newError(p.currentToken, "expected { or [, got %s", p.currentToken.Type)
+	}
+
+	w := &walker{p: p, fn: fn}
+	w.walkValue("", 0)
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if len(p.errors) > 0 {
+		return p.firstError()
+	}
+
+	p.nextToken() // move past the value
+
+	return nil
+}
+
+// emit calls the handler and records its error.
+func (w *walker) emit(kind EventKind, key string, depth int) bool {
+	if w.err != nil {
+		return false
+	}
+
+	w.err = w.fn(Event{
+		Kind:  kind,
+		Key:   key,
+		Token: w.p.currentToken,
+		Depth: depth,
+		Path:  w.path[:len(w.path):len(w.path)],
+	})
+
+	return w.err == nil
+}
+
+// walkValue walks the value at the current token.
+func (w *walker) walkValue(key string, depth int) bool {
+	p := w.p
+
+	switch p.currentToken.Type {
+	case TokenString:
+		return p.checkString(p.currentToken) && w.emit(EventValue, key, depth)
+
+	case TokenTrue, TokenFalse, TokenNull:
+		return w.emit(EventValue, key, depth)
+
+	case TokenNumber:
+		if !p.acceptsNumber(NewNumberLiteral(p.currentToken)) {
+			p.addError("invalid number format: %s", p.currentToken.Literal)
+			return false
+		}
+
+		return w.emit(EventValue, key, depth)
+
+	case TokenBraceOpen, TokenBracketOpen:
+		if !p.enter() {
+			return false
+		}
+
+		defer p.leave()
+
+		if p.currentToken.Type == TokenBraceOpen {
+			return w.emit(EventObjectStart, key, depth) && w.walkObject(depth) && w.emit(EventObjectEnd, key, depth)
+		}
+
+		return w.emit(EventArrayStart, key, depth) && w.walkArray(depth) && w.emit(EventArrayEnd, key, depth)
+
+	case TokenIllegal:
+		p.addError("%s", p.currentToken.Literal)
+		return false
+
+	default:
+		p.addError("unexpected token %s", p.currentToken.Type)
+		return false
+	}
+}
+
+// walkChild walks a member or element with el appended to the path.
+func (w *walker) walkChild(el PathElement, depth int) bool {
+	w.path = append(w.path, el)
+	ok := w.walkValue(el.Key, depth)
+	w.path = w.path[:len(w.path)-1]
+
+	return ok
+}
+
+// walkMember walks a key-value pair starting at the key token.
+func (w *walker) walkMember(keys KeySet, depth int) bool {
+	p := w.p
+
+	if p.currentToken.Type != TokenString {
+		p.addError("expected string key")
+		return false
+	}
+
+	key := p.currentToken.Literal
+	if !p.checkString(p.currentToken) {
+		return false
+	}
+
+	if p.peekToken.Type != TokenColon {
+		p.addError("expected :, got %s", p.peekToken.Type)
+		return false
+	}
+
+	if !p.addKey(keys, key) {
+		return false
+	}
+
+	p.nextToken() // move past key
+	p.nextToken() // move past colon
+
+	return w.walkChild(PathElement{Key: key}, depth+1)
+}
+
+// walkObject walks the members of an object whose '{' is the current token and leaves
+// the parser on its '}'.
+func (w *walker) walkObject(depth int) bool {
+	p := w.p
+
+	if p.peekToken.Type == TokenBraceClose {
+		p.nextToken()
+		return true
+	}
+
+	p.nextToken() // move past {
+
+	var keys KeySet
+	if p.newKeySet != nil {
+		keys = p.newKeySet()
+	}
+
+	if !w.walkMember(keys, depth) {
+		return false
+	}
+
+	for members := 1; p.peekToken.Type == TokenComma; {
+		p.nextToken() // move past comma
+
+		if p.peekToken.Type == TokenBraceClose {
+			if p.allowTrailingCommas {
+				break
+			}
+
+			p.addError("unexpected token ,")
+			return false
+		}
+
+		p.nextToken() // move to next key
+
+		if members++; !p.checkCount(members, p.limits.MaxObjectKeys, "MaxObjectKeys") || !w.walkMember(keys, depth) {
+			return false
+		}
+	}
+
+	if p.peekToken.Type == TokenEOF {
+		p.addError("expected }, got EOF")
+		return false
+	}
+
+	if p.peekToken.Type != TokenBraceClose {
+		p.addError("expected }, got %s", p.peekToken.Type)
+		return false
+	}
+
+	p.nextToken() // move to }
+
+	return true
+}
+
+// walkArray walks the elements of an array whose '[' is the current token and leaves
+// the parser on its ']'.
+func (w *walker) walkArray(depth int) bool {
+	p := w.p
+
+	if p.peekToken.Type == TokenBracketClose {
+		p.nextToken()
+		return true
+	}
+
+	p.nextToken() // move past [
+
+	for i := 0; ; i++ {
+		if !p.checkCount(i+1, p.limits.MaxArrayElements, "MaxArrayElements") || !w.walkChild(PathElement{Index: i, IsIndex: true}, depth+1) {
+			return false
+		}
+
+		if p.peekToken.Type != TokenComma {
+			break
+		}
+
+		p.nextToken() // move past comma
+
+		if p.allowTrailingCommas && p.peekToken.Type == TokenBracketClose {
+			break
+		}
+
+		p.nextToken() // move to next value
+	}
+
+	if p.peekToken.Type != TokenBracketClose {
+		p.addError("expected ], got %s", p.peekToken.Type)
+		return false
+	}
+
+	p.nextToken() // move to ]
+
+	return true
+}
@@ -11,5 +11,6 @@ type Node interface {
 // This is also an interface since JSON can have different types of values
 type Value interface {
 	Node
-	valueNode() // Dummy method to ensure type safety
+	ToInterface() interface{} // Converts the value into plain Go maps, slices and scalars
+	valueNode()               // Dummy method to ensure type safety
 }
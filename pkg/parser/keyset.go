@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"hash/fnv"
+	"slices"
+)
+
+// KeySet records the keys seen in a single object so that duplicates can be detected.
+type KeySet interface {
+	// Add records key and reports whether it (possibly) was added before.
+	Add(key string) bool
+	// Exact reports whether Add never reports false positives.
+	Exact() bool
+}
+
+// exactKeySet tracks every key and never reports false positives.
+type exactKeySet map[string]struct{}
+
+// NewExactKeySet returns a KeySet that remembers every key.
+func NewExactKeySet() KeySet {
+	return exactKeySet{}
+}
+
+// Add implements KeySet.
+func (s exactKeySet) Add(key string) bool {
+	if _, ok := s[key]; ok {
+		return true
+	}
+
+	s[key] = struct{}{}
+
+	return false
+}
+
+// Exact implements KeySet.
+func (s exactKeySet) Exact() bool { return true }
+
+// bloomHashes is the number of bit positions set per key.
+const bloomHashes = 3
+
+// bloomKeySet screens object keys with a bloom filter. A key the filter has not seen is
+// new, which settles most keys with a few bit operations; a hit is confirmed against the
+// keys added so far, so that a false positive of the filter is never reported.
+type bloomKeySet struct {
+	bits []uint64
+	keys []string
+}
+
+// NewBloomKeySet returns a KeySet backed by a bloom filter of the given number of bits
+// (rounded up to a multiple of 64). Filter hits are confirmed by comparing the key with
+// the keys added before, whose cost grows with the false positive rate, so the filter
+// should have roughly 10 bits per expected key.
+func NewBloomKeySet(bits int) KeySet {
+	words := (bits + 63) / 64
+	if words < 1 {
+		words = 1
+	}
+
+	return &bloomKeySet{bits: make([]uint64, words)}
+}
+
+// Add implements KeySet.
+func (s *bloomKeySet) Add(key string) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+
+	// Double hashing derives every probe from the two halves of one 64-bit hash
+	h1, h2 := sum&0xFFFFFFFF, sum>>32|1
+	size := uint64(len(s.bits) * 64)
+	seen := true
+
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+
+		if s.bits[word]&mask == 0 {
+			seen = false
+			s.bits[word] |= mask
+		}
+	}
+
+	if seen && slices.Contains(s.keys, key) {
+		return true
+	}
+
+	s.keys = append(s.keys, key)
+
+	return false
+}
+
+// Exact implements KeySet.
+func (s *bloomKeySet) Exact() bool { return true }
@@ -2,7 +2,13 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"reflect"
+	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -27,119 +33,391 @@ type Lexer struct {
 	buffer []byte
 	// Flag to indicate if the lexer is in streaming mode.
 	isStreaming bool
+	// The number of times the streaming input has been replaced by a new chunk.
+	chunk int
+	// The byte offset of the start of input in the whole stream.
+	base int64
+	// Flag to skip // line and /* block */ comments between tokens.
+	allowComments bool
+	// Flag to record the skipped comments in comments.
+	keepComments bool
+	// The comments skipped since the parser last took them.
+	comments []comment
+	// How invalid UTF-8 in strings is handled.
+	invalidUTF8 InvalidUTF8Policy
+	// The encoding detected at the start of the input; only UTF-8 can be read.
+	encoding Encoding
+	// The position of the first character of the number or keyword being read, which
+	// is kept across refills while marked is set.
+	start  int
+	marked bool
+	// The offset in the whole stream of the first byte kept across refills while holding
+	// is set, so that the input after the last value parsed stays available.
+	hold    int64
+	holding bool
 }
 
-// NewLexer creates a new Lexer instance for the given input string.
+// Checkpoint is a snapshot of the lexer state that can be restored with Rewind.
+type Checkpoint struct {
+	position     int
+	readPosition int
+	ch           rune
+	line         int
+	column       int
+	chunk        int
+}
+
+// DefaultBufferSize is the size of the chunks read from an io.Reader by NewLexer.
+const DefaultBufferSize = 4096
+
+// NewLexer creates a new Lexer for input, which must be a string, a []byte or an
+// io.Reader read in chunks of DefaultBufferSize bytes. It panics with ErrInvalidInput
+// for any other input.
+//
+// Deprecated: Use NewLexerString, NewLexerBytes or NewLexerSize, whose input type is
+// checked at compile time, or NewLexerInput, which returns an error.
 func NewLexer(input interface{}) *Lexer {
-	l := &Lexer{
-		line:   1,
-		column: 0,
-		buffer: make([]byte, 4096),
+	l, err := NewLexerInput(input)
+	if err != nil {
+		panic(err)
 	}
 
+	return l
+}
+
+// NewLexerInput is like NewLexer, for an input whose type is only known at run time, but
+// returns an error wrapping ErrInvalidInput instead of panicking.
+func NewLexerInput(input interface{}) (*Lexer, error) {
 	switch v := input.(type) {
 	case string:
-		l.input = v
-		l.isStreaming = false
+		return NewLexerString(v), nil
 	case []byte:
-		l.input = string(v)
-		l.isStreaming = false
+		return NewLexerBytes(v), nil
 	case io.Reader:
-		l.reader = bufio.NewReader(v)
-		l.isStreaming = true
-		l.readChunk()
-	default:
-		panic("invalid input type")
+		if !isNilReader(v) {
+			return NewLexerSize(v, DefaultBufferSize), nil
+		}
 	}
 
-	l.readChar()
+	return nil, fmt.Errorf("%w, got %T", ErrInvalidInput, input)
+}
+
+// NewLexerString creates a new Lexer for the JSON text s.
+func NewLexerString(s string) *Lexer {
+	l := newLexer(DefaultBufferSize)
+	l.input = s
+	l.begin()
+
+	return l
+}
+
+// NewLexerBytes creates a new Lexer for the JSON text b, which is copied.
+func NewLexerBytes(b []byte) *Lexer {
+	return NewLexerString(string(b))
+}
+
+// NewLexerSize creates a new Lexer that reads r in chunks of size bytes. A size below 1
+// selects DefaultBufferSize. A *bufio.Reader whose buffer holds at least size bytes is
+// read directly, so a caller can supply the buffer.
+func NewLexerSize(r io.Reader, size int) *Lexer {
+	if isNilReader(r) {
+		panic(fmt.Errorf("%w, got nil %T", ErrInvalidInput, r))
+	}
+
+	if size < 1 {
+		size = DefaultBufferSize
+	}
+
+	l := newLexer(size)
+	l.reader = bufio.NewReaderSize(r, size)
+	l.isStreaming = true
+	l.readChunk()
+	l.begin()
 
 	return l
 }
 
-// readChunk reads the next chunk of data from the input reader.
-func (l *Lexer) readChunk() {
+// isNilReader reports whether r is nil or a nil pointer, which would only fail on the
+// first read.
+func isNilReader(r io.Reader) bool {
+	if r == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(r)
+
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// newLexer creates a Lexer without input whose chunks hold size bytes.
+func newLexer(size int) *Lexer {
+	return &Lexer{
+		line:   1,
+		column: 0,
+		buffer: make([]byte, size),
+	}
+}
+
+// Reset discards the state of the lexer and makes it read from r, as if it had been
+// created with NewLexerSize, while reusing its buffers. Settings such as comment support
+// and the invalid UTF-8 policy are kept.
+func (l *Lexer) Reset(r io.Reader) {
+	reader, buffer := l.reader, l.buffer
+	if reader == nil {
+		reader = bufio.NewReaderSize(r, len(buffer))
+	} else {
+		reader.Reset(r)
+	}
+
+	*l = Lexer{
+		line:          1,
+		reader:        reader,
+		buffer:        buffer,
+		isStreaming:   true,
+		allowComments: l.allowComments,
+		keepComments:  l.keepComments,
+		comments:      l.comments[:0],
+		invalidUTF8:   l.invalidUTF8,
+	}
+
+	l.readChunk()
+	l.begin()
+}
+
+// begin reads the first character of the input.
+func (l *Lexer) begin() {
+	// Skip a UTF-8 byte order mark; other encodings must be transcoded first
+	head := l.input[:min(len(l.input), 4)]
+	l.encoding, l.readPosition = DetectEncoding([]byte(head))
+
+	l.readChar()
+}
+
+// readChunk reads the next chunk of data from the input reader and appends it to the
+// input that was not consumed yet, starting at the current character or at the start of
+// the number or keyword being read. It reports whether
+// anything was read; the current chunk is kept when nothing new was read, so that EOF does
+// not discard input that checkpoints may still refer to.
+func (l *Lexer) readChunk() bool {
 	if !l.isStreaming || l.reader == nil {
-		return
+		return false
+	}
+
+	n, _ := l.reader.Read(l.buffer)
+	if n == 0 {
+		return false
 	}
 
-	remaining := len(l.input) - l.position
-	if remaining > 0 {
-		copy(l.buffer, l.input[l.position:])
-		l.position += copy(l.buffer[remaining:], l.input[l.position:])
+	keep := min(l.position, l.readPosition)
+	if l.holding {
+		keep = min(keep, int(l.hold-l.base))
+	}
 
-		return
+	if l.marked {
+		keep = min(keep, l.start)
+		l.start -= keep
 	}
 
-	l.input = ""
+	l.base += int64(keep)
+	l.input = l.input[keep:] + string(l.buffer[:n])
+	l.position -= keep
+	l.readPosition -= keep
+	l.chunk++
 
-	n, err := l.reader.Read(l.buffer[remaining:])
-	if n > 0 {
-		l.input += string(l.buffer[remaining : remaining+n])
+	return true
+}
+
+// Checkpoint captures the current lexer state. Tokens read after the checkpoint can be
+// read again by passing it to Rewind, which allows speculative lexing without re-reading
+// the input from the start.
+func (l *Lexer) Checkpoint() Checkpoint {
+	return Checkpoint{
+		position:     l.position,
+		readPosition: l.readPosition,
+		ch:           l.ch,
+		line:         l.line,
+		column:       l.column,
+		chunk:        l.chunk,
 	}
+}
 
-	if err != nil && err != io.EOF {
-		return
+// Rewind restores the lexer to a state captured by Checkpoint. In streaming mode it fails
+// when the input buffered at the time of the checkpoint has since been discarded.
+func (l *Lexer) Rewind(cp Checkpoint) error {
+	if cp.chunk != l.chunk {
+		return fmt.Errorf("cannot rewind: checkpoint input is no longer buffered")
+	}
+
+	l.position = cp.position
+	l.readPosition = cp.readPosition
+	l.ch = cp.ch
+	l.line = cp.line
+	l.column = cp.column
+
+	// Comments after the checkpoint are recorded again when they are read again
+	for len(l.comments) > 0 && l.comments[len(l.comments)-1].offset >= l.Offset() {
+		l.comments = l.comments[:len(l.comments)-1]
 	}
 
-	l.position = 0
-	l.readPosition = 0
+	return nil
 }
 
 // NextToken retrieves the next token from the input, skipping any whitespace.
 func (l *Lexer) NextToken() Token {
+	if l.encoding != EncodingUTF8 {
+		return Token{Type: TokenIllegal, Literal: l.encoding.String() + " input must be transcoded to UTF-8", Line: 1}
+	}
+
+	l.marked = false
+
 	l.skipWhitespace()
 
+	for l.allowComments && l.ch == '/' {
+		offset := l.Offset()
+
+		if t, ok := l.skipComment(); !ok {
+			t.Offset = offset
+			return t
+		}
+
+		l.skipWhitespace()
+	}
+
 	currentLine := l.line
 	currentColumn := l.column
+	offset := l.Offset()
 
 	var t Token
 
+	// Punctuation, like keywords, uses constant literals and numbers slice the input, so
+	// that only string tokens allocate
 	switch l.ch {
 	case '{':
-		t = Token{Type: TokenBraceOpen, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenBraceOpen, Literal: "{", Line: currentLine, Column: currentColumn}
 	case '}':
-		t = Token{Type: TokenBraceClose, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenBraceClose, Literal: "}", Line: currentLine, Column: currentColumn}
 	case '[':
-		t = Token{Type: TokenBracketOpen, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenBracketOpen, Literal: "[", Line: currentLine, Column: currentColumn}
 	case ']':
-		t = Token{Type: TokenBracketClose, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenBracketClose, Literal: "]", Line: currentLine, Column: currentColumn}
 	case ':':
-		t = Token{Type: TokenColon, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenColon, Literal: ":", Line: currentLine, Column: currentColumn}
 	case ',':
-		t = Token{Type: TokenComma, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
+		t = Token{Type: TokenComma, Literal: ",", Line: currentLine, Column: currentColumn}
 	case '"':
-		return l.readString(currentLine, currentColumn)
+		t = l.readString(currentLine, currentColumn)
+		t.Offset, t.End = offset, l.Offset()
+
+		return t
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '-':
-		return l.readNumber(currentLine, currentColumn)
+		t = l.readNumber(currentLine, currentColumn)
+		t.Offset, t.End = offset, l.Offset()
+
+		return t
 	case 't':
-		return l.readTrue(currentLine, currentColumn)
+		t = l.readTrue(currentLine, currentColumn)
+		t.Offset, t.End = offset, l.Offset()
+
+		return t
 	case 'f':
-		return l.readFalse(currentLine, currentColumn)
+		t = l.readFalse(currentLine, currentColumn)
+		t.Offset, t.End = offset, l.Offset()
+
+		return t
 	case 'n':
-		return l.readNull(currentLine, currentColumn)
+		t = l.readNull(currentLine, currentColumn)
+		t.Offset, t.End = offset, l.Offset()
+
+		return t
 	case 0:
 		t = Token{Type: TokenEOF, Literal: "", Line: currentLine, Column: currentColumn}
 	default:
 		t = Token{Type: TokenIllegal, Literal: string(l.ch), Line: currentLine, Column: currentColumn}
 	}
 
+	t.Offset = offset
+
 	l.readChar()
 
+	t.End = l.Offset()
+
 	return t
 }
 
+// tokensBuffer is the number of tokens Tokens reads ahead of the receiver.
+const tokensBuffer = 256
+
+// Tokens reads the tokens of the input in a new goroutine and sends them on the returned
+// channel, which is closed after the TokenEOF token. Lexing then runs on another core
+// than the stage receiving the tokens, up to tokensBuffer tokens ahead of it; the
+// goroutine waits while the buffer is full. Canceling ctx stops it and closes the channel
+// without sending TokenEOF, but does not interrupt a read from the underlying reader.
+// The lexer must not be used in any other way until the channel is closed.
+// NewPipelinedParser parses the tokens as they arrive.
+func (l *Lexer) Tokens(ctx context.Context) <-chan Token {
+	tokens := make(chan Token, tokensBuffer)
+
+	go func() {
+		defer close(tokens)
+
+		for ctx.Err() == nil {
+			t := l.NextToken()
+
+			select {
+			case tokens <- t:
+			case <-ctx.Done():
+				return
+			}
+
+			// Input that is not UTF-8 yields the same illegal token forever
+			if t.Type == TokenEOF || l.encoding != EncodingUTF8 {
+				return
+			}
+		}
+	}()
+
+	return tokens
+}
+
+// holdFrom keeps the input from offset on buffered across refills.
+func (l *Lexer) holdFrom(offset int64) {
+	l.hold, l.holding = offset, true
+}
+
+// buffered returns the input from offset on that was read from the underlying reader:
+// the rest of the current chunk and the data buffered for the next chunks.
+func (l *Lexer) buffered(offset int64) io.Reader {
+	rest := l.input[min(max(int(offset-l.base), 0), len(l.input)):]
+	if l.reader == nil {
+		return strings.NewReader(rest)
+	}
+
+	ahead, _ := l.reader.Peek(l.reader.Buffered())
+
+	return io.MultiReader(strings.NewReader(rest), bytes.NewReader(bytes.Clone(ahead)))
+}
+
+// Offset returns the byte offset of the current character in the whole input, counting
+// the chunks already consumed in streaming mode. At the end of the input it is the input
+// length.
+func (l *Lexer) Offset() int64 {
+	return l.base + int64(l.position)
+}
+
 // readChar advances the position in the input string and updates the current character.
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		if l.isStreaming {
-			l.readChunk()
+	// Refill before the next character could be incomplete, so that a multi-byte
+	// character split between two chunks is decoded as a whole
+	if l.isStreaming && len(l.input)-l.readPosition < utf8.UTFMax {
+		for !utf8.FullRuneInString(l.input[l.readPosition:]) && l.readChunk() {
 		}
+	}
 
-		if l.readPosition >= len(l.input) {
-			l.ch = 0 // EOF
-			return
-		}
+	if l.readPosition >= len(l.input) {
+		l.ch = 0 // EOF
+		l.position = len(l.input)
+
+		return
 	}
 
 	var size int
@@ -160,20 +438,104 @@ func (l *Lexer) readChar() {
 	}
 }
 
-// skipWhitespace skips over any whitespace characters.
+// skipWhitespace skips over any whitespace characters. Runs of spaces, such as the
+// indentation of pretty-printed input, are skipped a word at a time.
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		if n := spacePrefix(l.input[l.position:]); n > 1 {
+			l.skipASCII(n)
+			continue
+		}
+
+		l.readChar()
+	}
+}
+
+// skipASCII moves past n single-byte characters other than newlines, starting with the
+// current one, like n calls to readChar.
+func (l *Lexer) skipASCII(n int) {
+	l.readPosition = l.position + n
+	l.column += n - 1
+	l.readChar()
+}
+
+// skipComment skips the comment starting at the current '/', and records it when
+// comments are kept. It returns an ILLEGAL token when the slash does not start a comment
+// or a block comment is not terminated.
+func (l *Lexer) skipComment() (Token, bool) {
+	line, column, offset := l.line, l.column, l.Offset()
+
+	var text strings.Builder
+
+	// next moves to the next character, keeping the current one in text
+	next := func() {
+		if l.keepComments {
+			text.WriteRune(l.ch)
+		}
+
 		l.readChar()
 	}
+
+	next()
+
+	switch l.ch {
+	case '/':
+		for l.ch != '\n' && l.ch != 0 {
+			next()
+		}
+
+		l.keepComment(strings.TrimSuffix(text.String(), "\r"), line, offset)
+
+		return Token{}, true
+	case '*':
+		next()
+
+		for l.ch != 0 {
+			if l.ch == '*' {
+				next()
+
+				if l.ch == '/' {
+					next()
+					l.keepComment(text.String(), line, offset)
+
+					return Token{}, true
+				}
+
+				continue
+			}
+
+			next()
+		}
+
+		return Token{Type: TokenIllegal, Literal: "Unterminated comment", Line: line, Column: column}, false
+	default:
+		return Token{Type: TokenIllegal, Literal: "/", Line: line, Column: column}, false
+	}
 }
 
-// readString reads a string token.
+// keepComment records a comment that starts at line and offset, if comments are kept.
+func (l *Lexer) keepComment(text string, line int, offset int64) {
+	if l.keepComments {
+		l.comments = append(l.comments, comment{text: text, line: line, offset: offset})
+	}
+}
+
+// readString reads a string token, decoding escape sequences. Bytes that are not valid
+// UTF-8 are handled according to the invalid UTF-8 policy.
 func (l *Lexer) readString(line, column int) Token {
-	var result []rune
+	var result []byte
 
 	l.readChar()
 
 	for l.ch != '"' && l.ch != 0 {
+		// Copy runs of plain ASCII characters a word at a time
+		if n := plainStringPrefix(l.input[l.position:]); n > 1 {
+			result = append(result, l.input[l.position:l.position+n]...)
+			l.skipASCII(n)
+
+			continue
+		}
+
 		if l.ch == '\\' {
 			l.readChar()
 
@@ -181,10 +543,23 @@ func (l *Lexer) readString(line, column int) Token {
 				return Token{Type: TokenIllegal, Literal: "Unterminated string", Line: line, Column: column}
 			}
 
-			result = append(result, '\\')
-			result = append(result, l.ch)
+			r, ok := l.readEscape()
+			if !ok {
+				return Token{Type: TokenIllegal, Literal: "Invalid escape sequence", Line: line, Column: column}
+			}
+
+			result = utf8.AppendRune(result, r)
+		} else if l.ch == utf8.RuneError && l.readPosition-l.position == 1 {
+			switch l.invalidUTF8 {
+			case InvalidUTF8Error:
+				return Token{Type: TokenIllegal, Literal: "Invalid UTF-8 in string", Line: line, Column: column}
+			case InvalidUTF8Pass:
+				result = append(result, l.input[l.position])
+			default:
+				result = utf8.AppendRune(result, utf8.RuneError)
+			}
 		} else {
-			result = append(result, l.ch)
+			result = utf8.AppendRune(result, l.ch)
 		}
 
 		l.readChar()
@@ -199,9 +574,107 @@ func (l *Lexer) readString(line, column int) Token {
 	return Token{Type: TokenString, Literal: string(result), Line: line, Column: column}
 }
 
+// readEscape decodes the escape sequence whose first character after the backslash is the
+// current character. It leaves the lexer on the last character of the sequence.
+func (l *Lexer) readEscape() (rune, bool) {
+	switch l.ch {
+	case '"', '\\', '/':
+		return l.ch, true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case 'u':
+		r, ok := l.readHex4()
+		if !ok {
+			return 0, false
+		}
+
+		if !utf16.IsSurrogate(r) {
+			return r, true
+		}
+
+		// Only a high surrogate followed by an escaped low surrogate forms a pair. Anything
+		// else is replaced and the following escape is left for the next iteration
+		if r >= 0xDC00 {
+			return utf8.RuneError, true
+		}
+
+		low, ok := l.peekLowSurrogate()
+		if !ok {
+			return utf8.RuneError, true
+		}
+
+		for i := 0; i < 6; i++ {
+			l.readChar()
+		}
+
+		return utf16.DecodeRune(r, low), true
+	default:
+		return 0, false
+	}
+}
+
+// peekLowSurrogate reports the low surrogate escaped by the \uXXXX sequence that follows
+// the current character, without consuming it.
+func (l *Lexer) peekLowSurrogate() (rune, bool) {
+	for len(l.input)-l.readPosition < 6 && l.readChunk() {
+	}
+
+	rest := l.input[l.readPosition:]
+	if len(rest) < 6 || !strings.HasPrefix(rest, `\u`) {
+		return 0, false
+	}
+
+	var r rune
+
+	for _, c := range rest[2:6] {
+		switch {
+		case '0' <= c && c <= '9':
+			r = r<<4 | (c - '0')
+		case 'a' <= c && c <= 'f':
+			r = r<<4 | (c - 'a' + 10)
+		case 'A' <= c && c <= 'F':
+			r = r<<4 | (c - 'A' + 10)
+		default:
+			return 0, false
+		}
+	}
+
+	return r, 0xDC00 <= r && r <= 0xDFFF
+}
+
+// readHex4 reads the four hex digits following a \u escape.
+func (l *Lexer) readHex4() (rune, bool) {
+	var r rune
+
+	for i := 0; i < 4; i++ {
+		l.readChar()
+
+		switch {
+		case '0' <= l.ch && l.ch <= '9':
+			r = r<<4 | (l.ch - '0')
+		case 'a' <= l.ch && l.ch <= 'f':
+			r = r<<4 | (l.ch - 'a' + 10)
+		case 'A' <= l.ch && l.ch <= 'F':
+			r = r<<4 | (l.ch - 'A' + 10)
+		default:
+			return 0, false
+		}
+	}
+
+	return r, true
+}
+
 // readNumber reads and validates a JSON number token.
 func (l *Lexer) readNumber(line, column int) Token {
-	start := l.position
+	l.mark()
 
 	// Handle negative numbers
 	if l.ch == '-' {
@@ -288,7 +761,7 @@ func (l *Lexer) readNumber(line, column int) Token {
 
 	return Token{
 		Type:    TokenNumber,
-		Literal: l.input[start:l.position],
+		Literal: l.literal(),
 		Line:    line,
 		Column:  column,
 	}
@@ -326,13 +799,27 @@ func (l *Lexer) readNull(line, column int) Token {
 
 // readWord reads a word token (used for true, false, null).
 func (l *Lexer) readWord() string {
-	start := l.position
+	l.mark()
 
 	for isLetter(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[start:l.position]
+	return l.literal()
+}
+
+// mark starts keeping the input from the current character across refills.
+func (l *Lexer) mark() {
+	l.start = l.position
+	l.marked = true
+}
+
+// literal returns the input from the position saved by mark up to the current character
+// and stops keeping it.
+func (l *Lexer) literal() string {
+	l.marked = false
+
+	return l.input[l.start:l.position]
 }
 
 // isLetter checks if a character is a letter.
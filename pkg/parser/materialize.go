@@ -0,0 +1,59 @@
+package parser
+
+// ToInterface converts the object into a map[string]interface{} holding plain Go values.
+func (o *Object) ToInterface() interface{} { return o.ToMap() }
+
+// ToMap converts the object into a map of plain Go values, as ToInterface does for each
+// member.
+func (o *Object) ToMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(o.Pairs))
+
+	for k, v := range o.Pairs {
+		m[k] = toInterface(v)
+	}
+
+	return m
+}
+
+// ToInterface converts the array into a []interface{} holding plain Go values.
+func (a *Array) ToInterface() interface{} { return a.ToSlice() }
+
+// ToSlice converts the array into a slice of plain Go values, as ToInterface does for
+// each element.
+func (a *Array) ToSlice() []interface{} {
+	s := make([]interface{}, len(a.Elements))
+
+	for i, v := range a.Elements {
+		s[i] = toInterface(v)
+	}
+
+	return s
+}
+
+// ToInterface returns the string value.
+func (s *StringLiteral) ToInterface() interface{} { return s.Value }
+
+// ToInterface returns the number as an int64 when it is an integer that fits, and as a
+// float64 otherwise, matching encoding.Unmarshal into an interface{}.
+func (n *NumberLiteral) ToInterface() interface{} {
+	if n.IsInt {
+		return n.Int
+	}
+
+	return n.Float
+}
+
+// ToInterface returns the boolean value.
+func (b *Boolean) ToInterface() interface{} { return b.Value }
+
+// ToInterface returns nil.
+func (n *Null) ToInterface() interface{} { return nil }
+
+// toInterface converts v, which may be a nil Value, into a plain Go value.
+func toInterface(v Value) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return v.ToInterface()
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+)
+
+// NormalizeOptions selects the optional steps of Normalize.
+type NormalizeOptions struct {
+	// SortArrays sorts the arrays whose elements are all scalars: null first, then false,
+	// true, numbers by value and strings.
+	SortArrays bool
+	// RemoveNulls drops the object members whose value is null. Null array elements are
+	// kept, since removing them would shift the other elements.
+	RemoveNulls bool
+}
+
+// Normalize rewrites v into a reproducible form for caching and comparison: object keys
+// are sorted and numbers are written in one form per value, so 1.0 and 1e0 both become 1
+// and 0.50 becomes 0.5. v is modified in place and returned.
+func Normalize(v Value, opts NormalizeOptions) Value {
+	switch val := v.(type) {
+	case *Object:
+		keys := make([]string, 0, len(val.Pairs))
+
+		for key, member := range val.Pairs {
+			if _, ok := member.(*Null); ok && opts.RemoveNulls {
+				delete(val.Pairs, key)
+				continue
+			}
+
+			val.Pairs[key] = Normalize(member, opts)
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		val.Keys = keys
+	case *Array:
+		for i, elem := range val.Elements {
+			val.Elements[i] = Normalize(elem, opts)
+		}
+
+		if opts.SortArrays && allScalars(val.Elements) {
+			sort.SliceStable(val.Elements, func(i, j int) bool {
+				return compareScalars(val.Elements[i], val.Elements[j]) < 0
+			})
+		}
+	case *NumberLiteral:
+		return normalizeNumber(val)
+	}
+
+	return v
+}
+
+// normalizeNumber returns n as an integer if it has an integral value that fits in an
+// int64, and in its shortest floating-point form otherwise.
+func normalizeNumber(n *NumberLiteral) *NumberLiteral {
+	if n.IsInt || !n.IsValid {
+		return n
+	}
+
+	if r := n.Rat(); r.IsInt() && r.Num().IsInt64() {
+		return NewNumber(r.Num().Int64())
+	}
+
+	if f, err := NewFloat(n.Float); err == nil {
+		return f
+	}
+
+	return n
+}
+
+// allScalars reports whether elems holds no objects or arrays.
+func allScalars(elems []Value) bool {
+	for _, elem := range elems {
+		switch elem.(type) {
+		case *Object, *Array:
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareScalars orders two scalar values for SortArrays.
+func compareScalars(a, b Value) int {
+	if ra, rb := scalarRank(a), scalarRank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch left := a.(type) {
+	case *NumberLiteral:
+		right := b.(*NumberLiteral)
+		if left.IsInt && right.IsInt {
+			return cmp.Compare(left.Int, right.Int)
+		}
+
+		return left.Rat().Cmp(right.Rat())
+	case *StringLiteral:
+		return strings.Compare(left.Value, b.(*StringLiteral).Value)
+	default:
+		return 0
+	}
+}
+
+// scalarRank returns the position of the type of v in the order of SortArrays.
+func scalarRank(v Value) int {
+	switch val := v.(type) {
+	case *Null:
+		return 0
+	case *Boolean:
+		if val.Value {
+			return 2
+		}
+
+		return 1
+	case *NumberLiteral:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import "fmt"
+
+// Option configures optional Parser behavior.
+type Option func(*Parser)
+
+// InvalidUTF8Policy determines how bytes that are not valid UTF-8 are handled in strings.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Replace replaces each invalid byte with U+FFFD. This is the default.
+	InvalidUTF8Replace InvalidUTF8Policy = iota
+	// InvalidUTF8Error rejects strings that contain invalid UTF-8
+	InvalidUTF8Error
+	// InvalidUTF8Pass keeps invalid bytes in the decoded string as they are
+	InvalidUTF8Pass
+)
+
+// String returns the name of the policy.
+func (p InvalidUTF8Policy) String() string {
+	switch p {
+	case InvalidUTF8Replace:
+		return "replace"
+	case InvalidUTF8Error:
+		return "error"
+	case InvalidUTF8Pass:
+		return "pass"
+	default:
+		return fmt.Sprintf("InvalidUTF8Policy(%d)", int(p))
+	}
+}
+
+// WithDuplicateKeyCheck reports an error when an object contains the same key twice.
+// newSet is called once per object; use NewExactKeySet for precise detection or
+// NewBloomKeySet to bound the memory used per object at the cost of false positives.
+func WithDuplicateKeyCheck(newSet func() KeySet) Option {
+	return func(p *Parser) {
+		p.newKeySet = newSet
+	}
+}
+
+// Limits bounds the resources a document may use, to defend against documents crafted to
+// exhaust memory or stack. Zero fields are not limited.
+type Limits struct {
+	// MaxDepth is the deepest nesting of objects and arrays; the top-level container has
+	// depth 1.
+	MaxDepth int
+	// MaxStringLength is the longest string, key or value, in bytes after decoding.
+	MaxStringLength int
+	// MaxArrayElements is the largest number of elements in an array.
+	MaxArrayElements int
+	// MaxObjectKeys is the largest number of members in an object.
+	MaxObjectKeys int
+}
+
+// WithLimits makes ParseJSON, Walk and Validate stop at the first value that exceeds one
+// of limits, reporting a *ParseError that wraps a *LimitError. Elements passed to the
+// handler of ParseArray are checked, but the streamed array itself has no element limit.
+func WithLimits(limits Limits) Option {
+	return func(p *Parser) {
+		p.limits = limits
+	}
+}
+
+// WithInterrupt makes the parser call check every 1024 tokens. A non-nil error stops
+// the parse, which then returns a *ParseError wrapping it. This bounds the time spent on
+// input that is already in memory, where no read gives a chance to give up.
+func WithInterrupt(check func() error) Option {
+	return func(p *Parser) {
+		p.interrupt = check
+	}
+}
+
+// WithAllowComments skips // line comments and /* block */ comments between tokens, as
+// found in JSONC configuration files.
+func WithAllowComments() Option {
+	return func(p *Parser) {
+		p.lexer.allowComments = true
+	}
+}
+
+// WithPreserveComments accepts comments like WithAllowComments and attaches them to the
+// nodes built by ParseJSON, as described in Comments, so that a commented configuration
+// file can be edited and written back without losing them.
+func WithPreserveComments() Option {
+	return func(p *Parser) {
+		p.lexer.allowComments = true
+		p.lexer.keepComments = true
+	}
+}
+
+// WithAllowTrailingCommas accepts a comma after the last member of an object or the last
+// element of an array.
+func WithAllowTrailingCommas() Option {
+	return func(p *Parser) {
+		p.allowTrailingCommas = true
+	}
+}
+
+// WithBigNumbers accepts numbers that are valid JSON but out of the range of int64, for
+// integers, or float64. They are parsed into a NumberLiteral with IsBig set, whose Value
+// keeps the literal exactly.
+func WithBigNumbers() Option {
+	return func(p *Parser) {
+		p.bigNumbers = true
+	}
+}
+
+// WithErrorRecovery makes the parser resynchronize after a syntax error, by skipping to
+// the next comma or closing bracket, and carry on so that every error in a document is
+// reported in one pass. ParseJSON then returns an ErrorList, and ParseErrors lists the
+// same errors. An error about a missing delimiter is located at the token found in its
+// place. Walk does not recover from errors.
+func WithErrorRecovery() Option {
+	return func(p *Parser) {
+		p.recoverErrors = true
+	}
+}
+
+// WithInvalidUTF8Policy sets how bytes that are not valid UTF-8 are handled in strings.
+func WithInvalidUTF8Policy(policy InvalidUTF8Policy) Option {
+	return func(p *Parser) {
+		p.lexer.invalidUTF8 = policy
+	}
+}
@@ -2,7 +2,10 @@
 package parser
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
 )
 
 // Parser holds the state while parsing JSON input. It maintains the current token and the next token,
@@ -10,22 +13,75 @@ import (
 type Parser struct {
 	// lexer provides tokens from the input string.
 	lexer *Lexer
+	// opts are the options the parser was created with, applied again by Reset.
+	opts []Option
 	// currentToken is the current token being examined.
 	currentToken Token
 	// peekToken is the next token in the stream.
 	peekToken Token
+	// consumed is the byte offset just past the last token the parser moved past.
+	consumed int64
 	// errors is a collection of parsing errors.
-	errors []string
+	errors []ParseError
+	// newKeySet creates the duplicate key tracker for each object, if enabled.
+	newKeySet func() KeySet
+	// allowTrailingCommas accepts a comma before a closing } or ].
+	allowTrailingCommas bool
+	// bigNumbers accepts numbers out of the range of int64 and float64.
+	bigNumbers bool
+	// recoverErrors resynchronizes after a syntax error to report every error in one pass.
+	recoverErrors bool
+	// limits bounds the resources a document may use.
+	limits Limits
+	// depth is the nesting level of the container being parsed.
+	depth int
+	// aborted is set when a limit is exceeded; no more tokens are read and no more errors
+	// are recorded after it.
+	aborted bool
+	// interrupt is called every interruptInterval tokens to stop a parse that runs too long.
+	interrupt func() error
+	// tokens counts the tokens read, to pace the interrupt checks.
+	tokens uint
+	// interrupted is the error recorded when interrupt stopped the parse.
+	interrupted *ParseError
+	// prev is the last value parsed in the current container, which the comments on
+	// the line where it ends are attached to.
+	prev Value
+	// arena allocates the parsed nodes, if set by NewArenaParser.
+	arena *Arena
+	// source receives the tokens lexed on another goroutine, if set by
+	// NewPipelinedParser, until that goroutine is done with the lexer.
+	source <-chan Token
+	// ctx stops the goroutine feeding source.
+	ctx context.Context
+}
+
+// interruptInterval is the number of tokens read between two interrupt checks.
+const interruptInterval = 1024
+
+// ParserCheckpoint is a snapshot of the parser state that can be restored with Rewind.
+type ParserCheckpoint struct {
+	lexer        Checkpoint
+	currentToken Token
+	peekToken    Token
+	consumed     int64
+	errors       int
+	pipelined    bool
 }
 
 // NewParser creates a new Parser instance for the given lexer.
 //
-// The function initializes the Parser by reading two tokens
+// The function applies the options and initializes the Parser by reading two tokens
 // to set up the currentToken and peekToken fields.
-func NewParser(lexer *Lexer) *Parser {
+func NewParser(lexer *Lexer, opts ...Option) *Parser {
 	p := &Parser{
 		lexer:  lexer,
-		errors: []string{},
+		opts:   opts,
+		errors: []ParseError{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
 
 	// Read two tokens to initialize currentToken and peekToken
@@ -35,97 +91,529 @@ func NewParser(lexer *Lexer) *Parser {
 	return p
 }
 
+// NewPipelinedParser creates a Parser like NewParser that lexes on a separate goroutine
+// with Lexer.Tokens, so that lexing and parsing a large input run on two cores. Comments
+// allowed by the options are skipped rather than kept. Until the lexer reaches the end of
+// the input, Checkpoint cannot be rewound, Buffered is empty and syntax errors have no
+// snippet. Canceling ctx stops the lexer goroutine; the parse then fails with ctx's error.
+// The lexer must not be used in any other way, and the parser must not be Reset with it,
+// before its tokens are exhausted or ctx is canceled.
+func NewPipelinedParser(ctx context.Context, lexer *Lexer, opts ...Option) *Parser {
+	p := &Parser{
+		lexer:  lexer,
+		opts:   opts,
+		errors: []ParseError{},
+		ctx:    ctx,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// The pending comments would be shared with the lexer goroutine
+	lexer.keepComments = false
+	p.source = lexer.Tokens(ctx)
+
+	p.nextToken()
+	p.nextToken()
+
+	return p
+}
+
+// Reset clears the tokens and errors of the parser and reads the first tokens from
+// lexer, or from its current lexer when lexer is nil, which must then have been reset to
+// a new input first. Options are kept and applied to a new lexer.
+func (p *Parser) Reset(lexer *Lexer) {
+	if lexer != nil {
+		p.lexer, p.source = lexer, nil
+
+		for _, opt := range p.opts {
+			opt(p)
+		}
+	}
+
+	p.errors = p.errors[:0]
+	p.depth, p.aborted, p.interrupted = 0, false, nil
+	p.currentToken, p.peekToken, p.consumed = Token{}, Token{}, 0
+
+	p.nextToken()
+	p.nextToken()
+}
+
 // nextToken advances to the next token in the token stream.
 // It updates currentToken to the value of peekToken,
 // and then gets a new value for peekToken from the lexer.
 func (p *Parser) nextToken() {
+	p.consumed = p.currentToken.End
 	p.currentToken = p.peekToken
-	p.peekToken = p.lexer.NextToken()
+
+	if p.source == nil {
+		p.lexer.holdFrom(p.consumed)
+	}
+
+	if p.aborted {
+		p.peekToken = Token{
+			Type: TokenEOF, Line: p.currentToken.Line, Column: p.currentToken.Column,
+			Offset: p.currentToken.Offset, End: p.currentToken.End,
+		}
+		return
+	}
+
+	if p.source != nil {
+		p.peekToken = p.receiveToken()
+	} else {
+		p.peekToken = p.lexer.NextToken()
+	}
+
+	if p.tokens++; p.interrupt != nil && p.tokens%interruptInterval == 0 {
+		if err := p.interrupt(); err != nil {
+			p.interrupted = p.newError(p.peekToken, "%s", err.Error())
+			p.interrupted.Err = err
+
+			p.errors = append(p.errors, *p.interrupted)
+			p.aborted = true
+		}
+	}
+}
+
+// receiveToken returns the next token sent by the lexer goroutine. Once the goroutine is
+// done, the parser reads from the lexer directly again, and a canceled context stops the
+// parse like an interrupt.
+func (p *Parser) receiveToken() Token {
+	t, ok := <-p.source
+	if ok && t.Type != TokenEOF {
+		return t
+	}
+
+	if ok {
+		// Wait for the goroutine to close the channel, after its last use of the lexer
+		for range p.source {
+		}
+	}
+
+	p.source = nil
+
+	if ok {
+		return t
+	}
+
+	if err := p.ctx.Err(); err != nil {
+		eof := Token{
+			Type: TokenEOF, Line: p.currentToken.Line, Column: p.currentToken.Column,
+			Offset: p.currentToken.Offset, End: p.currentToken.End,
+		}
+
+		p.interrupted = p.newError(eof, "%s", err.Error())
+		p.interrupted.Err = err
+
+		p.errors = append(p.errors, *p.interrupted)
+		p.aborted = true
+
+		return eof
+	}
+
+	return p.lexer.NextToken()
+}
+
+// interruption returns the error recorded when interrupt stopped the parse, which takes
+// precedence over err, the error the parse ran into after being stopped.
+func (p *Parser) interruption(err error) error {
+	if p.interrupted != nil {
+		return p.interrupted
+	}
+
+	return err
+}
+
+// Checkpoint captures the parser state, including its token lookahead, so that a
+// speculative parse can be undone with Rewind.
+func (p *Parser) Checkpoint() ParserCheckpoint {
+	if p.source != nil {
+		return ParserCheckpoint{pipelined: true}
+	}
+
+	return ParserCheckpoint{
+		lexer:        p.lexer.Checkpoint(),
+		currentToken: p.currentToken,
+		peekToken:    p.peekToken,
+		consumed:     p.consumed,
+		errors:       len(p.errors),
+	}
+}
+
+// Rewind restores a state captured by Checkpoint and drops errors recorded since then.
+func (p *Parser) Rewind(cp ParserCheckpoint) error {
+	if cp.pipelined {
+		return fmt.Errorf("cannot rewind: checkpoint was taken while lexing on another goroutine")
+	}
+
+	if err := p.lexer.Rewind(cp.lexer); err != nil {
+		return err
+	}
+
+	p.currentToken = cp.currentToken
+	p.peekToken = cp.peekToken
+	p.consumed = cp.consumed
+	p.errors = p.errors[:cp.errors]
+
+	return nil
 }
 
 // ParseJSON is the entry point for parsing JSON content. It returns the parsed
 // Value and an error if the parsing fails.
-// The function expects the JSON input to start with either a '{' or a '['.
+// The function expects the JSON input to start with either a '{' or a '['. On success the
+// parser moves past the value, so that a stream of values can be parsed by calling
+// ParseJSON while More reports true. Syntax errors are returned as a *ParseError for the
+// first error; ParseErrors lists all of them.
 func (p *Parser) ParseJSON() (Value, error) {
-	var value Value
+	var (
+		value Value
+		// leading are the comments before the value, when comments are kept
+		leading []comment
+	)
+
+	if p.lexer.keepComments {
+		p.prev = nil
+		leading = p.leadingComments(p.currentToken.Offset)
+	}
 
 	switch p.currentToken.Type {
 	case TokenBraceOpen:
 		value = p.parseObject()
 	case TokenBracketOpen:
 		value = p.parseArray()
+	case TokenIllegal:
+		return nil, p.interruption(p.newError(p.currentToken, "%s", p.currentToken.Literal))
 	default:
-		return nil, fmt.Errorf("expected { or [, got %s at line %d, column %d",
-			p.currentToken.Type, p.currentToken.Line, p.currentToken.Column)
+		return nil, p.interruption(p.newError(p.currentToken, "expected { or [, got %s", p.currentToken.Type))
 	}
 
 	// Check for parsing errors
 	if len(p.errors) > 0 {
-		return nil, fmt.Errorf("%s", p.errors[0]) // Return the first error
+		if p.recoverErrors {
+			return nil, p.errorList()
+		}
+
+		return nil, p.firstError()
+	}
+
+	if p.lexer.keepComments {
+		p.rootComments(value, leading)
 	}
 
+	p.nextToken() // move past the value
+
 	return value, nil
 }
 
+// ParseNext parses the next value of a stream of values like ParseJSON, and returns
+// io.EOF once the input holds no more values:
+//
+//	for {
+//		value, err := p.ParseNext()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+func (p *Parser) ParseNext() (Value, error) {
+	if p.currentToken.Type == TokenEOF && len(p.errors) == 0 {
+		return nil, io.EOF
+	}
+
+	return p.ParseJSON()
+}
+
+// ParseArray parses the array at the current token one element at a time and calls fn
+// with each element as soon as it is parsed, so that only one element is held in memory.
+// On success the parser moves past the array like ParseJSON. It returns the first syntax
+// error, or the first error returned by fn, which stops parsing. Errors are not recovered.
+func (p *Parser) ParseArray(fn func(Value) error) error {
+	if p.currentToken.Type != TokenBracketOpen {
+		return p.newError(p.currentToken, "expected [, got %s", p.currentToken.Type)
+	}
+
+	if !p.enter() {
+		return p.firstError()
+	}
+
+	defer p.leave()
+
+	if p.peekToken.Type == TokenBracketClose {
+		p.nextToken() // move to ]
+		p.nextToken() // move past the array
+
+		return nil
+	}
+
+	for {
+		p.nextToken() // move to the value
+
+		value := p.parseValue()
+		if len(p.errors) > 0 {
+			return p.firstError()
+		}
+
+		if err := fn(value); err != nil {
+			return err
+		}
+
+		p.nextToken() // move past the value
+
+		switch p.currentToken.Type {
+		case TokenComma:
+			if p.allowTrailingCommas && p.peekToken.Type == TokenBracketClose {
+				p.nextToken() // move to ]
+				p.nextToken() // move past the array
+
+				return nil
+			}
+		case TokenBracketClose:
+			p.nextToken() // move past the array
+			return nil
+		default:
+			return p.interruption(p.newError(p.currentToken, "expected ], got %s", p.currentToken.Type))
+		}
+	}
+}
+
+// SkipValue consumes the value starting at the current token without building AST nodes
+// for it, and moves past it like ParseJSON. Unlike ParseJSON it also accepts a scalar.
+// Only the nesting of brackets is checked, not the placement of commas and colons.
+func (p *Parser) SkipValue() error {
+	return p.interruption(p.skipValue())
+}
+
+// skipValue implements SkipValue.
+func (p *Parser) skipValue() error {
+	var closers []TokenType
+
+	for {
+		tok := p.currentToken
+
+		switch tok.Type {
+		case TokenBraceOpen:
+			closers = append(closers, TokenBraceClose)
+		case TokenBracketOpen:
+			closers = append(closers, TokenBracketClose)
+		case TokenBraceClose, TokenBracketClose:
+			if len(closers) == 0 || closers[len(closers)-1] != tok.Type {
+				return p.newError(tok, "unexpected token %s", tok.Type)
+			}
+
+			closers = closers[:len(closers)-1]
+		case TokenColon, TokenComma:
+			if len(closers) == 0 {
+				return p.newError(tok, "unexpected token %s", tok.Type)
+			}
+		case TokenEOF:
+			return p.newError(tok, "unexpected EOF")
+		case TokenIllegal:
+			return p.newError(tok, "%s", tok.Literal)
+		}
+
+		p.nextToken()
+
+		if len(closers) == 0 {
+			return nil
+		}
+	}
+}
+
+// Offset returns the byte offset of the current token. After ParseJSON succeeds it is the
+// offset of the next value, and after it fails the offset of the first error.
+func (p *Parser) Offset() int64 {
+	if len(p.errors) > 0 {
+		return p.errors[0].Offset
+	}
+
+	return p.currentToken.Offset
+}
+
+// CurrentToken returns the current token. After ParseJSON succeeds it is the first token
+// after the value, or TokenEOF at the end of the input.
+func (p *Parser) CurrentToken() Token {
+	return p.currentToken
+}
+
+// InputOffset returns the byte offset just past the last token the parser moved past.
+// After ParseJSON, ParseArray or SkipValue succeeds it is the offset of the end of the
+// value, before any whitespace that follows it, while the lexer may have read further.
+func (p *Parser) InputOffset() int64 {
+	return p.consumed
+}
+
+// Buffered returns the input the lexer has read past InputOffset, such as the whitespace
+// and tokens it looked ahead at and the data buffered from its reader. It is valid until
+// the next call that reads tokens.
+func (p *Parser) Buffered() io.Reader {
+	if p.source != nil {
+		return strings.NewReader("")
+	}
+
+	return p.lexer.buffered(p.consumed)
+}
+
+// More reports whether there is another token after the values parsed so far.
+func (p *Parser) More() bool {
+	return p.currentToken.Type != TokenEOF
+}
+
 // parseObject parses a JSON object: { "key": value, ... }.
 // It returns an Object value containing the key-value pairs.
 func (p *Parser) parseObject() Value {
-	object := &Object{
-		Token: p.currentToken,
-		Pairs: make(map[string]Value),
+	if !p.enter() {
+		return nil
 	}
 
+	defer p.leave()
+
+	var object *Object
+	if p.arena != nil {
+		object = p.arena.newObject(p.currentToken)
+	} else {
+		object = &Object{
+			Token: p.currentToken,
+			Pairs: make(map[string]Value),
+		}
+	}
+
+	p.prev = nil
+
 	// Handle empty object case: {}
 	if p.peekToken.Type == TokenBraceClose {
+		p.footerComments(object)
 		p.nextToken()
+		object.End = p.currentToken.End
+
 		return object
 	}
 
-	p.nextToken() // move past {
+	var keys KeySet
+	if p.newKeySet != nil {
+		keys = p.newKeySet()
+	}
 
 	// Parse first key-value pair
-	key, value := p.parseKeyValuePair()
-	if key == "" && value == nil {
-		return nil
+	if !p.parseMember(object, keys) {
+		return p.unclosed(TokenBraceClose)
 	}
 
-	object.Pairs[key] = value
+	for {
+		// Parse additional key-value pairs
+		for p.peekToken.Type == TokenComma {
+			p.nextToken() // move past comma
 
-	// Parse additional key-value pairs
-	for p.peekToken.Type == TokenComma {
-		p.nextToken() // move past comma
+			// Check for trailing comma
+			if p.peekToken.Type == TokenBraceClose {
+				if p.allowTrailingCommas {
+					break
+				}
 
-		// Check for trailing comma
-		if p.peekToken.Type == TokenBraceClose {
-			p.addError("unexpected token ,")
+				p.addError("unexpected token ,")
+
+				if !p.recoverErrors {
+					return nil
+				}
+
+				break
+			}
+
+			if !p.parseMember(object, keys) {
+				return p.unclosed(TokenBraceClose)
+			}
+		}
+
+		// Handle EOF before closing brace
+		if p.peekToken.Type == TokenEOF {
+			p.addErrorAt(p.peekToken, "expected }, got EOF")
 			return nil
 		}
 
-		p.nextToken() // move to next key
+		if p.peekToken.Type == TokenBraceClose {
+			break
+		}
+
+		// Ensure we have a closing }
+		p.addErrorAt(p.peekToken, "expected }, got %s", p.peekToken.Type)
 
-		key, value := p.parseKeyValuePair()
-		if key == "" && value == nil {
+		if !p.recoverErrors || !p.synchronize(false) {
 			return nil
 		}
 
-		object.Pairs[key] = value
+		// A closer that does not match is taken as ours to stay in step
+		if p.peekToken.Type != TokenComma {
+			break
+		}
 	}
 
-	// Handle EOF before closing brace
-	if p.peekToken.Type == TokenEOF {
-		p.addError("expected }, got EOF")
-		return nil
+	p.footerComments(object)
+	p.nextToken() // move past }
+
+	object.End = p.currentToken.End
+
+	return object
+}
+
+// parseMember moves to the next key and parses a key-value pair into object. It reports
+// false on a syntax error, unless error recovery is enabled and the parser could skip to
+// the next member.
+func (p *Parser) parseMember(object *Object, keys KeySet) bool {
+	if p.recoverErrors && p.structuralAhead() {
+		p.addErrorAt(p.peekToken, "expected string key")
+		return p.synchronize(false)
 	}
 
-	// Ensure we have a closing }
-	if p.peekToken.Type != TokenBraceClose {
-		p.addError("expected }, got %s", p.peekToken.Type)
-		return nil
+	var leading []comment
+	if p.lexer.keepComments {
+		leading = p.leadingComments(p.peekToken.Offset)
 	}
 
-	p.nextToken() // move past }
+	p.nextToken() // move to the key
 
-	return object
+	if !p.checkCount(len(object.Pairs)+1, p.limits.MaxObjectKeys, "MaxObjectKeys") {
+		return false
+	}
+
+	errs := len(p.errors)
+	key, value := p.parseKeyValuePair()
+
+	if !p.recoverErrors {
+		if key == "" && value == nil || !p.addKey(keys, key) {
+			return false
+		}
+
+		object.Set(key, value)
+		p.parsed(value, leading)
+
+		return true
+	}
+
+	if len(p.errors) == errs && p.addKey(keys, key) {
+		object.Set(key, value)
+		p.parsed(value, leading)
+
+		return true
+	}
+
+	// A container in place of the key is skipped as a whole
+	return p.synchronize(key == "" && value == nil)
+}
+
+// addKey records an object key in the duplicate key tracker, if any. It reports false and
+// records an error when the key was seen before.
+func (p *Parser) addKey(keys KeySet, key string) bool {
+	if keys == nil || !keys.Add(key) {
+		return true
+	}
+
+	if keys.Exact() {
+		p.addError("duplicate key %q", key)
+	} else {
+		p.addError("likely duplicate key %q", key)
+	}
+
+	return false
 }
 
 // parseKeyValuePair parses a key-value pair in a JSON object.
@@ -138,14 +626,23 @@ func (p *Parser) parseKeyValuePair() (string, Value) {
 	}
 
 	key := p.currentToken.Literal
+	if !p.checkString(p.currentToken) {
+		return "", nil
+	}
 
 	// Must have a colon after key
 	if p.peekToken.Type != TokenColon {
-		p.addError("expected :, got %s", p.peekToken.Type)
+		p.addErrorAt(p.peekToken, "expected :, got %s", p.peekToken.Type)
 		return "", nil
 	}
 
 	p.nextToken() // move past key
+
+	if p.recoverErrors && p.structuralAhead() {
+		p.addErrorAt(p.peekToken, "unexpected token %s", p.peekToken.Type)
+		return key, nil
+	}
+
 	p.nextToken() // move past colon
 
 	value := p.parseValue()
@@ -156,65 +653,230 @@ func (p *Parser) parseKeyValuePair() (string, Value) {
 // parseArray parses a JSON array: [ value, value, ... ].
 // It returns an Array value containing the elements.
 func (p *Parser) parseArray() Value {
-	array := &Array{
-		Token:    p.currentToken,
-		Elements: []Value{},
+	if !p.enter() {
+		return nil
+	}
+
+	defer p.leave()
+
+	var array *Array
+	if p.arena != nil {
+		array = p.arena.newArray(p.currentToken)
+	} else {
+		array = &Array{
+			Token:    p.currentToken,
+			Elements: []Value{},
+		}
 	}
 
+	p.prev = nil
+
 	// Handle empty array case: []
 	if p.peekToken.Type == TokenBracketClose {
+		p.footerComments(array)
 		p.nextToken()
+		array.End = p.currentToken.End
+
 		return array
 	}
 
-	p.nextToken() // move past [
-
 	// Parse first value
-	value := p.parseValue()
-	array.Elements = append(array.Elements, value)
+	if !p.parseElement(array) {
+		return p.unclosed(TokenBracketClose)
+	}
 
 	// Parse additional values
-	for p.peekToken.Type == TokenComma {
-		p.nextToken() // move past comma
-		p.nextToken() // move to next value
-		value = p.parseValue()
-		array.Elements = append(array.Elements, value)
-	}
+	for {
+		for p.peekToken.Type == TokenComma {
+			p.nextToken() // move past comma
 
-	// Ensure we have a closing ]
-	if p.peekToken.Type != TokenBracketClose {
-		p.addError("expected ], got %s", p.peekToken.Type)
-		return nil
+			if p.allowTrailingCommas && p.peekToken.Type == TokenBracketClose {
+				break
+			}
+
+			if !p.parseElement(array) {
+				return p.unclosed(TokenBracketClose)
+			}
+		}
+
+		if p.peekToken.Type == TokenBracketClose {
+			break
+		}
+
+		// Ensure we have a closing ]
+		p.addErrorAt(p.peekToken, "expected ], got %s", p.peekToken.Type)
+
+		if !p.recoverErrors || !p.synchronize(false) {
+			return nil
+		}
+
+		// A closer that does not match is taken as ours to stay in step
+		if p.peekToken.Type != TokenComma {
+			break
+		}
 	}
 
+	p.footerComments(array)
 	p.nextToken() // move past ]
 
+	array.End = p.currentToken.End
+
 	return array
 }
 
+// parseElement moves to the next value and appends it to array. Without error recovery a
+// failed element is appended as nil and parsing goes on; with it the parser skips to the
+// next element, and false is reported when the input ends first.
+func (p *Parser) parseElement(array *Array) bool {
+	if p.recoverErrors && p.structuralAhead() {
+		p.addErrorAt(p.peekToken, "unexpected token %s", p.peekToken.Type)
+		return p.synchronize(false)
+	}
+
+	var leading []comment
+	if p.lexer.keepComments {
+		leading = p.leadingComments(p.peekToken.Offset)
+	}
+
+	p.nextToken() // move to the value
+
+	if !p.checkCount(len(array.Elements)+1, p.limits.MaxArrayElements, "MaxArrayElements") {
+		return false
+	}
+
+	errs := len(p.errors)
+	value := p.parseValue()
+
+	if !p.recoverErrors {
+		array.Elements = append(array.Elements, value)
+		p.parsed(value, leading)
+
+		return true
+	}
+
+	if len(p.errors) == errs {
+		array.Elements = append(array.Elements, value)
+		p.parsed(value, leading)
+
+		return true
+	}
+
+	return p.synchronize(false)
+}
+
+// unclosed reports, in error recovery mode, that the input ended inside a container
+// closed by closer. It returns nil.
+func (p *Parser) unclosed(closer TokenType) Value {
+	if p.recoverErrors {
+		p.addErrorAt(p.peekToken, "expected %s, got EOF", closer)
+	}
+
+	return nil
+}
+
+// structuralAhead reports whether the next token ends a member or container instead of
+// starting a value.
+func (p *Parser) structuralAhead() bool {
+	switch p.peekToken.Type {
+	case TokenComma, TokenColon, TokenBraceClose, TokenBracketClose, TokenEOF:
+		return true
+	default:
+		return false
+	}
+}
+
+// synchronize skips tokens after a syntax error until the next token is a comma or a
+// closing bracket outside any container entered while skipping, so that the enclosing
+// container can go on with its next member or element. When the current token is the
+// failing one and opens a container, that container is skipped as well. It reports false
+// when the input ends first.
+func (p *Parser) synchronize(skipCurrent bool) bool {
+	depth := 0
+
+	if skipCurrent && (p.currentToken.Type == TokenBraceOpen || p.currentToken.Type == TokenBracketOpen) {
+		depth++
+	}
+
+	for {
+		switch p.peekToken.Type {
+		case TokenEOF:
+			return false
+		case TokenBraceOpen, TokenBracketOpen:
+			depth++
+		case TokenBraceClose, TokenBracketClose:
+			if depth == 0 {
+				return true
+			}
+
+			depth--
+		case TokenComma:
+			if depth == 0 {
+				return true
+			}
+		}
+
+		p.nextToken()
+	}
+}
+
+// acceptsNumber reports whether num is valid, or too large for Go numbers with
+// WithBigNumbers.
+func (p *Parser) acceptsNumber(num *NumberLiteral) bool {
+	return num.IsValid || num.IsBig && p.bigNumbers
+}
+
 // parseValue parses any JSON value. It returns the parsed value.
 // The function handles strings, numbers, booleans, nulls, objects, and arrays.
 func (p *Parser) parseValue() Value {
 	switch p.currentToken.Type {
 	case TokenString:
+		if !p.checkString(p.currentToken) {
+			return nil
+		}
+
+		if p.arena != nil {
+			str := p.arena.strings.alloc()
+			str.Token, str.Value = p.currentToken, p.currentToken.Literal
+
+			return str
+		}
+
 		return &StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal}
 
 	case TokenNumber:
-		num := NewNumberLiteral(p.currentToken)
-		if !num.IsValidNumber() {
+		var num *NumberLiteral
+		if p.arena != nil {
+			num = initNumberLiteral(p.arena.numbers.alloc(), p.currentToken)
+		} else {
+			num = NewNumberLiteral(p.currentToken)
+		}
+
+		if !p.acceptsNumber(num) {
 			p.addError("invalid number format: %s", p.currentToken.Literal)
 			return nil
 		}
 
 		return num
 
-	case TokenTrue:
-		return &Boolean{Token: p.currentToken, Value: true}
+	case TokenTrue, TokenFalse:
+		value := p.currentToken.Type == TokenTrue
+		if p.arena != nil {
+			b := p.arena.booleans.alloc()
+			b.Token, b.Value = p.currentToken, value
 
-	case TokenFalse:
-		return &Boolean{Token: p.currentToken, Value: false}
+			return b
+		}
+
+		return &Boolean{Token: p.currentToken, Value: value}
 
 	case TokenNull:
+		if p.arena != nil {
+			n := p.arena.nulls.alloc()
+			n.Token = p.currentToken
+
+			return n
+		}
+
 		return &Null{Token: p.currentToken}
 
 	case TokenBraceOpen:
@@ -224,7 +886,7 @@ func (p *Parser) parseValue() Value {
 		return p.parseArray()
 
 	case TokenIllegal:
-		p.addError("expected string key")
+		p.addError("%s", p.currentToken.Literal)
 		return nil
 
 	default:
@@ -235,16 +897,99 @@ func (p *Parser) parseValue() Value {
 
 // addError adds a formatted error message to the parser's error list.
 //
-// The function records the error message along with the line and column numbers
+// The function records the error message along with the line, column and byte offset
 // where the error occurred.
 func (p *Parser) addError(format string, a ...interface{}) {
-	msg := fmt.Sprintf(format, a...)
-	formattedMsg := fmt.Sprintf("Line %d, Column %d: %s",
-		p.currentToken.Line, p.currentToken.Column, msg)
-	p.errors = append(p.errors, formattedMsg)
+	p.addErrorAt(p.currentToken, format, a...)
+}
+
+// addErrorAt adds a formatted error message located at tok to the parser's error list.
+func (p *Parser) addErrorAt(tok Token, format string, a ...interface{}) {
+	if p.aborted {
+		return
+	}
+
+	p.errors = append(p.errors, *p.newError(tok, format, a...))
+}
+
+// enter records that the current token opens a container, and reports false when that
+// exceeds MaxDepth.
+func (p *Parser) enter() bool {
+	p.depth++
+
+	if max := p.limits.MaxDepth; max > 0 && p.depth > max {
+		p.depth--
+		p.exceed(p.currentToken, "MaxDepth", max)
+
+		return false
+	}
+
+	return true
+}
+
+// leave records that the container opened by enter is done.
+func (p *Parser) leave() {
+	p.depth--
+}
+
+// checkString reports false when the string token tok exceeds MaxStringLength.
+func (p *Parser) checkString(tok Token) bool {
+	if max := p.limits.MaxStringLength; max > 0 && len(tok.Literal) > max {
+		p.exceed(tok, "MaxStringLength", max)
+		return false
+	}
+
+	return true
 }
 
-// Errors returns all parsing errors encountered by the parser.
+// checkCount reports false when the member or element starting at the current token is
+// the nth of its container and n exceeds max.
+func (p *Parser) checkCount(n, max int, limit string) bool {
+	if max > 0 && n > max {
+		p.exceed(p.currentToken, limit, max)
+		return false
+	}
+
+	return true
+}
+
+// exceed records a *LimitError at tok and stops parsing.
+func (p *Parser) exceed(tok Token, limit string, max int) {
+	if p.aborted {
+		return
+	}
+
+	p.errors = append(p.errors, *p.newLimitError(tok, limit, max))
+	p.aborted = true
+}
+
+// newLimitError creates a ParseError located at tok that wraps a *LimitError.
+func (p *Parser) newLimitError(tok Token, limit string, max int) *ParseError {
+	err := &LimitError{Limit: limit, Max: max}
+
+	pe := p.newError(tok, "%s", err.Error())
+	pe.Err = err
+
+	return pe
+}
+
+// firstError returns the first recorded error.
+func (p *Parser) firstError() *ParseError {
+	err := p.errors[0]
+	return &err
+}
+
+// Errors returns all parsing errors encountered by the parser as formatted messages.
 func (p *Parser) Errors() []string {
+	msgs := make([]string, len(p.errors))
+	for i := range p.errors {
+		msgs[i] = p.errors[i].Error()
+	}
+
+	return msgs
+}
+
+// ParseErrors returns all parsing errors encountered by the parser.
+func (p *Parser) ParseErrors() []ParseError {
 	return p.errors
 }
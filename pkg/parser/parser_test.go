@@ -1,10 +1,19 @@
 package parser_test
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/rafaelmgr12/jingo/pkg/parser"
 )
@@ -29,7 +38,7 @@ func TestParser(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		l := parser.NewLexer(tt.input)
+		l := parser.NewLexerString(tt.input)
 		p := parser.NewParser(l)
 
 		value, err := p.ParseJSON()
@@ -102,7 +111,7 @@ func TestLexerTokenization(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		l := parser.NewLexer(tt.input)
+		l := parser.NewLexerString(tt.input)
 		for _, expectedType := range tt.expected {
 			token := l.NextToken()
 			if token.Type != expectedType {
@@ -119,7 +128,7 @@ func TestParserErrors(t *testing.T) {
 	}{
 		{
 			input:       `{"key": value}`,
-			expectedErr: "expected string key",
+			expectedErr: "Offset 8: v",
 		},
 		{
 			input:       `{"key" value}`,
@@ -136,7 +145,7 @@ func TestParserErrors(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		l := parser.NewLexer(tt.input)
+		l := parser.NewLexerString(tt.input)
 		p := parser.NewParser(l)
 		_, err := p.ParseJSON()
 		errors := p.Errors()
@@ -164,7 +173,7 @@ func TestComplexJSON(t *testing.T) {
         "key4": true
     }`
 
-	l := parser.NewLexer(input)
+	l := parser.NewLexerString(input)
 	p := parser.NewParser(l)
 
 	value, err := p.ParseJSON()
@@ -244,7 +253,7 @@ func TestNumberParsing(t *testing.T) {
 
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("Case %d: %s", i, tt.input), func(t *testing.T) {
-			l := parser.NewLexer(tt.input)
+			l := parser.NewLexerString(tt.input)
 			p := parser.NewParser(l)
 			value, err := p.ParseJSON()
 
@@ -304,7 +313,7 @@ func FuzzParseJSON(f *testing.F) {
 	f.Add(`{"deeplyNested": {"level1": {"level2": {"level3": {"level4": "value"}}}}}`)
 
 	f.Fuzz(func(t *testing.T, input string) {
-		lexer := parser.NewLexer(input)
+		lexer := parser.NewLexerString(input)
 		parser := parser.NewParser(lexer)
 		parsed, err := parser.ParseJSON()
 
@@ -333,7 +342,7 @@ func BenchmarkParseJSON(b *testing.B) {
     }`
 
 	for i := 0; i < b.N; i++ {
-		lexer := parser.NewLexer(input)
+		lexer := parser.NewLexerString(input)
 		parser := parser.NewParser(lexer)
 
 		_, err := parser.ParseJSON()
@@ -343,6 +352,30 @@ func BenchmarkParseJSON(b *testing.B) {
 	}
 }
 
+func BenchmarkParseJSONArena(b *testing.B) {
+	input := `{
+        "key1": "value1",
+        "key2": 123,
+        "key3": [1, 2, 3],
+        "key4": {"nestedKey": "nestedValue"},
+        "key5": true,
+        "key6": null
+    }`
+	arena := parser.NewArena()
+	p := parser.NewArenaParser(parser.NewLexerString(input), arena)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		arena.Release()
+		p.Reset(parser.NewLexerString(input))
+
+		if _, err := p.ParseJSON(); err != nil {
+			b.Fatalf("Error parsing JSON: %v", err)
+		}
+	}
+}
+
 func TestStreamingJSON(t *testing.T) {
 	input := `{
 		"key1": "value1",
@@ -358,7 +391,7 @@ func TestStreamingJSON(t *testing.T) {
 
 	reader := strings.NewReader(input)
 
-	lexer := parser.NewLexer(reader)
+	lexer := parser.NewLexerSize(reader, parser.DefaultBufferSize)
 	p := parser.NewParser(lexer)
 
 	value, err := p.ParseJSON()
@@ -423,7 +456,7 @@ func TestUtf8Parsing(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		l := parser.NewLexer(tt.input)
+		l := parser.NewLexerString(tt.input)
 		p := parser.NewParser(l)
 
 		value, err := p.ParseJSON()
@@ -441,7 +474,8 @@ func TestUtf8Parsing(t *testing.T) {
 			t.Fatalf("Test %d: key '%s' does not exist in the parsed object", i, tt.key)
 		}
 
-		if val.String() != tt.expected {
+		str, ok := val.(*parser.StringLiteral)
+		if !ok || str.Value != tt.expected {
 			t.Fatalf("Test %d: expected value %s, got %s", i, tt.expected, val.String())
 		}
 	}
@@ -502,7 +536,7 @@ func TestLexerWithByteInput(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		l := parser.NewLexer(tt.input)
+		l := parser.NewLexerBytes(tt.input)
 		for _, expectedType := range tt.expected {
 			token := l.NextToken()
 			if token.Type != expectedType {
@@ -554,3 +588,1820 @@ func hasMatchingError(errors []string, expectedErr string) bool {
 
 	return false
 }
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		illegal  bool
+	}{
+		{input: `"a\"b\\c\/d"`, expected: `a"b\c/d`},
+		{input: `"\b\f\n\r\t"`, expected: "\b\f\n\r\t"},
+		{input: `"\u00e9\u4E16"`, expected: "é世"},
+		{input: `"\ud83d\ude80"`, expected: "🚀"},
+		{input: `"\ud83d!"`, expected: "�!"},
+		{input: `"\udc00\udc00x"`, expected: "��x"},
+		{input: `"\ud800\u0041"`, expected: "�A"},
+		{input: `"\ud800\ud83d\ude80"`, expected: "�🚀"},
+		{input: `"\x"`, illegal: true},
+		{input: `"\u12g4"`, illegal: true},
+	}
+
+	for i, tt := range tests {
+		token := parser.NewLexerString(tt.input).NextToken()
+
+		if tt.illegal {
+			if token.Type != parser.TokenIllegal {
+				t.Errorf("Test %d: expected ILLEGAL token, got %q", i, token.Type)
+			}
+
+			continue
+		}
+
+		if token.Type != parser.TokenString || token.Literal != tt.expected {
+			t.Errorf("Test %d: expected string %q, got %s %q", i, tt.expected, token.Type, token.Literal)
+		}
+	}
+}
+
+func TestNewLexerConstructors(t *testing.T) {
+	input := `{"a": [1, "x"]}`
+
+	lexers := map[string]*parser.Lexer{
+		"String": parser.NewLexerString(input),
+		"Bytes":  parser.NewLexerBytes([]byte(input)),
+		"Reader": parser.NewLexerSize(iotest.HalfReader(strings.NewReader(input)), 4),
+		// A bufio.Reader at least as large as the chunk size is read directly
+		"Bufio": parser.NewLexerSize(bufio.NewReaderSize(strings.NewReader(input), 64), 16),
+		//nolint:staticcheck // the deprecated constructor must keep working
+		"Deprecated": parser.NewLexer(strings.NewReader(input)),
+	}
+
+	for name, l := range lexers {
+		value, err := parser.NewParser(l).ParseJSON()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		want := map[string]interface{}{"a": []interface{}{int64(1), "x"}}
+		if got := value.ToInterface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	var nilReader *strings.Reader
+
+	for _, input := range []interface{}{42, nil, nilReader} {
+		if _, err := parser.NewLexerInput(input); !errors.Is(err, parser.ErrInvalidInput) {
+			t.Errorf("%T: expected ErrInvalidInput, got %v", input, err)
+		}
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, parser.ErrInvalidInput) || !strings.Contains(err.Error(), "got int") {
+			t.Errorf("Expected a panic naming the input type, got %v", err)
+		}
+	}()
+
+	parser.NewLexer(42) //nolint:staticcheck // the deprecated constructor rejects other types
+}
+
+func TestLexerCheckpoint(t *testing.T) {
+	lexers := []*parser.Lexer{
+		parser.NewLexerString(`{"a": [1, true], "b": "x"}`),
+		parser.NewLexerSize(strings.NewReader(`{"a": [1, true], "b": "x"}`), parser.DefaultBufferSize),
+	}
+
+	for i, l := range lexers {
+		l.NextToken()
+		l.NextToken()
+
+		cp := l.Checkpoint()
+
+		var first []parser.Token
+		for tok := l.NextToken(); tok.Type != parser.TokenEOF; tok = l.NextToken() {
+			first = append(first, tok)
+		}
+
+		if err := l.Rewind(cp); err != nil {
+			t.Fatalf("Test %d: unexpected rewind error: %v", i, err)
+		}
+
+		for j, expected := range first {
+			tok := l.NextToken()
+			if tok != expected {
+				t.Fatalf("Test %d: token %d after rewind: expected %+v, got %+v", i, j, expected, tok)
+			}
+		}
+
+		if tok := l.NextToken(); tok.Type != parser.TokenEOF {
+			t.Fatalf("Test %d: expected EOF after replay, got %s", i, tok.Type)
+		}
+	}
+}
+
+func TestLexerTokens(t *testing.T) {
+	input := `{"a": [1, true, null], "b": "x"} @ ` + strings.Repeat("0 ", 1000)
+
+	var want []parser.Token
+
+	l := parser.NewLexerString(input)
+	for tok := l.NextToken(); ; tok = l.NextToken() {
+		want = append(want, tok)
+		if tok.Type == parser.TokenEOF {
+			break
+		}
+	}
+
+	var got []parser.Token
+	for tok := range parser.NewLexerSize(strings.NewReader(input), 16).Tokens(context.Background()) {
+		got = append(got, tok)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the tokens of NextToken, got %d tokens instead of %d", len(got), len(want))
+	}
+
+	// Canceling stops the lexer before the end of the input
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens := parser.NewLexerString(input).Tokens(ctx)
+
+	<-tokens
+	cancel()
+
+	n := 1
+	for tok := range tokens {
+		if n++; tok.Type == parser.TokenEOF {
+			t.Fatal("Expected no TokenEOF after cancel")
+		}
+	}
+
+	if n >= len(want) {
+		t.Errorf("Expected the lexer to stop early, got %d tokens", n)
+	}
+
+	// Input that is not UTF-8 yields a single illegal token
+	got = nil
+	for tok := range parser.NewLexerString("\xFF\xFE{\x00}\x00").Tokens(context.Background()) {
+		got = append(got, tok)
+	}
+
+	if len(got) != 1 || got[0].Type != parser.TokenIllegal {
+		t.Errorf("Expected one illegal token, got %v", got)
+	}
+}
+
+func TestPipelinedParser(t *testing.T) {
+	var b strings.Builder
+
+	b.WriteString(`{"items": [`)
+
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		fmt.Fprintf(&b, `{"id": %d, "name": "item-%d", "tags": [true, null, 1.5]}`, i, i)
+	}
+
+	b.WriteString(`]} {"next": 1}`)
+	input := b.String()
+
+	want, err := parser.NewParser(parser.NewLexerString(input)).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The lexer runs ahead on its own goroutine while the parser builds the tree
+	p := parser.NewPipelinedParser(context.Background(), parser.NewLexerSize(strings.NewReader(input), 64))
+
+	got, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !parser.Equal(got, want) {
+		t.Error("Expected the same tree as a synchronous parse")
+	}
+
+	next, err := p.ParseNext()
+	if err != nil || next.(*parser.Object).Pairs["next"] == nil {
+		t.Fatalf("Expected the second value, got %v (%v)", next, err)
+	}
+
+	if _, err := p.ParseNext(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	// Syntax errors are located as usual
+	p = parser.NewPipelinedParser(context.Background(), parser.NewLexerString(`{"a": [1, }`))
+
+	var parseErr *parser.ParseError
+	if _, err := p.ParseJSON(); !errors.As(err, &parseErr) || parseErr.Column != 11 {
+		t.Errorf("Expected a syntax error at column 11, got %v", err)
+	}
+
+	// Canceling the context stops the parse
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p = parser.NewPipelinedParser(ctx, parser.NewLexerString(input))
+	if _, err := p.ParseJSON(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParserCheckpoint(t *testing.T) {
+	p := parser.NewParser(parser.NewLexerString(`{"key": [1, 2, 3]}`))
+	cp := p.Checkpoint()
+
+	first, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := p.Rewind(cp); err != nil {
+		t.Fatalf("Unexpected rewind error: %v", err)
+	}
+
+	second, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error after rewind: %v", err)
+	}
+
+	if len(second.(*parser.Object).Pairs["key"].(*parser.Array).Elements) != 3 ||
+		len(first.(*parser.Object).Pairs) != len(second.(*parser.Object).Pairs) {
+		t.Fatalf("Expected identical parse after rewind, got %v and %v", first, second)
+	}
+}
+
+func TestBloomKeySet(t *testing.T) {
+	// A filter this small is saturated, so every new key is a filter hit that must be
+	// confirmed before it is reported
+	set := parser.NewBloomKeySet(64)
+
+	for i := 0; i < 1000; i++ {
+		if set.Add(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Distinct key-%d reported as a duplicate", i)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !set.Add(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Bloom filter missed duplicate key-%d", i)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	input := `{"name": "a/b", "items": [1, {"ok": true}], "empty": {}, "none": null}`
+
+	var got []string
+
+	err := parser.NewParser(parser.NewLexerBytes([]byte(input))).Walk(func(e parser.Event) error {
+		got = append(got, fmt.Sprintf("%s %d %q %s %s", e.Kind, e.Depth, e.Key, e.Pointer(), e.Token.Literal))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`ObjectStart 0 ""  {`,
+		`Value 1 "name" /name a/b`,
+		`ArrayStart 1 "items" /items [`,
+		`Value 2 "" /items/0 1`,
+		`ObjectStart 2 "" /items/1 {`,
+		`Value 3 "ok" /items/1/ok true`,
+		`ObjectEnd 2 "" /items/1 }`,
+		`ArrayEnd 1 "items" /items ]`,
+		`ObjectStart 1 "empty" /empty {`,
+		`ObjectEnd 1 "empty" /empty }`,
+		`Value 1 "none" /none null`,
+		`ObjectEnd 0 ""  }`,
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %v", len(expected), len(got), got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestWalkEventValue(t *testing.T) {
+	var values []parser.Value
+
+	err := parser.NewParser(parser.NewLexerBytes([]byte(`["s", 1.5, false, null, []]`))).Walk(func(e parser.Event) error {
+		if v := e.Value(); v != nil {
+			values = append(values, v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []parser.Value{
+		&parser.StringLiteral{Value: "s"},
+		&parser.NumberLiteral{Value: "1.5", Float: 1.5, IsValid: true},
+		&parser.Boolean{Value: false},
+		&parser.Null{},
+	}
+
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d values, got %d", len(expected), len(values))
+	}
+
+	for i := range expected {
+		if !parser.Equal(values[i], expected[i]) {
+			t.Errorf("Value %d: expected %v, got %v", i, expected[i], values[i])
+		}
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	stop := fmt.Errorf("stop")
+	calls := 0
+
+	err := parser.NewParser(parser.NewLexerBytes([]byte(`[1, 2, 3]`))).Walk(func(e parser.Event) error {
+		calls++
+		if e.Kind == parser.EventValue {
+			return stop
+		}
+
+		return nil
+	})
+	if err != stop || calls != 2 {
+		t.Errorf("Expected handler error after 2 calls, got %v after %d", err, calls)
+	}
+
+	tests := []struct {
+		input  string
+		errMsg string
+	}{
+		{input: `"scalar"`, errMsg: "expected { or ["},
+		{input: `{"a": 1,}`, errMsg: "unexpected token ,"},
+		{input: `{"a" 1}`, errMsg: "expected :"},
+		{input: `[1, "x"`, errMsg: "expected ], got EOF"},
+		{input: `{"a": 1, "a": 2}`, errMsg: `duplicate key "a"`},
+	}
+
+	for _, tt := range tests {
+		p := parser.NewParser(parser.NewLexerBytes([]byte(tt.input)), parser.WithDuplicateKeyCheck(parser.NewExactKeySet))
+
+		err := p.Walk(func(parser.Event) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("Input %s: expected error containing %q, got %v", tt.input, tt.errMsg, err)
+		}
+	}
+}
+
+func TestLenientSyntax(t *testing.T) {
+	jsonc := `// settings
+{
+	/* editor */ "tabSize": 4, // spaces
+	"exclude": ["a", "b",],
+	"url": "http://example.com/*x*/", /* "skipped": true, */
+}
+`
+
+	tests := []struct {
+		name   string
+		input  string
+		opts   []parser.Option
+		errMsg string
+	}{
+		{name: "JSONC", input: jsonc, opts: []parser.Option{parser.WithAllowComments(), parser.WithAllowTrailingCommas()}},
+		{name: "Comments rejected by default", input: `{"a": 1 /* c */}`, errMsg: "expected }"},
+		{name: "Trailing object comma rejected", input: `{"a": 1,}`, opts: []parser.Option{parser.WithAllowComments()}, errMsg: "unexpected token ,"},
+		{name: "Trailing array comma rejected", input: `[1,]`, opts: []parser.Option{parser.WithAllowComments()}, errMsg: "unexpected token ]"},
+		{name: "Only one trailing comma", input: `[1,,]`, opts: []parser.Option{parser.WithAllowTrailingCommas()}, errMsg: "unexpected token ,"},
+		{name: "Unterminated comment", input: `{"a": 1 /* c`, opts: []parser.Option{parser.WithAllowComments()}, errMsg: "expected }, got ILLEGAL"},
+		{name: "Lone slash", input: `{"a": /1}`, opts: []parser.Option{parser.WithAllowComments()}, errMsg: "Offset 6: /"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := parser.NewParser(parser.NewLexerString(tt.input), tt.opts...).ParseJSON()
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("Expected error containing %q, got %v", tt.errMsg, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			obj := value.(*parser.Object)
+			if len(obj.Pairs) != 3 {
+				t.Fatalf("Expected 3 members, got %d", len(obj.Pairs))
+			}
+
+			if url := obj.Pairs["url"].(*parser.StringLiteral).Value; url != "http://example.com/*x*/" {
+				t.Errorf("Comment markers inside strings must be kept, got %q", url)
+			}
+
+			if n := len(obj.Pairs["exclude"].(*parser.Array).Elements); n != 2 {
+				t.Errorf("Expected 2 elements, got %d", n)
+			}
+		})
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	input := `{"a": "}{"}[1,[2]] "s\"x" 12,-3.5e1 true
+null,{"b": {"c": []}}`
+
+	s := parser.NewSplitter(strings.NewReader(input))
+
+	var values []string
+
+	var offsets []int64
+
+	for s.Next() {
+		values = append(values, string(s.Bytes()))
+		offsets = append(offsets, s.Offset())
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{`{"a": "}{"}`, `[1,[2]]`, `"s\"x"`, `12`, `-3.5e1`, `true`, `null`, `{"b": {"c": []}}`}
+	expectedOffsets := []int64{0, 11, 19, 26, 29, 36, 41, 46}
+
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d values, got %d: %q", len(expected), len(values), values)
+	}
+
+	for i := range expected {
+		if values[i] != expected[i] || offsets[i] != expectedOffsets[i] {
+			t.Errorf("Value %d: expected %s at %d, got %s at %d", i, expected[i], expectedOffsets[i], values[i], offsets[i])
+		}
+	}
+}
+
+func TestSplitterErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		values int
+		errMsg string
+	}{
+		{input: ``, values: 0},
+		{input: " \n, ", values: 0},
+		{input: `{"a": 1} {"b": `, values: 1, errMsg: "value at offset 9: unexpected EOF"},
+		{input: `[1}`, values: 0, errMsg: `expected ']', got '}'`},
+		{input: `1 }`, values: 1, errMsg: `invalid character '}'`},
+	}
+
+	for _, tt := range tests {
+		s := parser.NewSplitter(strings.NewReader(tt.input))
+
+		n := 0
+		for s.Next() {
+			n++
+		}
+
+		if n != tt.values {
+			t.Errorf("Input %q: expected %d values, got %d", tt.input, tt.values, n)
+		}
+
+		err := s.Err()
+		if tt.errMsg == "" {
+			if err != nil {
+				t.Errorf("Input %q: unexpected error: %v", tt.input, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("Input %q: expected error containing %q, got %v", tt.input, tt.errMsg, err)
+		}
+	}
+}
+
+func TestToInterface(t *testing.T) {
+	l := parser.NewLexerBytes([]byte(`{"name": "x", "n": 1, "f": 1.5, "ok": true, "nil": null, "list": [1, "a", {"b": false}]}`))
+
+	v, err := parser.NewParser(l).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name": "x",
+		"n":    int64(1),
+		"f":    1.5,
+		"ok":   true,
+		"nil":  nil,
+		"list": []interface{}{int64(1), "a", map[string]interface{}{"b": false}},
+	}
+
+	if got := v.ToInterface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %#v, got %#v", want, got)
+	}
+
+	obj := v.(*parser.Object)
+	if m := obj.ToMap(); m["name"] != "x" {
+		t.Errorf("Unexpected map: %#v", m)
+	}
+
+	if s := obj.Pairs["list"].(*parser.Array).ToSlice(); len(s) != 3 || s[1] != "a" {
+		t.Errorf("Unexpected slice: %#v", s)
+	}
+}
+
+func TestObjectKeyOrder(t *testing.T) {
+	l := parser.NewLexerBytes([]byte(`{"z": 1, "a": 2, "m": 3}`))
+
+	v, err := parser.NewParser(l).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := v.(*parser.Object)
+	obj.Set("b", parser.NewString("x"))
+	obj.Set("z", parser.NewNumber(9))
+	obj.Delete("a")
+
+	want := []string{"z", "m", "b"}
+	if got := obj.OrderedKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected keys %v, got %v", want, got)
+	}
+
+	if got := obj.String(); got != `{"z":9,"m":3,"b":"x"}` {
+		t.Errorf("Unexpected string: %s", got)
+	}
+
+	// Keys of objects built without Set follow in lexicographic order
+	manual := &parser.Object{Pairs: map[string]parser.Value{"b": parser.NewNull(), "a": parser.NewNull()}}
+	manual.Set("c", parser.NewNull())
+
+	if got := manual.OrderedKeys(); !reflect.DeepEqual(got, []string{"c", "a", "b"}) {
+		t.Errorf("Unexpected keys: %v", got)
+	}
+}
+
+func TestFromInterface(t *testing.T) {
+	in := map[string]interface{}{
+		"name":   "x",
+		"count":  3,
+		"ratio":  0.5,
+		"small":  float32(0.1),
+		"size":   uint16(7),
+		"ok":     true,
+		"none":   nil,
+		"tags":   []string{"a", "b"},
+		"nested": map[string]int{"b": 2, "a": 1},
+	}
+
+	v, err := parser.FromInterface(in)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name":   "x",
+		"count":  int64(3),
+		"ratio":  0.5,
+		"small":  0.1,
+		"size":   int64(7),
+		"ok":     true,
+		"none":   nil,
+		"tags":   []interface{}{"a", "b"},
+		"nested": map[string]interface{}{"a": int64(1), "b": int64(2)},
+	}
+
+	if got := v.ToInterface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %#v, got %#v", want, got)
+	}
+
+	obj := v.(*parser.Object)
+	if keys := obj.Pairs["nested"].(*parser.Object).OrderedKeys(); !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("Expected map keys in lexicographic order, got %v", keys)
+	}
+
+	errorCases := []interface{}{
+		math.NaN(),
+		struct{ A int }{1},
+		map[int]string{1: "a"},
+		[]interface{}{make(chan int)},
+		uint64(math.MaxUint64),
+	}
+
+	for _, c := range errorCases {
+		if _, err := parser.FromInterface(c); err == nil {
+			t.Errorf("Expected error for %T", c)
+		}
+	}
+}
+
+func TestSkipValue(t *testing.T) {
+	p := parser.NewParser(parser.NewLexerBytes([]byte(`{"a": [1, {"b": "]"}]} "x" [3] {"c": 4}`)))
+
+	for i := 0; i < 2; i++ {
+		if err := p.SkipValue(); err != nil {
+			t.Fatalf("Skip %d: unexpected error: %v", i, err)
+		}
+	}
+
+	v, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if arr, ok := v.(*parser.Array); !ok || len(arr.Elements) != 1 {
+		t.Errorf("Expected [3] after skipped values, got %v", v)
+	}
+
+	if err := p.SkipValue(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if p.More() {
+		t.Error("Expected no more values")
+	}
+
+	errorCases := []string{`{"a": ]`, `{"a": [1, "x"`, `}`, `, 1`, `{"a": @}`}
+
+	for _, input := range errorCases {
+		if err := parser.NewParser(parser.NewLexerBytes([]byte(input))).SkipValue(); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestTokenOffset(t *testing.T) {
+	input := "{\"é\": [1,\n  true], \"b\": null}"
+	want := []int64{0, 1, 5, 7, 8, 9, 13, 17, 18, 20, 23, 25, 29, 30}
+
+	lexers := map[string]*parser.Lexer{
+		"String": parser.NewLexerString(input),
+		"Reader": parser.NewLexerSize(strings.NewReader(input), parser.DefaultBufferSize),
+	}
+
+	for name, l := range lexers {
+		for i, offset := range want {
+			tok := l.NextToken()
+			if tok.Offset != offset {
+				t.Errorf("%s: token %d (%s): expected offset %d, got %d", name, i, tok.Literal, offset, tok.Offset)
+			}
+		}
+	}
+
+	p := parser.NewParser(parser.NewLexerBytes([]byte("{\"a\": 1,\n \"b\" 2}")))
+	if _, err := p.ParseJSON(); err == nil || !strings.Contains(err.Error(), "Offset 14") {
+		t.Errorf("Expected error at offset 14, got %v", err)
+	}
+
+	if p.Offset() != 14 {
+		t.Errorf("Expected parser offset 14, got %d", p.Offset())
+	}
+}
+
+func TestParseError(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\" 2\n}"
+	p := parser.NewParser(parser.NewLexerString(input))
+
+	_, err := p.ParseJSON()
+
+	var perr *parser.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a *ParseError, got %T: %v", err, err)
+	}
+
+	want := parser.ParseError{Offset: 18, Line: 3, Column: 7, Msg: "expected :, got NUMBER", Snippet: `  "b" 2`}
+	if *perr != want {
+		t.Errorf("Expected %+v, got %+v", want, *perr)
+	}
+
+	if errs := p.ParseErrors(); len(errs) != 1 || errs[0] != want {
+		t.Errorf("Unexpected ParseErrors: %+v", errs)
+	}
+
+	if msgs := p.Errors(); len(msgs) != 1 || msgs[0] != err.Error() {
+		t.Errorf("Expected Errors to format ParseErrors, got %v", msgs)
+	}
+
+	long := `{"key": "` + strings.Repeat("a", 100) + `" "` + strings.Repeat("b", 100) + `"}`
+
+	_, err = parser.NewParser(parser.NewLexerString(long)).ParseJSON()
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a *ParseError, got %T: %v", err, err)
+	}
+
+	if snippet := long[perr.Offset-40 : perr.Offset+40]; perr.Snippet != snippet {
+		t.Errorf("Expected snippet %q, got %q", snippet, perr.Snippet)
+	}
+
+	_, err = parser.NewParser(parser.NewLexerString(`{"a": ` + strings.Repeat(" ", 100) + `]}`)).ParseJSON()
+	if !errors.As(err, &perr) || perr.Snippet != strings.Repeat(" ", 40)+"]}" {
+		t.Errorf("Expected the snippet to start 40 bytes before the error, got %q", perr.Snippet)
+	}
+
+	_, err = parser.NewParser(parser.NewLexerString(`"scalar"`)).ParseJSON()
+	if !errors.As(err, &perr) || perr.Offset != 0 || perr.Snippet != `"scalar"` {
+		t.Errorf("Expected a *ParseError for a top-level scalar, got %v", err)
+	}
+}
+
+func TestParseErrorLexerMessages(t *testing.T) {
+	tests := []struct {
+		input  string
+		msg    string
+		offset int64
+	}{
+		{input: `{"a": @}`, msg: "@", offset: 6},
+		{input: `{"a": tru}`, msg: "Invalid token", offset: 6},
+		{input: `{"a": 01}`, msg: "Invalid number format: leading zeros not allowed", offset: 6},
+		{input: `{"a": "\x"}`, msg: "Invalid escape sequence", offset: 6},
+		{input: `["abc`, msg: "Unterminated string", offset: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parser.NewParser(parser.NewLexerString(tt.input)).ParseJSON()
+
+			var perr *parser.ParseError
+			if !errors.As(err, &perr) || perr.Msg != tt.msg || perr.Offset != tt.offset {
+				t.Errorf("Expected %q at offset %d, got %v", tt.msg, tt.offset, err)
+			}
+
+			err = parser.NewParser(parser.NewLexerString(tt.input)).Validate()
+			if !errors.As(err, &perr) || perr.Msg != tt.msg || perr.Offset != tt.offset {
+				t.Errorf("Validate: expected %q at offset %d, got %v", tt.msg, tt.offset, err)
+			}
+		})
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Missing values",
+			input: `{"a": , "b": 1, "c": }`,
+			want:  []string{"Offset 6: unexpected token ,", "Offset 21: unexpected token }"},
+		},
+		{
+			name:  "Errors in nested containers",
+			input: `{"a": [1, @, 3], "b": {"x" 1}, "c": [}`,
+			want: []string{
+				"Offset 10: @", "Offset 27: expected :, got NUMBER", "Offset 37: unexpected token }",
+				"Offset 37: expected ], got }", "Offset 38: expected }, got EOF",
+			},
+		},
+		{
+			name:  "Missing member comma",
+			input: `{"a":1 "b":2}`,
+			want:  []string{"Line 1, Column 8, Offset 7: expected }, got STRING"},
+		},
+		{
+			name:  "Missing comma",
+			input: `[1 2, 3 4]`,
+			want:  []string{"Offset 3: expected ], got NUMBER", "Offset 8: expected ], got NUMBER"},
+		},
+		{
+			name:  "Duplicate key and trailing comma",
+			input: `{"a": 1, "a": 2, "b": [1,],}`,
+			want:  []string{`Offset 14: duplicate key "a"`, "Offset 25: unexpected token ]", "Offset 26: unexpected token ,"},
+		},
+		{
+			name:  "Mismatched closer",
+			input: `[{"a": 1], 2]`,
+			want:  []string{"Offset 8: expected }, got ]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.NewParser(parser.NewLexerString(tt.input),
+				parser.WithErrorRecovery(), parser.WithDuplicateKeyCheck(parser.NewExactKeySet))
+
+			_, err := p.ParseJSON()
+
+			var list parser.ErrorList
+			if !errors.As(err, &list) {
+				t.Fatalf("Expected an ErrorList, got %T: %v", err, err)
+			}
+
+			if len(list) != len(tt.want) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.want), len(list), p.Errors())
+			}
+
+			for i, want := range tt.want {
+				if !strings.Contains(list[i].Error(), want) {
+					t.Errorf("Error %d: expected %q, got %q", i, want, list[i].Error())
+				}
+			}
+
+			var perr *parser.ParseError
+			if !errors.As(err, &perr) || perr != list[0] {
+				t.Errorf("Expected ErrorList to unwrap to its first ParseError")
+			}
+		})
+	}
+
+	p := parser.NewParser(parser.NewLexerString(`{"a": [1, 2]}`), parser.WithErrorRecovery())
+	if _, err := p.ParseJSON(); err != nil {
+		t.Errorf("Unexpected error for valid input: %v", err)
+	}
+}
+
+func TestInvalidUTF8Policy(t *testing.T) {
+	input := "{\"a\": \"x\xffyé\"}"
+
+	tests := []struct {
+		policy   parser.InvalidUTF8Policy
+		expected string
+		illegal  bool
+	}{
+		{policy: parser.InvalidUTF8Replace, expected: "x�yé"},
+		{policy: parser.InvalidUTF8Pass, expected: "x\xffyé"},
+		{policy: parser.InvalidUTF8Error, illegal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			p := parser.NewParser(parser.NewLexerString(input), parser.WithInvalidUTF8Policy(tt.policy))
+
+			value, err := p.ParseJSON()
+			if tt.illegal {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", value)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := value.(*parser.Object).Pairs["a"].(*parser.StringLiteral).Value; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// A valid U+FFFD in the input is not mistaken for an invalid byte
+	p := parser.NewParser(parser.NewLexerString("{\"a\": \"�\"}"), parser.WithInvalidUTF8Policy(parser.InvalidUTF8Error))
+	if _, err := p.ParseJSON(); err != nil {
+		t.Errorf("Unexpected error for U+FFFD: %v", err)
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		encoding parser.Encoding
+		bom      int
+	}{
+		{input: `{}`, encoding: parser.EncodingUTF8},
+		{input: "\xEF\xBB\xBF{}", encoding: parser.EncodingUTF8, bom: 3},
+		{input: "\xFF\xFE{\x00}\x00", encoding: parser.EncodingUTF16LE, bom: 2},
+		{input: "\xFE\xFF\x00{\x00}", encoding: parser.EncodingUTF16BE, bom: 2},
+		{input: "\xFF\xFE\x00\x00{\x00\x00\x00", encoding: parser.EncodingUTF32LE, bom: 4},
+		{input: "\x00\x00\xFE\xFF\x00\x00\x00{", encoding: parser.EncodingUTF32BE, bom: 4},
+		{input: "{\x00}\x00", encoding: parser.EncodingUTF16LE},
+		{input: "\x00{\x00}", encoding: parser.EncodingUTF16BE},
+		{input: "{\x00\x00\x00", encoding: parser.EncodingUTF32LE},
+		{input: "\x00\x00\x00{", encoding: parser.EncodingUTF32BE},
+	}
+
+	for _, tt := range tests {
+		enc, bom := parser.DetectEncoding([]byte(tt.input))
+		if enc != tt.encoding || bom != tt.bom {
+			t.Errorf("%q: expected %v with BOM length %d, got %v with %d", tt.input, tt.encoding, tt.bom, enc, bom)
+		}
+	}
+}
+
+func TestTranscodingReader(t *testing.T) {
+	utf16le := func(s string) string {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+
+		return string(b)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "UTF-8 with BOM", input: "\xEF\xBB\xBF{\"a\": \"é\"}", expected: `{"a": "é"}`},
+		{name: "UTF-16LE with BOM", input: "\xFF\xFE" + utf16le(`{"a": "é🚀"}`), expected: `{"a": "é🚀"}`},
+		{name: "UTF-16LE", input: utf16le(`["x"]`), expected: `["x"]`},
+		{name: "UTF-16BE", input: "\x00[\x00\"\x00x\x00\"\x00]", expected: `["x"]`},
+		{name: "UTF-32BE with BOM", input: "\x00\x00\xFE\xFF\x00\x00\x00[\x00\x01\xF6\x80\x00\x00\x00]", expected: "[🚀]"},
+		{name: "Lone surrogate", input: utf16le("[") + "\x3D\xD8" + utf16le("]"), expected: "[�]"},
+		{name: "Truncated unit", input: utf16le("[]") + "\x00", expected: "[]�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(parser.NewTranscodingReader(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if string(got) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLexerByteOrderMark(t *testing.T) {
+	input := "\xEF\xBB\xBF{\"a\": 1}"
+	lexers := map[string]*parser.Lexer{
+		"String": parser.NewLexerString(input),
+		"Reader": parser.NewLexerSize(strings.NewReader(input), parser.DefaultBufferSize),
+	}
+
+	for name, l := range lexers {
+		if _, err := parser.NewParser(l).ParseJSON(); err != nil {
+			t.Errorf("Unexpected error with BOM in %s: %v", name, err)
+		}
+	}
+
+	_, err := parser.NewParser(parser.NewLexerString("\xFF\xFE{\x00}\x00")).ParseJSON()
+	if err == nil || !strings.Contains(err.Error(), "UTF-16LE input must be transcoded to UTF-8") {
+		t.Errorf("Expected a transcoding error, got %v", err)
+	}
+}
+
+func TestLexerMultiByteCharacters(t *testing.T) {
+	input := `{"ключ": "日本語 🚀", "b": x}`
+
+	// One byte per read splits every multi-byte character between chunks
+	for _, src := range []interface{}{input, iotest.OneByteReader(strings.NewReader(input))} {
+		p := parser.NewParser(lexerFor(src))
+
+		_, err := p.ParseJSON()
+
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%T: expected a ParseError, got %v", src, err)
+		}
+
+		if parseErr.Line != 1 || parseErr.Column != 24 || parseErr.Offset != 36 {
+			t.Errorf("%T: expected error at column 24, offset 36, got %+v", src, parseErr)
+		}
+	}
+
+	l := parser.NewLexerSize(iotest.OneByteReader(strings.NewReader(`"日本語 🚀"`)), parser.DefaultBufferSize)
+	if tok := l.NextToken(); tok.Type != parser.TokenString || tok.Literal != "日本語 🚀" {
+		t.Errorf("Expected string token, got %s %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestStreamingChunkBoundaries(t *testing.T) {
+	values := []struct {
+		literal string
+		typ     parser.TokenType
+		value   string
+	}{
+		{literal: `-12345.678e+10`, typ: parser.TokenNumber, value: `-12345.678e+10`},
+		{literal: `"a long string value"`, typ: parser.TokenString, value: `a long string value`},
+		{literal: `true`, typ: parser.TokenTrue, value: `true`},
+		{literal: `false`, typ: parser.TokenFalse, value: `false`},
+		{literal: `null`, typ: parser.TokenNull, value: `null`},
+	}
+
+	for _, v := range values {
+		// Place the value so that each of its bytes in turn ends the first 4096-byte chunk
+		for shift := 1; shift < len(v.literal); shift++ {
+			input := strings.Repeat(" ", 4096-shift) + v.literal + " "
+
+			l := parser.NewLexerSize(strings.NewReader(input), parser.DefaultBufferSize)
+
+			tok := l.NextToken()
+			if tok.Type != v.typ || tok.Literal != v.value || tok.Offset != int64(4096-shift) {
+				t.Errorf("%s split after %d bytes: got %s %q at offset %d", v.literal, len(v.literal)-shift, tok.Type, tok.Literal, tok.Offset)
+			}
+
+			if tok := l.NextToken(); tok.Type != parser.TokenEOF || tok.Offset != int64(len(input)) {
+				t.Errorf("%s split after %d bytes: expected EOF at %d, got %s at %d", v.literal, len(v.literal)-shift, len(input), tok.Type, tok.Offset)
+			}
+		}
+	}
+
+	input := `[` + strings.Repeat(" ", 5000) + `1234567890, "x"]`
+
+	value, err := parser.NewParser(parser.NewLexerSize(strings.NewReader(input), parser.DefaultBufferSize)).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, want := value.ToInterface(), []interface{}{int64(1234567890), "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestLexerValueAtEndOfInput(t *testing.T) {
+	for _, input := range []string{"123", "-0.5e3", "true", "false", "null"} {
+		for _, src := range []interface{}{input, strings.NewReader(input)} {
+			if tok := lexerFor(src).NextToken(); tok.Literal != input {
+				t.Errorf("%T: expected literal %q, got %q", src, input, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestParseArray(t *testing.T) {
+	p := parser.NewParser(parser.NewLexerString(`[1, {"a": [2]}, "x",] []`), parser.WithAllowTrailingCommas())
+
+	var got []interface{}
+
+	err := p.ParseArray(func(v parser.Value) error {
+		got = append(got, v.ToInterface())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []interface{}{int64(1), map[string]interface{}{"a": []interface{}{int64(2)}}, "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if err := p.ParseArray(func(parser.Value) error { return errors.New("unexpected element") }); err != nil {
+		t.Errorf("Unexpected error for empty array: %v", err)
+	}
+
+	if p.More() {
+		t.Error("Expected the parser to be at the end of the input")
+	}
+
+	p = parser.NewParser(parser.NewLexerString(`[1, 2 3]`))
+	if err := p.ParseArray(func(parser.Value) error { return nil }); err == nil || !strings.Contains(err.Error(), "expected ], got NUMBER") {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+}
+
+func TestSplitterEnterArray(t *testing.T) {
+	s := parser.NewSplitter(strings.NewReader(` [{"a": [1]}, "x,]", 3 ] {"after": true}`))
+	if err := s.EnterArray(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var values []string
+	for s.Next() {
+		values = append(values, string(s.Bytes()))
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if want := []string{`{"a": [1]}`, `"x,]"`, `3`}; !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %q, got %q", want, values)
+	}
+
+	s = parser.NewSplitter(strings.NewReader(`{"a": 1}`))
+	if err := s.EnterArray(); err == nil {
+		t.Error("Expected an error for an object")
+	}
+
+	s = parser.NewSplitter(strings.NewReader(`[1, 2`))
+	if err := s.EnterArray(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for s.Next() {
+	}
+
+	if err := s.Err(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected unexpected EOF for an unterminated array, got %v", err)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	s := parser.NewScanner(strings.NewReader("\x1e{\"a\": [1, 2], \"b,\": {}}\n[1, [2, 3], \"]\"] 4"))
+
+	if _, rs, err := s.SkipSeparators("\x1e"); err != nil || !rs {
+		t.Fatalf("Expected the record separator to be skipped, got %v, %v", rs, err)
+	}
+
+	tests := []struct {
+		children int
+		end      int64
+	}{
+		{children: 2, end: 24},
+		{children: 3, end: 41},
+		{children: 0, end: 43},
+	}
+
+	for i, tt := range tests {
+		if _, _, err := s.SkipSeparators(""); err != nil {
+			t.Fatalf("Value %d: unexpected error: %v", i, err)
+		}
+
+		children, err := s.ScanValue()
+		if err != nil {
+			t.Fatalf("Value %d: unexpected error: %v", i, err)
+		}
+
+		if children != tt.children || s.Offset() != tt.end {
+			t.Errorf("Value %d: expected %d children ending at %d, got %d ending at %d", i, tt.children, tt.end, children, s.Offset())
+		}
+	}
+
+	if _, _, err := s.SkipSeparators(""); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last value, got %v", err)
+	}
+
+	s = parser.NewScanner(strings.NewReader(`["abc", 1] [2]`))
+	s.SetLimit(12)
+
+	var buf bytes.Buffer
+
+	s.SetSink(&buf)
+
+	if _, err := s.ScanValue(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != `["abc", 1]` {
+		t.Errorf("Expected the sink to hold the value, got %q", buf.String())
+	}
+
+	if _, _, err := s.SkipSeparators(""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := s.ScanValue(); !errors.Is(err, parser.ErrScanLimit) {
+		t.Errorf("Expected ErrScanLimit, got %v", err)
+	}
+
+	if _, err := parser.NewScanner(strings.NewReader(`{"a": [1}`)).ScanValue(); err == nil {
+		t.Error("Expected an error for mismatched brackets")
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	l := parser.NewLexerSize(strings.NewReader(`{"a": 1, /* c */ "b": `), 8)
+	p := parser.NewParser(l, parser.WithAllowComments())
+
+	if _, err := p.ParseJSON(); err == nil {
+		t.Fatal("Expected an error for the truncated input")
+	}
+
+	l.Reset(strings.NewReader(`[1, /* c */ "x"]`))
+	p.Reset(nil)
+
+	value, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error after reset: %v", err)
+	}
+
+	want := []interface{}{int64(1), "x"}
+	if got := value.ToInterface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if p.More() {
+		t.Error("Expected no more values")
+	}
+}
+
+func TestParserResetLexer(t *testing.T) {
+	p := parser.NewParser(parser.NewLexerString(`{}`), parser.WithAllowComments())
+
+	// The options are applied to the new lexer
+	p.Reset(parser.NewLexerString(`/* c */ [true]`))
+
+	value, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error after reset: %v", err)
+	}
+
+	if got := value.ToInterface(); !reflect.DeepEqual(got, []interface{}{true}) {
+		t.Errorf("Expected [true], got %v", got)
+	}
+}
+
+func TestParserParseNext(t *testing.T) {
+	p := parser.NewParser(parser.NewLexerString(" {\"a\": 1}\n[2]\n{} \n"))
+
+	var got []interface{}
+
+	for {
+		value, err := p.ParseNext()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("ParseNext failed: %v", err)
+		}
+
+		got = append(got, value.ToInterface())
+	}
+
+	want := []interface{}{map[string]interface{}{"a": int64(1)}, []interface{}{int64(2)}, map[string]interface{}{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := p.ParseNext(); err != io.EOF {
+		t.Errorf("Expected io.EOF again, got %v", err)
+	}
+
+	p.Reset(parser.NewLexerString(`[1] [`))
+
+	if _, err := p.ParseNext(); err != nil {
+		t.Fatalf("ParseNext failed: %v", err)
+	}
+
+	if _, err := p.ParseNext(); err == nil || err == io.EOF {
+		t.Errorf("Expected a syntax error for the truncated value, got %v", err)
+	}
+}
+
+func TestArenaParser(t *testing.T) {
+	input := `{"name": "a", "n": -1.5, "ok": true, "no": false, "none": null, "list": [1, [], {}, "x"]}`
+
+	want, err := parser.NewParser(parser.NewLexerString(input)).ParseJSON()
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	arena := parser.NewArena()
+	p := parser.NewArenaParser(parser.NewLexerString(input), arena)
+
+	got, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("ParseJSON with an arena failed: %v", err)
+	}
+
+	if !parser.Equal(got, want) || got.String() != want.String() {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if arena.Len() != 11 {
+		t.Errorf("Expected 11 nodes in the arena, got %d", arena.Len())
+	}
+
+	// Released nodes are reused, and the maps and slices of containers are cleared
+	arena.Release()
+
+	if arena.Len() != 0 {
+		t.Errorf("Expected an empty arena after Release, got %d nodes", arena.Len())
+	}
+
+	p.Reset(parser.NewLexerString(`{"other": [2]}`))
+
+	again, err := p.ParseJSON()
+	if err != nil {
+		t.Fatalf("ParseJSON after Release failed: %v", err)
+	}
+
+	if again.String() != `{"other":[2]}` {
+		t.Errorf("Expected the second document, got %s", again)
+	}
+
+	if again != got {
+		t.Error("Expected the root object to be reused after Release")
+	}
+
+	// More nodes than fit in one slab
+	arena.Release()
+	p.Reset(parser.NewLexerString("[" + strings.Repeat("0, ", 999) + "0]"))
+
+	big, err := p.ParseJSON()
+	if err != nil || len(big.(*parser.Array).Elements) != 1000 || arena.Len() != 1001 {
+		t.Errorf("Expected 1000 elements, got %d nodes (%v)", arena.Len(), err)
+	}
+}
+
+func TestArenaAllocations(t *testing.T) {
+	input := `{"a": [1, 2, {"b": null}], "c": "d", "e": true}`
+	arena := parser.NewArena()
+	p := parser.NewArenaParser(parser.NewLexerString(input), arena)
+
+	parse := func(p *parser.Parser) float64 {
+		return testing.AllocsPerRun(10, func() {
+			arena.Release()
+			p.Reset(parser.NewLexerString(input))
+
+			if _, err := p.ParseJSON(); err != nil {
+				t.Fatalf("ParseJSON failed: %v", err)
+			}
+		})
+	}
+
+	withArena := parse(p)
+	without := parse(parser.NewParser(parser.NewLexerString(input)))
+
+	if withArena+10 > without {
+		t.Errorf("Expected the arena to save the node allocations, got %v allocations with it and %v without", withArena, without)
+	}
+}
+
+func TestLexerTokenAllocations(t *testing.T) {
+	lex := func(input string) float64 {
+		return testing.AllocsPerRun(10, func() {
+			l := parser.NewLexerString(input)
+			for l.NextToken().Type != parser.TokenEOF {
+			}
+		})
+	}
+
+	// Tokens other than strings do not allocate, so only the lexer setup is counted
+	setup := lex("0")
+	if allocs := lex("[" + strings.Repeat(`true, false, null, 1, -22.5e3, {"": [0]}, `, 100) + "0]"); allocs > setup+1 {
+		t.Errorf("Expected about %v allocations for 1,800 tokens, got %v", setup, allocs)
+	}
+}
+
+func TestLexerWordScanning(t *testing.T) {
+	specials := []string{`\"`, `\\`, `\n`, "é", "\x01", "\x7f"}
+	decoded := map[string]string{`\"`: `"`, `\\`: `\`, `\n`: "\n"}
+
+	for _, special := range specials {
+		for pos := 0; pos <= 20; pos++ {
+			content := strings.Repeat("a", pos) + special + strings.Repeat("b", 20-pos)
+			input := `"` + content + `"` + strings.Repeat(" ", pos) + `1`
+
+			l := parser.NewLexerString(input)
+
+			str := l.NextToken()
+			if str.Type != parser.TokenString {
+				t.Fatalf("%q: expected a string, got %v", input, str)
+			}
+
+			literal, escaped := decoded[special]
+			if !escaped {
+				literal = special
+			}
+
+			want := strings.Repeat("a", pos) + literal + strings.Repeat("b", 20-pos)
+
+			if str.Literal != want {
+				t.Errorf("%q: expected literal %q, got %q", input, want, str.Literal)
+			}
+
+			num := l.NextToken()
+			if wantColumn := utf8.RuneCountInString(input); num.Type != parser.TokenNumber || num.Column != wantColumn || num.Offset != int64(len(input)-1) {
+				t.Errorf("%q: expected number at column %d, offset %d, got %+v", input, wantColumn, len(input)-1, num)
+			}
+		}
+	}
+}
+
+func TestLexerIndentation(t *testing.T) {
+	input := "{\n" + strings.Repeat(" ", 17) + "\"a\": [\n\t\t 1,\r\n" + strings.Repeat(" ", 9) + "2]}"
+
+	want := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"{", 1, 1}, {"a", 2, 18}, {":", 2, 21}, {"[", 2, 23}, {"1", 3, 4}, {",", 3, 5}, {"2", 4, 10}, {"]", 4, 11}, {"}", 4, 12},
+	}
+
+	l := parser.NewLexerString(input)
+
+	for _, w := range want {
+		tok := l.NextToken()
+		if tok.Literal != w.literal || tok.Line != w.line || tok.Column != w.column {
+			t.Errorf("Expected %q at %d:%d, got %q at %d:%d", w.literal, w.line, w.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	var sb strings.Builder
+
+	sb.WriteString("[\n")
+
+	for i := 0; i < 200; i++ {
+		sb.WriteString(`        {"name": "a fairly long string value for the benchmark", "id": 12345, "ok": true},` + "\n")
+	}
+
+	sb.WriteString("        null\n]")
+
+	input := sb.String()
+
+	b.SetBytes(int64(len(input)))
+
+	for i := 0; i < b.N; i++ {
+		l := parser.NewLexerString(input)
+		for l.NextToken().Type != parser.TokenEOF {
+		}
+	}
+}
+
+func TestParserValidate(t *testing.T) {
+	inputs := []string{
+		`{"a": [1, {"b": null}], "c": "x"}`, `[]`, `{}`, `[[[]]]`, `{"a": {"b": {}}}`,
+		`{"a": }`, `{"a" 1}`, `[1 2]`, `[1,]`, `{"a": 1,}`, `{,}`, `[`, `{"a": [}`, `"x"`, `[01]`, `[tru]`,
+	}
+
+	for _, input := range inputs {
+		_, parseErr := parser.NewParser(parser.NewLexerString(input)).ParseJSON()
+
+		p := parser.NewParser(parser.NewLexerString(input + ` {"next": 1}`))
+		err := p.Validate()
+
+		if (err == nil) != (parseErr == nil) {
+			t.Errorf("%s: Validate returned %v, ParseJSON returned %v", input, err, parseErr)
+			continue
+		}
+
+		var pe *parser.ParseError
+		if err != nil && !errors.As(err, &pe) {
+			t.Errorf("%s: expected a *ParseError, got %T", input, err)
+		}
+
+		if err == nil && p.Offset() != int64(len(input)+1) {
+			t.Errorf("%s: expected the parser to move past the value, offset %d", input, p.Offset())
+		}
+	}
+}
+
+func TestParserLimits(t *testing.T) {
+	limits := parser.Limits{MaxDepth: 2, MaxStringLength: 3, MaxArrayElements: 2, MaxObjectKeys: 2}
+
+	tests := []struct {
+		input string
+		limit string
+	}{
+		{`{"a": [1, 2], "b": {"c": "abc"}}`, ""},
+		{`[[[]]]`, "MaxDepth"},
+		{`{"a": {"b": {}}}`, "MaxDepth"},
+		{`["abcd"]`, "MaxStringLength"},
+		{`{"abcd": 1}`, "MaxStringLength"},
+		{`[1, 2, 3]`, "MaxArrayElements"},
+		{`{"a": 1, "b": 2, "c": 3}`, "MaxObjectKeys"},
+	}
+
+	check := func(t *testing.T, name string, err error, limit string) {
+		t.Helper()
+
+		if limit == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+
+			return
+		}
+
+		var le *parser.LimitError
+		if !errors.As(err, &le) || le.Limit != limit {
+			t.Errorf("%s: expected a %s *LimitError, got %v", name, limit, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parser.NewParser(parser.NewLexerString(tt.input), parser.WithLimits(limits)).ParseJSON()
+			check(t, "ParseJSON", err, tt.limit)
+
+			err = parser.NewParser(parser.NewLexerString(tt.input), parser.WithLimits(limits)).Validate()
+			check(t, "Validate", err, tt.limit)
+
+			err = parser.NewParser(parser.NewLexerString(tt.input), parser.WithLimits(limits)).Walk(func(parser.Event) error { return nil })
+			check(t, "Walk", err, tt.limit)
+		})
+	}
+}
+
+func TestParserBigNumbers(t *testing.T) {
+	input := `[123456789012345678901234567890, -1e400, 1.5]`
+
+	if _, err := parser.NewParser(parser.NewLexerString(input)).ParseJSON(); err == nil {
+		t.Error("Expected numbers out of range to be rejected by default")
+	}
+
+	if err := parser.NewParser(parser.NewLexerString(input), parser.WithBigNumbers()).Validate(); err != nil {
+		t.Errorf("Validate: unexpected error: %v", err)
+	}
+
+	v, err := parser.NewParser(parser.NewLexerString(input), parser.WithBigNumbers()).ParseJSON()
+	if err != nil {
+		t.Fatalf("ParseJSON: unexpected error: %v", err)
+	}
+
+	for i, expected := range []bool{true, true, false} {
+		n := v.(*parser.Array).Elements[i].(*parser.NumberLiteral)
+		if n.IsBig != expected || n.IsValid == expected {
+			t.Errorf("Element %d: expected IsBig %v, got %+v", i, expected, n)
+		}
+	}
+
+	if v.String() != `[123456789012345678901234567890,-1e400,1.5]` {
+		t.Errorf("Expected the literals to be kept, got %s", v)
+	}
+}
+
+func TestParserInterrupt(t *testing.T) {
+	stop := errors.New("stop")
+	input := "[" + strings.Repeat("[1, 2], ", 1000) + "[]]"
+
+	calls := 0
+	check := func() error {
+		if calls++; calls == 2 {
+			return stop
+		}
+
+		return nil
+	}
+
+	_, err := parser.NewParser(parser.NewLexerString(input), parser.WithInterrupt(check)).ParseJSON()
+	if !errors.Is(err, stop) || calls != 2 {
+		t.Errorf("ParseJSON: expected the interrupt error after 2 checks, got %v after %d", err, calls)
+	}
+
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) || pe.Offset == 0 {
+		t.Errorf("ParseJSON: expected a located *ParseError, got %#v", err)
+	}
+
+	calls = 0
+	if err := parser.NewParser(parser.NewLexerString(input), parser.WithInterrupt(check)).Validate(); !errors.Is(err, stop) {
+		t.Errorf("Validate: expected the interrupt error, got %v", err)
+	}
+
+	calls = 0
+	if err := parser.NewParser(parser.NewLexerString(input), parser.WithInterrupt(check)).SkipValue(); !errors.Is(err, stop) {
+		t.Errorf("SkipValue: expected the interrupt error, got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	input := `{"b": [3, "x", null, 1.50, true, 2e0, false], "a": {"z": null, "y": 1.0}, "c": null}`
+
+	v, err := parser.NewParser(parser.NewLexerString(input)).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := parser.Normalize(v, parser.NormalizeOptions{SortArrays: true, RemoveNulls: true}).(*parser.Object)
+
+	if keys := obj.OrderedKeys(); !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("Expected keys [a b], got %v", keys)
+	}
+
+	var literals []string
+	for _, elem := range obj.Pairs["b"].(*parser.Array).Elements {
+		literals = append(literals, elem.TokenLiteral())
+	}
+
+	if expected := []string{"null", "false", "true", "1.5", "2", "3", "x"}; !reflect.DeepEqual(literals, expected) {
+		t.Errorf("Expected elements %v, got %v", expected, literals)
+	}
+
+	inner := obj.Pairs["a"].(*parser.Object)
+	if keys := inner.OrderedKeys(); !reflect.DeepEqual(keys, []string{"y"}) || !inner.Pairs["y"].(*parser.NumberLiteral).IsInt {
+		t.Errorf("Expected {\"y\": 1} as an integer, got %v", keys)
+	}
+
+	// Without options arrays keep their order and nulls are kept
+	v, _ = parser.NewParser(parser.NewLexerString(`[2, 1, null]`)).ParseJSON()
+	if elems := parser.Normalize(v, parser.NormalizeOptions{}).(*parser.Array).Elements; len(elems) != 3 || elems[0].TokenLiteral() != "2" {
+		t.Errorf("Expected the array to be unchanged, got %v", elems)
+	}
+}
+
+func TestValueString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `"a\"b\\c\n\u0001é"`, expected: `"a\"b\\c\n\u0001é"`},
+		{input: `-1.50e3`, expected: `-1.50e3`},
+		{input: `false`, expected: `false`},
+		{input: `null`, expected: `null`},
+		{input: `[]`, expected: `[]`},
+		{input: `[1, [true], {}]`, expected: `[1,[true],{}]`},
+		{input: `{"k\"ey": {"list": [1.5, "x"]}, "n": null}`, expected: `{"k\"ey":{"list":[1.5,"x"]},"n":null}`},
+	}
+
+	for _, tt := range tests {
+		// Top-level values must be containers, so each value is parsed inside an array
+		wrapped, err := parser.NewParser(parser.NewLexerString("[" + tt.input + "]")).ParseJSON()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+
+		v := wrapped.(*parser.Array).Elements[0]
+
+		got := v.String()
+		if got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, got)
+		}
+
+		// The output parses back to an equal value
+		if again, err := parser.NewParser(parser.NewLexerString("[" + got + "]")).ParseJSON(); err != nil || !parser.Equal(wrapped, again) {
+			t.Errorf("%s: %s does not round-trip: %v", tt.input, got, err)
+		}
+	}
+
+	f, _ := parser.NewFloat(0.1)
+	if got := parser.NewArray().String() + f.String() + parser.NewBoolean(true).String(); got != "[]0.1true" {
+		t.Errorf("Expected built values to print as JSON, got %s", got)
+	}
+}
+
+func TestValueAccessors(t *testing.T) {
+	input := `{"name": "a", "n": 2.0, "f": 1.5, "big": 1e300, "ok": true, "obj": {}, "list": ["x", 3, false, [], {}]}`
+
+	v, err := parser.NewParser(parser.NewLexerString(input)).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := v.(*parser.Object)
+
+	if s, ok := obj.GetString("name"); !ok || s != "a" {
+		t.Errorf("GetString: expected a, got %q, %v", s, ok)
+	}
+
+	if n, ok := obj.GetInt("n"); !ok || n != 2 {
+		t.Errorf("GetInt: expected 2, got %d, %v", n, ok)
+	}
+
+	for _, key := range []string{"f", "big", "name", "missing"} {
+		if _, ok := obj.GetInt(key); ok {
+			t.Errorf("GetInt(%q): expected no integer", key)
+		}
+	}
+
+	if f, ok := obj.GetFloat("f"); !ok || f != 1.5 {
+		t.Errorf("GetFloat: expected 1.5, got %v, %v", f, ok)
+	}
+
+	if b, ok := obj.GetBool("ok"); !ok || !b {
+		t.Errorf("GetBool: expected true, got %v, %v", b, ok)
+	}
+
+	if _, ok := obj.GetObject("obj"); !ok {
+		t.Error("GetObject: expected an object")
+	}
+
+	list, ok := obj.GetArray("list")
+	if !ok {
+		t.Fatal("GetArray: expected an array")
+	}
+
+	s, sOK := list.GetString(0)
+	n, nOK := list.GetInt(1)
+	b, bOK := list.GetBool(2)
+	_, arrOK := list.GetArray(3)
+	_, objOK := list.GetObject(4)
+
+	if !sOK || s != "x" || !nOK || n != 3 || !bOK || b || !arrOK || !objOK {
+		t.Errorf("Unexpected array elements: %q %v, %d %v, %v %v, %v, %v", s, sOK, n, nOK, b, bOK, arrOK, objOK)
+	}
+
+	if _, ok := list.Get(5); ok {
+		t.Error("Get: expected index 5 to be out of range")
+	}
+
+	if _, ok := list.GetString(-1); ok {
+		t.Error("GetString: expected index -1 to be out of range")
+	}
+}
+
+func TestValueSpan(t *testing.T) {
+	input := "{\n  \"name\": \"a\\u0062\",  \"list\": [1.5e2, true, null, {}],\n  \"x\": -0\n}"
+
+	parsers := map[string]*parser.Parser{
+		"In memory": parser.NewParser(parser.NewLexerString(input)),
+		"Streaming": parser.NewParser(parser.NewLexerSize(iotest.OneByteReader(strings.NewReader(input)), 4)),
+	}
+
+	for name, p := range parsers {
+		v, err := p.ParseJSON()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		source := func(v parser.Value) string {
+			start, end := parser.Span(v)
+			return input[start:end]
+		}
+
+		obj := v.(*parser.Object)
+		list := obj.Pairs["list"].(*parser.Array)
+
+		spans := []struct {
+			value    parser.Value
+			expected string
+		}{
+			{value: obj, expected: input},
+			{value: obj.Pairs["name"], expected: `"a\u0062"`},
+			{value: list, expected: `[1.5e2, true, null, {}]`},
+			{value: list.Elements[0], expected: `1.5e2`},
+			{value: list.Elements[1], expected: `true`},
+			{value: list.Elements[2], expected: `null`},
+			{value: list.Elements[3], expected: `{}`},
+			{value: obj.Pairs["x"], expected: `-0`},
+		}
+
+		for _, s := range spans {
+			if got := source(s.value); got != s.expected {
+				t.Errorf("%s: expected span of %s, got %s", name, s.expected, got)
+			}
+		}
+
+		if tok := parser.StartToken(obj.Pairs["x"]); tok.Line != 3 || tok.Column != 8 || tok.Literal != "-0" {
+			t.Errorf("%s: expected the token of -0 at line 3, column 8, got %+v", name, tok)
+		}
+	}
+
+	if start, end := parser.Span(parser.NewString("built")); start != 0 || end != 0 {
+		t.Errorf("Expected a built value to have an empty span, got %d, %d", start, end)
+	}
+
+	if tok := parser.StartToken(parser.NewString("built")); tok.Line != 0 {
+		t.Errorf("Expected a built value to have no position, got %+v", tok)
+	}
+}
+
+func TestPreserveComments(t *testing.T) {
+	input := `// config
+{
+  // the name
+  "name": "api", // trailing
+  "list": [
+    1, /* one */
+    // two
+    2
+    // end of list
+  ],
+  "empty": {
+    // nothing yet
+  }
+  /* last */
+} // done`
+
+	v, err := parser.NewParser(parser.NewLexerString(input), parser.WithPreserveComments()).ParseJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := v.(*parser.Object)
+	list := obj.Pairs["list"].(*parser.Array)
+
+	tests := []struct {
+		name     string
+		value    parser.Value
+		expected parser.Comments
+	}{
+		{name: "root", value: obj, expected: parser.Comments{Leading: []string{"// config"}, Trailing: []string{"// done"}, Footer: []string{"/* last */"}}},
+		{name: "name", value: obj.Pairs["name"], expected: parser.Comments{Leading: []string{"// the name"}, Trailing: []string{"// trailing"}}},
+		{name: "list", value: list, expected: parser.Comments{Footer: []string{"// end of list"}}},
+		{name: "list[0]", value: list.Elements[0], expected: parser.Comments{Trailing: []string{"/* one */"}}},
+		{name: "list[1]", value: list.Elements[1], expected: parser.Comments{Leading: []string{"// two"}}},
+		{name: "empty", value: obj.Pairs["empty"], expected: parser.Comments{Footer: []string{"// nothing yet"}}},
+	}
+
+	for _, tt := range tests {
+		got := parser.NodeComments(tt.value)
+		if got == nil || !reflect.DeepEqual(*got, tt.expected) {
+			t.Errorf("%s: expected comments %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+
+	// A replaced value keeps the comments of the value it replaces
+	obj.Set("name", parser.NewString("web"))
+	if got := parser.NodeComments(obj.Pairs["name"]); got == nil || got.Leading[0] != "// the name" {
+		t.Errorf("Expected the comments to move to the new value, got %+v", got)
+	}
+
+	// Comments are skipped but not kept with WithAllowComments
+	v, err = parser.NewParser(parser.NewLexerString(input), parser.WithAllowComments()).ParseJSON()
+	if err != nil || parser.NodeComments(v) != nil {
+		t.Errorf("Expected no comments, got %v, %v", parser.NodeComments(v), err)
+	}
+}
+
+// lexerFor creates a lexer for a test input given as a string or an io.Reader.
+func lexerFor(input interface{}) *parser.Lexer {
+	if r, ok := input.(io.Reader); ok {
+		return parser.NewLexerSize(r, parser.DefaultBufferSize)
+	}
+
+	return parser.NewLexerString(input.(string))
+}
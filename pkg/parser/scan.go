@@ -0,0 +1,70 @@
+package parser
+
+import "math/bits"
+
+// The scanners below test eight bytes at a time with SWAR (SIMD within a register)
+// arithmetic on a uint64, in portable Go. For a word x, x - 0x01 in each byte borrows
+// into the high bit of exactly the bytes that are zero, unless an earlier byte already
+// borrowed, so the lowest flagged byte of the result is always exact.
+const (
+	swarOnes  = 0x0101010101010101
+	swarHighs = 0x8080808080808080
+)
+
+// load64 returns the first eight bytes of s as a little-endian word.
+func load64(s string) uint64 {
+	_ = s[7] // bounds check hint
+
+	return uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
+}
+
+// zeroBytes flags the bytes of x that are zero.
+func zeroBytes(x uint64) uint64 {
+	return (x - swarOnes) & ^x & swarHighs
+}
+
+// plainStringPrefix returns the length of the prefix of s made of printable ASCII
+// characters other than '"' and '\\', which a string token copies as they are.
+func plainStringPrefix(s string) int {
+	i := 0
+
+	for ; i+8 <= len(s); i += 8 {
+		x := load64(s[i:])
+
+		// Quotes, backslashes, control characters and non-ASCII bytes end the prefix
+		special := zeroBytes(x^(swarOnes*'"')) | zeroBytes(x^(swarOnes*'\\')) |
+			(x-swarOnes*0x20) & ^x | x&swarHighs
+
+		if special &= swarHighs; special != 0 {
+			return i + bits.TrailingZeros64(special)/8
+		}
+	}
+
+	for ; i < len(s) && isPlainStringByte(s[i]); i++ {
+	}
+
+	return i
+}
+
+// isPlainStringByte reports whether c is copied as it is by plainStringPrefix.
+func isPlainStringByte(c byte) bool {
+	return c >= 0x20 && c < 0x80 && c != '"' && c != '\\'
+}
+
+// spacePrefix returns the number of leading spaces in s.
+func spacePrefix(s string) int {
+	i := 0
+
+	for ; i+8 <= len(s); i += 8 {
+		// Spaces become zero bytes, so the lowest set bit is in the first other byte
+		if other := load64(s[i:]) ^ (swarOnes * ' '); other != 0 {
+			return i + bits.TrailingZeros64(other)/8
+		}
+	}
+
+	for ; i < len(s) && s[i] == ' '; i++ {
+	}
+
+	return i
+}
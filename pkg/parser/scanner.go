@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// recordSeparator is the ASCII RS byte that prefixes every record in a JSON text
+// sequence (RFC 7464).
+const recordSeparator = 0x1E
+
+// ErrScanLimit is returned by a Scanner once the limit set with SetLimit is reached.
+var ErrScanLimit = errors.New("scan limit reached")
+
+// Scanner walks raw JSON bytes and finds the boundaries of values without tokenizing or
+// allocating them. It only tracks nesting and string state: brackets must balance, but
+// a value is not otherwise validated. Splitter, and the probing, sampling and field
+// lookup functions of the encoding package, are built on it.
+type Scanner struct {
+	r *bufio.Reader
+	// sink receives a copy of every consumed byte when set
+	sink *bytes.Buffer
+	// offset is the number of bytes consumed so far
+	offset int64
+	// limit stops scanning with ErrScanLimit once this many bytes were consumed, if positive
+	limit int64
+	stack []byte
+}
+
+// NewScanner creates a Scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &Scanner{r: br}
+}
+
+// SetSink makes the scanner copy every byte it consumes into buf. A nil buf stops the
+// copying.
+func (s *Scanner) SetSink(buf *bytes.Buffer) {
+	s.sink = buf
+}
+
+// SetLimit makes the scanner fail with ErrScanLimit once n bytes were consumed. A limit
+// of zero or less means no limit.
+func (s *Scanner) SetLimit(n int64) {
+	s.limit = n
+}
+
+// Offset returns the number of bytes consumed so far.
+func (s *Scanner) Offset() int64 {
+	return s.offset
+}
+
+// ReadByte consumes and returns the next byte.
+func (s *Scanner) ReadByte() (byte, error) {
+	if s.limit > 0 && s.offset >= s.limit {
+		return 0, ErrScanLimit
+	}
+
+	c, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	s.offset++
+
+	if s.sink != nil {
+		s.sink.WriteByte(c)
+	}
+
+	return c, nil
+}
+
+// Peek returns the next byte without consuming it.
+func (s *Scanner) Peek() (byte, error) {
+	b, err := s.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+// SkipSeparators consumes whitespace and the bytes of extra in front of the next value.
+// It reports whether a newline and whether a byte of extra were seen. io.EOF is returned
+// when the input holds no further value.
+func (s *Scanner) SkipSeparators(extra string) (newline, other bool, err error) {
+	for {
+		c, err := s.Peek()
+		if err != nil {
+			return newline, other, err
+		}
+
+		switch {
+		case c == '\n':
+			newline = true
+		case c == ' ' || c == '\t' || c == '\r':
+		case strings.IndexByte(extra, c) >= 0:
+			other = true
+		default:
+			return newline, other, nil
+		}
+
+		if _, err := s.ReadByte(); err != nil {
+			return newline, other, err
+		}
+	}
+}
+
+// ScanValue consumes one complete value and returns the number of its direct children,
+// the elements of an array or the members of an object. io.EOF is returned when the
+// input ends before the value and io.ErrUnexpectedEOF when it ends inside it.
+func (s *Scanner) ScanValue() (int, error) {
+	c, err := s.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case c == '{' || c == '[':
+		return s.scanContainer(c)
+	case c == '"':
+		return 0, s.ScanString()
+	case c == '-' || isDigit(rune(c)) || c == 't' || c == 'f' || c == 'n':
+		return 0, s.scanBare()
+	default:
+		return 0, fmt.Errorf("invalid character %q", c)
+	}
+}
+
+// ScanString consumes the rest of a string whose opening quote was already read.
+func (s *Scanner) ScanString() error {
+	for {
+		c, err := s.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		switch c {
+		case '\\':
+			if _, err := s.ReadByte(); err != nil {
+				return unexpectedEOF(err)
+			}
+		case '"':
+			return nil
+		}
+	}
+}
+
+// scanContainer consumes the rest of an object or array whose opening byte was read.
+func (s *Scanner) scanContainer(open byte) (int, error) {
+	s.stack = append(s.stack[:0], closerOf(open))
+	children := 0
+	pending := true
+
+	for len(s.stack) > 0 {
+		c, err := s.ReadByte()
+		if err != nil {
+			return children, unexpectedEOF(err)
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r', ':':
+			continue
+		case '}', ']':
+			if want := s.stack[len(s.stack)-1]; c != want {
+				return children, fmt.Errorf("expected %q, got %q", want, c)
+			}
+
+			s.stack = s.stack[:len(s.stack)-1]
+
+			continue
+		case ',':
+			if len(s.stack) == 1 {
+				pending = true
+			}
+
+			continue
+		}
+
+		if len(s.stack) == 1 && pending {
+			children++
+			pending = false
+		}
+
+		switch c {
+		case '"':
+			if err := s.ScanString(); err != nil {
+				return children, err
+			}
+		case '{', '[':
+			s.stack = append(s.stack, closerOf(c))
+		}
+	}
+
+	return children, nil
+}
+
+// scanBare consumes the rest of a number or literal up to the next delimiter.
+func (s *Scanner) scanBare() error {
+	for {
+		c, err := s.Peek()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r', ',', ':', '{', '}', '[', ']', '"', recordSeparator:
+			return nil
+		}
+
+		if _, err := s.ReadByte(); err != nil {
+			return err
+		}
+	}
+}
+
+// closerOf returns the closing bracket for an opening one.
+func closerOf(open byte) byte {
+	if open == '{' {
+		return '}'
+	}
+
+	return ']'
+}
+
+// unexpectedEOF converts io.EOF in the middle of a value into io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
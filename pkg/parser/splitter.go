@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Splitter scans a stream of concatenated, whitespace-separated or comma-separated
+// top-level JSON values and yields the raw bytes of each one without parsing it, so
+// that values can be handed to workers for decoding. It only tracks nesting and string
+// state: a value is checked for balanced brackets but not otherwise validated.
+//
+//	s := parser.NewSplitter(r)
+//	for s.Next() {
+//		jobs <- append([]byte(nil), s.Bytes()...)
+//	}
+//	if err := s.Err(); err != nil { ... }
+type Splitter struct {
+	scanner *Scanner
+	buf     bytes.Buffer
+	start   int64
+	err     error
+
+	// inArray is set by EnterArray until the closing bracket of the array is read
+	inArray bool
+	closed  bool
+}
+
+// NewSplitter creates a Splitter reading from r.
+func NewSplitter(r io.Reader) *Splitter {
+	s := &Splitter{scanner: NewScanner(r)}
+	s.scanner.SetSink(&s.buf)
+
+	return s
+}
+
+// Next advances to the next value. It returns false at the end of the input or on an
+// error, which Err reports.
+func (s *Splitter) Next() bool {
+	if s.err != nil || s.closed {
+		return false
+	}
+
+	if _, _, err := s.scanner.SkipSeparators(","); err != nil {
+		if err == io.EOF && s.inArray {
+			err = fmt.Errorf("array: %w", io.ErrUnexpectedEOF)
+		}
+
+		if err != io.EOF {
+			s.err = err
+		}
+
+		return false
+	}
+
+	if c, _ := s.scanner.Peek(); s.inArray && c == ']' {
+		_, _ = s.scanner.ReadByte()
+		s.inArray, s.closed = false, true
+
+		return false
+	}
+
+	s.buf.Reset()
+	s.start = s.scanner.Offset()
+
+	if _, err := s.scanner.ScanValue(); err != nil {
+		s.err = fmt.Errorf("value at offset %d: %w", s.start, unexpectedEOF(err))
+		return false
+	}
+
+	return true
+}
+
+// EnterArray consumes the opening bracket of a top-level array. Next then yields the
+// elements of the array one by one and returns false after its closing bracket, so that
+// the elements of one huge array can be handed to workers like a stream of values.
+func (s *Splitter) EnterArray() error {
+	_, _, err := s.scanner.SkipSeparators(",")
+	if err == nil {
+		var c byte
+		if c, err = s.scanner.Peek(); err == nil && c != '[' {
+			err = fmt.Errorf("expected '[', got %q", c)
+		}
+	}
+
+	if err != nil {
+		s.err = fmt.Errorf("value at offset %d: %w", s.scanner.Offset(), unexpectedEOF(err))
+		return s.err
+	}
+
+	_, _ = s.scanner.ReadByte()
+	s.inArray = true
+
+	return nil
+}
+
+// Bytes returns the raw bytes of the current value. The slice is overwritten by the next
+// call to Next, so copy it to keep it.
+func (s *Splitter) Bytes() []byte {
+	return s.buf.Bytes()
+}
+
+// Offset returns the byte offset of the current value in the input.
+func (s *Splitter) Offset() int64 {
+	return s.start
+}
+
+// Err returns the first error encountered, or nil when the input ended cleanly.
+func (s *Splitter) Err() error {
+	return s.err
+}
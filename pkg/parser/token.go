@@ -21,11 +21,15 @@ const (
 	TokenIllegal      TokenType = "ILLEGAL"
 )
 
-// Token represents a token in a JSON document. It consists of a type, a literal value, and the line and
-// column where the token was found in the document.
+// Token represents a token in a JSON document. It consists of a type, a literal value, and the line,
+// column and byte offset where the token was found in the document.
 type Token struct {
 	Type    TokenType
 	Literal string
 	Line    int
 	Column  int
+	Offset  int64
+	// End is the byte offset just past the token, so that the source of the token is
+	// input[Offset:End]
+	End int64
 }
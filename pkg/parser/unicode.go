@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the Unicode encoding form of a JSON text.
+type Encoding int
+
+const (
+	// EncodingUTF8 is UTF-8, the only encoding the lexer reads directly
+	EncodingUTF8 Encoding = iota
+	// EncodingUTF16LE is little-endian UTF-16
+	EncodingUTF16LE
+	// EncodingUTF16BE is big-endian UTF-16
+	EncodingUTF16BE
+	// EncodingUTF32LE is little-endian UTF-32
+	EncodingUTF32LE
+	// EncodingUTF32BE is big-endian UTF-32
+	EncodingUTF32BE
+)
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// DetectEncoding reports the encoding of a JSON text from its first four bytes and the
+// length of its byte order mark, if it has one. Without a byte order mark the encoding is
+// told from the position of the zero bytes, since a JSON text starts with an ASCII
+// character (RFC 4627, section 3).
+func DetectEncoding(b []byte) (Encoding, int) {
+	switch {
+	case len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF:
+		return EncodingUTF8, 3
+	case len(b) >= 4 && b[0] == 0 && b[1] == 0 && b[2] == 0xFE && b[3] == 0xFF:
+		return EncodingUTF32BE, 4
+	case len(b) >= 4 && b[0] == 0xFF && b[1] == 0xFE && b[2] == 0 && b[3] == 0:
+		return EncodingUTF32LE, 4
+	case len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF:
+		return EncodingUTF16BE, 2
+	case len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE:
+		return EncodingUTF16LE, 2
+	case len(b) >= 4 && b[0] == 0 && b[1] == 0 && b[2] == 0 && b[3] != 0:
+		return EncodingUTF32BE, 0
+	case len(b) >= 4 && b[0] != 0 && b[1] == 0 && b[2] == 0 && b[3] == 0:
+		return EncodingUTF32LE, 0
+	case len(b) >= 2 && b[0] == 0 && b[1] != 0:
+		return EncodingUTF16BE, 0
+	case len(b) >= 2 && b[0] != 0 && b[1] == 0:
+		return EncodingUTF16LE, 0
+	default:
+		return EncodingUTF8, 0
+	}
+}
+
+// NewTranscodingReader returns a reader that yields the JSON text read from r as UTF-8
+// without a byte order mark. The encoding is detected with DetectEncoding; UTF-8 input is
+// passed through as is. Invalid code units are replaced with U+FFFD.
+func NewTranscodingReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+
+	head, _ := br.Peek(4)
+	enc, bom := DetectEncoding(head)
+	_, _ = br.Discard(bom)
+
+	switch enc {
+	case EncodingUTF16LE:
+		return &transcoder{r: br, order: binary.LittleEndian, width: 2}
+	case EncodingUTF16BE:
+		return &transcoder{r: br, order: binary.BigEndian, width: 2}
+	case EncodingUTF32LE:
+		return &transcoder{r: br, order: binary.LittleEndian, width: 4}
+	case EncodingUTF32BE:
+		return &transcoder{r: br, order: binary.BigEndian, width: 4}
+	default:
+		return br
+	}
+}
+
+// transcoder converts UTF-16 or UTF-32 input to UTF-8.
+type transcoder struct {
+	r       *bufio.Reader
+	order   binary.ByteOrder
+	width   int
+	pending []byte
+	held    rune
+	hasHeld bool
+	err     error
+}
+
+// Read implements io.Reader
+func (t *transcoder) Read(p []byte) (int, error) {
+	n := 0
+
+	for n < len(p) {
+		if len(t.pending) == 0 {
+			// Do not block on the underlying reader once some output is ready
+			if t.err != nil || n > 0 && !t.hasHeld && t.r.Buffered() < t.width {
+				break
+			}
+
+			r, err := t.next()
+			if err != nil {
+				t.err = err
+				continue
+			}
+
+			t.pending = utf8.AppendRune(t.pending[:0], r)
+		}
+
+		c := copy(p[n:], t.pending)
+		t.pending = t.pending[c:]
+		n += c
+	}
+
+	if n == 0 && t.err != nil {
+		return 0, t.err
+	}
+
+	return n, nil
+}
+
+// next decodes the next character, combining UTF-16 surrogate pairs.
+func (t *transcoder) next() (rune, error) {
+	u, err := t.unit()
+	if err != nil {
+		return 0, err
+	}
+
+	if t.width == 4 {
+		if u < 0 || u > unicode.MaxRune || utf16.IsSurrogate(u) {
+			return utf8.RuneError, nil
+		}
+
+		return u, nil
+	}
+
+	if !utf16.IsSurrogate(u) {
+		return u, nil
+	}
+
+	u2, err := t.unit()
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+
+	if r := utf16.DecodeRune(u, u2); r != utf8.RuneError {
+		return r, nil
+	}
+
+	// Not a valid pair: the second unit starts the next character
+	t.held, t.hasHeld = u2, true
+
+	return utf8.RuneError, nil
+}
+
+// unit reads the next code unit. A truncated unit at the end of the input is decoded
+// as U+FFFD.
+func (t *transcoder) unit() (rune, error) {
+	if t.hasHeld {
+		t.hasHeld = false
+		return t.held, nil
+	}
+
+	var b [4]byte
+
+	_, err := io.ReadFull(t.r, b[:t.width])
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		return utf8.RuneError, nil
+	case err != nil:
+		return 0, err
+	case t.width == 2:
+		return rune(t.order.Uint16(b[:2])), nil
+	default:
+		return rune(t.order.Uint32(b[:])), nil
+	}
+}
@@ -0,0 +1,154 @@
+package parser
+
+// validateFrame is an open container during Validate.
+type validateFrame struct {
+	closer TokenType
+	object bool
+	keys   KeySet
+	// count is the number of members or elements started so far
+	count int
+}
+
+// Validate checks that the value at the current token is well-formed without building a
+// tree, and moves past it like ParseJSON. It reads one token at a time and keeps only a
+// stack of the open containers, so memory use does not grow with the size of the input,
+// except for the keys remembered by an exact duplicate key check. It returns the first
+// syntax error as a *ParseError. Like ParseJSON, it leaves the input after the value to
+// the caller; a document is only well-formed if More then reports false.
+func (p *Parser) Validate() error {
+	return p.interruption(p.validate())
+}
+
+// validate implements Validate.
+func (p *Parser) validate() error {
+	switch p.currentToken.Type {
+	case TokenBraceOpen, TokenBracketOpen:
+	case TokenIllegal:
+		return p.newError(p.currentToken, "%s", p.currentToken.Literal)
+	default:
+		return p.newError(p.currentToken, "expected { or [, got %s", p.currentToken.Type)
+	}
+
+	var stack []validateFrame
+
+	for {
+		// The current token starts a value
+		switch tok := p.currentToken; tok.Type {
+		case TokenString:
+			if max := p.limits.MaxStringLength; max > 0 && len(tok.Literal) > max {
+				return p.newLimitError(tok, "MaxStringLength", max)
+			}
+		case TokenTrue, TokenFalse, TokenNull:
+		case TokenNumber:
+			if !p.acceptsNumber(NewNumberLiteral(tok)) {
+				return p.newError(tok, "invalid number format: %s", tok.Literal)
+			}
+		case TokenBraceOpen, TokenBracketOpen:
+			if max := p.limits.MaxDepth; max > 0 && len(stack) >= max {
+				return p.newLimitError(tok, "MaxDepth", max)
+			}
+
+			frame := validateFrame{closer: TokenBracketClose}
+			if tok.Type == TokenBraceOpen {
+				frame = validateFrame{closer: TokenBraceClose, object: true}
+
+				if p.newKeySet != nil {
+					frame.keys = p.newKeySet()
+				}
+			}
+
+			p.nextToken() // move past the opening bracket
+
+			if p.currentToken.Type != frame.closer {
+				stack = append(stack, frame)
+
+				if err := p.validateMemberStart(&stack[len(stack)-1]); err != nil {
+					return err
+				}
+
+				continue
+			}
+		case TokenIllegal:
+			return p.newError(tok, "%s", tok.Literal)
+		default:
+			return p.newError(tok, "unexpected token %s", tok.Type)
+		}
+
+		done, err := p.validateAfterValue(&stack)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// validateAfterValue moves past the value that ends at the current token and past the
+// containers it completes. It stops at the start of the next element and reports true
+// once the top-level value is complete.
+func (p *Parser) validateAfterValue(stack *[]validateFrame) (bool, error) {
+	for {
+		p.nextToken() // move past the value
+
+		if len(*stack) == 0 {
+			return true, nil
+		}
+
+		top := &(*stack)[len(*stack)-1]
+
+		switch p.currentToken.Type {
+		case top.closer:
+			*stack = (*stack)[:len(*stack)-1]
+		case TokenComma:
+			p.nextToken() // move past comma
+
+			if p.allowTrailingCommas && p.currentToken.Type == top.closer {
+				*stack = (*stack)[:len(*stack)-1]
+				continue
+			}
+
+			return false, p.validateMemberStart(top)
+		default:
+			return false, p.newError(p.currentToken, "expected %s, got %s", top.closer, p.currentToken.Type)
+		}
+	}
+}
+
+// validateMemberStart counts the member or element that starts at the current token and,
+// in an object, checks its key and colon and moves to its value.
+func (p *Parser) validateMemberStart(frame *validateFrame) error {
+	frame.count++
+
+	if !frame.object {
+		if max := p.limits.MaxArrayElements; max > 0 && frame.count > max {
+			return p.newLimitError(p.currentToken, "MaxArrayElements", max)
+		}
+
+		return nil
+	}
+
+	key := p.currentToken
+	if key.Type != TokenString {
+		return p.newError(key, "expected string key")
+	}
+
+	if max := p.limits.MaxObjectKeys; max > 0 && frame.count > max {
+		return p.newLimitError(key, "MaxObjectKeys", max)
+	}
+
+	if max := p.limits.MaxStringLength; max > 0 && len(key.Literal) > max {
+		return p.newLimitError(key, "MaxStringLength", max)
+	}
+
+	if p.peekToken.Type != TokenColon {
+		return p.newError(p.peekToken, "expected :, got %s", p.peekToken.Type)
+	}
+
+	if !p.addKey(frame.keys, key.Literal) {
+		err := p.errors[len(p.errors)-1]
+		return &err
+	}
+
+	p.nextToken() // move past key
+	p.nextToken() // move past colon
+
+	return nil
+}
@@ -0,0 +1,519 @@
+package schema
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// node is a compiled schema or subschema.
+type node struct {
+	// boolean is set for the boolean schemas true and false
+	boolean *bool
+	ref     *node
+
+	types      []string
+	enum       []parser.Value
+	constValue parser.Value
+
+	multipleOf       *big.Rat
+	maximum          *big.Rat
+	exclusiveMaximum *big.Rat
+	minimum          *big.Rat
+	exclusiveMinimum *big.Rat
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	items       *node
+	prefixItems []*node
+	contains    *node
+	minContains *int
+	maxContains *int
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	properties           map[string]*node
+	patternProperties    []patternProperty
+	additionalProperties *node
+	propertyNames        *node
+	required             []string
+	dependentRequired    map[string][]string
+	minProperties        *int
+	maxProperties        *int
+
+	allOf    []*node
+	anyOf    []*node
+	oneOf    []*node
+	not      *node
+	ifNode   *node
+	thenNode *node
+	elseNode *node
+}
+
+// patternProperty is a compiled patternProperties entry.
+type patternProperty struct {
+	pattern *regexp.Regexp
+	schema  *node
+}
+
+// compiler turns schema documents into nodes. Nodes are cached by their JSON Pointer so
+// that references, including recursive ones, resolve to a single node.
+type compiler struct {
+	root  parser.Value
+	nodes map[string]*node
+}
+
+// validTypes lists the names accepted by the "type" keyword.
+var validTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// compile compiles the schema v found at the given pointer.
+func (c *compiler) compile(v parser.Value, pointer string) (*node, error) {
+	if n, ok := c.nodes[pointer]; ok {
+		return n, nil
+	}
+
+	n := &node{}
+	c.nodes[pointer] = n
+
+	switch val := v.(type) {
+	case *parser.Boolean:
+		b := val.Value
+		n.boolean = &b
+
+		return n, nil
+	case *parser.Object:
+		if err := c.compileKeywords(n, val, pointer); err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	default:
+		return nil, fmt.Errorf("schema at %q must be an object or a boolean", pointer)
+	}
+}
+
+// compileKeywords fills n from the keywords of a schema object.
+func (c *compiler) compileKeywords(n *node, obj *parser.Object, pointer string) error {
+	steps := []func(*node, *parser.Object, string) error{
+		c.compileCore,
+		c.compileNumeric,
+		c.compileString,
+		c.compileArray,
+		c.compileObject,
+		c.compileApplicators,
+	}
+
+	for _, step := range steps {
+		if err := step(n, obj, pointer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileCore handles $ref, type, enum and const.
+func (c *compiler) compileCore(n *node, obj *parser.Object, pointer string) error {
+	if v, ok := obj.Pairs["$ref"]; ok {
+		ref, ok := v.(*parser.StringLiteral)
+		if !ok {
+			return keywordError(pointer, "$ref", "must be a string")
+		}
+
+		target, err := c.resolve(ref.Value)
+		if err != nil {
+			return keywordError(pointer, "$ref", err.Error())
+		}
+
+		n.ref = target
+	}
+
+	if v, ok := obj.Pairs["type"]; ok {
+		switch t := v.(type) {
+		case *parser.StringLiteral:
+			n.types = []string{t.Value}
+		case *parser.Array:
+			names, err := stringArray(t)
+			if err != nil {
+				return keywordError(pointer, "type", err.Error())
+			}
+
+			n.types = names
+		default:
+			return keywordError(pointer, "type", "must be a string or an array of strings")
+		}
+
+		for _, name := range n.types {
+			if !validTypes[name] {
+				return keywordError(pointer, "type", fmt.Sprintf("unknown type %q", name))
+			}
+		}
+	}
+
+	if v, ok := obj.Pairs["enum"]; ok {
+		arr, ok := v.(*parser.Array)
+		if !ok {
+			return keywordError(pointer, "enum", "must be an array")
+		}
+
+		n.enum = arr.Elements
+	}
+
+	if v, ok := obj.Pairs["const"]; ok {
+		n.constValue = v
+	}
+
+	return nil
+}
+
+// compileNumeric handles the numeric assertions.
+func (c *compiler) compileNumeric(n *node, obj *parser.Object, pointer string) error {
+	targets := map[string]**big.Rat{
+		"multipleOf":       &n.multipleOf,
+		"maximum":          &n.maximum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"minimum":          &n.minimum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+	}
+
+	for keyword, target := range targets {
+		v, ok := obj.Pairs[keyword]
+		if !ok {
+			continue
+		}
+
+		num, ok := v.(*parser.NumberLiteral)
+		if !ok {
+			return keywordError(pointer, keyword, "must be a number")
+		}
+
+		*target = num.Rat()
+	}
+
+	if n.multipleOf != nil && n.multipleOf.Sign() <= 0 {
+		return keywordError(pointer, "multipleOf", "must be greater than 0")
+	}
+
+	return nil
+}
+
+// compileString handles the string assertions.
+func (c *compiler) compileString(n *node, obj *parser.Object, pointer string) error {
+	var err error
+
+	if n.minLength, err = intKeyword(obj, "minLength", pointer); err != nil {
+		return err
+	}
+
+	if n.maxLength, err = intKeyword(obj, "maxLength", pointer); err != nil {
+		return err
+	}
+
+	if v, ok := obj.Pairs["pattern"]; ok {
+		if n.pattern, err = compilePattern(v); err != nil {
+			return keywordError(pointer, "pattern", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// compileArray handles the array assertions and applicators.
+func (c *compiler) compileArray(n *node, obj *parser.Object, pointer string) error {
+	var err error
+
+	if n.items, err = c.subschema(obj, "items", pointer); err != nil {
+		return err
+	}
+
+	if n.contains, err = c.subschema(obj, "contains", pointer); err != nil {
+		return err
+	}
+
+	if n.prefixItems, err = c.subschemaArray(obj, "prefixItems", pointer); err != nil {
+		return err
+	}
+
+	ints := map[string]**int{
+		"minItems":    &n.minItems,
+		"maxItems":    &n.maxItems,
+		"minContains": &n.minContains,
+		"maxContains": &n.maxContains,
+	}
+
+	for keyword, target := range ints {
+		if *target, err = intKeyword(obj, keyword, pointer); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := obj.Pairs["uniqueItems"]; ok {
+		b, ok := v.(*parser.Boolean)
+		if !ok {
+			return keywordError(pointer, "uniqueItems", "must be a boolean")
+		}
+
+		n.uniqueItems = b.Value
+	}
+
+	return nil
+}
+
+// compileObject handles the object assertions and applicators.
+func (c *compiler) compileObject(n *node, obj *parser.Object, pointer string) error {
+	var err error
+
+	if v, ok := obj.Pairs["properties"]; ok {
+		if n.properties, _, err = c.schemaMap(v, pointer+"/properties"); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := obj.Pairs["patternProperties"]; ok {
+		schemas, order, err := c.schemaMap(v, pointer+"/patternProperties")
+		if err != nil {
+			return err
+		}
+
+		for _, expr := range order {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return keywordError(pointer, "patternProperties", err.Error())
+			}
+
+			n.patternProperties = append(n.patternProperties, patternProperty{pattern: re, schema: schemas[expr]})
+		}
+	}
+
+	if n.additionalProperties, err = c.subschema(obj, "additionalProperties", pointer); err != nil {
+		return err
+	}
+
+	if n.propertyNames, err = c.subschema(obj, "propertyNames", pointer); err != nil {
+		return err
+	}
+
+	if v, ok := obj.Pairs["required"]; ok {
+		arr, ok := v.(*parser.Array)
+		if !ok {
+			return keywordError(pointer, "required", "must be an array of strings")
+		}
+
+		if n.required, err = stringArray(arr); err != nil {
+			return keywordError(pointer, "required", err.Error())
+		}
+	}
+
+	if v, ok := obj.Pairs["dependentRequired"]; ok {
+		deps, ok := v.(*parser.Object)
+		if !ok {
+			return keywordError(pointer, "dependentRequired", "must be an object")
+		}
+
+		n.dependentRequired = map[string][]string{}
+
+		for key, value := range deps.Pairs {
+			arr, ok := value.(*parser.Array)
+			if !ok {
+				return keywordError(pointer, "dependentRequired", "values must be arrays of strings")
+			}
+
+			if n.dependentRequired[key], err = stringArray(arr); err != nil {
+				return keywordError(pointer, "dependentRequired", err.Error())
+			}
+		}
+	}
+
+	if n.minProperties, err = intKeyword(obj, "minProperties", pointer); err != nil {
+		return err
+	}
+
+	n.maxProperties, err = intKeyword(obj, "maxProperties", pointer)
+
+	return err
+}
+
+// compileApplicators handles allOf, anyOf, oneOf, not and if/then/else.
+func (c *compiler) compileApplicators(n *node, obj *parser.Object, pointer string) error {
+	var err error
+
+	arrays := map[string]*[]*node{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf}
+	for keyword, target := range arrays {
+		if *target, err = c.subschemaArray(obj, keyword, pointer); err != nil {
+			return err
+		}
+	}
+
+	singles := map[string]**node{"not": &n.not, "if": &n.ifNode, "then": &n.thenNode, "else": &n.elseNode}
+	for keyword, target := range singles {
+		if *target, err = c.subschema(obj, keyword, pointer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// subschema compiles the schema under keyword, returning nil when it is absent.
+func (c *compiler) subschema(obj *parser.Object, keyword, pointer string) (*node, error) {
+	v, ok := obj.Pairs[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	return c.compile(v, pointer+"/"+escapePointer(keyword))
+}
+
+// subschemaArray compiles the array of schemas under keyword.
+func (c *compiler) subschemaArray(obj *parser.Object, keyword, pointer string) ([]*node, error) {
+	v, ok := obj.Pairs[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	arr, ok := v.(*parser.Array)
+	if !ok || len(arr.Elements) == 0 {
+		return nil, keywordError(pointer, keyword, "must be a non-empty array of schemas")
+	}
+
+	nodes := make([]*node, len(arr.Elements))
+
+	for i, elem := range arr.Elements {
+		n, err := c.compile(elem, fmt.Sprintf("%s/%s/%d", pointer, keyword, i))
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[i] = n
+	}
+
+	return nodes, nil
+}
+
+// schemaMap compiles an object whose values are schemas, returning its keys in sorted order.
+func (c *compiler) schemaMap(v parser.Value, pointer string) (map[string]*node, []string, error) {
+	obj, ok := v.(*parser.Object)
+	if !ok {
+		return nil, nil, fmt.Errorf("schema at %q must be an object", pointer)
+	}
+
+	nodes := make(map[string]*node, len(obj.Pairs))
+	keys := make([]string, 0, len(obj.Pairs))
+
+	for key, value := range obj.Pairs {
+		n, err := c.compile(value, pointer+"/"+escapePointer(key))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nodes[key] = n
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return nodes, keys, nil
+}
+
+// resolve compiles the target of a $ref. Only references into the same document are
+// supported.
+func (c *compiler) resolve(ref string) (*node, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only local JSON Pointer references are supported", ref)
+	}
+
+	pointer := strings.TrimPrefix(ref, "#")
+	target := c.root
+
+	if pointer != "" {
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+			switch val := target.(type) {
+			case *parser.Object:
+				next, ok := val.Pairs[token]
+				if !ok {
+					return nil, fmt.Errorf("reference %q not found", ref)
+				}
+
+				target = next
+			case *parser.Array:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(val.Elements) {
+					return nil, fmt.Errorf("reference %q not found", ref)
+				}
+
+				target = val.Elements[i]
+			default:
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+		}
+	}
+
+	return c.compile(target, pointer)
+}
+
+// intKeyword reads a non-negative integer keyword, returning nil when it is absent.
+func intKeyword(obj *parser.Object, keyword, pointer string) (*int, error) {
+	v, ok := obj.Pairs[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	num, ok := v.(*parser.NumberLiteral)
+	if !ok || !num.Rat().IsInt() || num.Float < 0 {
+		return nil, keywordError(pointer, keyword, "must be a non-negative integer")
+	}
+
+	i := int(num.Float)
+
+	return &i, nil
+}
+
+// compilePattern compiles a regular expression keyword. Patterns use Go's RE2 syntax,
+// which covers the common subset of ECMA-262 regular expressions.
+func compilePattern(v parser.Value) (*regexp.Regexp, error) {
+	s, ok := v.(*parser.StringLiteral)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+
+	return regexp.Compile(s.Value)
+}
+
+// stringArray converts an array of strings.
+func stringArray(arr *parser.Array) ([]string, error) {
+	result := make([]string, len(arr.Elements))
+
+	for i, elem := range arr.Elements {
+		s, ok := elem.(*parser.StringLiteral)
+		if !ok {
+			return nil, fmt.Errorf("element %d must be a string", i)
+		}
+
+		result[i] = s.Value
+	}
+
+	return result, nil
+}
+
+// keywordError reports an invalid keyword in the schema.
+func keywordError(pointer, keyword, msg string) error {
+	return fmt.Errorf("invalid schema at %q: %s %s", pointer+"/"+escapePointer(keyword), keyword, msg)
+}
+
+// escapePointer escapes a JSON Pointer reference token.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
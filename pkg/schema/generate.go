@@ -0,0 +1,392 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+)
+
+// draft is the $schema URI emitted by Generate.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate returns a JSON Schema describing the JSON encoding of a Go type. v is either a
+// reflect.Type or a value of the type to describe.
+//
+// Field names follow the json tag and pointers may be null. A field is required unless its
+// json tag has omitempty or it is a pointer; the jsonschema tag overrides this and adds
+// constraints:
+//
+//	Status string `json:"status" jsonschema:"enum=active|disabled,description=Account state"`
+//	Note   string `json:"note" jsonschema:"optional"`
+//
+// Supported jsonschema options are required, optional, enum=a|b|c and description=text.
+// The description takes the rest of the tag, commas included, so it comes last.
+// Named struct types other than the root are emitted once under $defs and referenced, so
+// recursive types are supported. A type that implements encoding.Marshaler accepts any
+// value, and map keys may be strings, integers or implement MarshalText, as in Marshal.
+func Generate(v interface{}) ([]byte, error) {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate a schema for nil")
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	g := &generator{root: t, names: map[reflect.Type]string{}, defs: map[string]interface{}{}}
+
+	root, err := g.schemaFor(t)
+	if err != nil {
+		return nil, err
+	}
+
+	root["$schema"] = draft
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+
+	return encoding.MarshalIndent(root, "", "  ", encoding.WithSortKeys())
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*encoding.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()
+)
+
+// generator builds schemas for Go types.
+type generator struct {
+	root  reflect.Type
+	names map[reflect.Type]string
+	defs  map[string]interface{}
+}
+
+// schemaFor returns the schema for t.
+func (g *generator) schemaFor(t reflect.Type) (map[string]interface{}, error) {
+	// A type that writes its own JSON, such as time.Time, may write any value
+	if t.Kind() != reflect.Ptr && t.Implements(marshalerType) {
+		return map[string]interface{}{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		// Marshal writes []byte as a base64 string, and a nil one as null
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return nullable(map[string]interface{}{"type": "string", "contentEncoding": "base64"}), nil
+		}
+
+		items, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		s := map[string]interface{}{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			s["minItems"] = t.Len()
+			s["maxItems"] = t.Len()
+		}
+
+		return s, nil
+	case reflect.Map:
+		keys, err := keySchema(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		s := map[string]interface{}{"type": "object", "additionalProperties": values}
+		if keys != nil {
+			s["propertyNames"] = keys
+		}
+
+		return s, nil
+	case reflect.Ptr:
+		elem, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return nullable(elem), nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return nil, fmt.Errorf("unsupported type: %v", t)
+	}
+}
+
+// keySchema returns the schema of the object keys written for map keys of type t, or nil
+// when they may be any string. Like Marshal, keys are strings, implement MarshalText or
+// are integers written in base 10.
+func keySchema(t reflect.Type) (map[string]interface{}, error) {
+	switch {
+	case t.Kind() == reflect.String, t.Implements(textMarshalerType):
+		return nil, nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		return map[string]interface{}{"pattern": "^-?[0-9]+$"}, nil
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+		return map[string]interface{}{"pattern": "^[0-9]+$"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported map key type %v: map key must be a string, an integer or implement MarshalText", t)
+	}
+}
+
+// structRef returns the schema of the root struct inline and a $ref to $defs for every
+// other named struct.
+func (g *generator) structRef(t reflect.Type) (map[string]interface{}, error) {
+	if t == g.root {
+		if _, ok := g.names[t]; ok {
+			return map[string]interface{}{"$ref": "#"}, nil
+		}
+
+		g.names[t] = ""
+
+		return g.structSchema(t)
+	}
+
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+
+	if name, ok := g.names[t]; ok {
+		return map[string]interface{}{"$ref": "#/$defs/" + name}, nil
+	}
+
+	name := g.defName(t)
+	g.names[t] = name
+
+	s, err := g.structSchema(t)
+	if err != nil {
+		return nil, err
+	}
+
+	g.defs[name] = s
+
+	return map[string]interface{}{"$ref": "#/$defs/" + name}, nil
+}
+
+// defName picks a unique $defs name for t.
+func (g *generator) defName(t reflect.Type) string {
+	name := t.Name()
+
+	for i := 2; ; i++ {
+		if !g.nameUsed(name) {
+			return name
+		}
+
+		name = t.Name() + strconv.Itoa(i)
+	}
+}
+
+// nameUsed reports whether name was already assigned to a type.
+func (g *generator) nameUsed(name string) bool {
+	for _, n := range g.names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// structSchema describes the exported fields of a struct.
+func (g *generator) structSchema(t reflect.Type) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	var additional interface{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := field.Name
+		parts := strings.Split(tag, ",")
+
+		// A catch-all map describes the members that match no other field
+		if parts[0] == "" && hasOption(parts[1:], "inline") || parts[0] == "-" && hasOption(parts[1:], "extra") {
+			if field.Type.Kind() != reflect.Map {
+				return nil, fmt.Errorf("field %s: catch-all field must be a map", field.Name)
+			}
+
+			s, err := g.schemaFor(field.Type.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", field.Name, err)
+			}
+
+			additional = s
+
+			continue
+		}
+
+		if parts[0] != "" {
+			name = parts[0]
+		}
+
+		s, err := g.schemaFor(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+
+		isRequired := field.Type.Kind() != reflect.Ptr && !hasOption(parts[1:], "omitempty")
+
+		if isRequired, err = applyTag(s, field, isRequired); err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+
+		properties[name] = s
+
+		if isRequired {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+
+	if additional != nil {
+		s["additionalProperties"] = additional
+	}
+
+	return s, nil
+}
+
+// applyTag applies the jsonschema tag of field to s and returns whether the field is
+// required.
+func applyTag(s map[string]interface{}, field reflect.StructField, required bool) (bool, error) {
+	tag, ok := field.Tag.Lookup("jsonschema")
+	if !ok {
+		return required, nil
+	}
+
+	for tag != "" {
+		// A description takes the rest of the tag, so that it may contain commas
+		var opt string
+		if strings.HasPrefix(tag, "description=") {
+			opt, tag = tag, ""
+		} else {
+			opt, tag, _ = strings.Cut(tag, ",")
+		}
+
+		key, value, _ := strings.Cut(opt, "=")
+
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "description":
+			s["description"] = value
+		case "enum":
+			enum, err := enumValues(field.Type, strings.Split(value, "|"))
+			if err != nil {
+				return required, err
+			}
+
+			s["enum"] = enum
+		case "":
+		default:
+			return required, fmt.Errorf("unknown jsonschema option %q", key)
+		}
+	}
+
+	return required, nil
+}
+
+// enumValues converts the enum tag values to the kind of t.
+func enumValues(t reflect.Type, values []string) ([]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	enum := make([]interface{}, len(values))
+
+	for i, v := range values {
+		switch t.Kind() {
+		case reflect.String:
+			enum[i] = v
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer enum value %q", v)
+			}
+
+			enum[i] = n
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number enum value %q", v)
+			}
+
+			enum[i] = f
+		case reflect.Bool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean enum value %q", v)
+			}
+
+			enum[i] = b
+		default:
+			return nil, fmt.Errorf("enum is not supported for %v", t)
+		}
+	}
+
+	return enum, nil
+}
+
+// nullable extends s to also accept null, which is how nil pointers are encoded.
+func nullable(s map[string]interface{}) map[string]interface{} {
+	if name, ok := s["type"].(string); ok {
+		s["type"] = []string{name, "null"}
+		return s
+	}
+
+	if _, ok := s["$ref"]; ok {
+		return map[string]interface{}{"anyOf": []interface{}{s, map[string]interface{}{"type": "null"}}}
+	}
+
+	return s
+}
+
+// hasOption reports whether a tag option list contains opt.
+func hasOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+
+	return false
+}
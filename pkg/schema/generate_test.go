@@ -0,0 +1,278 @@
+package schema_test
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelmgr12/jingo/pkg/encoding"
+	"github.com/rafaelmgr12/jingo/pkg/schema"
+)
+
+type account struct {
+	ID       int64             `json:"id"`
+	Email    string            `json:"email" jsonschema:"required,description=Primary address, used for login"`
+	Status   string            `json:"status" jsonschema:"enum=active|disabled"`
+	Nickname string            `json:"nickname,omitempty"`
+	Limit    *float64          `json:"limit"`
+	Retries  int               `json:"retries" jsonschema:"optional"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Owner    *person           `json:"owner,omitempty"`
+	Skipped  string            `json:"-"`
+	internal string
+}
+
+type person struct {
+	Name    string    `json:"name"`
+	Friends []*person `json:"friends,omitempty"`
+}
+
+func TestGenerate(t *testing.T) {
+	data, err := schema.Generate(account{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`"$schema": "https://json-schema.org/draft/2020-12/schema"`,
+		`"required": [
+    "id",
+    "email",
+    "status"
+  ]`,
+		`"description": "Primary address, used for login"`,
+		`"$ref": "#/$defs/person"`,
+		`"type": [
+        "number",
+        "null"
+      ]`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected schema to contain %s, got:\n%s", want, data)
+		}
+	}
+
+	if strings.Contains(string(data), "Skipped") || strings.Contains(string(data), "internal") {
+		t.Errorf("Expected ignored fields to be omitted, got:\n%s", data)
+	}
+
+	s, err := schema.Compile(data)
+	if err != nil {
+		t.Fatalf("Generated schema does not compile: %v", err)
+	}
+
+	valid, err := encoding.Marshal(account{
+		ID:     1,
+		Email:  "a@example.com",
+		Status: "active",
+		Owner:  &person{Name: "root", Friends: []*person{{Name: "friend"}}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected marshal error: %v", err)
+	}
+
+	if err := s.Check(valid); err != nil {
+		t.Errorf("Expected marshaled value to be valid: %v", err)
+	}
+
+	violations, err := s.ValidateBytes([]byte(`{"id": 1.5, "email": "x", "status": "gone", "owner": {"friends": [{}]}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"/id: expected integer, got number",
+		"/owner: value does not match any of the schemas",
+		"/status: value is not one of the allowed values",
+	}
+
+	if len(violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %d: %v", len(expected), len(violations), violations)
+	}
+
+	for i, v := range violations {
+		if v.String() != expected[i] {
+			t.Errorf("Violation %d: expected %q, got %q", i, expected[i], v.String())
+		}
+	}
+}
+
+func TestGenerateFromType(t *testing.T) {
+	fromValue, err := schema.Generate(&person{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fromType, err := schema.Generate(reflect.TypeOf(person{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(fromValue) != string(fromType) {
+		t.Errorf("Expected identical schemas, got:\n%s\nand:\n%s", fromValue, fromType)
+	}
+
+	if !strings.Contains(string(fromType), `"$ref": "#"`) {
+		t.Errorf("Expected recursive reference to the root, got:\n%s", fromType)
+	}
+}
+
+func TestGenerateCatchAll(t *testing.T) {
+	data, err := schema.Generate(struct {
+		Kind  string                 `json:"kind"`
+		Extra map[string]interface{} `json:",inline"`
+	}{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var doc struct {
+		AdditionalProperties map[string]interface{} `json:"additionalProperties"`
+	}
+
+	if err := encoding.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if doc.AdditionalProperties == nil || len(doc.AdditionalProperties) != 0 || strings.Contains(string(data), "Extra") {
+		t.Errorf("Expected the catch-all field to allow additional properties, got:\n%s", data)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		errMsg string
+	}{
+		{name: "Nil", value: nil, errMsg: "nil"},
+		{name: "Unsupported kind", value: struct{ C chan int }{}, errMsg: "unsupported type"},
+		{name: "Unsupported map key", value: map[float64]string{}, errMsg: "map key must be a string, an integer"},
+		{name: "Bad enum", value: struct {
+			N int `jsonschema:"enum=1|x"`
+		}{}, errMsg: `invalid integer enum value "x"`},
+		{name: "Unknown option", value: struct {
+			N int `jsonschema:"format=uuid"`
+		}{}, errMsg: `unknown jsonschema option "format"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := schema.Generate(tt.value)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestGenerateBytes(t *testing.T) {
+	type blob struct {
+		Data  []byte `json:"data"`
+		Empty []byte `json:"empty"`
+	}
+
+	data, err := schema.Generate(blob{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"contentEncoding": "base64"`) {
+		t.Errorf("Expected a base64 string schema, got:\n%s", data)
+	}
+
+	s, err := schema.Compile(data)
+	if err != nil {
+		t.Fatalf("Generated schema does not compile: %v", err)
+	}
+
+	valid, err := encoding.Marshal(blob{Data: []byte("hello")})
+	if err != nil {
+		t.Fatalf("Unexpected marshal error: %v", err)
+	}
+
+	if err := s.Check(valid); err != nil {
+		t.Errorf("Expected marshaled value %s to be valid: %v", valid, err)
+	}
+}
+
+func TestGenerateMapKeys(t *testing.T) {
+	type scores struct {
+		ByID    map[int]float64           `json:"by_id"`
+		ByCount map[uint8]string          `json:"by_count"`
+		ByIP    map[netip.Addr]bool       `json:"by_ip"`
+		ByName  map[string]map[int]string `json:"by_name"`
+	}
+
+	data, err := schema.Generate(scores{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	s, err := schema.Compile(data)
+	if err != nil {
+		t.Fatalf("Generated schema does not compile: %v", err)
+	}
+
+	valid, err := encoding.Marshal(scores{
+		ByID:    map[int]float64{-1: 0.5, 2: 1},
+		ByCount: map[uint8]string{3: "c"},
+		ByIP:    map[netip.Addr]bool{netip.MustParseAddr("10.0.0.1"): true},
+		ByName:  map[string]map[int]string{"a": {1: "x"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected marshal error: %v", err)
+	}
+
+	if err := s.Check(valid); err != nil {
+		t.Errorf("Expected marshaled value %s to be valid: %v", valid, err)
+	}
+
+	invalid := `{"by_id": {"x": 1}, "by_count": {}, "by_ip": {}, "by_name": {}}`
+	if err := s.Check([]byte(invalid)); err == nil {
+		t.Errorf("Expected a non-integer key to be rejected by:\n%s", data)
+	}
+}
+
+func TestGenerateMarshaler(t *testing.T) {
+	type event struct {
+		At      time.Time           `json:"at"`
+		Expires *time.Time          `json:"expires"`
+		Payload encoding.RawMessage `json:"payload"`
+	}
+
+	data, err := schema.Generate(event{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var doc struct {
+		Defs       map[string]interface{}            `json:"$defs"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+
+	if err := encoding.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Types that write their own JSON are not described by their Go fields
+	if len(doc.Defs) != 0 || len(doc.Properties["at"]) != 0 || len(doc.Properties["payload"]) != 0 {
+		t.Errorf("Unexpected schema:\n%s", data)
+	}
+
+	s, err := schema.Compile(data)
+	if err != nil {
+		t.Fatalf("Generated schema does not compile: %v", err)
+	}
+
+	valid := `{"at": "2024-01-02T03:04:05Z", "expires": null, "payload": [1, "a"]}`
+	if err := s.Check([]byte(valid)); err != nil {
+		t.Errorf("Expected %s to be valid: %v", valid, err)
+	}
+}
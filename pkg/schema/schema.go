@@ -0,0 +1,134 @@
+// Package schema compiles JSON Schema documents and validates JSON values against them.
+//
+// A subset of draft 2020-12 is supported: type, enum, const, the numeric, string, array
+// and object assertions, the in-place applicators (allOf, anyOf, oneOf, not, if/then/else)
+// and $ref to locations within the same document (e.g. "#/$defs/item"). Unknown keywords
+// are ignored, as the specification requires for annotations.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// Schema is a compiled JSON Schema. It is safe for concurrent use.
+type Schema struct {
+	root *node
+}
+
+// Violation describes a single way in which a value does not satisfy a schema.
+type Violation struct {
+	// Path is the JSON Pointer of the failing value in the validated document
+	Path string
+	// Keyword is the schema keyword that failed, e.g. "required"
+	Keyword string
+	// Message is a human-readable description of the failure
+	Message string
+}
+
+// String returns the violation as "path: message".
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// ValidationError is returned by Check when a value has violations.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface, listing every violation.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+
+	return fmt.Sprintf("schema validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// Compile parses and compiles a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	value, err := parser.NewParser(parser.NewLexerBytes(data)).ParseJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	return CompileValue(value)
+}
+
+// CompileValue compiles a JSON Schema that was already parsed.
+func CompileValue(v parser.Value) (*Schema, error) {
+	c := &compiler{
+		root:  v,
+		nodes: map[string]*node{},
+	}
+
+	root, err := c.compile(v, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Validate checks v against the schema and returns every violation found. A nil result
+// means v is valid.
+func (s *Schema) Validate(v parser.Value) []Violation {
+	val := &validator{}
+	val.validate(s.root, v)
+
+	return val.violations
+}
+
+// ValidateBytes parses data and validates it against the schema.
+func (s *Schema) ValidateBytes(data []byte) ([]Violation, error) {
+	value, err := parser.NewParser(parser.NewLexerBytes(data)).ParseJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	return s.Validate(value), nil
+}
+
+// Check validates data and returns a *ValidationError if there are violations.
+func (s *Schema) Check(data []byte) error {
+	violations, err := s.ValidateBytes(data)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// formatPointer renders path segments as a JSON Pointer (RFC 6901).
+func formatPointer(path []string) string {
+	var b strings.Builder
+
+	for _, s := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(s))
+	}
+
+	return b.String()
+}
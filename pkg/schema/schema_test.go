@@ -0,0 +1,214 @@
+package schema_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rafaelmgr12/jingo/pkg/schema"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		expected []string
+	}{
+		{
+			name:     "Valid object",
+			schema:   `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`,
+			document: `{"id": 1, "extra": true}`,
+		},
+		{
+			name:     "Type mismatch",
+			schema:   `{"properties": {"v": {"type": ["string", "null"]}}}`,
+			document: `{"v": 12}`,
+			expected: []string{"/v: expected string or null, got number"},
+		},
+		{
+			name:     "Integer accepts integral floats",
+			schema:   `{"items": {"type": "integer"}}`,
+			document: `[2.0, 3]`,
+		},
+		{
+			name:     "Required and additional properties",
+			schema:   `{"required": ["id", "name"], "properties": {"id": {}}, "additionalProperties": false}`,
+			document: `{"id": 1, "x": 2}`,
+			expected: []string{
+				`/: missing required property "name"`,
+				`/: property "x" is not allowed`,
+			},
+		},
+		{
+			name: "Nested paths",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"items": {
+						"type": "array",
+						"items": {"type": "object", "properties": {"name": {"type": "string", "minLength": 2}}}
+					}
+				}
+			}`,
+			document: `{"items": [{"name": "ok"}, {"name": "x"}, {"name": 3}]}`,
+			expected: []string{
+				"/items/1/name: length 1 is less than 2",
+				"/items/2/name: expected string, got number",
+			},
+		},
+		{
+			name:     "Pointer escaping",
+			schema:   `{"properties": {"a/b": {"const": 1}, "c~d": {"const": 2}}}`,
+			document: `{"a/b": 0, "c~d": 0}`,
+			expected: []string{
+				"/a~1b: value does not match the constant",
+				"/c~0d: value does not match the constant",
+			},
+		},
+		{
+			name:     "Enum and numeric limits",
+			schema:   `{"properties": {"e": {"enum": ["a", 1]}, "n": {"minimum": 1, "exclusiveMaximum": 10, "multipleOf": 0.5}}}`,
+			document: `{"e": 1.0, "n": 10}`,
+			expected: []string{"/n: 10 is not less than 10"},
+		},
+		{
+			name:     "Multiple of decimal",
+			schema:   `{"items": {"multipleOf": 0.1}}`,
+			document: `[0.3, 0.35]`,
+			expected: []string{"/1: 0.35 is not a multiple of 0.1"},
+		},
+		{
+			name:     "Pattern and lengths",
+			schema:   `{"items": {"pattern": "^[a-z]+$", "maxLength": 3}}`,
+			document: `["abc", "abcD"]`,
+			expected: []string{
+				"/1: length 4 is greater than 3",
+				`/1: "abcD" does not match pattern "^[a-z]+$"`,
+			},
+		},
+		{
+			name:     "Array assertions",
+			schema:   `{"prefixItems": [{"type": "string"}], "items": {"type": "number"}, "uniqueItems": true, "contains": {"const": 5}, "maxItems": 3}`,
+			document: `["a", 1, 1, true]`,
+			expected: []string{
+				"/: array has 4 items, more than 3",
+				"/: items 1 and 2 are equal",
+				"/3: expected number, got boolean",
+				"/: array contains 0 matching items, fewer than 1",
+			},
+		},
+		{
+			name: "References and definitions",
+			schema: `{
+				"$defs": {"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}, "v": {"type": "integer"}}}},
+				"$ref": "#/$defs/node"
+			}`,
+			document: `{"v": 1, "children": [{"v": 2, "children": [{"v": "x"}]}]}`,
+			expected: []string{"/children/0/children/0/v: expected integer, got string"},
+		},
+		{
+			name:     "Combinators",
+			schema:   `{"properties": {"a": {"anyOf": [{"type": "string"}, {"type": "null"}]}, "b": {"oneOf": [{"minimum": 0}, {"maximum": 10}]}, "c": {"not": {"type": "string"}}}}`,
+			document: `{"a": 1, "b": 5, "c": "s"}`,
+			expected: []string{
+				"/a: value does not match any of the schemas",
+				"/b: value matches 2 of the schemas, expected exactly one",
+				"/c: value must not match the schema",
+			},
+		},
+		{
+			name:     "Conditional",
+			schema:   `{"if": {"properties": {"kind": {"const": "user"}}}, "then": {"required": ["email"]}, "else": {"required": ["id"]}}`,
+			document: `{"kind": "user"}`,
+			expected: []string{`/: missing required property "email"`},
+		},
+		{
+			name:     "Dependent required and property names",
+			schema:   `{"dependentRequired": {"card": ["billing"]}, "propertyNames": {"maxLength": 4}}`,
+			document: `{"card": 1, "toolong": 2}`,
+			expected: []string{
+				`/: property "card" requires property "billing"`,
+				`/: property name "toolong" is not allowed`,
+			},
+		},
+		{
+			name:     "False schema",
+			schema:   `{"properties": {"a": false}}`,
+			document: `{"a": null}`,
+			expected: []string{"/a: no value is allowed here"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := schema.Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Unexpected compile error: %v", err)
+			}
+
+			violations, err := s.ValidateBytes([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(violations) != len(tt.expected) {
+				t.Fatalf("Expected %d violations, got %d: %v", len(tt.expected), len(violations), violations)
+			}
+
+			for i, v := range violations {
+				if v.String() != tt.expected[i] {
+					t.Errorf("Violation %d: expected %q, got %q", i, tt.expected[i], v.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		errMsg string
+	}{
+		{name: "Not a schema", schema: `[1]`, errMsg: "must be an object or a boolean"},
+		{name: "Unknown type", schema: `{"type": "float"}`, errMsg: `unknown type "float"`},
+		{name: "Bad pattern", schema: `{"pattern": "("}`, errMsg: "pattern"},
+		{name: "Negative length", schema: `{"minLength": -1}`, errMsg: "must be a non-negative integer"},
+		{name: "Remote reference", schema: `{"$ref": "http://example.com/s.json"}`, errMsg: "only local JSON Pointer references"},
+		{name: "Missing reference", schema: `{"$ref": "#/$defs/missing"}`, errMsg: "not found"},
+		{name: "Invalid JSON", schema: `{"type": }`, errMsg: "failed to parse schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := schema.Compile([]byte(tt.schema))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := schema.MustCompile([]byte(`{"type": "object", "required": ["id"]}`))
+
+	if err := s.Check([]byte(`{"id": 1}`)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err := s.Check([]byte(`{}`))
+
+	var verr *schema.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+
+	if len(verr.Violations) != 1 || verr.Violations[0].Keyword != "required" {
+		t.Errorf("Unexpected violations: %v", verr.Violations)
+	}
+}
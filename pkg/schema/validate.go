@@ -0,0 +1,375 @@
+package schema
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rafaelmgr12/jingo/pkg/parser"
+)
+
+// validator walks a value alongside a compiled schema and collects violations.
+type validator struct {
+	path       []string
+	violations []Violation
+}
+
+// fail records a violation at the current path.
+func (val *validator) fail(keyword, format string, args ...interface{}) {
+	val.violations = append(val.violations, Violation{
+		Path:    formatPointer(val.path),
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// child validates v against n with seg appended to the current path.
+func (val *validator) child(seg string, n *node, v parser.Value) {
+	val.path = append(val.path, seg)
+	val.validate(n, v)
+	val.path = val.path[:len(val.path)-1]
+}
+
+// valid reports whether v satisfies n without recording any violation.
+func (val *validator) valid(n *node, v parser.Value) bool {
+	sub := &validator{path: val.path}
+	sub.validate(n, v)
+
+	return len(sub.violations) == 0
+}
+
+// validate checks v against n.
+func (val *validator) validate(n *node, v parser.Value) {
+	if n.boolean != nil {
+		if !*n.boolean {
+			val.fail("false", "no value is allowed here")
+		}
+
+		return
+	}
+
+	if n.ref != nil {
+		val.validate(n.ref, v)
+	}
+
+	val.validateCore(n, v)
+
+	switch value := v.(type) {
+	case *parser.NumberLiteral:
+		val.validateNumber(n, value)
+	case *parser.StringLiteral:
+		val.validateString(n, value)
+	case *parser.Array:
+		val.validateArray(n, value)
+	case *parser.Object:
+		val.validateObject(n, value)
+	}
+
+	val.validateApplicators(n, v)
+}
+
+// validateCore checks type, enum and const.
+func (val *validator) validateCore(n *node, v parser.Value) {
+	if len(n.types) > 0 {
+		matched := false
+
+		for _, t := range n.types {
+			if hasType(v, t) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			val.fail("type", "expected %s, got %s", strings.Join(n.types, " or "), typeName(v))
+		}
+	}
+
+	if n.enum != nil {
+		matched := false
+
+		for _, e := range n.enum {
+			if parser.Equal(e, v) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			val.fail("enum", "value is not one of the allowed values")
+		}
+	}
+
+	if n.constValue != nil && !parser.Equal(n.constValue, v) {
+		val.fail("const", "value does not match the constant")
+	}
+}
+
+// validateNumber checks the numeric assertions.
+func (val *validator) validateNumber(n *node, num *parser.NumberLiteral) {
+	r := num.Rat()
+
+	if n.multipleOf != nil && !new(big.Rat).Quo(r, n.multipleOf).IsInt() {
+		val.fail("multipleOf", "%s is not a multiple of %s", num.Value, ratString(n.multipleOf))
+	}
+
+	if n.maximum != nil && r.Cmp(n.maximum) > 0 {
+		val.fail("maximum", "%s is greater than %s", num.Value, ratString(n.maximum))
+	}
+
+	if n.exclusiveMaximum != nil && r.Cmp(n.exclusiveMaximum) >= 0 {
+		val.fail("exclusiveMaximum", "%s is not less than %s", num.Value, ratString(n.exclusiveMaximum))
+	}
+
+	if n.minimum != nil && r.Cmp(n.minimum) < 0 {
+		val.fail("minimum", "%s is less than %s", num.Value, ratString(n.minimum))
+	}
+
+	if n.exclusiveMinimum != nil && r.Cmp(n.exclusiveMinimum) <= 0 {
+		val.fail("exclusiveMinimum", "%s is not greater than %s", num.Value, ratString(n.exclusiveMinimum))
+	}
+}
+
+// validateString checks the string assertions. Lengths are counted in code points.
+func (val *validator) validateString(n *node, s *parser.StringLiteral) {
+	length := utf8.RuneCountInString(s.Value)
+
+	if n.minLength != nil && length < *n.minLength {
+		val.fail("minLength", "length %d is less than %d", length, *n.minLength)
+	}
+
+	if n.maxLength != nil && length > *n.maxLength {
+		val.fail("maxLength", "length %d is greater than %d", length, *n.maxLength)
+	}
+
+	if n.pattern != nil && !n.pattern.MatchString(s.Value) {
+		val.fail("pattern", "%q does not match pattern %q", s.Value, n.pattern.String())
+	}
+}
+
+// validateArray checks the array assertions and applies the item schemas.
+func (val *validator) validateArray(n *node, arr *parser.Array) {
+	count := len(arr.Elements)
+
+	if n.minItems != nil && count < *n.minItems {
+		val.fail("minItems", "array has %d items, fewer than %d", count, *n.minItems)
+	}
+
+	if n.maxItems != nil && count > *n.maxItems {
+		val.fail("maxItems", "array has %d items, more than %d", count, *n.maxItems)
+	}
+
+	if n.uniqueItems {
+		for i := 1; i < count; i++ {
+			for j := 0; j < i; j++ {
+				if parser.Equal(arr.Elements[i], arr.Elements[j]) {
+					val.fail("uniqueItems", "items %d and %d are equal", j, i)
+				}
+			}
+		}
+	}
+
+	for i, elem := range arr.Elements {
+		switch {
+		case i < len(n.prefixItems):
+			val.child(strconv.Itoa(i), n.prefixItems[i], elem)
+		case n.items != nil:
+			val.child(strconv.Itoa(i), n.items, elem)
+		}
+	}
+
+	if n.contains != nil {
+		matches := 0
+
+		for _, elem := range arr.Elements {
+			if val.valid(n.contains, elem) {
+				matches++
+			}
+		}
+
+		minContains := 1
+		if n.minContains != nil {
+			minContains = *n.minContains
+		}
+
+		if matches < minContains {
+			val.fail("contains", "array contains %d matching items, fewer than %d", matches, minContains)
+		}
+
+		if n.maxContains != nil && matches > *n.maxContains {
+			val.fail("maxContains", "array contains %d matching items, more than %d", matches, *n.maxContains)
+		}
+	}
+}
+
+// validateObject checks the object assertions and applies the property schemas.
+func (val *validator) validateObject(n *node, obj *parser.Object) {
+	count := len(obj.Pairs)
+
+	if n.minProperties != nil && count < *n.minProperties {
+		val.fail("minProperties", "object has %d properties, fewer than %d", count, *n.minProperties)
+	}
+
+	if n.maxProperties != nil && count > *n.maxProperties {
+		val.fail("maxProperties", "object has %d properties, more than %d", count, *n.maxProperties)
+	}
+
+	for _, key := range n.required {
+		if _, ok := obj.Pairs[key]; !ok {
+			val.fail("required", "missing required property %q", key)
+		}
+	}
+
+	for _, key := range sortedKeys(n.dependentRequired) {
+		if _, ok := obj.Pairs[key]; !ok {
+			continue
+		}
+
+		for _, dep := range n.dependentRequired[key] {
+			if _, ok := obj.Pairs[dep]; !ok {
+				val.fail("dependentRequired", "property %q requires property %q", key, dep)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(obj.Pairs) {
+		value := obj.Pairs[key]
+
+		if n.propertyNames != nil && !val.valid(n.propertyNames, &parser.StringLiteral{Value: key}) {
+			val.fail("propertyNames", "property name %q is not allowed", key)
+		}
+
+		matched := false
+
+		if schema, ok := n.properties[key]; ok {
+			matched = true
+
+			val.child(key, schema, value)
+		}
+
+		for _, pp := range n.patternProperties {
+			if pp.pattern.MatchString(key) {
+				matched = true
+
+				val.child(key, pp.schema, value)
+			}
+		}
+
+		if !matched && n.additionalProperties != nil {
+			if n.additionalProperties.boolean != nil && !*n.additionalProperties.boolean {
+				val.fail("additionalProperties", "property %q is not allowed", key)
+				continue
+			}
+
+			val.child(key, n.additionalProperties, value)
+		}
+	}
+}
+
+// validateApplicators checks allOf, anyOf, oneOf, not and if/then/else.
+func (val *validator) validateApplicators(n *node, v parser.Value) {
+	for _, sub := range n.allOf {
+		val.validate(sub, v)
+	}
+
+	if n.anyOf != nil {
+		matched := false
+
+		for _, sub := range n.anyOf {
+			if val.valid(sub, v) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			val.fail("anyOf", "value does not match any of the schemas")
+		}
+	}
+
+	if n.oneOf != nil {
+		matches := 0
+
+		for _, sub := range n.oneOf {
+			if val.valid(sub, v) {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			val.fail("oneOf", "value matches %d of the schemas, expected exactly one", matches)
+		}
+	}
+
+	if n.not != nil && val.valid(n.not, v) {
+		val.fail("not", "value must not match the schema")
+	}
+
+	if n.ifNode != nil {
+		if val.valid(n.ifNode, v) {
+			if n.thenNode != nil {
+				val.validate(n.thenNode, v)
+			}
+		} else if n.elseNode != nil {
+			val.validate(n.elseNode, v)
+		}
+	}
+}
+
+// hasType reports whether v is an instance of the named JSON Schema type.
+func hasType(v parser.Value, name string) bool {
+	switch name {
+	case "integer":
+		num, ok := v.(*parser.NumberLiteral)
+		return ok && num.Rat().IsInt()
+	default:
+		return typeName(v) == name
+	}
+}
+
+// typeName returns the JSON Schema type name of v.
+func typeName(v parser.Value) string {
+	switch v.(type) {
+	case *parser.Object:
+		return "object"
+	case *parser.Array:
+		return "array"
+	case *parser.StringLiteral:
+		return "string"
+	case *parser.NumberLiteral:
+		return "number"
+	case *parser.Boolean:
+		return "boolean"
+	case *parser.Null:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
+
+// ratString formats a schema limit for messages.
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	f, _ := r.Float64()
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in sorted order so that violations are reported
+// deterministically.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}